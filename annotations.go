@@ -0,0 +1,232 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"sort"
+	"text/template"
+
+	"github.com/sourcegraph/annotate"
+)
+
+// CoalesceAnnotations returns anns with adjacent annotations that have the
+// same markup merged, so that, for example, consecutive tokens of the same
+// class are wrapped in a single span. anns must be sorted by position and
+// must not overlap, as those returned by Annotate are.
+func CoalesceAnnotations(anns annotate.Annotations) annotate.Annotations {
+	var out annotate.Annotations
+	for _, a := range anns {
+		if n := len(out); n > 0 {
+			last := out[n-1]
+			if last.End == a.Start && last.WantInner == a.WantInner &&
+				bytes.Equal(last.Left, a.Left) && bytes.Equal(last.Right, a.Right) {
+				merged := *last
+				merged.End = a.End
+				out[n-1] = &merged
+				continue
+			}
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// MergeAnnotations merges highlighting annotations, such as those returned
+// by Annotate, with other annotations of the same source, such as links to
+// definitions, so that the result nests properly when it is applied with
+// annotate.Annotate. Annotations in external that overlap an earlier one
+// without being inside it are split at its end, and highlighting
+// annotations are split at the boundaries of the external ones, so that each
+// piece lies within the external annotations it overlaps and is nested
+// inside them. The result is sorted. Neither anns nor external is modified.
+func MergeAnnotations(anns, external annotate.Annotations) annotate.Annotations {
+	ext := make(annotate.Annotations, len(external))
+	copy(ext, external)
+	sort.Stable(ext)
+
+	// split external annotations that cross the end of an earlier one
+	var resolved annotate.Annotations
+	for len(ext) > 0 {
+		a := ext[0]
+		ext = ext[1:]
+		for _, prev := range resolved {
+			if prev.Start < a.Start && a.Start < prev.End && prev.End < a.End {
+				head, tail := *a, *a
+				head.End, tail.Start = prev.End, prev.End
+				a = &head
+				ext = append(ext, &tail)
+				sort.Stable(ext)
+				break
+			}
+		}
+		resolved = append(resolved, a)
+	}
+
+	inner := 1
+	var bounds []int
+	for _, a := range resolved {
+		if a.WantInner >= inner {
+			inner = a.WantInner + 1
+		}
+		bounds = append(bounds, a.Start, a.End)
+	}
+	sort.Ints(bounds)
+	bounds = dedupInts(bounds)
+
+	out := append(annotate.Annotations(nil), resolved...)
+	for _, a := range anns {
+		start := a.Start
+		i := sort.SearchInts(bounds, start+1)
+		for ; start < a.End; i++ {
+			end := a.End
+			if i < len(bounds) && bounds[i] < end {
+				end = bounds[i]
+			}
+			piece := *a
+			piece.Start, piece.End, piece.WantInner = start, end, inner
+			out = append(out, &piece)
+			start = end
+		}
+	}
+	sort.Stable(out)
+	return out
+}
+
+// dedupInts removes repeated values from the sorted slice a.
+func dedupInts(a []int) []int {
+	out := a[:0]
+	for _, v := range a {
+		if len(out) == 0 || v != out[len(out)-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// LinkAnnotator is an Annotator that wraps the tokens for which Link
+// returns a URL in a link to it, around the markup of Annotator, such as
+// the $ref values of OpenAPI documents with OpenAPIRefLink. Annotate scans
+// the source in the language of Annotator if it is an HTMLAnnotator.
+type LinkAnnotator struct {
+	Annotator Annotator
+	Link      func(kind Kind, tokText string) string
+}
+
+func (a LinkAnnotator) Annotate(start int, kind Kind, tokText string) (*annotate.Annotation, error) {
+	ann, err := a.Annotator.Annotate(start, kind, tokText)
+	if err != nil {
+		return nil, err
+	}
+	href := a.Link(kind, tokText)
+	if href == "" {
+		return ann, nil
+	}
+	link := &annotate.Annotation{Start: start, End: start + len(tokText)}
+	if ann != nil {
+		*link = *ann
+	}
+	link.Left = append([]byte(`<a href="`+template.HTMLEscapeString(href)+`">`), link.Left...)
+	link.Right = append(link.Right[:len(link.Right):len(link.Right)], "</a>"...)
+	return link, nil
+}
+
+// annotatorLanguage returns the language set in the HTMLConfig of a, if it
+// is an HTMLAnnotator or a LinkAnnotator around one, or nil.
+func annotatorLanguage(a Annotator) (*Language, error) {
+	switch a := a.(type) {
+	case HTMLAnnotator:
+		return ((*HTMLConfig)(&a)).language()
+	case LinkAnnotator:
+		return annotatorLanguage(a.Annotator)
+	}
+	return nil, nil
+}
+
+// LineAnnotator is implemented by annotators that also wrap every line of
+// the source, and the source as a whole, in markup of their own, like
+// LinePrinter does for printers. Annotate then returns annotations that
+// nest in a fixed order: the block contains the lines, which contain the
+// tokens. A line spans from its first byte up to, but not including, its
+// newline; tokens that span lines are split at newlines so that every
+// piece lies within a line. Lines are numbered starting at 1. Either method
+// may return nil for no markup.
+type LineAnnotator interface {
+	Annotator
+	AnnotateLine(start, end, line int) (*annotate.Annotation, error)
+	AnnotateBlock(start, end int) (*annotate.Annotation, error)
+}
+
+// Nesting levels of the annotations returned for a LineAnnotator, as
+// values of WantInner.
+const (
+	nestBlock = iota
+	nestLine
+	nestToken
+)
+
+// annotateLines adds the line and block annotations of a for src to the
+// token annotations anns.
+func annotateLines(src []byte, anns annotate.Annotations, a LineAnnotator) (annotate.Annotations, error) {
+	var lines annotate.Annotations
+	for line, start := 1, 0; ; line++ {
+		end := bytes.IndexByte(src[start:], '\n')
+		if end < 0 {
+			end = len(src)
+		} else {
+			end += start
+		}
+		ann, err := a.AnnotateLine(start, end, line)
+		if err != nil {
+			return nil, err
+		}
+		if ann != nil {
+			ann.WantInner = nestLine
+			if start == len(src) && start > 0 {
+				// annotate.Annotate drops annotations at the end of the
+				// source, so the empty last line is written after the
+				// newline before it
+				ann.Start, ann.Left, ann.Right = start-1, nil, append(ann.Left[:len(ann.Left):len(ann.Left)], ann.Right...)
+			}
+			lines = append(lines, ann)
+		}
+		if end == len(src) {
+			break
+		}
+		start = end + 1
+	}
+
+	block, err := a.AnnotateBlock(0, len(src))
+	if err != nil {
+		return nil, err
+	}
+	if block == nil && len(lines) == 0 {
+		return anns, nil
+	}
+
+	out := make(annotate.Annotations, 0, len(anns)+len(lines)+1)
+	if block != nil {
+		block.WantInner = nestBlock
+		out = append(out, block)
+	}
+	for _, ann := range anns {
+		if len(lines) == 0 {
+			ann.WantInner = nestToken
+			out = append(out, ann)
+			continue
+		}
+		for start := ann.Start; start < ann.End; {
+			end := ann.End
+			if i := bytes.IndexByte(src[start:end], '\n'); i >= 0 {
+				end = start + i
+			}
+			if end > start {
+				piece := *ann
+				piece.Start, piece.End, piece.WantInner = start, end, nestToken
+				out = append(out, &piece)
+			}
+			start = end + 1
+		}
+	}
+	out = append(out, lines...)
+	sort.Stable(out)
+	return out, nil
+}
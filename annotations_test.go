@@ -0,0 +1,136 @@
+package syntaxhighlight
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/sourcegraph/annotate"
+)
+
+func TestCoalesceAnnotations(t *testing.T) {
+	src := []byte("a := b+(c)")
+	anns, err := Annotate(src, HTMLAnnotator(newHTMLConfig([]Option{Coalesce()})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := annotate.Annotate(src, anns, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<span class="pln">a</span> <span class="pun">:=</span> <span class="pln">b</span><span class="pun">+(</span><span class="pln">c</span><span class="pun">)</span>`
+	if string(got) != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestMergeAnnotations(t *testing.T) {
+	src := []byte("foo.bar(x)")
+	anns, err := Annotate(src, HTMLAnnotator(DefaultHTMLConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	link := func(start, end int, href string) *annotate.Annotation {
+		return &annotate.Annotation{Start: start, End: end, Left: []byte(`<a href="` + href + `">`), Right: []byte("</a>")}
+	}
+
+	tests := []struct {
+		external annotate.Annotations
+		want     string
+	}{
+		{
+			// same range as a token: the link is outside
+			external: annotate.Annotations{link(4, 7, "#bar")},
+			want:     `<span class="pln">foo</span><span class="pun">.</span><a href="#bar"><span class="pln">bar</span></a><span class="pun">(</span><span class="pln">x</span><span class="pun">)</span>`,
+		},
+		{
+			// crossing token boundaries: tokens are split
+			external: annotate.Annotations{link(2, 5, "#x")},
+			want:     `<span class="pln">fo</span><a href="#x"><span class="pln">o</span><span class="pun">.</span><span class="pln">b</span></a><span class="pln">ar</span><span class="pun">(</span><span class="pln">x</span><span class="pun">)</span>`,
+		},
+		{
+			// overlapping links: the later one is split
+			external: annotate.Annotations{link(4, 9, "#2"), link(0, 7, "#1")},
+			want:     `<a href="#1"><span class="pln">foo</span><span class="pun">.</span><a href="#2"><span class="pln">bar</span></a></a><a href="#2"><span class="pun">(</span><span class="pln">x</span></a><span class="pun">)</span>`,
+		},
+	}
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			got, err := annotate.Annotate(src, MergeAnnotations(anns, test.external), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != test.want {
+				t.Errorf("want %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestLinkAnnotator(t *testing.T) {
+	src := []byte("$ref: '#/a'\nb: '#/a'\n")
+	a := LinkAnnotator{Annotator: HTMLAnnotator(newHTMLConfig([]Option{Lang("openapi")})), Link: OpenAPIRefLink("spec.yaml")}
+	anns, err := Annotate(src, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := annotate.Annotate(src, anns, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<span class="kwd">$ref</span><span class="pun">:</span> <a href="spec.yaml#/a"><span class="pln lbl">'#/a'</span></a>` + "\n" +
+		`<span class="pln var">b</span><span class="pun">:</span> <span class="str">'#/a'</span>` + "\n"
+	if string(got) != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	if _, err := Annotate(src, LinkAnnotator{Annotator: HTMLAnnotator(newHTMLConfig([]Option{Lang("klingon")}))}); err == nil {
+		t.Error("got no error for an unsupported language")
+	}
+}
+
+func TestAnnotateLines(t *testing.T) {
+	tests := []struct {
+		src     string
+		options []Option
+		want    string
+	}{
+		{
+			src:     "a := 1\n\nb()",
+			options: []Option{OrderedList()},
+		},
+		{
+			src:     "x\ny\n",
+			options: []Option{Table(), LineAnchors()},
+		},
+		{
+			src:     "/* a\nb */ c",
+			options: []Option{LineAnchors()},
+			want:    `<span class="line" id="L1" data-line="1"><span class="com">/* a</span></span>` + "\n" + `<span class="line" id="L2" data-line="2"><span class="com">b */</span> <span class="pln">c</span></span>`,
+		},
+	}
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			src := []byte(test.src)
+			anns, err := Annotate(src, HTMLAnnotator(newHTMLConfig(test.options)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := annotate.Annotate(src, anns, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := test.want
+			if want == "" {
+				// without tokens that span lines, the output is the same as AsHTML's
+				out, err := AsHTML(src, test.options...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want = string(out)
+			}
+			if string(got) != want {
+				t.Errorf("want %q, got %q", want, got)
+			}
+		})
+	}
+}
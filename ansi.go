@@ -0,0 +1,83 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ANSIPrinter implements the Printer interface and highlights code for
+// terminals, using ANSI escape sequences with 24-bit colors to apply the
+// styles of a theme. Kinds without a style are written unstyled, in the
+// terminal's own colors.
+type ANSIPrinter Theme
+
+// Print writes tokText in the style of kind. Every line of the token is
+// styled and reset separately, so that the output can be cut into lines
+// (by a pager, for example) without styles leaking from one line into the
+// next.
+func (p ANSIPrinter) Print(w io.Writer, kind Kind, tokText string) error {
+	st, ok := p.Styles[kind]
+	if !ok || kind == Whitespace {
+		_, err := io.WriteString(w, tokText)
+		return err
+	}
+	sgr, err := st.sgr()
+	if err != nil {
+		return err
+	}
+
+	buf := bufPool.Get().(*bytes.Buffer)
+	defer bufPool.Put(buf)
+	buf.Reset()
+	for i, line := range strings.Split(tokText, "\n") {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		if line == "" {
+			continue
+		}
+		buf.WriteString(sgr)
+		buf.WriteString(line)
+		buf.WriteString("\x1b[0m")
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// sgr returns the ANSI "select graphic rendition" escape sequence that
+// turns on style st.
+func (st Style) sgr() (string, error) {
+	var params []string
+	if st.Bold {
+		params = append(params, "1")
+	}
+	if st.Italic {
+		params = append(params, "3")
+	}
+	if st.Underline {
+		params = append(params, "4")
+	}
+	if st.Color != "" {
+		rgb, err := parseColor(st.Color)
+		if err != nil {
+			return "", err
+		}
+		params = append(params, fmt.Sprintf("38;2;%d;%d;%d", rgb[0], rgb[1], rgb[2]))
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m", nil
+}
+
+// AsANSI converts source code into a version highlighted for terminals with
+// the given theme (see ANSIPrinter).
+//
+// Example:
+// AsANSI(input, HighContrastTheme)
+func AsANSI(src []byte, theme Theme) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := Print(NewScanner(src), &buf, ANSIPrinter(theme)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
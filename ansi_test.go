@@ -0,0 +1,27 @@
+package syntaxhighlight
+
+import "testing"
+
+func TestAsANSI(t *testing.T) {
+	theme := Theme{
+		Styles: map[Kind]Style{
+			Keyword:    {Color: "#ff8000", Bold: true},
+			Comment:    {Color: "#888", Italic: true},
+			Plaintext:  {Underline: true},
+			Whitespace: {Color: "#ffffff"},
+		},
+	}
+	got, err := AsANSI([]byte("if x /* a\nb */ 1"), theme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "\x1b[1;38;2;255;128;0mif\x1b[0m \x1b[4mx\x1b[0m \x1b[3;38;2;136;136;136m/* a\x1b[0m\n\x1b[3;38;2;136;136;136mb */\x1b[0m 1"
+	if string(got) != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	theme.Styles[Keyword] = Style{Color: "orange"}
+	if _, err := AsANSI([]byte("if"), theme); err == nil {
+		t.Error("want error for invalid color")
+	}
+}
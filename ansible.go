@@ -0,0 +1,73 @@
+package syntaxhighlight
+
+import "strings"
+
+// ansibleKeywords are the keywords of Ansible plays, tasks and blocks.
+// Keys starting with "with_", such as with_items, are keywords too.
+var ansibleKeywords = setOf(
+	"name", "hosts", "tasks", "pre_tasks", "post_tasks", "handlers", "roles", "vars", "vars_files", "vars_prompt",
+	"become", "become_user", "become_method", "become_flags", "gather_facts", "remote_user", "connection", "serial",
+	"strategy", "any_errors_fatal", "max_fail_percentage", "when", "loop", "loop_control", "register", "notify",
+	"listen", "tags", "changed_when", "failed_when", "ignore_errors", "ignore_unreachable", "until", "retries",
+	"delay", "delegate_to", "delegate_facts", "run_once", "environment", "no_log", "block", "rescue", "always",
+	"check_mode", "diff", "args", "async", "poll", "module_defaults", "collections", "throttle", "timeout",
+	"debugger", "action", "local_action",
+)
+
+// ansibleTaskLists are the keys whose values are lists of tasks.
+var ansibleTaskLists = setOf("tasks", "pre_tasks", "post_tasks", "handlers", "block", "rescue", "always")
+
+// ansibleConditions are the keywords whose values are bare Jinja2
+// expressions, without "{{ }}".
+var ansibleConditions = setOf("when", "changed_when", "failed_when", "until")
+
+// ansibleMode specializes YAML for Ansible playbooks and task files: the
+// keywords of plays and tasks, such as when and loop, are Keyword, and the
+// other keys of tasks, the modules they run, such as ansible.builtin.copy,
+// Type. The values of conditions are Jinja2 expressions.
+var ansibleMode = &yamlMode{
+	key: func(path []string) (Kind, bool) {
+		if !ansibleTask(path) {
+			return 0, false
+		}
+		if key := path[len(path)-1]; ansibleKeywords[key] || strings.HasPrefix(key, "with_") {
+			return Keyword, true
+		}
+		return Type, true
+	},
+	value: func(toks *lineTokens, path []string, value []byte) bool {
+		if n := len(path); n > 1 && path[n-1] == "-" {
+			path = path[:n-1]
+		}
+		if len(value) == 0 || !ansibleTask(path) || !ansibleConditions[path[len(path)-1]] {
+			return false
+		}
+		if q := value[0]; (q == '"' || q == '\'') && len(value) > 1 && value[len(value)-1] == q {
+			toks.add(1, String)
+			addJinjaTokens(toks, value[1:len(value)-1])
+			toks.add(1, String)
+			return true
+		}
+		addJinjaTokens(toks, value)
+		return true
+	},
+}
+
+// newAnsibleLexer returns a Lexer for Ansible playbooks and task files,
+// which are YAML (see ansibleMode) with Jinja2 tags in values.
+func newAnsibleLexer() Lexer {
+	return &templateLexer{syntax: jinjaSyntax, host: newYAMLModeLexer(ansibleMode)()}
+}
+
+// ansibleTask reports whether the key at the end of path is a key of a
+// task or play: a key of an item of a list of tasks, or of the top-level
+// list of a playbook or task file.
+func ansibleTask(path []string) bool {
+	n := len(path)
+	return n >= 2 && path[n-2] == "-" && (n == 2 || ansibleTaskLists[path[n-3]])
+}
+
+// addJinjaTokens adds the tokens of src, a Jinja2 expression, to toks.
+func addJinjaTokens(toks *lineTokens, src []byte) {
+	addLexerTokens(toks, src, &templateCodeLexer{syntax: jinjaSyntax, Lexer: &languageLexer{&Scanner{}}})
+}
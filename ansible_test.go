@@ -0,0 +1,57 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"testing/iotest"
+)
+
+func TestAnsibleLexer(t *testing.T) {
+	src := "- hosts: web\n  tasks:\n    - name: Install {{ pkg }}\n      ansible.builtin.apt:\n        name: \"{{ pkg }}\"\n      loop: \"{{ pkgs }}\"\n      when: os == \"Debian\" and not skip\n    - debug: msg=hi\n      failed_when:\n        - 'x is defined'\n"
+	want := []token{
+		{Punctuation, "-"}, {Whitespace, " "}, {Keyword, "hosts"}, {Punctuation, ":"}, {Whitespace, " "}, {String, "web"}, {Whitespace, "\n"},
+		{Whitespace, "  "}, {Keyword, "tasks"}, {Punctuation, ":"}, {Whitespace, "\n"},
+		{Whitespace, "    "}, {Punctuation, "-"}, {Whitespace, " "}, {Keyword, "name"}, {Punctuation, ":"}, {Whitespace, " "}, {String, "Install "},
+		{Punctuation, "{{"}, {Whitespace, " "}, {Plaintext, "pkg"}, {Whitespace, " "}, {Punctuation, "}}"}, {Whitespace, "\n"},
+		{Whitespace, "      "}, {Type, "ansible.builtin.apt"}, {Punctuation, ":"}, {Whitespace, "\n"},
+		{Whitespace, "        "}, {Variable, "name"}, {Punctuation, ":"}, {Whitespace, " "},
+		{String, `"`}, {Punctuation, "{{"}, {Whitespace, " "}, {Plaintext, "pkg"}, {Whitespace, " "}, {Punctuation, "}}"}, {String, `"`}, {Whitespace, "\n"},
+		{Whitespace, "      "}, {Keyword, "loop"}, {Punctuation, ":"}, {Whitespace, " "},
+		{String, `"`}, {Punctuation, "{{"}, {Whitespace, " "}, {Plaintext, "pkgs"}, {Whitespace, " "}, {Punctuation, "}}"}, {String, `"`}, {Whitespace, "\n"},
+		{Whitespace, "      "}, {Keyword, "when"}, {Punctuation, ":"}, {Whitespace, " "}, {Plaintext, "os"}, {Whitespace, " "}, {Punctuation, "=="},
+		{Whitespace, " "}, {String, `"Debian"`}, {Whitespace, " "}, {Keyword, "and"}, {Whitespace, " "}, {Keyword, "not"}, {Whitespace, " "},
+		{Plaintext, "skip"}, {Whitespace, "\n"},
+		{Whitespace, "    "}, {Punctuation, "-"}, {Whitespace, " "}, {Type, "debug"}, {Punctuation, ":"}, {Whitespace, " "}, {String, "msg=hi"}, {Whitespace, "\n"},
+		{Whitespace, "      "}, {Keyword, "failed_when"}, {Punctuation, ":"}, {Whitespace, "\n"},
+		{Whitespace, "        "}, {Punctuation, "-"}, {Whitespace, " "}, {String, "'"}, {Plaintext, "x"}, {Whitespace, " "}, {Keyword, "is"},
+		{Whitespace, " "}, {Plaintext, "defined"}, {String, "'"}, {Whitespace, "\n"},
+	}
+	for _, oneByte := range []bool{false, true} {
+		s := NewScanner([]byte(src))
+		if oneByte {
+			s = NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(src))))
+		}
+		s.UseLanguage(LookupLanguage("ansible"))
+		if got := scanAll(t, s); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q:\nwant %v\ngot  %v", src, want, got)
+		}
+	}
+}
+
+func TestAnsibleModeEmpty(t *testing.T) {
+	var toks lineTokens
+	for _, path := range [][]string{nil, {"-"}, {"-", "when"}} {
+		if _, ok := ansibleMode.key(path); ok && len(path) < 2 {
+			t.Errorf("%q: got a kind for the key", path)
+		}
+		if ansibleMode.value(&toks, path, nil) || len(toks) != 0 {
+			t.Errorf("%q: got %v for an empty value", path, toks)
+		}
+	}
+	s := NewScanner([]byte("x"))
+	s.UseLanguage(LookupLanguage("ansible"))
+	if got, want := scanAll(t, s), []token{{String, "x"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
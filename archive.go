@@ -0,0 +1,162 @@
+package syntaxhighlight
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// Archive reads the files in a zip or tar archive and highlights them one
+// at a time (see HighlightArchive).
+type Archive struct {
+	options []Option
+	maxFile int64 // the size limit of a file
+	read    int64 // the uncompressed bytes read so far
+
+	zip        []*zip.File // remaining files of a zip archive
+	tar        *tar.Reader
+	compressed bool // the tar archive is gzip-compressed
+}
+
+// ArchiveEntry is a highlighted file in an archive.
+type ArchiveEntry struct {
+	// Name is the path of the file in the archive.
+	Name string
+	// Language is the detected language of the file, or "" if it is not
+	// known (see DetectLanguage).
+	Language string
+	// HTML is the highlighted file, as returned by AsHTML.
+	HTML []byte
+}
+
+// HighlightArchive opens the zip, tar or gzip-compressed tar archive of the
+// given size read from r. Its files are then highlighted one by one by
+// calling Next on the returned Archive, with their languages detected from
+// their names and contents and with the given options, so that archives can
+// be shown without extracting them to disk.
+//
+// Files are read up to MaxSize bytes, if it is among options, or 32 MiB
+// otherwise, and the archive up to 1 GiB once uncompressed, so that
+// archives that expand enormously do not exhaust memory. Next returns an
+// error wrapping ErrTooLarge for a larger file, and can then be called
+// again to go on with the next one.
+func HighlightArchive(r io.ReaderAt, size int64, options ...Option) (*Archive, error) {
+	a := &Archive{options: options, maxFile: maxArchiveFileSize}
+	if n := newHTMLConfig(options).MaxSize; n > 0 {
+		a.maxFile = int64(n)
+	}
+
+	if zr, err := zip.NewReader(r, size); err == nil {
+		a.zip = zr.File
+		return a, nil
+	}
+
+	var magic [2]byte
+	if _, err := r.ReadAt(magic[:], 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+	var tr io.Reader = io.NewSectionReader(r, 0, size)
+	if magic == [2]byte{0x1f, 0x8b} {
+		gz, err := gzip.NewReader(tr)
+		if err != nil {
+			return nil, err
+		}
+		a.compressed, tr = true, gz
+	}
+	a.tar = tar.NewReader(tr)
+	return a, nil
+}
+
+// The limits on the uncompressed sizes of the files of an archive and of
+// the archive as a whole.
+const (
+	maxArchiveFileSize = 32 << 20
+	maxArchiveSize     = 1 << 30
+)
+
+// errNotArchive is returned by Next when the input is neither a zip nor a
+// tar archive.
+var errNotArchive = errors.New("syntaxhighlight: not a zip or tar archive")
+
+// Next highlights the next regular file in the archive. It returns io.EOF
+// when there are no more files.
+func (a *Archive) Next() (*ArchiveEntry, error) {
+	name, src, err := a.next()
+	if err != nil {
+		return nil, err
+	}
+	lang := DetectLanguage(name, src)
+	out, err := AsHTML(src, append([]Option{Lang(lang)}, a.options...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &ArchiveEntry{Name: name, Language: lang, HTML: out}, nil
+}
+
+// next returns the name and contents of the next regular file in the
+// archive.
+func (a *Archive) next() (string, []byte, error) {
+	if a.tar == nil {
+		for len(a.zip) > 0 {
+			f := a.zip[0]
+			a.zip = a.zip[1:]
+			if !f.Mode().IsRegular() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return "", nil, err
+			}
+			src, err := a.readFile(f.Name, rc)
+			rc.Close()
+			if err != nil {
+				return "", nil, err
+			}
+			return f.Name, src, nil
+		}
+		return "", nil, io.EOF
+	}
+
+	for {
+		hdr, err := a.tar.Next()
+		if err == tar.ErrHeader && !a.compressed {
+			return "", nil, errNotArchive
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		src, err := a.readFile(hdr.Name, a.tar)
+		if err != nil {
+			return "", nil, err
+		}
+		return hdr.Name, src, nil
+	}
+}
+
+// readFile reads the file of the archive with the given name from r, within
+// the limits of the archive.
+func (a *Archive) readFile(name string, r io.Reader) ([]byte, error) {
+	limit := a.maxFile
+	if rest := maxArchiveSize - a.read; rest < limit {
+		limit = rest
+	}
+	src, err := ioutil.ReadAll(io.LimitReader(r, limit+1))
+	a.read += int64(len(src))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(src)) > limit {
+		if limit < a.maxFile {
+			return nil, fmt.Errorf("%w: archive larger than %d bytes uncompressed", ErrTooLarge, maxArchiveSize)
+		}
+		return nil, fmt.Errorf("%w: archive file %s larger than %d bytes", ErrTooLarge, name, a.maxFile)
+	}
+	return src, nil
+}
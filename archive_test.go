@@ -0,0 +1,144 @@
+package syntaxhighlight
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+var archiveFiles = []struct{ name, src string }{
+	{"a.py", "x # c"},
+	{"dir/run", "#!/bin/sh\n"},
+	{"notes", "hi"},
+}
+
+func zipArchive(t *testing.T) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	if _, err := w.Create("dir/"); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range archiveFiles {
+		fw, err := w.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(fw, f.src)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func tarArchive(t *testing.T, compress bool) []byte {
+	var buf bytes.Buffer
+	var out io.Writer = &buf
+	var gz *gzip.Writer
+	if compress {
+		gz = gzip.NewWriter(&buf)
+		out = gz
+	}
+	w := tar.NewWriter(out)
+	if err := w.WriteHeader(&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range archiveFiles {
+		if err := w.WriteHeader(&tar.Header{Name: f.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(f.src))}); err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, f.src)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestHighlightArchive(t *testing.T) {
+	want := []ArchiveEntry{
+		{"a.py", "python", []byte(`<span class="pln">x</span> <span class="com"># c</span>`)},
+		{"dir/run", "shell", []byte(`<span class="com shb">#!/bin/sh</span>` + "\n")},
+		{"notes", "", []byte(`<span class="pln">hi</span>`)},
+	}
+	archives := map[string][]byte{
+		"zip":    zipArchive(t),
+		"tar":    tarArchive(t, false),
+		"tar.gz": tarArchive(t, true),
+	}
+	for format, data := range archives {
+		a, err := HighlightArchive(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("%s: %s", format, err)
+		}
+		var got []ArchiveEntry
+		for {
+			e, err := a.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: %s", format, err)
+			}
+			got = append(got, *e)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: want %q, got %q", format, want, got)
+		}
+	}
+}
+
+func TestHighlightArchiveTooLarge(t *testing.T) {
+	// files over MaxSize are skipped with an error
+	for _, data := range [][]byte{zipArchive(t), tarArchive(t, false), tarArchive(t, true)} {
+		a, err := HighlightArchive(bytes.NewReader(data), int64(len(data)), MaxSize(3))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range archiveFiles[:2] {
+			if _, err := a.Next(); !errors.Is(err, ErrTooLarge) {
+				t.Errorf("want ErrTooLarge, got %v", err)
+			}
+		}
+		if e, err := a.Next(); err != nil || e.Name != "notes" {
+			t.Errorf("want notes, got %v, %v", e, err)
+		}
+		if _, err := a.Next(); err != io.EOF {
+			t.Errorf("want io.EOF, got %v", err)
+		}
+	}
+
+	// the archive is read up to its limit only
+	data := zipArchive(t)
+	a, err := HighlightArchive(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.read = maxArchiveSize - 1
+	for range archiveFiles {
+		if _, err := a.Next(); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("want ErrTooLarge, got %v", err)
+		}
+	}
+}
+
+func TestHighlightArchiveInvalid(t *testing.T) {
+	data := bytes.Repeat([]byte("not an archive "), 100)
+	a, err := HighlightArchive(bytes.NewReader(data), int64(len(data)))
+	if err == nil {
+		_, err = a.Next()
+	}
+	if err == nil || err == io.EOF {
+		t.Errorf("want error, got %v", err)
+	}
+}
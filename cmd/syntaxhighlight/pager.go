@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"golang.org/x/term"
+)
+
+// pager is a pager program, such as less, reading the output.
+type pager struct {
+	cmd *exec.Cmd
+	in  io.WriteCloser
+}
+
+// startPager starts the pager named by $PAGER, or else less, writing to out.
+// It returns nil if out is not a terminal or if there is no pager to run.
+// While the pager runs, interrupts are left to it, so that Ctrl-C stops a
+// search in less rather than the whole pipeline.
+func startPager(out *os.File) (*pager, error) {
+	if !term.IsTerminal(int(out.Fd())) {
+		return nil, nil
+	}
+	args := strings.Fields(os.Getenv("PAGER"))
+	if len(args) == 0 {
+		if _, err := exec.LookPath("less"); err != nil {
+			return nil, nil
+		}
+		args = []string{"less", "-R"}
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout, cmd.Stderr = out, os.Stderr
+	cmd.Env = os.Environ()
+	if os.Getenv("LESS") == "" {
+		// quit if the output fits on one screen, keep colors and leave the
+		// output on the screen, as git does
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	signal.Ignore(os.Interrupt)
+	if err := cmd.Start(); err != nil {
+		signal.Reset(os.Interrupt)
+		return nil, err
+	}
+	return &pager{cmd: cmd, in: in}, nil
+}
+
+// Write writes to the pager. Once the pager has been quit, writes are
+// discarded.
+func (p *pager) Write(b []byte) (int, error) {
+	n, err := p.in.Write(b)
+	if errors.Is(err, syscall.EPIPE) || errors.Is(err, os.ErrClosed) {
+		return len(b), nil
+	}
+	return n, err
+}
+
+// Close ends the input of the pager and waits for it to be quit.
+func (p *pager) Close() error {
+	p.in.Close()
+	err := p.cmd.Wait()
+	signal.Reset(os.Interrupt)
+	return err
+}
@@ -1,12 +1,30 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
+	"text/template"
 
 	"github.com/sourcegraph/syntaxhighlight"
+	"github.com/sourcegraph/syntaxhighlight/terminal"
+)
+
+var (
+	format    = flag.String("format", "html", "output format: html or ansi")
+	themeName = flag.String("theme", "auto", "theme of ansi output: auto, default, dark or high-contrast; auto picks one for the terminal's background")
+	wrap      = flag.Int("wrap", 0, "soft-wrap lines longer than this many characters; 0 means no wrapping")
+	color     = flag.String("color", "auto", "when to color ansi output: auto, always or never; auto colors output to a terminal unless NO_COLOR is set")
+	usePager  = flag.Bool("pager", false, "page output to a terminal with $PAGER, or less -R if it is not set")
+	output    = flag.String("o", "", "write output to this file instead of standard output")
+	watchMode = flag.Bool("watch", false, "highlight the files again and rewrite the output whenever they change")
+	lang      = flag.String("lang", "auto", "language of the input, such as go or python; auto detects it from the names and contents of the files")
+	detect    = flag.Bool("detect-only", false, "print the detected language of the input instead of highlighting it")
 )
 
 func main() {
@@ -14,19 +32,260 @@ func main() {
 
 	log.SetFlags(0)
 
-	if flag.NArg() != 1 {
-		log.Fatal("Must specify exactly 1 filename argument.")
+	files, err := expand(flag.Args())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(files) == 0 {
+		// standard input
+		files = []string{"-"}
+	}
+	if *lang != "auto" && syntaxhighlight.LookupLanguage(*lang) == nil {
+		log.Fatalf("Unknown language %q.", *lang)
+	}
+	if *detect {
+		if !detectLanguages(files) {
+			os.Exit(1)
+		}
+		return
 	}
 
-	input, err := ioutil.ReadFile(flag.Arg(0))
+	var (
+		w io.Writer = os.Stdout
+		p syntaxhighlight.Printer
+	)
+	switch *format {
+	case "html":
+	case "ansi":
+		mode, err := terminal.ParseColorMode(*color)
+		if err != nil {
+			log.Fatal(err)
+		}
+		colored := mode.Enabled(os.Stdout)
+		if *output != "" {
+			colored = mode == terminal.ColorAlways
+		}
+		p = plainPrinter{}
+		if colored {
+			p = syntaxhighlight.ANSIPrinter(theme(*themeName))
+			w = terminal.Writer(os.Stdout)
+		}
+	default:
+		log.Fatalf("Unknown format %q.", *format)
+	}
+
+	if *watchMode && *usePager {
+		log.Fatal("-pager and -watch cannot be used together.")
+	}
+	if *watchMode && files[0] == "-" {
+		log.Fatal("-watch needs files to watch.")
+	}
+	out, err := render(files, p)
 	if err != nil {
 		log.Fatal(err)
 	}
-
-	html, err := syntaxhighlight.AsHTML(input)
+	if *output != "" {
+		err = ioutil.WriteFile(*output, out, 0644)
+	} else if *usePager {
+		err = page(out, w)
+	} else {
+		_, err = w.Write(out)
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	fmt.Printf("%s", html)
+	if *watchMode {
+		err = watch(files, func() {
+			out, err := render(files, p)
+			if err == nil && *output != "" {
+				err = ioutil.WriteFile(*output, out, 0644)
+			} else if err == nil {
+				_, err = w.Write(out)
+			}
+			if err != nil {
+				log.Print(err)
+			}
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// render returns files highlighted with p, or as HTML if p is nil.
+func render(files []string, p syntaxhighlight.Printer) ([]byte, error) {
+	var out bytes.Buffer
+	if p == nil && len(files) > 1 {
+		writeIndex(&out, files)
+	}
+	for i, name := range files {
+		input, err := readInput(name)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) > 1 {
+			writeHeader(&out, i, name, p)
+		}
+		l := language(name, input)
+		if p == nil {
+			html, err := syntaxhighlight.AsHTML(input, syntaxhighlight.Lang(l), syntaxhighlight.WrapWidth(*wrap))
+			if err != nil {
+				return nil, err
+			}
+			if len(files) > 1 {
+				html = append(append([]byte("<pre>"), html...), "</pre>\n"...)
+			}
+			out.Write(html)
+			continue
+		}
+		s := syntaxhighlight.NewScanner(input)
+		if l != "" {
+			s.UseLanguage(syntaxhighlight.LookupLanguage(l))
+		}
+		if err := syntaxhighlight.Print(s, &out, syntaxhighlight.WrapLines(p, *wrap)); err != nil {
+			return nil, err
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// readInput reads the file name, or standard input if name is "-".
+func readInput(name string) ([]byte, error) {
+	if name == "-" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+	return ioutil.ReadFile(name)
+}
+
+// minGuessScore is the confidence from which a guess at the language of
+// the input is taken.
+const minGuessScore = 0.4
+
+// language returns the language of the file name with contents input: the
+// one set with -lang, or else the detected one, which is "" if it is not
+// known. Inputs whose language is not told by their names or #! lines are
+// guessed from their contents.
+func language(name string, input []byte) string {
+	if *lang != "auto" {
+		return *lang
+	}
+	if name == "-" {
+		name = ""
+	}
+	if l := syntaxhighlight.DetectLanguage(name, input); l != "" {
+		return l
+	}
+	if guesses := syntaxhighlight.GuessLanguage(name, input); len(guesses) > 0 && guesses[0].Score >= minGuessScore {
+		return guesses[0].Lang
+	}
+	return ""
+}
+
+// detectLanguages prints the detected languages of files, preceded by their
+// names if there are several, and reports whether all were detected.
+func detectLanguages(files []string) bool {
+	ok := true
+	for _, name := range files {
+		input, err := readInput(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		l := language(name, input)
+		if l == "" {
+			l, ok = "unknown", false
+		}
+		if len(files) > 1 {
+			fmt.Printf("%s: %s\n", name, l)
+		} else {
+			fmt.Println(l)
+		}
+	}
+	return ok
+}
+
+// page writes out through a pager if standard output is a terminal, and to
+// w otherwise.
+func page(out []byte, w io.Writer) error {
+	p, err := startPager(os.Stdout)
+	if err != nil || p == nil {
+		if err == nil {
+			_, err = w.Write(out)
+		}
+		return err
+	}
+	if _, err := p.Write(out); err != nil {
+		p.Close()
+		return err
+	}
+	return p.Close()
+}
+
+// expand returns the files named by args, expanding glob patterns, such as
+// "*.go", for shells that do not.
+func expand(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", arg, err)
+		}
+		if len(matches) == 0 {
+			// not a pattern, or one that matches nothing: report it as
+			// missing when it is read
+			matches = []string{arg}
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// writeIndex writes an HTML list linking to the headers of files.
+func writeIndex(w io.Writer, files []string) {
+	fmt.Fprintf(w, "<ul class=\"index\">\n")
+	for i, name := range files {
+		fmt.Fprintf(w, "<li><a href=\"#file-%d\">%s</a></li>\n", i+1, template.HTMLEscapeString(name))
+	}
+	fmt.Fprintf(w, "</ul>\n")
+}
+
+// writeHeader writes the header of the i-th file, name: an HTML heading if p
+// is nil, for HTML output, and otherwise a line that is bold if p is an
+// ANSIPrinter.
+func writeHeader(w io.Writer, i int, name string, p syntaxhighlight.Printer) {
+	switch p.(type) {
+	case nil:
+		fmt.Fprintf(w, "<h2 id=\"file-%d\">%s</h2>\n", i+1, template.HTMLEscapeString(name))
+		return
+	case syntaxhighlight.ANSIPrinter:
+		name = "\x1b[1m" + name + "\x1b[0m"
+	}
+	if i > 0 {
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "==> %s <==\n", name)
+}
+
+// theme returns the theme with the given name.
+func theme(name string) syntaxhighlight.Theme {
+	switch name {
+	case "auto":
+		return terminal.Theme()
+	case "default":
+		return syntaxhighlight.DefaultTheme
+	case "dark":
+		return syntaxhighlight.DarkTheme
+	case "high-contrast":
+		return syntaxhighlight.HighContrastTheme
+	}
+	log.Fatalf("Unknown theme %q.", name)
+	panic("unreachable")
+}
+
+// plainPrinter prints tokens without any styling.
+type plainPrinter struct{}
+
+func (plainPrinter) Print(w io.Writer, kind syntaxhighlight.Kind, tokText string) error {
+	_, err := io.WriteString(w, tokText)
+	return err
 }
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDelay is how long watch waits for more changes after a change, so
+// that a burst of writes, as editors make when saving, leads to one update.
+const watchDelay = 100 * time.Millisecond
+
+// watch calls update whenever one of files changes, until watching fails.
+// The directories of files are watched rather than the files themselves, so
+// that files replaced by editors, which often save by renaming a new file
+// over the old one, are still watched.
+func watch(files []string, update func()) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	watched := map[string]bool{}
+	for _, name := range files {
+		abs, err := filepath.Abs(name)
+		if err != nil {
+			return err
+		}
+		watched[abs] = true
+		if err := w.Add(filepath.Dir(abs)); err != nil {
+			return err
+		}
+	}
+
+	timer := time.NewTimer(watchDelay)
+	timer.Stop()
+	for {
+		select {
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if watched[filepath.Clean(ev.Name)] && ev.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+				timer.Reset(watchDelay)
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		case <-timer.C:
+			update()
+		}
+	}
+}
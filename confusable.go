@@ -0,0 +1,83 @@
+package syntaxhighlight
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// confusableScripts are the scripts whose letters are commonly mistaken
+// for one another.
+var confusableScripts = []*unicode.RangeTable{unicode.Latin, unicode.Cyrillic, unicode.Greek, unicode.Armenian, unicode.Cherokee}
+
+// lookalikes holds the non-Latin letters that look like Latin letters, so
+// that identifiers written entirely in them, such as Cyrillic "раураl",
+// pass for Latin ones.
+var lookalikes = map[rune]bool{}
+
+func init() {
+	for _, r := range "аеорсухіјѕԁԛԝҽһӏАВЕКМНОРСТХЅІЈԌԜ" + "οαντιρκΑΒΕΖΗΙΚΜΝΟΡΤΥΧ" + "օսոհցԛ" {
+		lookalikes[r] = true
+	}
+}
+
+// ConfusableKind returns Confusable if tok is an identifier that mixes
+// letters of scripts that are easily confused, such as Latin and Cyrillic,
+// or that is written entirely in letters that look like Latin ones;
+// otherwise it returns kind. It can be passed to Scanner.OnToken to flag
+// identifiers that may not be what they seem.
+func ConfusableKind(tok []byte, kind Kind) Kind {
+	switch kind {
+	case Plaintext, Type, Namespace, Label, Variable:
+	default:
+		return kind
+	}
+	if isASCII(tok) {
+		return kind
+	}
+
+	var script *unicode.RangeTable
+	allLookalikes := true
+	for len(tok) > 0 {
+		r, size := utf8.DecodeRune(tok)
+		tok = tok[size:]
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		allLookalikes = allLookalikes && lookalikes[r]
+		for _, t := range confusableScripts {
+			if unicode.Is(t, r) {
+				if script != nil && script != t {
+					return Confusable
+				}
+				script = t
+				break
+			}
+		}
+	}
+	if script != nil && script != unicode.Latin && allLookalikes {
+		return Confusable
+	}
+	return kind
+}
+
+// isASCII reports whether b contains only ASCII characters.
+func isASCII(b []byte) bool {
+	for _, c := range b {
+		if c >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// FlagConfusables highlights identifiers that may be mistaken for others,
+// because they mix letters of different scripts or consist of letters that
+// look like Latin ones, as Confusable (see ConfusableKind).
+//
+// Example:
+// AsHTML(input, FlagConfusables())
+func FlagConfusables() Option {
+	return func(o *HTMLConfig) {
+		o.FlagConfusables = true
+	}
+}
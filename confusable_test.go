@@ -0,0 +1,35 @@
+package syntaxhighlight
+
+import "testing"
+
+func TestConfusableKind(t *testing.T) {
+	tests := []struct {
+		tok  string
+		kind Kind
+		want Kind
+	}{
+		{"paypal", Plaintext, Plaintext},
+		{"pаypal", Plaintext, Confusable}, // Cyrillic а
+		{"раураl", Plaintext, Confusable}, // Cyrillic except for the l
+		{"рор", Plaintext, Confusable},    // all Cyrillic lookalikes
+		{"привет", Plaintext, Plaintext},
+		{"λx", Plaintext, Confusable},
+		{"Ελλάδα", Type, Type},
+		{"café", Plaintext, Plaintext},
+		{"pаypal", String, String},
+		{"日本_data", Plaintext, Plaintext},
+	}
+	for _, test := range tests {
+		if got := ConfusableKind([]byte(test.tok), test.kind); got != test.want {
+			t.Errorf("%q: want %#v, got %#v", test.tok, test.want, got)
+		}
+	}
+
+	got, err := AsHTML([]byte("if pаypal {}"), FlagConfusables())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<span class="kwd">if</span> <span class="pln cfs">pаypal</span> <span class="pun">{</span><span class="pun">}</span>`; string(got) != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
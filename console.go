@@ -0,0 +1,61 @@
+package syntaxhighlight
+
+import "regexp"
+
+// consolePromptRE matches the prompt at the start of a command line of a
+// shell session, such as "$ ", "# ", "user@host:~$ ", "(venv) $ ",
+// "[user@host dir]$ " or "PS C:\> ". The prompt must be followed by a space
+// or the end of the line, so that output such as "$5" is not mistaken for
+// a command.
+var consolePromptRE = regexp.MustCompile(`^(?:\([^()]*\) )?(?:PS [^>]*>|(?:[\w.-]+@[\w.-]+(?::[^\s$#%>]*)?|\[[^\]]+\])?[$#%>])(?: |$)`)
+
+// consoleLexer is a Lexer for shell sessions, made of commands after a
+// prompt and their output.
+type consoleLexer struct {
+	shell     Lexer // lexer of the current command, if any
+	rest      int   // length of the rest of the current command line
+	continued bool  // the current command line ends with a backslash
+}
+
+// newConsoleLexer returns a Lexer for shell sessions, as shown in
+// tutorials. Prompts are highlighted as Punctuation and the commands after
+// them as shell, including lines continued with a backslash; the other
+// lines are output and are left as Plaintext.
+func newConsoleLexer() Lexer {
+	return &consoleLexer{}
+}
+
+func (l *consoleLexer) Lex(data []byte, atEOF bool) (int, Kind) {
+	if l.rest > 0 {
+		// the shell lexer only sees the command line, so that a string
+		// left open by a command does not run into its output
+		if l.rest > len(data) {
+			return 0, 0
+		}
+		n, kind := l.shell.Lex(data[:l.rest], true)
+		l.rest -= n
+		return n, kind
+	}
+	if data[0] == '\n' {
+		if !l.continued {
+			l.shell = nil
+		}
+		return 1, Whitespace
+	}
+
+	n := scanLine(data, atEOF)
+	if n == 0 {
+		return 0, 0
+	}
+	line := data[:n]
+	if l.shell != nil {
+		l.rest, l.continued = n, endsWithBackslash(line)
+		return l.Lex(data, atEOF)
+	}
+	if prompt := consolePromptRE.Find(line); prompt != nil {
+		l.shell = newLanguageLexer(LookupLanguage("shell"))
+		l.rest, l.continued = n-len(prompt), endsWithBackslash(line)
+		return len(prompt), Punctuation
+	}
+	return n, Plaintext
+}
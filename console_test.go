@@ -0,0 +1,32 @@
+package syntaxhighlight
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConsoleLexer(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []token
+	}{
+		{"$ echo \"hi\" # greet\nhi\n$5 left\n", []token{
+			{Punctuation, "$ "}, {Plaintext, "echo"}, {Whitespace, " "}, {String, `"hi"`}, {Whitespace, " "}, {Comment, "# greet"}, {Whitespace, "\n"},
+			{Plaintext, "hi"}, {Whitespace, "\n"},
+			{Plaintext, "$5 left"}, {Whitespace, "\n"},
+		}},
+		{"user@host:~/src$ ls \\\n  -l\n(venv) # echo 'oops\n'\n", []token{
+			{Punctuation, "user@host:~/src$ "}, {Plaintext, "ls"}, {Whitespace, " "}, {Punctuation, "\\"}, {Whitespace, "\n"},
+			{Whitespace, "  "}, {Punctuation, "-"}, {Plaintext, "l"}, {Whitespace, "\n"},
+			{Punctuation, "(venv) # "}, {Plaintext, "echo"}, {Whitespace, " "}, {String, "'oops"}, {Whitespace, "\n"},
+			{Plaintext, "'"}, {Whitespace, "\n"},
+		}},
+	}
+	for _, test := range tests {
+		s := NewScanner([]byte(test.src))
+		s.UseLanguage(LookupLanguage("console"))
+		if got := scanAll(t, s); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q:\nwant %v\ngot  %v", test.src, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// sniffLen is the number of bytes at the start of the source that IsBinary
+// looks at, the same as git uses.
+const sniffLen = 8000
+
+// IsBinary reports whether src looks like binary data rather than text: it
+// does if the first 8000 bytes contain a NUL byte, or if more than a tenth
+// of them are control characters other than the usual whitespace, escape,
+// and form feed. Text in UTF-16 contains NUL bytes, so it is considered
+// binary.
+func IsBinary(src []byte) bool {
+	if len(src) > sniffLen {
+		src = src[:sniffLen]
+	}
+	if bytes.IndexByte(src, 0) >= 0 {
+		return true
+	}
+	control := 0
+	for _, c := range src {
+		if c < 0x20 && c != '\t' && c != '\n' && c != '\r' && c != '\f' && c != '\b' && c != 0x1b || c == 0x7f {
+			control++
+		}
+	}
+	return control*10 > len(src)
+}
+
+var (
+	// goGeneratedRE matches the line that marks generated Go files (see
+	// https://golang.org/s/generatedcode), which may be anywhere in a file.
+	goGeneratedRE = regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.$`)
+	// generatedRE matches other common markers of generated files.
+	generatedRE = regexp.MustCompile(`(?i)@generated\b|\bDO NOT EDIT\b|\bauto-?generated\b|\b(?:code )?generated (?:by|from|with)\b`)
+)
+
+// maxGeneratedLine is the number of lines at the start of the source in
+// which IsGenerated looks for markers other than Go's.
+const maxGeneratedLine = 10
+
+// IsGenerated reports whether src looks like it was generated by a tool,
+// that is, whether it has a line like "// Code generated by stringer; DO NOT
+// EDIT." as Go specifies, or a marker such as "@generated", "DO NOT EDIT" or
+// "auto-generated" in its first 10 lines.
+func IsGenerated(src []byte) bool {
+	if goGeneratedRE.Match(src) {
+		return true
+	}
+	n := 0 // end of the first maxGeneratedLine lines
+	for i := 0; i < maxGeneratedLine && n < len(src); i++ {
+		j := bytes.IndexByte(src[n:], '\n')
+		if j < 0 {
+			n = len(src)
+			break
+		}
+		n += j + 1
+	}
+	return generatedRE.Match(src[:n])
+}
@@ -0,0 +1,48 @@
+package syntaxhighlight
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsBinary(t *testing.T) {
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{"", false},
+		{"package main\n\nfunc main() {}\n", false},
+		{"\x1b[1mbold\x1b[0m\r\n\f\ttab", false},
+		{"héllo wörld", false},
+		{"\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", true},
+		{"\xff\xfeh\x00i\x00", true},
+		{"\x01\x02\x03\x04abc", true},
+		{strings.Repeat("a", sniffLen) + "\x00", false},
+	}
+	for _, test := range tests {
+		if got := IsBinary([]byte(test.src)); got != test.want {
+			t.Errorf("%q: want %v, got %v", test.src, test.want, got)
+		}
+	}
+}
+
+func TestIsGenerated(t *testing.T) {
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{"// Code generated by stringer -type=Kind; DO NOT EDIT.\n\npackage x", true},
+		{"// Copyright\n\n" + strings.Repeat("\n", 20) + "// Code generated by protoc-gen-go. DO NOT EDIT.\npackage x", true},
+		{"# @generated by yarn\n", true},
+		{"/* This file is auto-generated. */", true},
+		{"// generated by gostringer -type=Kind; DO NOT EDIT\n", true},
+		{"package main\n\n// Generate the thing.\nfunc generate() {}\n", false},
+		{strings.Repeat("x\n", maxGeneratedLine) + "// DO NOT EDIT\n", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if got := IsGenerated([]byte(test.src)); got != test.want {
+			t.Errorf("%q: want %v, got %v", test.src, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package syntaxhighlight
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CoverBlock is a block of statements in a Go coverage profile, as written
+// by go test -coverprofile.
+type CoverBlock struct {
+	FileName            string
+	StartLine, StartCol int
+	EndLine, EndCol     int
+	NumStmt             int
+	Count               int
+}
+
+var coverBlockRE = regexp.MustCompile(`^(.+):(\d+)\.(\d+),(\d+)\.(\d+) (\d+) (\d+)$`)
+
+// ParseCoverProfile reads the blocks of a Go coverage profile.
+func ParseCoverProfile(r io.Reader) ([]CoverBlock, error) {
+	var blocks []CoverBlock
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := s.Text()
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		m := coverBlockRE.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("syntaxhighlight: line %d of coverage profile: invalid block %q", n, line)
+		}
+		b := CoverBlock{FileName: m[1]}
+		for i, v := range []*int{&b.StartLine, &b.StartCol, &b.EndLine, &b.EndCol, &b.NumStmt, &b.Count} {
+			*v, _ = strconv.Atoi(m[i+2])
+		}
+		blocks = append(blocks, b)
+	}
+	return blocks, s.Err()
+}
+
+// Coverage marks every line of the output that is part of a coverage block
+// as covered or uncovered, by wrapping it in a <span class="covered"> or
+// <span class="uncovered"> element. A line is uncovered if any block on it
+// was not executed. The FileName of the blocks is ignored; pass only the
+// blocks of the file being highlighted.
+//
+// Example:
+// AsHTML(input, Coverage(blocks...))
+func Coverage(blocks ...CoverBlock) Option {
+	return func(o *HTMLConfig) {
+		o.Coverage = append(o.Coverage, blocks...)
+	}
+}
+
+// coveragePrinter wraps the lines printed by p in covered/uncovered spans.
+type coveragePrinter struct {
+	LinePrinter
+	covered map[int]bool // by line; absent if no block covers the line
+}
+
+func newCoveragePrinter(p LinePrinter, blocks []CoverBlock) *coveragePrinter {
+	c := &coveragePrinter{LinePrinter: p, covered: make(map[int]bool)}
+	for _, b := range blocks {
+		for line := b.StartLine; line <= b.EndLine; line++ {
+			if covered, ok := c.covered[line]; !ok || covered {
+				c.covered[line] = b.Count > 0
+			}
+		}
+	}
+	return c
+}
+
+func (c *coveragePrinter) BeginLine(w io.Writer, line int) error {
+	if err := c.LinePrinter.BeginLine(w, line); err != nil {
+		return err
+	}
+	if covered, ok := c.covered[line]; ok {
+		class := "uncovered"
+		if covered {
+			class = "covered"
+		}
+		_, err := fmt.Fprintf(w, `<span class="%s">`, class)
+		return err
+	}
+	return nil
+}
+
+func (c *coveragePrinter) EndLine(w io.Writer, line int) error {
+	if _, ok := c.covered[line]; ok {
+		if _, err := io.WriteString(w, "</span>"); err != nil {
+			return err
+		}
+	}
+	return c.LinePrinter.EndLine(w, line)
+}
@@ -0,0 +1,49 @@
+package syntaxhighlight
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCoverProfile(t *testing.T) {
+	input := `mode: set
+example.com/foo/foo.go:3.14,5.2 1 1
+example.com/foo/foo.go:5.2,6.3 2 0
+`
+	want := []CoverBlock{
+		{FileName: "example.com/foo/foo.go", StartLine: 3, StartCol: 14, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 1},
+		{FileName: "example.com/foo/foo.go", StartLine: 5, StartCol: 2, EndLine: 6, EndCol: 3, NumStmt: 2, Count: 0},
+	}
+	got, err := ParseCoverProfile(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+
+	if _, err := ParseCoverProfile(strings.NewReader("mode: set\nfoo.go:1 1 1\n")); err == nil {
+		t.Error("want error for invalid block")
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	src := []byte("a\nb\nc\nd")
+	got, err := AsHTML(src, OrderedList(), Coverage(
+		CoverBlock{StartLine: 2, EndLine: 3, Count: 1},
+		CoverBlock{StartLine: 3, EndLine: 4, Count: 0},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<ol>
+<li><span class="pln">a</span></li>
+<li><span class="covered"><span class="pln">b</span></span></li>
+<li><span class="uncovered"><span class="pln">c</span></span></li>
+<li><span class="uncovered"><span class="pln">d</span></span></li>
+</ol>`
+	if string(got) != want {
+		t.Errorf("want ==========\n%s\ngot ===========\n%s", want, got)
+	}
+}
@@ -0,0 +1,219 @@
+package syntaxhighlight
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+// cssNumberRE matches the numbers of CSS, with their units, such as "1.5em"
+// and "50%".
+var cssNumberRE = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?(%|[a-zA-Z]+)?`)
+
+// cssColorRE matches the hexadecimal colors of CSS, such as "#fff".
+var cssColorRE = regexp.MustCompile(`^#[0-9a-fA-F]+\b`)
+
+// cssTags are the tags of template literals of CSS in styled-components and
+// similar libraries, such as css`color: red;`.
+var cssTags = setOf("css", "keyframes", "createGlobalStyle", "injectGlobal")
+
+// cssTagState is the progress of a Scanner through the tag of a template
+// literal of CSS, such as css, styled.div or styled(Button).
+type cssTagState uint8
+
+const (
+	cssTagNone      cssTagState = iota
+	cssTagStyled                // after "styled"
+	cssTagDot                   // after "styled."
+	cssTagParen                 // after "styled("
+	cssTagComponent             // after "styled(Button"
+	cssTagDone                  // after a whole tag, before its template
+)
+
+// next returns the state after the token tok, which is neither whitespace
+// nor a comment.
+func (st cssTagState) next(tok []byte) cssTagState {
+	switch t := string(tok); {
+	case t == "." && st == cssTagStyled:
+		return cssTagDot
+	case t == "(" && st == cssTagStyled:
+		return cssTagParen
+	case t == ")" && st == cssTagComponent:
+		return cssTagDone
+	case t == "styled":
+		return cssTagStyled
+	case cssTags[t] || st == cssTagDot && isIdentRune(rune(t[0])):
+		return cssTagDone
+	case st == cssTagParen && isIdentRune(rune(t[0])):
+		return cssTagComponent
+	}
+	return cssTagNone
+}
+
+// cssLexer is a Lexer for CSS.
+type cssLexer struct {
+	value    bool // in the value of a declaration, after its colon
+	selector bool // in a selector, which ends at "{"
+	atRule   bool // in the prelude of an at-rule, such as "@media screen"
+}
+
+// newCSSLexer returns a Lexer for CSS. In selectors, element names are Tag,
+// class and ID selectors Type and pseudo-classes and pseudo-elements, such
+// as ":hover", Keyword. The names of properties are Variable, as are custom
+// properties such as "--gap", and in their values numbers are Decimal,
+// colors such as "#fff" Literal and "!important" Keyword. At-rules such as
+// "@media" are Keyword, strings String and comments Comment. Rules may be
+// nested, and declarations need not be in a block, as in the templates of
+// styled-components.
+func newCSSLexer() Lexer {
+	return &cssLexer{}
+}
+
+func (l *cssLexer) Lex(data []byte, atEOF bool) (int, Kind) {
+	if !atEOF && (len(data) < 3 || !utf8.FullRune(data)) {
+		return 0, 0
+	}
+	switch c := data[0]; {
+	case isBlank(c) || c == '\n':
+		n := markupSpace(data)
+		if n == len(data) && !atEOF {
+			return 0, 0
+		}
+		return n, Whitespace
+	case hasPrefix(data, "/*"):
+		return markupUntil(data, 2, "*/", atEOF), Comment
+	case c == '"' || c == '\'':
+		return grammarString(data, atEOF), String
+	case c == '{' || c == '}':
+		l.value, l.selector, l.atRule = false, false, false
+		return 1, Punctuation
+	case c == ';':
+		l.value, l.atRule = false, false
+		return 1, Punctuation
+	case c == '@':
+		n := 1 + cssName(data[1:])
+		if n == len(data) && !atEOF {
+			return 0, 0
+		}
+		l.atRule = n > 1
+		return n, Keyword
+	case l.value || l.atRule:
+		return l.lexValue(data, atEOF)
+	}
+
+	if !l.selector {
+		isSelector, ok := cssIsSelector(data, atEOF)
+		if !ok {
+			return 0, 0
+		}
+		if !isSelector {
+			// the property of a declaration
+			n := cssName(data)
+			if n == 0 {
+				l.value = data[0] == ':'
+				return 1, Punctuation
+			}
+			if n == len(data) && !atEOF {
+				return 0, 0
+			}
+			return n, Variable
+		}
+		l.selector = true
+	}
+
+	n, kind := 0, Tag
+	switch c := data[0]; c {
+	case '.', '#':
+		n, kind = 1+cssName(data[1:]), Type
+	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		// a keyframe selector, such as "50%"
+		n, kind = len(cssNumberRE.Find(data)), Decimal
+	case ':':
+		n = 1
+		if len(data) > 1 && data[1] == ':' {
+			n = 2
+		}
+		n, kind = n+cssName(data[n:]), Keyword
+	default:
+		n = cssName(data)
+	}
+	if n == len(data) && !atEOF {
+		return 0, 0
+	}
+	if n == 0 || n == 1 && (kind == Type || kind == Keyword) {
+		_, size := utf8.DecodeRune(data)
+		return size, Punctuation
+	}
+	return n, kind
+}
+
+// lexValue lexes a token of the value of a declaration or of the prelude of
+// an at-rule.
+func (l *cssLexer) lexValue(data []byte, atEOF bool) (int, Kind) {
+	n, kind := 0, Plaintext
+	switch c := data[0]; {
+	case c == '#':
+		n, kind = len(cssColorRE.Find(data)), Literal
+	case c == '!':
+		n, kind = 1+cssName(data[1:]), Keyword
+	case hasPrefix(data, "--"):
+		n, kind = cssName(data), Variable
+	case '0' <= c && c <= '9' || c == '.' || c == '+' || c == '-':
+		if m := cssNumberRE.Find(data); m != nil {
+			n, kind = len(m), Decimal
+		} else {
+			n = cssName(data)
+		}
+	default:
+		n = cssName(data)
+	}
+	if n == len(data) && !atEOF {
+		return 0, 0
+	}
+	if n == 0 || n == 1 && (kind == Literal || kind == Keyword) {
+		_, size := utf8.DecodeRune(data)
+		return size, Punctuation
+	}
+	return n, kind
+}
+
+// cssIsSelector reports whether the source at the start of data, in a
+// block or at the top level, is a selector, which is followed by a block,
+// rather than a declaration. ok is false if more data is needed.
+func cssIsSelector(data []byte, atEOF bool) (isSelector, ok bool) {
+	for i := 0; i < len(data); i++ {
+		switch data[i] {
+		case '{':
+			return true, true
+		case ';', '}':
+			return false, true
+		case '"', '\'':
+			n := grammarString(data[i:], atEOF)
+			if n == 0 {
+				return false, false
+			}
+			i += n - 1
+		}
+	}
+	return false, atEOF
+}
+
+// cssName returns the length of the identifier at the start of data, such
+// as "color", "-webkit-box" or "--gap", or 0 if there is none.
+func cssName(data []byte) int {
+	n := 0
+	for n < len(data) && data[n] == '-' {
+		n++
+	}
+	start := n
+	for n < len(data) {
+		r, size := utf8.DecodeRune(data[n:])
+		if r != '-' && !isIdentRune(r) {
+			break
+		}
+		n += size
+	}
+	if n == start && start < 2 {
+		return 0
+	}
+	return n
+}
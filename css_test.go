@@ -0,0 +1,55 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"testing/iotest"
+)
+
+func TestCSSLexer(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []token
+	}{
+		{"a.btn:hover, #main > p::before { color: #fff !important; margin: 0 -1.5em }", []token{
+			{Tag, "a"}, {Type, ".btn"}, {Keyword, ":hover"}, {Punctuation, ","}, {Whitespace, " "}, {Type, "#main"}, {Whitespace, " "},
+			{Punctuation, ">"}, {Whitespace, " "}, {Tag, "p"}, {Keyword, "::before"}, {Whitespace, " "}, {Punctuation, "{"}, {Whitespace, " "},
+			{Variable, "color"}, {Punctuation, ":"}, {Whitespace, " "}, {Literal, "#fff"}, {Whitespace, " "}, {Keyword, "!important"}, {Punctuation, ";"},
+			{Whitespace, " "}, {Variable, "margin"}, {Punctuation, ":"}, {Whitespace, " "}, {Decimal, "0"}, {Whitespace, " "}, {Decimal, "-1.5em"},
+			{Whitespace, " "}, {Punctuation, "}"},
+		}},
+		{"/* vars */\n:root { --gap: 4px; }\n@media (min-width: 600px) { .a { gap: var(--gap); content: \"{\" } }", []token{
+			{Comment, "/* vars */"}, {Whitespace, "\n"},
+			{Keyword, ":root"}, {Whitespace, " "}, {Punctuation, "{"}, {Whitespace, " "}, {Variable, "--gap"}, {Punctuation, ":"}, {Whitespace, " "},
+			{Decimal, "4px"}, {Punctuation, ";"}, {Whitespace, " "}, {Punctuation, "}"}, {Whitespace, "\n"},
+			{Keyword, "@media"}, {Whitespace, " "}, {Punctuation, "("}, {Plaintext, "min-width"}, {Punctuation, ":"}, {Whitespace, " "}, {Decimal, "600px"},
+			{Punctuation, ")"}, {Whitespace, " "}, {Punctuation, "{"}, {Whitespace, " "}, {Type, ".a"}, {Whitespace, " "}, {Punctuation, "{"}, {Whitespace, " "},
+			{Variable, "gap"}, {Punctuation, ":"}, {Whitespace, " "}, {Plaintext, "var"}, {Punctuation, "("}, {Variable, "--gap"}, {Punctuation, ")"},
+			{Punctuation, ";"}, {Whitespace, " "}, {Variable, "content"}, {Punctuation, ":"}, {Whitespace, " "}, {String, `"{"`}, {Whitespace, " "},
+			{Punctuation, "}"}, {Whitespace, " "}, {Punctuation, "}"},
+		}},
+		{"@keyframes spin { 50% { opacity: .5 } }", []token{
+			{Keyword, "@keyframes"}, {Whitespace, " "}, {Plaintext, "spin"}, {Whitespace, " "}, {Punctuation, "{"}, {Whitespace, " "},
+			{Decimal, "50%"}, {Whitespace, " "}, {Punctuation, "{"}, {Whitespace, " "}, {Variable, "opacity"}, {Punctuation, ":"}, {Whitespace, " "},
+			{Decimal, ".5"}, {Whitespace, " "}, {Punctuation, "}"}, {Whitespace, " "}, {Punctuation, "}"},
+		}},
+		{"color: red;\n&:hover { color: blue }", []token{
+			{Variable, "color"}, {Punctuation, ":"}, {Whitespace, " "}, {Plaintext, "red"}, {Punctuation, ";"}, {Whitespace, "\n"},
+			{Punctuation, "&"}, {Keyword, ":hover"}, {Whitespace, " "}, {Punctuation, "{"}, {Whitespace, " "}, {Variable, "color"}, {Punctuation, ":"},
+			{Whitespace, " "}, {Plaintext, "blue"}, {Whitespace, " "}, {Punctuation, "}"},
+		}},
+	}
+	for _, test := range tests {
+		for _, oneByte := range []bool{false, true} {
+			s := NewScanner([]byte(test.src))
+			if oneByte {
+				s = NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(test.src))))
+			}
+			s.UseLanguage(LookupLanguage("css"))
+			if got := scanAll(t, s); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%q:\nwant %v\ngot  %v", test.src, test.want, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package syntaxhighlight
+
+// csvColumnKinds are the kinds that the columns of a CSV or TSV file cycle
+// through, so that neighboring columns are told apart by their colors.
+var csvColumnKinds = []Kind{Plaintext, String, Type, Variable, Literal, Namespace}
+
+// newCSVLexer returns a Lexer for CSV files.
+func newCSVLexer() Lexer {
+	return newTableLexer(',')
+}
+
+// newTSVLexer returns a Lexer for TSV files.
+func newTSVLexer() Lexer {
+	return newTableLexer('\t')
+}
+
+// newTableLexer returns a Lexer for tables with fields separated by sep,
+// which may be quoted with double quotes as in CSV files. The fields of the
+// first row, the header, are highlighted as Keyword, and those of the
+// other rows with the kinds of their columns (see csvColumnKinds); the
+// separators are Punctuation.
+func newTableLexer(sep byte) Lexer {
+	var (
+		row    = 0     // row of the current line
+		col    = 0     // column of the current field
+		quoted = false // in a quoted field that spans lines
+	)
+	return &lineLexer{lexLine: func(line []byte) []lexToken {
+		var toks lineTokens
+		kind := func() Kind {
+			if row == 0 {
+				return Keyword
+			}
+			return csvColumnKinds[col%len(csvColumnKinds)]
+		}
+		start := 0
+		for i := 0; i < len(line); i++ {
+			switch {
+			case quoted && line[i] == '"':
+				if i+1 < len(line) && line[i+1] == '"' {
+					// escaped quote
+					i++
+					continue
+				}
+				quoted = false
+			case quoted:
+			case line[i] == '"' && i == start:
+				quoted = true
+			case line[i] == sep:
+				toks.add(i-start, kind())
+				toks.add(1, Punctuation)
+				col++
+				start = i + 1
+			}
+		}
+		end := len(line)
+		if end > start && line[end-1] == '\r' && !quoted {
+			end--
+		}
+		toks.add(end-start, kind())
+		toks.add(len(line)-end, Whitespace)
+		if !quoted {
+			row, col = row+1, 0
+		}
+		return toks
+	}}
+}
@@ -0,0 +1,31 @@
+package syntaxhighlight
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTableLexer(t *testing.T) {
+	tests := []struct {
+		lang, src string
+		want      []token
+	}{
+		{"csv", "name,note\r\nada,\"a, \"\"b\"\"\nc\"\nbob,\n", []token{
+			{Keyword, "name"}, {Punctuation, ","}, {Keyword, "note"}, {Whitespace, "\r"}, {Whitespace, "\n"},
+			{Plaintext, "ada"}, {Punctuation, ","}, {String, `"a, ""b""`}, {Whitespace, "\n"},
+			{String, `c"`}, {Whitespace, "\n"},
+			{Plaintext, "bob"}, {Punctuation, ","}, {Whitespace, "\n"},
+		}},
+		{"tsv", "a\tb\tc\n1\t2\t3", []token{
+			{Keyword, "a"}, {Punctuation, "\t"}, {Keyword, "b"}, {Punctuation, "\t"}, {Keyword, "c"}, {Whitespace, "\n"},
+			{Plaintext, "1"}, {Punctuation, "\t"}, {String, "2"}, {Punctuation, "\t"}, {Type, "3"},
+		}},
+	}
+	for _, test := range tests {
+		s := NewScanner([]byte(test.src))
+		s.UseLanguage(LookupLanguage(test.lang))
+		if got := scanAll(t, s); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s %q:\nwant %v\ngot  %v", test.lang, test.src, test.want, got)
+		}
+	}
+}
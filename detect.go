@@ -0,0 +1,278 @@
+package syntaxhighlight
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Guess is a candidate language of a source (see GuessLanguage).
+type Guess struct {
+	// Lang is the name of a registered language.
+	Lang string
+	// Score is the confidence in the guess, between 0 and 1.
+	Score float64
+}
+
+// Confidence in the kinds of evidence GuessLanguage weighs.
+const (
+	linguistScore    = 0.95 // the only candidate of linguist
+	extensionScore   = 0.9
+	interpreterScore = 0.8
+	ambiguousScore   = 0.5 // one of several candidates of linguist
+	clueScore        = 0.2
+)
+
+// linguist, if set, returns the candidate languages of a file as named by
+// GitHub Linguist, best first. It is set when building with the enry build
+// tag (see detect_enry.go).
+var linguist func(filename string, src []byte) []string
+
+// linguistNames maps the names of languages in Linguist that are neither
+// the names nor the aliases of registered languages, even with dashes for
+// spaces, as "Common Lisp" is, to those languages.
+var linguistNames = map[string]string{
+	"E-mail":       "email",
+	"Go Checksums": "gosum",
+	"Go Module":    "gomod",
+	"Go Workspace": "gowork",
+	"HTML+ERB":     "erb",
+	"Ignore List":  "gitignore",
+	"Jinja":        "html+jinja",
+}
+
+// lookupLinguistLanguage returns the registered language named name in
+// Linguist, or nil if there is none.
+func lookupLinguistLanguage(name string) *Language {
+	if lang, ok := linguistNames[name]; ok {
+		name = lang
+	}
+	if l := LookupLanguage(name); l != nil {
+		return l
+	}
+	return LookupLanguage(strings.ReplaceAll(name, " ", "-"))
+}
+
+// linguistLanguages returns the registered languages among the candidates
+// of linguist for a file, best first, and the number of candidates,
+// registered or not.
+func linguistLanguages(filename string, src []byte) (langs []string, candidates int) {
+	if linguist == nil {
+		return nil, 0
+	}
+	names := linguist(filename, src)
+	for _, name := range names {
+		if l := lookupLinguistLanguage(name); l != nil {
+			langs = append(langs, l.Name)
+		}
+	}
+	return langs, len(names)
+}
+
+// linguistLanguage returns the language of a file according to linguist,
+// or "" if it is not sure of it. It is sure only of a single candidate that
+// is registered, which overrides lang, the language found from the name of
+// the file, only where the name does not settle it: if lang is "" or is one
+// of the languages of a shared extension. An extension used by one language
+// thus wins over a #! line, as it does without linguist.
+func linguistLanguage(filename string, src []byte, lang string) string {
+	langs, candidates := linguistLanguages(filename, src)
+	if candidates != 1 || len(langs) != 1 {
+		return ""
+	}
+	_, named := Filenames[filepath.Base(filename)]
+	if lang != "" && (named || sharedExtensions[strings.ToLower(filepath.Ext(filename))] == nil) {
+		return ""
+	}
+	return langs[0]
+}
+
+// maxGuessBytes is the length of the start of a source that is searched for
+// clues.
+const maxGuessBytes = 64 << 10
+
+// clue is a pattern typical of source in a language.
+type clue struct {
+	lang string
+	re   *regexp.Regexp
+}
+
+// clues are the patterns GuessLanguage looks for in sources. Each one that
+// is found adds to the score of its language.
+var clues = func() []clue {
+	patterns := []struct{ lang, re string }{
+		{"go", `(?m)^package \w+$`},
+		{"go", `\bfunc (\(\w+ \*?\w+\) )?\w+\(`},
+		{"go", `\w+ := `},
+		{"go", `\bfmt\.\w+\(`},
+		{"python", `(?m)^\s*def \w+\(.*\):\s*$`},
+		{"python", `(?m)^(from [\w.]+ )?import [\w.]+( as \w+)?$`},
+		{"python", `\bself\.\w+`},
+		{"python", `(?m)^if __name__ == .__main__.:`},
+		{"javascript", `\bfunction\s*\w*\(`},
+		{"javascript", `\b(const|let) \w+ = `},
+		{"javascript", `\bconsole\.log\(`},
+		{"javascript", `\brequire\(['"]`},
+		{"typescript", `\b\w+\??: (string|number|boolean|any)\b`},
+		{"typescript", `(?m)^(export )?interface \w+ \{`},
+		{"c", `(?m)^#include <\w+\.h>`},
+		{"c", `\bprintf\(`},
+		{"c", `(?m)^int main\(`},
+		{"cpp", `(?m)^#include <\w+>`},
+		{"cpp", `\bstd::\w+`},
+		{"cpp", `\btemplate ?<`},
+		{"cpp", `(?m)^(namespace \w+|class \w+ *[:{]?$)`},
+		{"objective-c", `(?m)^@(interface|implementation|protocol|end)\b`},
+		{"objective-c", `(?m)^#import [<"]`},
+		{"objective-c", `\[\[\w+ alloc\]`},
+		{"objective-c", `\bNS(String|Object|Array|Dictionary|Integer|Log)\b`},
+		{"matlab", `(?m)^\s*function (\[[\w, ]+\]|\w+) = \w+`},
+		{"matlab", `(?m)^\s*%[ %{]`},
+		{"matlab", `(?m)^\s*(clc|clear all|close all|hold on)\s*;?$`},
+		{"matlab", `\b(disp|fprintf|zeros|ones|plot)\(`},
+		{"prolog", `(?m)^:- (module|use_module|dynamic|initialization)\(`},
+		{"prolog", `(?m)^[a-z]\w*(\(.*\))? :-\s*$`},
+		{"prolog", `\b(format|writeln|findall)\(`},
+		{"common-lisp", `(?m)^\((defun|defmacro|defpackage|in-package) `},
+		{"opencl", `\b(__)?kernel void\b`},
+		{"opencl", `\bget_(global|local|group)_id\(`},
+		{"opencl", `\b(__)?global (const )?\w+ ?\*`},
+		{"java", `\bpublic (static )?(final )?(class|void|interface)\b`},
+		{"java", `\bSystem\.out\.print`},
+		{"java", `(?m)^import java\.`},
+		{"csharp", `(?m)^using System`},
+		{"csharp", `\bConsole\.Write`},
+		{"ruby", `(?m)^\s*def \w+[?!]?(\(.*\))?$`},
+		{"ruby", `(?m)^\s*end$`},
+		{"ruby", `\bputs `},
+		{"ruby", `\bdo \|\w+\|`},
+		{"rust", `\bfn \w+(<.*>)?\(`},
+		{"rust", `\blet mut\b`},
+		{"rust", `\b(println|vec)!`},
+		{"php", `<\?php`},
+		{"php", `\$\w+ = `},
+		{"shell", `(?m)^\s*(if \[|fi$|then$|done$|esac$)`},
+		{"shell", `(?m)^\s*(echo|export) `},
+		{"perl", `(?m)^use strict;`},
+		{"perl", `\bmy [$@%]\w+`},
+		{"sql", `(?i)\bselect\b.+\bfrom\b`},
+		{"sql", `(?i)\b(create table|insert into)\b`},
+		{"lua", `(?m)^\s*local \w+ = `},
+		{"lua", `\bfunction \w+[.:]\w+\(`},
+		{"haskell", `(?m)^\w+ :: `},
+		{"haskell", `(?m)^module \w+( \(.*\))? where`},
+		{"yaml", `(?m)^---$`},
+		{"yaml", `(?m)^\w+:( |$)`},
+		{"makefile", `(?m)^[\w.-]+:( .*)?\n\t`},
+		{"makefile", `(?m)^\.PHONY:`},
+	}
+	clues := make([]clue, len(patterns))
+	for i, p := range patterns {
+		clues[i] = clue{lang: p.lang, re: regexp.MustCompile(p.re)}
+	}
+	return clues
+}()
+
+// sharedExtensions maps file name extensions that several languages use to
+// those languages, which are told apart by the clues in the contents.
+var sharedExtensions = map[string][]string{
+	".h":  {"c", "cpp", "objective-c"},
+	".cl": {"common-lisp", "opencl"},
+	".m":  {"objective-c", "matlab"},
+	".pl": {"perl", "prolog"},
+}
+
+// sourceFileLanguage is like fileLanguage, but for an extension that
+// several languages use, it returns the one of them for which the most
+// clues are found in src, if there are more than for the language in
+// Extensions.
+func sourceFileLanguage(filename string, src []byte) string {
+	lang := fileLanguage(filename)
+	langs := sharedExtensions[strings.ToLower(filepath.Ext(filename))]
+	if _, named := Filenames[filepath.Base(filename)]; named || lang == "" || langs == nil {
+		return lang
+	}
+	if len(src) > maxGuessBytes {
+		src = src[:maxGuessBytes]
+	}
+	counts := map[string]int{}
+	for _, c := range clues {
+		if c.re.Match(src) {
+			counts[c.lang]++
+		}
+	}
+	for _, l := range langs {
+		if counts[l] > counts[lang] && languages[l] != nil {
+			lang = l
+		}
+	}
+	return lang
+}
+
+// GuessLanguage returns the registered languages that the file with the
+// given name and contents may be in, most likely first, so that callers can
+// let users choose or fall back to plain text below a confidence threshold.
+// The evidence is the name or its extension (see Filenames and
+// Extensions), the interpreter in a #! line or the mode in an editor mode
+// line (see Interpreters), and patterns typical of each language in the
+// contents. Each adds to the score of its language, the name most and each
+// pattern least; with only patterns to go by, scores stay below 0.6. Built
+// with the enry build tag, the candidates of GitHub Linguist count as
+// evidence too, and most of all when there is only one. It returns nil if
+// there is no evidence for any language.
+func GuessLanguage(filename string, src []byte) []Guess {
+	// the chance that each language is not the one, for combining
+	// independent evidence
+	not := map[string]float64{}
+	add := func(lang string, score float64) {
+		if languages[lang] == nil {
+			return
+		}
+		if _, ok := not[lang]; !ok {
+			not[lang] = 1
+		}
+		not[lang] *= 1 - score
+	}
+
+	if langs, candidates := linguistLanguages(filename, src); candidates == 1 && len(langs) == 1 {
+		add(langs[0], linguistScore)
+	} else {
+		for _, lang := range langs {
+			add(lang, ambiguousScore)
+		}
+	}
+	if lang := sourceFileLanguage(filename, src); lang != "" {
+		add(lang, extensionScore)
+	}
+	s := NewScanner(src)
+	for s.line < maxModeLine && s.Scan() {
+		if interp := s.Interpreter(); interp != "" {
+			add(interpreterLanguage(interp), interpreterScore)
+			break
+		}
+	}
+	if len(src) > maxGuessBytes {
+		src = src[:maxGuessBytes]
+	}
+	for _, c := range clues {
+		if c.re.Match(src) {
+			add(c.lang, clueScore)
+		}
+	}
+
+	guesses := make([]Guess, 0, len(not))
+	for lang, p := range not {
+		guesses = append(guesses, Guess{Lang: lang, Score: 1 - p})
+	}
+	sort.Slice(guesses, func(i, j int) bool {
+		if guesses[i].Score != guesses[j].Score {
+			return guesses[i].Score > guesses[j].Score
+		}
+		return guesses[i].Lang < guesses[j].Lang
+	})
+	if len(guesses) == 0 {
+		return nil
+	}
+	return guesses
+}
@@ -0,0 +1,13 @@
+//go:build enry
+// +build enry
+
+package syntaxhighlight
+
+import "github.com/go-enry/go-enry/v2"
+
+// Built with the enry tag, language detection consults the extensions, file
+// names and heuristics of GitHub Linguist, which tell apart languages that
+// share extensions, such as ".h", ".m" and ".pl".
+func init() {
+	linguist = enry.GetLanguages
+}
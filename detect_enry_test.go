@@ -0,0 +1,20 @@
+//go:build enry
+// +build enry
+
+package syntaxhighlight
+
+import "testing"
+
+func TestDetectLanguageEnry(t *testing.T) {
+	src := []byte("#include <vector>\n\nclass A {};\n")
+	if got := DetectLanguage("a.h", src); got != "cpp" {
+		t.Errorf("want a C++ header detected as cpp, got %q", got)
+	}
+	if got := GuessLanguage("a.h", src); len(got) == 0 || got[0].Lang != "cpp" {
+		t.Errorf("want cpp guessed first for a C++ header, got %v", got)
+	}
+
+	if got := DetectLanguage("x.asd", []byte("(defsystem \"x\")\n")); got != "common-lisp" {
+		t.Errorf("want Common Lisp detected as common-lisp, got %q", got)
+	}
+}
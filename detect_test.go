@@ -0,0 +1,71 @@
+package syntaxhighlight
+
+import "testing"
+
+func TestGuessLanguage(t *testing.T) {
+	tests := []struct {
+		filename, src string
+		want          []string // leading guesses, most likely first
+		minScore      float64  // of the first guess
+	}{
+		{"main.go", "package main\n\nfunc main() {\n\tx := 1\n}\n", []string{"go"}, 0.9},
+		{"", "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(1)\n}\n", []string{"go"}, 0.45},
+		{"", "import os\n\ndef f(x):\n    return self.x\n", []string{"python"}, 0.45},
+		{"run", "#!/usr/bin/env ruby\nputs 1\n", []string{"ruby"}, 0.8},
+		{"x.h", "#include <stdio.h>\nint main() { printf(\"\"); }\n", []string{"c"}, 0.9},
+		{"", "#include <vector>\nstd::vector<int> v;\n", []string{"cpp"}, 0.3},
+		{"", "fn main() {\n    let mut v = vec![1];\n    println!(\"{:?}\", v);\n}\n", []string{"rust"}, 0.45},
+	}
+	for _, test := range tests {
+		got := GuessLanguage(test.filename, []byte(test.src))
+		if len(got) < len(test.want) {
+			t.Errorf("%q, %q: want leading guesses %v, got %v", test.filename, test.src, test.want, got)
+			continue
+		}
+		for i, lang := range test.want {
+			if got[i].Lang != lang {
+				t.Errorf("%q, %q: want leading guesses %v, got %v", test.filename, test.src, test.want, got)
+				break
+			}
+		}
+		if got[0].Score < test.minScore || got[0].Score > 1 {
+			t.Errorf("%q, %q: want a score of at least %v, got %v", test.filename, test.src, test.minScore, got[0].Score)
+		}
+		for i := 1; i < len(got); i++ {
+			if got[i].Score > got[i-1].Score {
+				t.Errorf("%q, %q: guesses not ranked: %v", test.filename, test.src, got)
+			}
+		}
+	}
+
+	if got := GuessLanguage("README", []byte("hello")); got != nil {
+		t.Errorf("want no guesses for prose, got %v", got)
+	}
+}
+
+func TestLinguistLanguage(t *testing.T) {
+	defer func(l func(string, []byte) []string) { linguist = l }(linguist)
+	tests := []struct {
+		candidates []string
+		want       string
+	}{
+		{[]string{"Common Lisp"}, "common-lisp"},
+		{[]string{"Go Module"}, "gomod"},
+		{[]string{"Python"}, "python"},
+		{[]string{"Ruby", "Frobnicate"}, ""},
+		{[]string{"Frobnicate"}, ""},
+		{nil, ""},
+	}
+	for _, test := range tests {
+		linguist = func(string, []byte) []string { return test.candidates }
+		if got := linguistLanguage("run", nil, ""); got != test.want {
+			t.Errorf("%q: want %q, got %q", test.candidates, test.want, got)
+		}
+	}
+
+	// several candidates are guessed as such, even if one is registered
+	linguist = func(string, []byte) []string { return []string{"Ruby", "Frobnicate"} }
+	if got := GuessLanguage("run", nil); len(got) != 1 || got[0] != (Guess{"ruby", ambiguousScore}) {
+		t.Errorf("want ruby guessed as ambiguous, got %v", got)
+	}
+}
@@ -0,0 +1,169 @@
+package syntaxhighlight
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Diagnostic is a message reported by a compiler, linter or similar tool
+// about a position in the source.
+type Diagnostic struct {
+	File     string
+	Line     int    // 1-based line number
+	Col      int    // 1-based byte column; 0 means the whole line
+	Severity string // "error", "warning" or "note"; empty means "error"
+	Message  string
+}
+
+var diagnosticRE = regexp.MustCompile(`^(.+?):(\d+):(?:(\d+):)?\s*(.*)$`)
+
+// ParseDiagnostics reads diagnostics in the common "file:line:col: message"
+// format, one per line, as printed by go vet, gcc, clang and many others. The
+// column is optional. A leading "error:", "warning:" or "note:" in the
+// message is stored as the Severity. Lines that are not diagnostics are
+// skipped.
+func ParseDiagnostics(r io.Reader) ([]Diagnostic, error) {
+	var diags []Diagnostic
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		m := diagnosticRE.FindStringSubmatch(s.Text())
+		if m == nil {
+			continue
+		}
+		d := Diagnostic{File: m[1], Message: m[4]}
+		d.Line, _ = strconv.Atoi(m[2])
+		if m[3] != "" {
+			d.Col, _ = strconv.Atoi(m[3])
+		}
+		for _, sev := range []string{"error", "warning", "note"} {
+			if strings.HasPrefix(d.Message, sev+":") {
+				d.Severity = sev
+				d.Message = strings.TrimSpace(d.Message[len(sev)+1:])
+				break
+			}
+		}
+		diags = append(diags, d)
+	}
+	return diags, s.Err()
+}
+
+// Diagnostics overlays diagnostics on the output. The token at each
+// diagnostic's position (or the whole line if it has no column) is
+// underlined with a squiggly line, and an icon is placed at the start of each
+// line that has diagnostics. The messages are shown as tooltips. The File of
+// the diagnostics is ignored; filter them beforehand if needed.
+//
+// Example:
+// AsHTML(input, Diagnostics(diags...))
+func Diagnostics(diags ...Diagnostic) Option {
+	return func(o *HTMLConfig) {
+		o.Diagnostics = append(o.Diagnostics, diags...)
+	}
+}
+
+// diagnosticPrinter overlays diagnostics on the output of p.
+type diagnosticPrinter struct {
+	p     LinePrinter
+	diags map[int][]Diagnostic // by line
+
+	line int
+	col  int // column of the next token
+
+	accessible bool // label icons for screen readers
+}
+
+func newDiagnosticPrinter(p LinePrinter, diags []Diagnostic) *diagnosticPrinter {
+	d := &diagnosticPrinter{p: p, diags: make(map[int][]Diagnostic)}
+	for _, diag := range diags {
+		d.diags[diag.Line] = append(d.diags[diag.Line], diag)
+	}
+	return d
+}
+
+func (d *diagnosticPrinter) Print(w io.Writer, kind Kind, tokText string) error {
+	if tokText == "\n" {
+		return d.p.Print(w, kind, tokText)
+	}
+
+	col := d.col
+	d.col += len(tokText)
+	var hits []Diagnostic
+	for _, diag := range d.diags[d.line] {
+		if diag.Col <= 0 || (diag.Col >= col && diag.Col < d.col) {
+			hits = append(hits, diag)
+		}
+	}
+	if len(hits) == 0 {
+		return d.p.Print(w, kind, tokText)
+	}
+
+	_, err := fmt.Fprintf(w, `<span class="diagnostic %s" title="%s" style="text-decoration:underline wavy %s">`,
+		severity(hits), diagnosticTitle(hits), severityColor(severity(hits)))
+	if err != nil {
+		return err
+	}
+	if err := d.p.Print(w, kind, tokText); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "</span>")
+	return err
+}
+
+func (d *diagnosticPrinter) BeginLine(w io.Writer, line int) error {
+	d.line, d.col = line, 1
+	if err := d.p.BeginLine(w, line); err != nil {
+		return err
+	}
+	if diags := d.diags[line]; len(diags) > 0 {
+		var aria string
+		if d.accessible {
+			aria = fmt.Sprintf(` role="img" aria-label="%s: %s"`, severity(diags), diagnosticTitle(diags))
+		}
+		_, err := fmt.Fprintf(w, `<span class="diagnostic-icon %s" title="%s"%s style="user-select:none;color:%s">&#9679;</span>`,
+			severity(diags), diagnosticTitle(diags), aria, severityColor(severity(diags)))
+		return err
+	}
+	return nil
+}
+
+func (d *diagnosticPrinter) EndLine(w io.Writer, line int) error {
+	return d.p.EndLine(w, line)
+}
+
+// severity returns the most severe severity of diags.
+func severity(diags []Diagnostic) string {
+	sev := "note"
+	for _, d := range diags {
+		switch d.Severity {
+		case "error", "":
+			return "error"
+		case "warning":
+			sev = "warning"
+		}
+	}
+	return sev
+}
+
+func severityColor(sev string) string {
+	switch sev {
+	case "error":
+		return "red"
+	case "warning":
+		return "orange"
+	}
+	return "blue"
+}
+
+// diagnosticTitle returns the HTML-escaped messages of diags, one per line.
+func diagnosticTitle(diags []Diagnostic) string {
+	msgs := make([]string, len(diags))
+	for i, d := range diags {
+		msgs[i] = d.Message
+	}
+	return template.HTMLEscapeString(strings.Join(msgs, "\n"))
+}
@@ -0,0 +1,41 @@
+package syntaxhighlight
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDiagnostics(t *testing.T) {
+	input := `# example.com/foo
+foo.go:3:7: undefined: bar
+C:\src\foo.c:12: warning: unused variable 'x'
+exit status 2
+`
+	want := []Diagnostic{
+		{File: "foo.go", Line: 3, Col: 7, Message: "undefined: bar"},
+		{File: `C:\src\foo.c`, Line: 12, Severity: "warning", Message: "unused variable 'x'"},
+	}
+	got, err := ParseDiagnostics(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	src := []byte("a := b\nc")
+	got, err := AsHTML(src, Diagnostics(Diagnostic{Line: 1, Col: 6, Severity: "error", Message: "undefined: b"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<span class="diagnostic-icon error" title="undefined: b" style="user-select:none;color:red">&#9679;</span>` +
+		`<span class="pln">a</span> <span class="pun">:</span><span class="pun">=</span> ` +
+		`<span class="diagnostic error" title="undefined: b" style="text-decoration:underline wavy red"><span class="pln">b</span></span>` + "\n" +
+		`<span class="pln">c</span>`
+	if string(got) != want {
+		t.Errorf("want ==========\n%s\ngot ===========\n%s", want, got)
+	}
+}
@@ -0,0 +1,48 @@
+package syntaxhighlight
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTooLarge is the reason given to OnFallback when a source exceeds
+// MaxSize or MaxLineLength.
+var ErrTooLarge = errors.New("syntaxhighlight: source too large")
+
+// ErrBudgetExceeded is the reason given to OnFallback when highlighting a
+// source takes more work than WorkBudget allows.
+var ErrBudgetExceeded = errors.New("syntaxhighlight: work budget exceeded")
+
+// ErrUnterminatedString is the error of a strict Scanner (see
+// Scanner.Strict) that meets a string literal without its closing quote.
+type ErrUnterminatedString struct {
+	Pos Position // start of the string
+}
+
+func (e *ErrUnterminatedString) Error() string {
+	return fmt.Sprintf("syntaxhighlight: %s: unterminated string", e.Pos)
+}
+
+// Is reports whether target is an *ErrUnterminatedString at the same
+// position, or with a zero position, which matches any.
+func (e *ErrUnterminatedString) Is(target error) bool {
+	t, ok := target.(*ErrUnterminatedString)
+	return ok && (t.Pos == Position{} || t.Pos == e.Pos)
+}
+
+// ErrUnsupportedLanguage is the error for a language that is not
+// registered (see Lang and LookupLanguage).
+type ErrUnsupportedLanguage struct {
+	Name string
+}
+
+func (e *ErrUnsupportedLanguage) Error() string {
+	return fmt.Sprintf("syntaxhighlight: unsupported language %q", e.Name)
+}
+
+// Is reports whether target is an *ErrUnsupportedLanguage for the same
+// language, or with an empty name, which matches any.
+func (e *ErrUnsupportedLanguage) Is(target error) bool {
+	t, ok := target.(*ErrUnsupportedLanguage)
+	return ok && (t.Name == "" || t.Name == e.Name)
+}
@@ -0,0 +1,138 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// Excerpt returns the highlighted lines of src containing the byte range
+// [start, end), along with up to contextLines lines before and after it. The
+// range itself is emphasized with <mark> elements. The whole of src is lexed,
+// so tokens that begin before the excerpt (such as block comments) are
+// highlighted correctly. Line numbers in the output (see OrderedList and
+// LineAnchors) are those of src.
+func Excerpt(src []byte, start, end, contextLines int, options ...Option) ([]byte, error) {
+	if start < 0 || end < start || end > len(src) {
+		return nil, errors.New("syntaxhighlight: excerpt range out of bounds")
+	}
+	if contextLines < 0 {
+		contextLines = 0
+	}
+
+	last := end
+	if last > start {
+		last--
+	}
+	from := 1 + bytes.Count(src[:start], []byte("\n")) - contextLines
+	to := 1 + bytes.Count(src[:last], []byte("\n")) + contextLines
+	if from < 1 {
+		from = 1
+	}
+
+	opt := newHTMLConfig(options)
+	s, err := opt.scanner(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &markingPrinter{
+		LinePrinter: &lineRangePrinter{p: asLinePrinter(opt.printer()), from: from, to: to},
+		start:       start,
+		end:         end,
+	}
+	out, err := opt.renderFrom(s, p, from)
+	if err != nil && err != errTruncated {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PrintRange prints lines fromLine through toLine (inclusive, counting from
+// 1) of src to w using p, as Print would print them as part of the whole
+// source. src is lexed from the start, so tokens that begin before fromLine,
+// such as block comments, are highlighted correctly, but lexing stops after
+// toLine. If p is a LinePrinter, its lines keep their numbers in src.
+func PrintRange(src []byte, fromLine, toLine int, w io.Writer, p Printer) error {
+	r := &lineRangePrinter{p: asLinePrinter(p), from: fromLine, to: toLine}
+	if err := Print(NewScanner(src), w, r); err != nil && err != errTruncated {
+		return err
+	}
+	return nil
+}
+
+// lineRangePrinter passes on lines from through to (inclusive) to p and
+// drops all others.
+type lineRangePrinter struct {
+	p        LinePrinter
+	from, to int
+	line     int
+}
+
+func (r *lineRangePrinter) Print(w io.Writer, kind Kind, tokText string) error {
+	if r.line < r.from || r.line > r.to || (tokText == "\n" && r.line == r.to) {
+		return nil
+	}
+	return r.p.Print(w, kind, tokText)
+}
+
+func (r *lineRangePrinter) BeginLine(w io.Writer, line int) error {
+	r.line = line
+	if line > r.to {
+		return errTruncated
+	}
+	if line < r.from {
+		return nil
+	}
+	return r.p.BeginLine(w, line)
+}
+
+func (r *lineRangePrinter) EndLine(w io.Writer, line int) error {
+	if line < r.from || line > r.to {
+		return nil
+	}
+	return r.p.EndLine(w, line)
+}
+
+// markingPrinter wraps the part of its output that stems from the byte range
+// [start, end) of the source in <mark> elements. It relies on seeing every
+// byte of the source, so it must come before any printer that drops tokens.
+type markingPrinter struct {
+	LinePrinter
+	start, end int
+
+	off int // source offset of the next token
+}
+
+func (m *markingPrinter) Print(w io.Writer, kind Kind, tokText string) error {
+	off := m.off
+	m.off += len(tokText)
+	if tokText == "\n" || m.start == m.end || off >= m.end || off+len(tokText) <= m.start {
+		return m.LinePrinter.Print(w, kind, tokText)
+	}
+
+	i, j := m.start-off, m.end-off
+	if i < 0 {
+		i = 0
+	}
+	if j > len(tokText) {
+		j = len(tokText)
+	}
+	if i > 0 {
+		if err := m.LinePrinter.Print(w, kind, tokText[:i]); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(w, "<mark>"); err != nil {
+		return err
+	}
+	if err := m.LinePrinter.Print(w, kind, tokText[i:j]); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "</mark>"); err != nil {
+		return err
+	}
+	if j < len(tokText) {
+		return m.LinePrinter.Print(w, kind, tokText[j:])
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExcerpt(t *testing.T) {
+	src := []byte("/* one\ntwo */\nthree := 3\nfour\nfive")
+	start := bytes.Index(src, []byte("ee := 3"))
+	end := start + len("ee :")
+
+	got, err := Excerpt(src, start, end, 1, LineAnchors())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<span class="line" id="L2" data-line="2"><span class="com">two */</span></span>
+<span class="line" id="L3" data-line="3"><span class="pln">thr</span><mark><span class="pln">ee</span></mark><mark> </mark><mark><span class="pun">:</span></mark><span class="pun">=</span> <span class="dec">3</span></span>
+<span class="line" id="L4" data-line="4"><span class="pln">four</span></span>`
+	if string(got) != want {
+		t.Errorf("want ==========\n%s\ngot ===========\n%s", want, got)
+	}
+
+	if _, err := Excerpt(src, 3, len(src)+1, 0); err == nil {
+		t.Error("want error for out of bounds range")
+	}
+
+	// lists and tables number the lines of the excerpt as those of src
+	four := bytes.Index(src, []byte("four"))
+	numbered := []struct {
+		start  int
+		option Option
+		want   string
+	}{
+		{four, OrderedList(), `<ol start="4">` + "\n" + `<li><span class="pln">four</span></li>` + "\n</ol>"},
+		{four, Table(), `<table class="highlight" style="white-space:pre">` + "\n" + `<tr><td class="gutter" data-line="4" style="user-select:none">4</td><td class="code"><span class="pln">four</span></td></tr>` + "\n</table>"},
+		{0, OrderedList(), "<ol>\n" + `<li><span class="com">/* one</span></li>` + "\n</ol>"},
+	}
+	for _, test := range numbered {
+		got, err := Excerpt(src, test.start, test.start, 0, test.option)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != test.want {
+			t.Errorf("want %q, got %q", test.want, got)
+		}
+	}
+}
+
+func TestPrintRange(t *testing.T) {
+	src := []byte("a\n/* b\nc\nd */ e\nf\n")
+	tests := []struct {
+		from, to int
+		p        Printer
+		want     string
+	}{
+		{3, 4, HTMLPrinter(DefaultHTMLConfig), `<span class="com">c</span>` + "\n" + `<span class="com">d */</span> <span class="pln">e</span>`},
+		{4, 10, HTMLPrinter(DefaultHTMLConfig), `<span class="com">d */</span> <span class="pln">e</span>` + "\n" + `<span class="pln">f</span>` + "\n"},
+		{2, 2, htmlLinePrinter{HTMLPrinter(newHTMLConfig([]Option{LineAnchors()}))}, `<span class="line" id="L2" data-line="2"><span class="com">/* b</span></span>`},
+		{7, 8, HTMLPrinter(DefaultHTMLConfig), ""},
+	}
+	for _, test := range tests {
+		var buf strings.Builder
+		if err := PrintRange(src, test.from, test.to, &buf, test.p); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("lines %d-%d: want %q, got %q", test.from, test.to, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// HighlightFile reads the file at path and returns it highlighted as HTML,
+// like AsHTML. Its language is detected from its name and contents (see
+// DetectLanguage), unless it is set with the Lang option.
+func HighlightFile(path string, options ...Option) ([]byte, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	options = append([]Option{Lang(DetectLanguage(path, src))}, options...)
+	return AsHTML(src, options...)
+}
+
+// HighlightTree renders every source file in fsys, that is, every file whose
+// language is detected (see DetectLanguage), as a page (see RenderPage) in
+// outDir. The pages mirror the layout of fsys, with ".html" added to the
+// file names, and outDir/index.html links to all of them. The title of each
+// page is the path of its file; the Title of opts is used for the index.
+func HighlightTree(fsys fs.FS, outDir string, opts PageOptions) error {
+	var files []string
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		src, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		lang := DetectLanguage(name, src)
+		if lang == "" {
+			return nil
+		}
+
+		page := opts
+		page.Title = name
+		page.Options = append([]Option{Lang(lang)}, opts.Options...)
+		out, err := RenderPage(src, page)
+		if err != nil {
+			return fmt.Errorf("syntaxhighlight: %s: %v", name, err)
+		}
+		dst := filepath.Join(outDir, filepath.FromSlash(name)+".html")
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(dst, out, 0644); err != nil {
+			return err
+		}
+		files = append(files, name)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(outDir, "index.html"), treeIndex(opts.Title, files), 0644)
+}
+
+// treeIndex returns an HTML page with the given title that links to the
+// pages of files.
+func treeIndex(title string, files []string) []byte {
+	var buf bytes.Buffer
+	title = template.HTMLEscapeString(title)
+	fmt.Fprintf(&buf, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body>\n<h1>%s</h1>\n<ul>\n", title, title)
+	for _, name := range files {
+		href := (&url.URL{Path: name + ".html"}).String()
+		fmt.Fprintf(&buf, "<li><a href=\"%s\">%s</a></li>\n", template.HTMLEscapeString(href), template.HTMLEscapeString(name))
+	}
+	buf.WriteString("</ul>\n</body>\n</html>\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,95 @@
+package syntaxhighlight
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestHighlightFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syntaxhighlight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "a.py")
+	if err := ioutil.WriteFile(path, []byte("x # c"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := HighlightFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<span class="pln">x</span> <span class="com"># c</span>`; string(got) != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	got, err = HighlightFile(path, Lang("go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<span class="pln">x</span> <span class="pun">#</span> <span class="pln">c</span>`; string(got) != want {
+		t.Errorf("with Lang: want %q, got %q", want, got)
+	}
+
+	if _, err := HighlightFile(filepath.Join(dir, "missing.go")); err == nil {
+		t.Error("want error for missing file")
+	}
+}
+
+func TestHighlightTree(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":          {Data: []byte("package main")},
+		"lib/util.py":      {Data: []byte("x # c")},
+		"bin/run":          {Data: []byte("#!/bin/sh\necho hi")},
+		"README":           {Data: []byte("hello")},
+		"docs/a b&c.rb":    {Data: []byte("puts 1")},
+		"docs/empty/.keep": {Data: nil},
+	}
+	out, err := ioutil.TempDir("", "syntaxhighlight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(out)
+
+	if err := HighlightTree(fsys, out, PageOptions{Title: "tree"}); err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := ioutil.ReadFile(filepath.Join(out, "lib", "util.py.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"<title>lib/util.py</title>", `<span class="com"># c</span>`} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("util.py.html: want %q in\n%s", want, page)
+		}
+	}
+	for _, name := range []string{"README.html", "docs/empty/.keep.html"} {
+		if _, err := os.Stat(filepath.Join(out, name)); !os.IsNotExist(err) {
+			t.Errorf("%s: want no page, got error %v", name, err)
+		}
+	}
+
+	index, err := ioutil.ReadFile(filepath.Join(out, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<title>tree</title>
+</head>
+<body>
+<h1>tree</h1>
+<ul>
+<li><a href="bin/run.html">bin/run</a></li>
+<li><a href="docs/a%20b&amp;c.rb.html">docs/a b&amp;c.rb</a></li>
+<li><a href="lib/util.py.html">lib/util.py</a></li>
+<li><a href="main.go.html">main.go</a></li>
+</ul>`
+	if !strings.Contains(string(index), want) {
+		t.Errorf("index.html: want\n%s\ngot\n%s", want, index)
+	}
+}
@@ -0,0 +1,255 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// conventionalCommitRE matches the subject lines of conventional commits,
+// such as "feat(parser)!: add arrays", in which it captures the type, the
+// scope in parentheses and the "!" of breaking changes.
+var conventionalCommitRE = regexp.MustCompile(`^([a-z]+)(\([^()]*\))?(!)?: `)
+
+// gitTrailerRE matches the keys of the trailers of commit messages, such as
+// "Signed-off-by: ".
+var gitTrailerRE = regexp.MustCompile(`^([A-Za-z]+(-[A-Za-z]+)+|BREAKING CHANGE|Fixes|Closes|Refs):( |$)`)
+
+// gitScissors is the line of a commit message below which git ignores the
+// rest of the message.
+var gitScissors = []byte("# ------------------------ >8 ------------------------")
+
+// gitRebaseCommands are the commands of interactive rebase todo lists, with
+// their abbreviations.
+var gitRebaseCommands = setOf(
+	"pick", "p", "reword", "r", "edit", "e", "squash", "s", "fixup", "f", "exec", "x", "break", "b",
+	"drop", "d", "label", "l", "reset", "t", "merge", "m", "update-ref", "u", "noop",
+)
+
+// newGitignoreLexer returns a Lexer for gitignore files, which highlights
+// the paths of patterns as String, their wildcards, such as "*", "**" and
+// "[a-z]", as Variable and escaped characters as Literal. The "!" that
+// negates a pattern is Keyword, and comments, after "#", are Comment.
+func newGitignoreLexer() Lexer {
+	return &lineLexer{lexLine: func(line []byte) []lexToken {
+		var toks lineTokens
+		if len(line) > 0 && line[0] == '#' {
+			toks.add(len(line), Comment)
+			return toks
+		}
+		pattern := bytes.TrimRight(line, " \t\r")
+		i := 0
+		if len(pattern) > 0 && pattern[0] == '!' {
+			toks.add(1, Keyword)
+			i = 1
+		}
+		for i < len(pattern) {
+			n, kind := 1, String
+			switch c := pattern[i]; {
+			case c == '\\' && i+1 < len(pattern):
+				n, kind = 2, Literal
+			case c == '*' || c == '?':
+				kind = Variable
+			case c == '[':
+				if j := bytes.IndexByte(pattern[i+1:], ']'); j >= 0 {
+					n, kind = j+2, Variable
+				}
+			case c == '/':
+				kind = Punctuation
+			}
+			toks.add(n, kind)
+			i += n
+		}
+		toks.add(len(line)-len(pattern), Whitespace)
+		return toks
+	}}
+}
+
+// newGitConfigLexer returns a Lexer for git config files, which highlights
+// section names, as in `[remote "origin"]`, as Label, with subsections as
+// String, and the names of variables as Variable and their values as in
+// systemd unit files. Comments, after "#" or ";", are Comment.
+func newGitConfigLexer() Lexer {
+	return &lineLexer{lexLine: func(line []byte) []lexToken {
+		var toks lineTokens
+		i := blanks(line)
+		toks.add(i, Whitespace)
+		rest := line[i:]
+		switch {
+		case len(rest) > 0 && (rest[0] == '#' || rest[0] == ';'):
+			toks.add(len(rest), Comment)
+			return toks
+		case len(rest) > 0 && rest[0] == '[':
+			gitConfigSection(&toks, rest)
+			return toks
+		}
+		n := 0
+		for n < len(rest) && rest[n] != '=' && !isBlank(rest[n]) && rest[n] != '#' && rest[n] != ';' {
+			n++
+		}
+		toks.add(n, Variable)
+		rest = rest[n:]
+		n = blanks(rest)
+		toks.add(n, Whitespace)
+		rest = rest[n:]
+		if len(rest) > 0 && rest[0] == '=' {
+			toks.add(1, Punctuation)
+			n := blanks(rest[1:])
+			toks.add(n, Whitespace)
+			rest = rest[1+n:]
+		}
+
+		// the value ends at a comment outside quotes
+		value := len(rest)
+		quoted := false
+		for i := 0; i < len(rest) && value == len(rest); i++ {
+			switch c := rest[i]; {
+			case c == '\\':
+				i++
+			case c == '"':
+				quoted = !quoted
+			case !quoted && (c == '#' || c == ';'):
+				value = i
+			}
+		}
+		end := len(bytes.TrimRight(rest[:value], " \t\r"))
+		configValue(&toks, rest[:end], nil)
+		toks.add(value-end, Whitespace)
+		toks.add(len(rest)-value, Comment)
+		return toks
+	}}
+}
+
+// gitConfigSection adds the tokens of a section header of a git config
+// file, which may name a subsection in quotes, at the start of line.
+func gitConfigSection(toks *lineTokens, line []byte) {
+	q := bytes.IndexByte(line, '"')
+	end := bytes.LastIndexByte(line, ']')
+	if q < 0 || end < q {
+		configSection(toks, line)
+		return
+	}
+	toks.add(1, Punctuation)
+	name := bytes.TrimRight(line[1:q], " \t")
+	toks.add(len(name), Label)
+	toks.add(q-1-len(name), Whitespace)
+	toks.add(end-q, String)
+	toks.add(1, Punctuation)
+	rest := line[end+1:]
+	n := blanks(rest)
+	toks.add(n, Whitespace)
+	toks.add(len(rest)-n, Comment)
+}
+
+// newGitCommitLexer returns a Lexer for commit messages. The type of a
+// conventional commit, as "feat" in "feat(parser): add arrays", is
+// Keyword and its scope Label; trailers, such as "Signed-off-by:", are
+// Variable, with their values String. Comments, which are the lines that
+// start with "#", are Comment, as is everything after the scissors line
+// that git adds for "commit --verbose".
+func newGitCommitLexer() Lexer {
+	subject := true // the next line that is not a comment is the subject
+	scissors := false
+	return &lineLexer{lexLine: func(line []byte) []lexToken {
+		var toks lineTokens
+		switch {
+		case scissors || bytes.Equal(bytes.TrimRight(line, "\r"), gitScissors):
+			scissors = true
+			toks.add(len(line), Comment)
+			return toks
+		case len(line) > 0 && line[0] == '#':
+			toks.add(len(line), Comment)
+			return toks
+		case subject:
+			subject = false
+			m := conventionalCommitRE.FindSubmatchIndex(line)
+			if m == nil {
+				toks.add(len(line), Plaintext)
+				return toks
+			}
+			toks.add(m[3], Keyword)
+			end := m[3]
+			if m[4] >= 0 {
+				toks.add(1, Punctuation)
+				toks.add(m[5]-m[4]-2, Label)
+				toks.add(1, Punctuation)
+				end = m[5]
+			}
+			toks.add(m[1]-1-end, Punctuation)
+			toks.add(1, Whitespace)
+			toks.add(len(line)-m[1], Plaintext)
+			return toks
+		}
+		if m := gitTrailerRE.Find(line); m != nil {
+			key := bytes.TrimRight(m, " :")
+			toks.add(len(key), Variable)
+			toks.add(1, Punctuation)
+			toks.add(len(m)-len(key)-1, Whitespace)
+			toks.add(len(line)-len(m), String)
+			return toks
+		}
+		toks.add(len(line), Plaintext)
+		return toks
+	}}
+}
+
+// newGitRebaseTodoLexer returns a Lexer for the todo lists of interactive
+// rebases, which highlights commands, such as "pick" and "fixup", as
+// Keyword, commit hashes as Literal, labels and refs as Label and the
+// commands of "exec" as shell. Comments, after "#", are Comment.
+func newGitRebaseTodoLexer() Lexer {
+	return &lineLexer{lexLine: func(line []byte) []lexToken {
+		var toks lineTokens
+		i := blanks(line)
+		toks.add(i, Whitespace)
+		rest := line[i:]
+		if len(rest) > 0 && rest[0] == '#' {
+			toks.add(len(rest), Comment)
+			return toks
+		}
+		n := 0
+		for n < len(rest) && !isBlank(rest[n]) {
+			n++
+		}
+		command := string(rest[:n])
+		if !gitRebaseCommands[command] {
+			toks.add(len(rest), Plaintext)
+			return toks
+		}
+		toks.add(n, Keyword)
+		rest = rest[n:]
+
+		switch command {
+		case "exec", "x":
+			addLanguageTokens(&toks, rest, "shell")
+			return toks
+		case "label", "l", "reset", "t", "update-ref", "u":
+			configWords(&toks, rest, Label)
+			return toks
+		}
+		// options such as "-C", then a commit, then its subject
+		for len(rest) > 0 {
+			n := blanks(rest)
+			toks.add(n, Whitespace)
+			rest = rest[n:]
+			n = 0
+			for n < len(rest) && !isBlank(rest[n]) {
+				n++
+			}
+			if n > 0 && rest[0] == '-' {
+				toks.add(n, Punctuation)
+				rest = rest[n:]
+				continue
+			}
+			toks.add(n, Literal)
+			rest = rest[n:]
+			break
+		}
+		if c := bytes.IndexByte(rest, '#'); c >= 0 && (command == "merge" || command == "m") {
+			toks.add(c, Plaintext)
+			toks.add(len(rest)-c, Comment)
+			return toks
+		}
+		toks.add(len(rest), Plaintext)
+		return toks
+	}}
+}
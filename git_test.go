@@ -0,0 +1,64 @@
+package syntaxhighlight
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGitLexers(t *testing.T) {
+	tests := []struct {
+		lang, src string
+		want      []token
+	}{
+		{"gitignore", "# build\n/bin/\n*.log\n!keep\\#.log\nsrc/**/[a-z].tmp  \n", []token{
+			{Comment, "# build"}, {Whitespace, "\n"},
+			{Punctuation, "/"}, {String, "bin"}, {Punctuation, "/"}, {Whitespace, "\n"},
+			{Variable, "*"}, {String, ".log"}, {Whitespace, "\n"},
+			{Keyword, "!"}, {String, "keep"}, {Literal, `\#`}, {String, ".log"}, {Whitespace, "\n"},
+			{String, "src"}, {Punctuation, "/"}, {Variable, "**"}, {Punctuation, "/"}, {Variable, "[a-z]"}, {String, ".tmp"}, {Whitespace, "  "}, {Whitespace, "\n"},
+		}},
+		{"gitconfig", "[core]\n\tbare = false\n[remote \"origin\"]\n\turl = \"git@x:y.git\" ; main\n", []token{
+			{Punctuation, "["}, {Label, "core"}, {Punctuation, "]"}, {Whitespace, "\n"}, {Whitespace, "\t"},
+			{Variable, "bare"}, {Whitespace, " "}, {Punctuation, "="}, {Whitespace, " "}, {Literal, "false"}, {Whitespace, "\n"},
+			{Punctuation, "["}, {Label, "remote"}, {Whitespace, " "}, {String, `"origin"`}, {Punctuation, "]"}, {Whitespace, "\n"}, {Whitespace, "\t"},
+			{Variable, "url"}, {Whitespace, " "}, {Punctuation, "="}, {Whitespace, " "}, {String, `"git@x:y.git"`}, {Whitespace, " "},
+			{Comment, "; main"}, {Whitespace, "\n"},
+		}},
+		{"git-commit", "feat(parser)!: add arrays\n\nBody text.\n\nSigned-off-by: A <a@x>\n# Please enter\n", []token{
+			{Keyword, "feat"}, {Punctuation, "("}, {Label, "parser"}, {Punctuation, ")!:"}, {Whitespace, " "}, {Plaintext, "add arrays"},
+			{Whitespace, "\n"}, {Whitespace, "\n"}, {Plaintext, "Body text."}, {Whitespace, "\n"}, {Whitespace, "\n"},
+			{Variable, "Signed-off-by"}, {Punctuation, ":"}, {Whitespace, " "}, {String, "A <a@x>"}, {Whitespace, "\n"},
+			{Comment, "# Please enter"}, {Whitespace, "\n"},
+		}},
+		{"git-commit", "Fix typo\n# ------------------------ >8 ------------------------\ndiff --git a/x b/x\n", []token{
+			{Plaintext, "Fix typo"}, {Whitespace, "\n"},
+			{Comment, "# ------------------------ >8 ------------------------"}, {Whitespace, "\n"},
+			{Comment, "diff --git a/x b/x"}, {Whitespace, "\n"},
+		}},
+		{"git-rebase-todo", "pick 1a2b3c Add x\nfixup -C 4d5e6f Tweak\nexec echo done\nlabel onto\n# Commands:\n", []token{
+			{Keyword, "pick"}, {Whitespace, " "}, {Literal, "1a2b3c"}, {Plaintext, " Add x"}, {Whitespace, "\n"},
+			{Keyword, "fixup"}, {Whitespace, " "}, {Punctuation, "-C"}, {Whitespace, " "}, {Literal, "4d5e6f"}, {Plaintext, " Tweak"}, {Whitespace, "\n"},
+			{Keyword, "exec"}, {Whitespace, " "}, {Plaintext, "echo"}, {Whitespace, " "}, {Plaintext, "done"}, {Whitespace, "\n"},
+			{Keyword, "label"}, {Whitespace, " "}, {Label, "onto"}, {Whitespace, "\n"},
+			{Comment, "# Commands:"}, {Whitespace, "\n"},
+		}},
+	}
+	for _, test := range tests {
+		s := NewScanner([]byte(test.src))
+		s.UseLanguage(LookupLanguage(test.lang))
+		if got := scanAll(t, s); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s %q:\nwant %v\ngot  %v", test.lang, test.src, test.want, got)
+		}
+	}
+
+	for name, want := range map[string]string{
+		"repo/.gitignore":                   "gitignore",
+		"/home/u/.gitconfig":                "gitconfig",
+		".git/COMMIT_EDITMSG":               "git-commit",
+		".git/rebase-merge/git-rebase-todo": "git-rebase-todo",
+	} {
+		if got := DetectLanguage(name, nil); got != want {
+			t.Errorf("%s: want %q, got %q", name, want, got)
+		}
+	}
+}
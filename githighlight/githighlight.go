@@ -0,0 +1,43 @@
+// Package githighlight highlights files stored in git repositories.
+package githighlight
+
+import (
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/sourcegraph/syntaxhighlight"
+)
+
+// HighlightBlob returns the file at path in the git repository at repoPath,
+// as of revision rev, highlighted as HTML with syntaxhighlight.AsHTML. The
+// repository may also be given by a directory inside it. rev is anything git
+// rev-parse accepts that resolves to a commit, such as "HEAD", a branch or tag
+// name or a commit hash. The language of the file is detected from its path
+// and contents, unless it is set with syntaxhighlight.Lang.
+func HighlightBlob(repoPath, rev, path string, options ...syntaxhighlight.Option) ([]byte, error) {
+	repo, err := git.PlainOpenWithOptions(repoPath, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("githighlight: opening repository %s: %v", repoPath, err)
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("githighlight: resolving revision %q: %v", rev, err)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("githighlight: revision %q: %v", rev, err)
+	}
+	file, err := commit.File(path)
+	if err != nil {
+		return nil, fmt.Errorf("githighlight: %s at %s: %v", path, rev, err)
+	}
+	contents, err := file.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("githighlight: %s at %s: %v", path, rev, err)
+	}
+
+	src := []byte(contents)
+	options = append([]syntaxhighlight.Option{syntaxhighlight.Lang(syntaxhighlight.DetectLanguage(path, src))}, options...)
+	return syntaxhighlight.AsHTML(src, options...)
+}
@@ -0,0 +1,73 @@
+package githighlight
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/sourcegraph/syntaxhighlight"
+)
+
+func TestHighlightBlob(t *testing.T) {
+	dir, err := ioutil.TempDir("", "githighlight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := func(src string) {
+		if err := os.MkdirAll(filepath.Join(dir, "lib"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "lib", "a.py"), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wt.Add("lib/a.py"); err != nil {
+			t.Fatal(err)
+		}
+		sig := &object.Signature{Name: "test", Email: "test@example.com", When: time.Unix(0, 0)}
+		if _, err := wt.Commit("commit", &git.CommitOptions{Author: sig}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commit("x # old")
+	commit("y # later")
+
+	tests := []struct {
+		rev     string
+		options []syntaxhighlight.Option
+		want    string
+	}{
+		{"HEAD", nil, `<span class="pln">y</span> <span class="com"># later</span>`},
+		{"HEAD~1", nil, `<span class="pln">x</span> <span class="com"># old</span>`},
+		{"master", []syntaxhighlight.Option{syntaxhighlight.Lang("go")}, `<span class="pln">y</span> <span class="pun">#</span> <span class="pln">later</span>`},
+	}
+	for _, test := range tests {
+		got, err := HighlightBlob(filepath.Join(dir, "lib"), test.rev, "lib/a.py", test.options...)
+		if err != nil {
+			t.Errorf("%s: %s", test.rev, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("%s: want %q, got %q", test.rev, test.want, got)
+		}
+	}
+
+	for _, bad := range []struct{ rev, path string }{{"nope", "lib/a.py"}, {"HEAD", "missing.go"}} {
+		if _, err := HighlightBlob(dir, bad.rev, bad.path); err == nil {
+			t.Errorf("%s:%s: want error", bad.rev, bad.path)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// goModDirectives are the directives of go.mod and go.work files.
+var goModDirectives = map[string]bool{
+	"module":    true,
+	"go":        true,
+	"toolchain": true,
+	"godebug":   true,
+	"require":   true,
+	"replace":   true,
+	"exclude":   true,
+	"retract":   true,
+	"use":       true,
+	"tool":      true,
+	"ignore":    true,
+}
+
+// goVersionRE matches module versions, such as "v1.2.3" or pseudo-versions,
+// and Go versions, such as "1.22" or "go1.22.1".
+var goVersionRE = regexp.MustCompile(`^(v\d+(\.\d+)*([-+][\w.+-]*)?(/go\.mod)?|(go)?\d+(\.\d+)*(rc\d+)?)$`)
+
+// newGoModLexer returns a Lexer for go.mod and go.work files, which
+// highlights directives as Keyword, module paths and directories as
+// Namespace and versions as Decimal.
+func newGoModLexer() Lexer {
+	inBlock := false // in a parenthesized block of a directive
+	return &lineLexer{lexLine: func(line []byte) []lexToken {
+		var toks lineTokens
+		first := true
+		for i := 0; i < len(line); {
+			rest := line[i:]
+			var n int
+			var kind Kind
+			switch {
+			case isBlank(rest[0]):
+				n, kind = blanks(rest), Whitespace
+			case hasPrefix(rest, "//"):
+				n, kind = len(rest), Comment
+			case hasPrefix(rest, "=>"):
+				n, kind = 2, Punctuation
+			case bytes.IndexByte([]byte("()[],"), rest[0]) >= 0:
+				n, kind = 1, Punctuation
+				if rest[0] == '(' {
+					inBlock = true
+				} else if rest[0] == ')' && first {
+					inBlock = false
+				}
+			case rest[0] == '"' || rest[0] == '`':
+				n, kind = goModString(rest), String
+			default:
+				n = goModWord(rest)
+				word := rest[:n]
+				switch {
+				case first && !inBlock && goModDirectives[string(word)]:
+					kind = Keyword
+				case goVersionRE.Match(word):
+					kind = Decimal
+				case first && !inBlock:
+					kind = Plaintext
+				default:
+					kind = Namespace
+				}
+			}
+			toks.add(n, kind)
+			first = first && kind == Whitespace
+			i += n
+		}
+		return toks
+	}}
+}
+
+// goModString returns the length of the quoted string at the start of b,
+// which ends at the end of b if it is not terminated.
+func goModString(b []byte) int {
+	for i := 1; i < len(b); i++ {
+		switch {
+		case b[i] == '\\' && b[0] == '"':
+			i++
+		case b[i] == b[0]:
+			return i + 1
+		}
+	}
+	return len(b)
+}
+
+// goModWord returns the length of the word at the start of b, which ends at
+// a blank, punctuation or a comment.
+func goModWord(b []byte) int {
+	for i := 0; i < len(b); i++ {
+		if isBlank(b[i]) || bytes.IndexByte([]byte("()[],\"`"), b[i]) >= 0 || hasPrefix(b[i:], "//") || hasPrefix(b[i:], "=>") {
+			if i == 0 {
+				return 1
+			}
+			return i
+		}
+	}
+	return len(b)
+}
+
+// newGoSumLexer returns a Lexer for go.sum files, which highlights module
+// paths as Namespace, versions as Decimal and hashes as Literal.
+func newGoSumLexer() Lexer {
+	return &lineLexer{lexLine: func(line []byte) []lexToken {
+		var toks lineTokens
+		field := 0
+		for i := 0; i < len(line); {
+			rest := line[i:]
+			if isBlank(rest[0]) {
+				n := blanks(rest)
+				toks.add(n, Whitespace)
+				i += n
+				continue
+			}
+			n := bytes.IndexAny(rest, " \t\r")
+			if n < 0 {
+				n = len(rest)
+			}
+			kind := Plaintext
+			if field < 3 {
+				kind = [...]Kind{Namespace, Decimal, Literal}[field]
+			}
+			toks.add(n, kind)
+			field++
+			i += n
+		}
+		return toks
+	}}
+}
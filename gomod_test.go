@@ -0,0 +1,48 @@
+package syntaxhighlight
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGoModLexer(t *testing.T) {
+	tests := []struct {
+		lang, src string
+		want      []token
+	}{
+		{"gomod", "module example.com/m // main\n\ngo 1.22\n", []token{
+			{Keyword, "module"}, {Whitespace, " "}, {Namespace, "example.com/m"}, {Whitespace, " "}, {Comment, "// main"},
+			{Whitespace, "\n"}, {Whitespace, "\n"},
+			{Keyword, "go"}, {Whitespace, " "}, {Decimal, "1.22"}, {Whitespace, "\n"},
+		}},
+		{"gomod", "require (\n\tgolang.org/x/term v0.1.0 // indirect\n)\nreplace a => ./b\n", []token{
+			{Keyword, "require"}, {Whitespace, " "}, {Punctuation, "("}, {Whitespace, "\n"},
+			{Whitespace, "\t"}, {Namespace, "golang.org/x/term"}, {Whitespace, " "}, {Decimal, "v0.1.0"}, {Whitespace, " "}, {Comment, "// indirect"}, {Whitespace, "\n"},
+			{Punctuation, ")"}, {Whitespace, "\n"},
+			{Keyword, "replace"}, {Whitespace, " "}, {Namespace, "a"}, {Whitespace, " "}, {Punctuation, "=>"}, {Whitespace, " "}, {Namespace, "./b"}, {Whitespace, "\n"},
+		}},
+		{"gowork", "use (\n\t./api\n)", []token{
+			{Keyword, "use"}, {Whitespace, " "}, {Punctuation, "("}, {Whitespace, "\n"},
+			{Whitespace, "\t"}, {Namespace, "./api"}, {Whitespace, "\n"},
+			{Punctuation, ")"},
+		}},
+		{"gomod", "retract [v1.0.0, v1.0.5] // \"bad\"\n", []token{
+			{Keyword, "retract"}, {Whitespace, " "}, {Punctuation, "["}, {Decimal, "v1.0.0"}, {Punctuation, ","}, {Whitespace, " "},
+			{Decimal, "v1.0.5"}, {Punctuation, "]"}, {Whitespace, " "}, {Comment, `// "bad"`}, {Whitespace, "\n"},
+		}},
+		{"gosum", "golang.org/x/term v0.1.0/go.mod h1:abc=\n", []token{
+			{Namespace, "golang.org/x/term"}, {Whitespace, " "}, {Decimal, "v0.1.0/go.mod"}, {Whitespace, " "}, {Literal, "h1:abc="}, {Whitespace, "\n"},
+		}},
+	}
+	for _, test := range tests {
+		s := NewScanner([]byte(test.src))
+		s.UseLanguage(LookupLanguage(test.lang))
+		if got := scanAll(t, s); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s %q:\nwant %v\ngot  %v", test.lang, test.src, test.want, got)
+		}
+	}
+
+	if got := DetectLanguage("mod/go.mod", nil); got != "gomod" {
+		t.Errorf("want go.mod detected as gomod, got %q", got)
+	}
+}
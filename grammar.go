@@ -0,0 +1,364 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// bisonVariableRE matches the references to semantic values and locations
+// in the actions of Bison, such as "$$", "$1", "$<ival>2" and "@$".
+var bisonVariableRE = regexp.MustCompile(`^[$@](<[\w.:]*>)?(\$|-?\d+|[A-Za-z_][\w.]*|\[[\w.-]+\])`)
+
+// grammarSyntax describes the grammar language of a parser generator.
+type grammarSyntax struct {
+	// keywords are the keywords of the language, such as "grammar".
+	keywords map[string]bool
+	// define are the operators that define a rule after its name.
+	define []string
+	// lineComments and blockComments are the delimiters of comments.
+	lineComments  []string
+	blockComments [][2]string
+	// host is the language of actions in braces, which are delimiters of
+	// the language's own if host is "".
+	host string
+	// terminals makes capitalized names terminals, highlighted as Type.
+	terminals bool
+	// dashes allows dashes in names, as in "digit-excluding-zero".
+	dashes bool
+	// bison makes directives after "%" Keyword and enables the sections of
+	// Bison and Yacc grammars, type tags in "<>" and references to values,
+	// such as "$1", in actions.
+	bison bool
+}
+
+// bisonSyntax is the syntax of Bison and Yacc grammars.
+var bisonSyntax = &grammarSyntax{
+	keywords:      setOf("error"),
+	define:        []string{":"},
+	lineComments:  []string{"//"},
+	blockComments: [][2]string{{"/*", "*/"}},
+	host:          "c",
+	terminals:     true,
+	bison:         true,
+}
+
+// antlrSyntax is the syntax of ANTLR grammars.
+var antlrSyntax = &grammarSyntax{
+	keywords: setOf(
+		"catch", "channel", "channels", "finally", "fragment", "grammar", "import", "lexer", "locals",
+		"mode", "more", "options", "parser", "popMode", "pushMode", "returns", "skip", "throws", "tokens",
+	),
+	define:        []string{":"},
+	lineComments:  []string{"//"},
+	blockComments: [][2]string{{"/*", "*/"}},
+	host:          "java",
+	terminals:     true,
+}
+
+// ebnfSyntax is the syntax of EBNF, in its ISO form with "=" and "(* *)" as
+// well as the W3C form with "::=".
+var ebnfSyntax = &grammarSyntax{
+	define:        []string{"::=", "="},
+	blockComments: [][2]string{{"(*", "*)"}, {"/*", "*/"}},
+	dashes:        true,
+}
+
+// grammarLexer is a Lexer for the grammar languages of parser generators.
+type grammarLexer struct {
+	syntax *grammarSyntax
+
+	host    string // language of actions
+	code    Lexer  // lexer of the current action or code section
+	rest    int    // length of the rest of the current action
+	close   string // delimiter that closes the current action
+	block   bool   // braces enclose a block of options rather than an action
+	section int    // number of "%%" section separators seen
+	hostArg bool   // a string naming the host language is next
+}
+
+// newGrammarLexer returns a function making Lexers for grammars with the
+// given syntax. Names of rules where they are defined, such as "expr" in
+// "expr: term;", are Label, terminals String, or Type if they are named
+// and capitalized, and keywords and directives, such as "%token", Keyword.
+// Actions in braces, and in Bison the code sections before and after the
+// rules, are highlighted in the host language, with references to values
+// such as "$1" as Variable.
+func newGrammarLexer(syntax *grammarSyntax) func() Lexer {
+	return func() Lexer {
+		return &grammarLexer{syntax: syntax, host: syntax.host}
+	}
+}
+
+func (l *grammarLexer) Lex(data []byte, atEOF bool) (int, Kind) {
+	if l.code != nil {
+		if n, kind, ok := l.lexCode(data, atEOF); ok {
+			return n, kind
+		}
+	}
+	if !atEOF && len(data) < 4 {
+		return 0, 0
+	}
+	for _, c := range l.syntax.blockComments {
+		if hasPrefix(data, c[0]) {
+			n := markupUntil(data, len(c[0]), c[1], atEOF)
+			if c[0] == "/*" && hasPrefix(data, "/**") && !hasPrefix(data, "/**/") {
+				return n, DocComment
+			}
+			return n, Comment
+		}
+	}
+	for _, c := range l.syntax.lineComments {
+		if hasPrefix(data, c) {
+			return scanLine(data, atEOF), Comment
+		}
+	}
+
+	switch c := data[0]; {
+	case isBlank(c) || c == '\n':
+		n := markupSpace(data)
+		if n == len(data) && !atEOF {
+			return 0, 0
+		}
+		return n, Whitespace
+	case c == '"' || c == '\'':
+		n := grammarString(data, atEOF)
+		if n == 0 {
+			return 0, 0
+		}
+		if l.hostArg {
+			l.hostArg = false
+			l.host = bisonHost(string(data[1 : n-1]))
+		}
+		return n, String
+	case l.syntax.bison && hasPrefix(data, "%%"):
+		l.section++
+		if l.section == 2 {
+			l.code, l.rest, l.close = newLanguageLexer(LookupLanguage(l.host)), -1, ""
+		}
+		return 2, Punctuation
+	case l.syntax.bison && hasPrefix(data, "%{"):
+		return l.startCode(data, 2, "%}", atEOF)
+	case l.syntax.bison && c == '%':
+		n := 1 + grammarName(data[1:], true)
+		if n+1 >= len(data) && !atEOF {
+			return 0, 0
+		}
+		if d := string(data[:n]); d == "%language" || d == "%skeleton" {
+			l.hostArg = true
+		}
+		return n, Keyword
+	case l.syntax.bison && c == '<':
+		n := bytes.IndexByte(data, '>')
+		if n < 0 || bytes.IndexByte(data[:n], '\n') >= 0 {
+			if n < 0 && !atEOF {
+				return 0, 0
+			}
+			return 1, Punctuation
+		}
+		return n + 1, Type
+	case c == '{' && l.syntax.host != "" && !l.block:
+		return l.startCode(data, 1, "}", atEOF)
+	case c == '{' || c == '}':
+		l.block = false
+		return 1, Punctuation
+	case c == '@':
+		// an action of an ANTLR grammar, such as @header or @lexer::members
+		n := 1
+		for n < len(data) && (data[n] == ':' || grammarName(data[n:n+1], false) == 1) {
+			n++
+		}
+		if n == len(data) && !atEOF {
+			return 0, 0
+		}
+		return n, Keyword
+	case '0' <= c && c <= '9':
+		n := 1
+		for n < len(data) && '0' <= data[n] && data[n] <= '9' {
+			n++
+		}
+		if n == len(data) && !atEOF {
+			return 0, 0
+		}
+		return n, Decimal
+	}
+
+	r, size := utf8.DecodeRune(data)
+	if r != '_' && !unicode.IsLetter(r) {
+		for _, op := range l.syntax.define {
+			if hasPrefix(data, op) {
+				return len(op), Punctuation
+			}
+		}
+		return size, Punctuation
+	}
+	n := grammarName(data, l.syntax.dashes)
+	if n+1 >= len(data) && !atEOF {
+		return 0, 0
+	}
+	name := string(data[:n])
+	if l.syntax.keywords[name] {
+		l.block = name == "options" || name == "tokens" || name == "channels"
+		return n, Keyword
+	}
+	isDef, ok := l.isDefinition(data[n:], atEOF)
+	if !ok {
+		return 0, 0
+	}
+	switch {
+	case isDef:
+		return n, Label
+	case l.syntax.terminals && unicode.IsUpper(r):
+		return n, Type
+	}
+	return n, Plaintext
+}
+
+// startCode starts an action or a code section, whose opening delimiter, of
+// length open, is at the start of data, and which ends with close.
+func (l *grammarLexer) startCode(data []byte, open int, close string, atEOF bool) (int, Kind) {
+	n := grammarAction(data, open, close, atEOF)
+	if n == 0 {
+		return 0, 0
+	}
+	l.code = newLanguageLexer(LookupLanguage(l.host))
+	l.rest, l.close = n-open, close
+	if bytes.HasSuffix(data[:n], []byte(close)) {
+		l.rest -= len(close)
+	}
+	return open, Punctuation
+}
+
+// lexCode lexes the code of the current action or code section. It returns
+// false at the end of the code, after its closing delimiter.
+func (l *grammarLexer) lexCode(data []byte, atEOF bool) (int, Kind, bool) {
+	if l.rest < 0 {
+		// the code section after the rules, which runs to the end
+		n, kind := l.code.Lex(data, atEOF)
+		return n, kind, true
+	}
+	if l.rest == 0 {
+		l.code = nil
+		if hasPrefix(data, l.close) {
+			return len(l.close), Punctuation, true
+		}
+		return 0, 0, false
+	}
+	if l.rest > len(data) {
+		return 0, 0, true
+	}
+	code := data[:l.rest]
+	n, kind := 0, Kind(0)
+	if m := bisonVariableRE.Find(code); m != nil && l.syntax.bison {
+		n, kind = len(m), Variable
+	} else {
+		n, kind = l.code.Lex(code, true)
+	}
+	l.rest -= n
+	return n, kind, true
+}
+
+// isDefinition reports whether rest, the source after a name, starts with
+// an operator that defines a rule, after any spaces. It returns false if
+// more data is needed.
+func (l *grammarLexer) isDefinition(rest []byte, atEOF bool) (isDef, ok bool) {
+	i := markupSpace(rest)
+	if !atEOF && len(rest)-i < 3 {
+		return false, false
+	}
+	for _, op := range l.syntax.define {
+		if hasPrefix(rest[i:], op) && !(op == ":" && hasPrefix(rest[i:], "::")) {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// grammarAction returns the length of the action at the start of data, from
+// its opening delimiter, of length open, to the closing delimiter close
+// that balances it, or 0 if more data is needed. Strings, characters and
+// comments in the action are skipped, as their braces do not count. An
+// unterminated action runs to the end of the source.
+func grammarAction(data []byte, open int, close string, atEOF bool) int {
+	depth := 0
+	for i := open; i < len(data); i++ {
+		switch c := data[i]; {
+		case depth == 0 && hasPrefix(data[i:], close):
+			return i + len(close)
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		case c == '"' || c == '\'':
+			n := grammarString(data[i:], atEOF)
+			if n == 0 {
+				return 0
+			}
+			i += n - 1
+		case hasPrefix(data[i:], "//"):
+			i += scanLine(data[i:], true) - 1
+		case hasPrefix(data[i:], "/*"):
+			i += markupUntil(data[i:], 2, "*/", true) - 1
+		}
+	}
+	if !atEOF {
+		return 0
+	}
+	return len(data)
+}
+
+// grammarString returns the length of the string or character literal at
+// the start of data, which ends at the end of its line if unterminated, or
+// 0 if more data is needed.
+func grammarString(data []byte, atEOF bool) int {
+	for i := 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '\n':
+			return i
+		case data[0]:
+			return i + 1
+		}
+	}
+	if !atEOF {
+		return 0
+	}
+	return len(data)
+}
+
+// grammarName returns the length of the name at the start of data, which
+// may contain dots, and dashes if allowed, between letters, as in Bison's
+// "%define api.pure" and "%no-lines".
+func grammarName(data []byte, dashes bool) int {
+	n := 0
+	for n < len(data) {
+		r, size := utf8.DecodeRune(data[n:])
+		if (r == '.' || r == '-' && dashes) && n > 0 && n+1 < len(data) && grammarName(data[n+1:n+2], false) == 1 {
+			n++
+			continue
+		}
+		if !isIdentRune(r) {
+			break
+		}
+		n += size
+	}
+	return n
+}
+
+// bisonHost returns the name of the language of the code in a Bison grammar
+// given the argument of its %language or %skeleton directive.
+func bisonHost(arg string) string {
+	arg = strings.ToLower(arg)
+	switch {
+	case arg == "c++" || strings.HasSuffix(arg, ".cc") || strings.HasSuffix(arg, ".hh"):
+		return "cpp"
+	case strings.HasSuffix(arg, ".java"):
+		return "java"
+	case strings.HasSuffix(arg, ".c"):
+		return "c"
+	}
+	return arg
+}
@@ -0,0 +1,62 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"testing/iotest"
+)
+
+func TestGrammarLexer(t *testing.T) {
+	tests := []struct {
+		lang, src string
+		want      []token
+	}{
+		{"bison", "%{\nint n;\n%}\n%token <ival> NUM\n%%\nexp\n  : exp '+' NUM { $$ = $1 + 1; }\n  ;\n%%\nint main() {}", []token{
+			{Punctuation, "%{"}, {Whitespace, "\n"}, {Keyword, "int"}, {Whitespace, " "}, {Plaintext, "n"}, {Punctuation, ";"}, {Whitespace, "\n"},
+			{Punctuation, "%}"}, {Whitespace, "\n"},
+			{Keyword, "%token"}, {Whitespace, " "}, {Type, "<ival>"}, {Whitespace, " "}, {Type, "NUM"}, {Whitespace, "\n"},
+			{Punctuation, "%%"}, {Whitespace, "\n"},
+			{Label, "exp"}, {Whitespace, "\n  "}, {Punctuation, ":"}, {Whitespace, " "}, {Plaintext, "exp"}, {Whitespace, " "}, {String, "'+'"},
+			{Whitespace, " "}, {Type, "NUM"}, {Whitespace, " "}, {Punctuation, "{"}, {Whitespace, " "}, {Variable, "$$"}, {Whitespace, " "},
+			{Punctuation, "="}, {Whitespace, " "}, {Variable, "$1"}, {Whitespace, " "}, {Punctuation, "+"}, {Whitespace, " "}, {Decimal, "1"},
+			{Punctuation, ";"}, {Whitespace, " "}, {Punctuation, "}"}, {Whitespace, "\n  "}, {Punctuation, ";"}, {Whitespace, "\n"},
+			{Punctuation, "%%"}, {Whitespace, "\n"},
+			{Keyword, "int"}, {Whitespace, " "}, {Plaintext, "main"}, {Punctuation, "("}, {Punctuation, ")"}, {Whitespace, " "},
+			{Punctuation, "{"}, {Punctuation, "}"},
+		}},
+		{"antlr", "grammar Expr;\noptions { language = Java; }\n@members { int n = 0; }\nexpr : e=expr '*' ID {n++;} | INT ;\nID : [a-z]+ -> skip ; // ids", []token{
+			{Keyword, "grammar"}, {Whitespace, " "}, {Type, "Expr"}, {Punctuation, ";"}, {Whitespace, "\n"},
+			{Keyword, "options"}, {Whitespace, " "}, {Punctuation, "{"}, {Whitespace, " "}, {Plaintext, "language"}, {Whitespace, " "}, {Punctuation, "="},
+			{Whitespace, " "}, {Type, "Java"}, {Punctuation, ";"}, {Whitespace, " "}, {Punctuation, "}"}, {Whitespace, "\n"},
+			{Keyword, "@members"}, {Whitespace, " "}, {Punctuation, "{"}, {Whitespace, " "}, {Keyword, "int"}, {Whitespace, " "}, {Plaintext, "n"},
+			{Whitespace, " "}, {Punctuation, "="}, {Whitespace, " "}, {Decimal, "0"}, {Punctuation, ";"}, {Whitespace, " "}, {Punctuation, "}"}, {Whitespace, "\n"},
+			{Label, "expr"}, {Whitespace, " "}, {Punctuation, ":"}, {Whitespace, " "}, {Plaintext, "e"}, {Punctuation, "="}, {Plaintext, "expr"},
+			{Whitespace, " "}, {String, "'*'"}, {Whitespace, " "}, {Type, "ID"}, {Whitespace, " "}, {Punctuation, "{"}, {Plaintext, "n"},
+			{Punctuation, "+"}, {Punctuation, "+"}, {Punctuation, ";"}, {Punctuation, "}"}, {Whitespace, " "}, {Punctuation, "|"}, {Whitespace, " "},
+			{Type, "INT"}, {Whitespace, " "}, {Punctuation, ";"}, {Whitespace, "\n"},
+			{Label, "ID"}, {Whitespace, " "}, {Punctuation, ":"}, {Whitespace, " "}, {Punctuation, "["}, {Plaintext, "a"}, {Punctuation, "-"},
+			{Plaintext, "z"}, {Punctuation, "]"}, {Punctuation, "+"}, {Whitespace, " "}, {Punctuation, "-"}, {Punctuation, ">"}, {Whitespace, " "},
+			{Keyword, "skip"}, {Whitespace, " "}, {Punctuation, ";"}, {Whitespace, " "}, {Comment, "// ids"},
+		}},
+		{"ebnf", "(* digits *)\nnumber ::= digit { digit } ;\ndigit = \"0\" | \"1\" ;", []token{
+			{Comment, "(* digits *)"}, {Whitespace, "\n"},
+			{Label, "number"}, {Whitespace, " "}, {Punctuation, "::="}, {Whitespace, " "}, {Plaintext, "digit"}, {Whitespace, " "}, {Punctuation, "{"},
+			{Whitespace, " "}, {Plaintext, "digit"}, {Whitespace, " "}, {Punctuation, "}"}, {Whitespace, " "}, {Punctuation, ";"}, {Whitespace, "\n"},
+			{Label, "digit"}, {Whitespace, " "}, {Punctuation, "="}, {Whitespace, " "}, {String, `"0"`}, {Whitespace, " "}, {Punctuation, "|"},
+			{Whitespace, " "}, {String, `"1"`}, {Whitespace, " "}, {Punctuation, ";"},
+		}},
+	}
+	for _, test := range tests {
+		for _, oneByte := range []bool{false, true} {
+			s := NewScanner([]byte(test.src))
+			if oneByte {
+				s = NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(test.src))))
+			}
+			s.UseLanguage(LookupLanguage(test.lang))
+			if got := scanAll(t, s); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%s %q:\nwant %v\ngot  %v", test.lang, test.src, test.want, got)
+			}
+		}
+	}
+}
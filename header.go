@@ -0,0 +1,205 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// encodedWordRE matches the encoded words of RFC 2047, such as
+// "=?UTF-8?Q?caf=C3=A9?=", in header field values.
+var encodedWordRE = regexp.MustCompile(`^=\?[^?\s]+\?[bBqQ]\?[^?\s]*\?=`)
+
+// headerLexer is a Lexer for messages made of header fields in the style
+// of RFC 822, such as emails and HTTP messages. The start line, if any, and
+// the header fields are lexed a line at a time; the body, which follows
+// the first blank line, is handed over to the lexer of the language of its
+// Content-Type.
+type headerLexer struct {
+	head lineLexer
+
+	// startLine adds the tokens of the first line of the message and
+	// reports whether it is a start line, as the request line of HTTP,
+	// rather than a header field. It may be nil.
+	startLine func(toks *lineTokens, line []byte) bool
+
+	started     bool   // the first line has been lexed
+	blank       bool   // the current line is blank so far
+	contentType string // value of the Content-Type header field
+	body        Lexer  // lexer of the body, once it is reached
+}
+
+// newHeaderLexer returns a headerLexer whose messages start with the line
+// lexed by startLine, which may be nil.
+func newHeaderLexer(startLine func(toks *lineTokens, line []byte) bool) *headerLexer {
+	l := &headerLexer{startLine: startLine, blank: true}
+	l.head.lexLine = l.lexLine
+	return l
+}
+
+// newEmailLexer returns a Lexer for emails and other MIME messages, which
+// highlights header field names as Variable and their values as in
+// headerField. The "From " line that starts messages in mbox files is
+// Keyword and String. Bodies are highlighted in the language of their
+// media type, or as plain text if it is not known.
+func newEmailLexer() Lexer {
+	return newHeaderLexer(mboxFromLine)
+}
+
+func (l *headerLexer) Lex(data []byte, atEOF bool) (int, Kind) {
+	if l.body != nil {
+		return l.body.Lex(data, atEOF)
+	}
+	if data[0] == '\n' && len(l.head.queue) == 0 {
+		if l.started && l.blank {
+			l.body = newLanguageLexer(httpBodyLanguage(l.contentType))
+		}
+		l.blank = true
+		return 1, Whitespace
+	}
+	return l.head.Lex(data, atEOF)
+}
+
+// lexLine returns the tokens of the start line or of a header field.
+func (l *headerLexer) lexLine(line []byte) []lexToken {
+	var toks lineTokens
+	if blanks(line) == len(line) {
+		toks.add(len(line), Whitespace)
+		return toks
+	}
+	l.blank = false
+	if !l.started {
+		l.started = true
+		if l.startLine != nil && l.startLine(&toks, line) {
+			return toks
+		}
+	}
+	name, value, ok := headerField(&toks, line)
+	if !ok {
+		toks.add(len(line), Plaintext)
+		return toks
+	}
+	if strings.EqualFold(string(name), "Content-Type") {
+		l.contentType = string(bytes.TrimSpace(value))
+	}
+	return toks
+}
+
+// headerField adds the tokens of line if it is a header field, such as
+// "Host: example.com", or the continuation of the value of the one before,
+// which starts with blanks, and returns the name of the field, which is nil
+// for a continuation, and its value. The name is Variable, and the value
+// String, but for comments in parentheses, which are Comment, addresses in
+// angle brackets, which are Namespace, and encoded words and the escaped
+// characters of quoted strings, which are Literal. It adds no tokens and
+// returns false if line is neither.
+func headerField(toks *lineTokens, line []byte) (name, value []byte, ok bool) {
+	if n := blanks(line); n > 0 {
+		toks.add(n, Whitespace)
+		value = line[n:]
+	} else if i := bytes.IndexByte(line, ':'); i > 0 && bytes.IndexAny(line[:i], " \t") < 0 {
+		name = line[:i]
+		toks.add(i, Variable)
+		toks.add(1, Punctuation)
+		n := blanks(line[i+1:])
+		toks.add(n, Whitespace)
+		value = line[i+1+n:]
+	} else {
+		return nil, nil, false
+	}
+	end := len(bytes.TrimRight(value, " \t\r"))
+	headerValue(toks, value[:end])
+	toks.add(len(value)-end, Whitespace)
+	return name, value, true
+}
+
+// headerValue adds the tokens of a header field value, as described for
+// headerField. Comments, quoted strings and addresses that are not closed
+// run to the end of the value.
+func headerValue(toks *lineTokens, value []byte) {
+	for i := 0; i < len(value); {
+		n, kind := 1, String
+		switch c := value[i]; {
+		case c == '"':
+			n = headerQuoted(toks, value[i:])
+			i += n
+			continue
+		case c == '(':
+			n, kind = headerComment(value[i:]), Comment
+		case c == '<':
+			if j := bytes.IndexByte(value[i:], '>'); j >= 0 {
+				n = j + 1
+			} else {
+				n = len(value) - i
+			}
+			kind = Namespace
+		case c == '=':
+			if m := encodedWordRE.Find(value[i:]); m != nil {
+				n, kind = len(m), Literal
+			}
+		}
+		toks.add(n, kind)
+		i += n
+	}
+}
+
+// headerQuoted adds the tokens of the quoted string at the start of value,
+// whose escaped characters are Literal, and returns its length.
+func headerQuoted(toks *lineTokens, value []byte) int {
+	start := 0
+	for i := 1; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			if i+1 < len(value) {
+				toks.add(i-start, String)
+				toks.add(2, Literal)
+				start = i + 2
+				i++
+			}
+		case '"':
+			toks.add(i+1-start, String)
+			return i + 1
+		}
+	}
+	toks.add(len(value)-start, String)
+	return len(value)
+}
+
+// headerComment returns the length of the comment at the start of value,
+// in which comments may nest.
+func headerComment(value []byte) int {
+	depth := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(value)
+}
+
+// mboxFromLine adds the tokens of line if it is the "From " line that
+// starts a message in an mbox file, such as
+// "From jane@example.com Mon Jan  1 00:00:00 2024", and reports whether it
+// is.
+func mboxFromLine(toks *lineTokens, line []byte) bool {
+	if !bytes.HasPrefix(line, []byte("From ")) {
+		return false
+	}
+	toks.add(4, Keyword)
+	rest := line[4:]
+	n := blanks(rest)
+	toks.add(n, Whitespace)
+	rest = rest[n:]
+	end := len(bytes.TrimRight(rest, " \t\r"))
+	toks.add(end, String)
+	toks.add(len(rest)-end, Whitespace)
+	return true
+}
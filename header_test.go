@@ -0,0 +1,65 @@
+package syntaxhighlight
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHeaderField(t *testing.T) {
+	tests := []struct {
+		line string
+		want []lexToken
+		name string
+		ok   bool
+	}{
+		{"Host: example.com", []lexToken{{4, Variable}, {1, Punctuation}, {1, Whitespace}, {11, String}}, "Host", true},
+		{"  folded \r", []lexToken{{2, Whitespace}, {6, String}, {2, Whitespace}}, "", true},
+		{`From: "Doe, \"J\"" <j@example.com> (home)`, []lexToken{
+			{4, Variable}, {1, Punctuation}, {1, Whitespace},
+			{6, String}, {2, Literal}, {1, String}, {2, Literal}, {2, String},
+			{15, Namespace}, {1, String}, {6, Comment},
+		}, "From", true},
+		{"Subject: =?UTF-8?Q?caf=C3=A9?= (nested (comment))", []lexToken{
+			{7, Variable}, {1, Punctuation}, {1, Whitespace}, {21, Literal}, {1, String}, {18, Comment},
+		}, "Subject", true},
+		{"not a field", nil, "", false},
+	}
+	for _, test := range tests {
+		var toks lineTokens
+		name, _, ok := headerField(&toks, []byte(test.line))
+		if string(name) != test.name || ok != test.ok {
+			t.Errorf("%q: got name %q, ok %v, want %q, %v", test.line, name, ok, test.name, test.ok)
+		}
+		if got := []lexToken(toks); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q:\nwant %v\ngot  %v", test.line, test.want, got)
+		}
+	}
+}
+
+func TestEmailLexer(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []token
+	}{
+		{"From jane@example.com Mon Jan  1 00:00:00 2024\nTo: <bob@example.com>\nSubject: Hi\n  there\n\nBody: text\n", []token{
+			{Keyword, "From"}, {Whitespace, " "}, {String, "jane@example.com Mon Jan  1 00:00:00 2024"}, {Whitespace, "\n"},
+			{Variable, "To"}, {Punctuation, ":"}, {Whitespace, " "}, {Namespace, "<bob@example.com>"}, {Whitespace, "\n"},
+			{Variable, "Subject"}, {Punctuation, ":"}, {Whitespace, " "}, {String, "Hi"}, {Whitespace, "\n"},
+			{Whitespace, "  "}, {String, "there"}, {Whitespace, "\n"},
+			{Whitespace, "\n"},
+			{Plaintext, "Body: text"}, {Whitespace, "\n"},
+		}},
+		{"Content-Type: text/html\n\n<p>", []token{
+			{Variable, "Content-Type"}, {Punctuation, ":"}, {Whitespace, " "}, {String, "text/html"}, {Whitespace, "\n"},
+			{Whitespace, "\n"},
+			{Punctuation, "<"}, {HTMLTag, "p"}, {Punctuation, ">"},
+		}},
+	}
+	for _, test := range tests {
+		s := NewScanner([]byte(test.src))
+		s.UseLanguage(LookupLanguage("eml"))
+		if got := scanAll(t, s); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q:\nwant %v\ngot  %v", test.src, test.want, got)
+		}
+	}
+}
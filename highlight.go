@@ -5,11 +5,13 @@ package syntaxhighlight
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
+	"regexp"
 	"strings"
-	"text/scanner"
+	"sync"
 	"text/template"
-	"unicode"
 	"unicode/utf8"
 
 	"github.com/sourcegraph/annotate"
@@ -34,10 +36,31 @@ const (
 	HTMLAttrName
 	HTMLAttrValue
 	Decimal
+	DocComment
+	Shebang
+	Label
+	Namespace
+	Variable
+	Confusable
+	LogError
+	LogWarning
+	LogInfo
+	XMLNamespace
+	ProcInst
+	Doctype
 )
 
 //go:generate gostringer -type=Kind
 
+// Kinds returns all kinds, in order.
+func Kinds() []Kind {
+	kinds := make([]Kind, len(_Kind_index)-1)
+	for i := range kinds {
+		kinds[i] = Kind(i)
+	}
+	return kinds
+}
+
 // Printer implements an interface to render highlighted output
 // (see HTMLPrinter for the implementation of this interface)
 type Printer interface {
@@ -59,9 +82,171 @@ type HTMLConfig struct {
 	HTMLAttrName  string
 	HTMLAttrValue string
 	Decimal       string
+	DocComment    string
+	Shebang       string
+	Label         string
+	Namespace     string
+	Variable      string
+	Confusable    string
+	LogError      string
+	LogWarning    string
+	LogInfo       string
+	XMLNamespace  string
+	ProcInst      string
+	Doctype       string
 	Whitespace    string
 
 	AsOrderedList bool
+	AsTable       bool
+	LineAnchors   bool
+
+	// Accessible adds ARIA attributes for screen readers (see Accessible).
+	Accessible bool
+
+	// Plain leaves out the spans around tokens (see Plain).
+	Plain bool
+
+	// Interpolate makes expressions embedded in strings highlighted as code
+	// (see Scanner.Interpolate).
+	Interpolate bool
+
+	// EmbeddedSQL makes SQL statements in strings highlighted as SQL (see
+	// Scanner.EmbeddedSQL).
+	EmbeddedSQL bool
+
+	// Language is the name of the language of the source (see Lang).
+	Language string
+
+	// Diagnostics are overlaid on the output (see Diagnostics).
+	Diagnostics []Diagnostic
+
+	// Coverage marks lines as covered or uncovered (see Coverage).
+	Coverage []CoverBlock
+
+	// Ellipsis is written where output is truncated (see AsHTMLTruncated).
+	// It is written as is, so it may contain markup.
+	Ellipsis string
+
+	// Escape is used to write token text; if nil, the text is HTML-escaped
+	// with template.HTMLEscape.
+	Escape EscapeFunc
+
+	// Sanitize, if set, is applied to the finished output (see Sanitize).
+	Sanitize func(html []byte) []byte
+
+	// MaxSize and MaxLineLength are the limits above which the source is
+	// not highlighted (see MaxSize and MaxLineLength); zero means no limit.
+	MaxSize       int
+	MaxLineLength int
+
+	// WorkBudget is the work after which the rest of the source is not
+	// highlighted (see WorkBudget); zero means no limit.
+	WorkBudget int
+
+	// OnFallback is called when the source, or the rest of it, is not
+	// highlighted because it exceeds a limit (see OnFallback).
+	OnFallback func(reason error)
+
+	// Metrics records the highlighting done (see Metrics).
+	Metrics MetricsObserver
+
+	// Strict makes unterminated strings errors (see Strict).
+	Strict bool
+
+	// Coalesce merges consecutive tokens of the same kind (see Coalesce).
+	Coalesce bool
+
+	// GroupPunctuation merges runs of punctuation (see GroupPunctuation).
+	GroupPunctuation bool
+
+	// BarePlaintext writes Whitespace and Plaintext tokens without markup
+	// (see BarePlaintext).
+	BarePlaintext bool
+
+	// WrapWidth is the width at which lines are soft-wrapped (see
+	// WrapWidth); zero means no wrapping.
+	WrapWidth int
+
+	// FlagConfusables highlights identifiers that may be mistaken for
+	// others as Confusable (see FlagConfusables).
+	FlagConfusables bool
+
+	// Invisible is the class of the markers that replace invisible
+	// characters (see FlagInvisible); if empty, they are written as they
+	// are.
+	Invisible string
+
+	// Markup holds markup that replaces the span around tokens of some
+	// kinds (see Markup).
+	Markup map[Kind]KindMarkup
+
+	plainText bool // the source exceeds a limit
+}
+
+// KindMarkup is the markup written before and after each token of a kind.
+// It is written as is.
+type KindMarkup struct {
+	Open, Close []byte
+}
+
+// markup returns the markup written around tokens of kind with
+// configuration c, as HTMLPrinter writes it, and whether there is any.
+func (c HTMLConfig) markup(kind Kind) (open, close []byte, ok bool) {
+	if c.Plain || c.BarePlaintext && isBare(kind) {
+		return nil, nil, false
+	}
+	if m, ok := c.Markup[kind]; ok {
+		return m.Open, m.Close, len(m.Open) > 0 || len(m.Close) > 0
+	}
+	class := c.Class(kind)
+	if class == "" {
+		return nil, nil, false
+	}
+	open = append([]byte(`<span class="`), template.HTMLEscapeString(class)...)
+	return append(open, `">`...), []byte("</span>"), true
+}
+
+// EscapeFunc writes text to w, escaping it as appropriate for the output
+// format. Its signature matches template.HTMLEscape.
+type EscapeFunc func(w io.Writer, text []byte)
+
+// NoEscape is an EscapeFunc that writes text unmodified. It should only be
+// used for trusted content or for output formats that need no escaping.
+func NoEscape(w io.Writer, text []byte) {
+	w.Write(text)
+}
+
+// HTMLEscapeASCII is an EscapeFunc that HTML-escapes text like
+// template.HTMLEscape, and in addition writes all non-ASCII characters as
+// numeric character references, such as &#233; for é. Its output is pure
+// ASCII, so it survives re-encoding to Latin-1 and other legacy charsets.
+// Invalid UTF-8 is written as &#65533;, the replacement character.
+func HTMLEscapeASCII(w io.Writer, text []byte) {
+	last := 0
+	for i := 0; i < len(text); {
+		if text[i] < utf8.RuneSelf {
+			i++
+			continue
+		}
+		template.HTMLEscape(w, text[last:i])
+		r, size := utf8.DecodeRune(text[i:])
+		fmt.Fprintf(w, "&#%d;", r)
+		i += size
+		last = i
+	}
+	template.HTMLEscape(w, text[last:])
+}
+
+func (c HTMLConfig) escape(w io.Writer, text []byte) {
+	escape := template.HTMLEscape
+	if c.Escape != nil {
+		escape = c.Escape
+	}
+	if c.Invisible != "" {
+		escapeFlaggingInvisibles(w, text, c.Invisible, escape)
+		return
+	}
+	escape(w, text)
 }
 
 // HTMLPrinter implements Printer interface and is used to produce
@@ -95,45 +280,214 @@ func (c HTMLConfig) Class(kind Kind) string {
 		return c.HTMLAttrValue
 	case Decimal:
 		return c.Decimal
+	case DocComment:
+		return c.DocComment
+	case Shebang:
+		return c.Shebang
+	case Label:
+		return c.Label
+	case Namespace:
+		return c.Namespace
+	case Variable:
+		return c.Variable
+	case Confusable:
+		return c.Confusable
+	case LogError:
+		return c.LogError
+	case LogWarning:
+		return c.LogWarning
+	case LogInfo:
+		return c.LogInfo
+	case XMLNamespace:
+		return c.XMLNamespace
+	case ProcInst:
+		return c.ProcInst
+	case Doctype:
+		return c.Doctype
 	}
 	return ""
 }
 
+// setClass sets the class of kind to class.
+func (c *HTMLConfig) setClass(kind Kind, class string) {
+	switch kind {
+	case String:
+		c.String = class
+	case Keyword:
+		c.Keyword = class
+	case Comment:
+		c.Comment = class
+	case Type:
+		c.Type = class
+	case Literal:
+		c.Literal = class
+	case Punctuation:
+		c.Punctuation = class
+	case Plaintext:
+		c.Plaintext = class
+	case Tag:
+		c.Tag = class
+	case HTMLTag:
+		c.HTMLTag = class
+	case HTMLAttrName:
+		c.HTMLAttrName = class
+	case HTMLAttrValue:
+		c.HTMLAttrValue = class
+	case Decimal:
+		c.Decimal = class
+	case DocComment:
+		c.DocComment = class
+	case Shebang:
+		c.Shebang = class
+	case Label:
+		c.Label = class
+	case Namespace:
+		c.Namespace = class
+	case Variable:
+		c.Variable = class
+	case Confusable:
+		c.Confusable = class
+	case LogError:
+		c.LogError = class
+	case LogWarning:
+		c.LogWarning = class
+	case LogInfo:
+		c.LogInfo = class
+	case XMLNamespace:
+		c.XMLNamespace = class
+	case ProcInst:
+		c.ProcInst = class
+	case Doctype:
+		c.Doctype = class
+	}
+}
+
+// classNameRE matches valid CSS class names.
+var classNameRE = regexp.MustCompile(`^-?[_a-zA-Z][_a-zA-Z0-9-]*$`)
+
+// Validate checks that the classes configured in c are lists of valid CSS
+// class names separated by spaces. Classes are escaped when written, so
+// invalid ones cannot inject markup, but they will not match any style
+// either; Validate is meant for checking configurations that come from
+// users, such as themes.
+func (c HTMLConfig) Validate() error {
+	for kind := Kind(0); int(kind) < len(_Kind_index)-1; kind++ {
+		for _, name := range strings.Fields(c.Class(kind)) {
+			if !classNameRE.MatchString(name) {
+				return fmt.Errorf("syntaxhighlight: invalid class name %q for %#v", name, kind)
+			}
+		}
+	}
+	return nil
+}
+
 // Print is the function that emits highlighted source code using
-// <span class="...">...</span> wrapper tags
+// <span class="...">...</span> wrapper tags. The output for the token is
+// written to w in a single Write.
 func (p HTMLPrinter) Print(w io.Writer, kind Kind, tokText string) error {
+	buf := bufPool.Get().(*bytes.Buffer)
+	defer bufPool.Put(buf)
+	buf.Reset()
+	p.print(buf, kind, tokText)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// bufPool holds scratch buffers used to assemble the output for a token.
+var bufPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+func (p HTMLPrinter) print(buf *bytes.Buffer, kind Kind, tokText string) {
 	if p.AsOrderedList {
 		if i := strings.Index(tokText, "\n"); i > -1 {
-			if err := p.Print(w, kind, tokText[:i]); err != nil {
-				return err
-			}
-			w.Write([]byte("</li>\n<li>"))
-			if err := p.Print(w, kind, tokText[i+1:]); err != nil {
-				return err
-			}
-			return nil
+			p.print(buf, kind, tokText[:i])
+			buf.WriteString("</li>\n<li>")
+			p.print(buf, kind, tokText[i+1:])
+			return
 		}
 	}
 
-	class := ((HTMLConfig)(p)).Class(kind)
+	bare := p.Plain || p.BarePlaintext && isBare(kind)
+	if m, ok := p.Markup[kind]; ok && !bare {
+		buf.Write(m.Open)
+		((HTMLConfig)(p)).escape(buf, []byte(tokText))
+		buf.Write(m.Close)
+		return
+	}
+
+	var class string
+	if !bare {
+		class = ((HTMLConfig)(p)).Class(kind)
+	}
 	if class != "" {
-		_, err := w.Write([]byte(`<span class="`))
+		buf.WriteString(`<span class="`)
+		buf.WriteString(template.HTMLEscapeString(class))
+		buf.WriteString(`">`)
+	}
+	((HTMLConfig)(p)).escape(buf, []byte(tokText))
+	if class != "" {
+		buf.WriteString(`</span>`)
+	}
+}
+
+// LinePrinter is implemented by printers that wrap every line of output in
+// markup of their own. For such printers Print splits tokens at newlines and
+// calls BeginLine and EndLine around each line; the newline itself is then
+// printed on its own as a Whitespace token between EndLine and the next
+// BeginLine. Lines are numbered starting at 1.
+type LinePrinter interface {
+	Printer
+	BeginLine(w io.Writer, line int) error
+	EndLine(w io.Writer, line int) error
+}
+
+// htmlLinePrinter wraps the lines printed by an HTMLPrinter in list items
+// and/or line anchors, depending on its configuration.
+type htmlLinePrinter struct {
+	HTMLPrinter
+}
+
+func (p htmlLinePrinter) Print(w io.Writer, kind Kind, tokText string) error {
+	if tokText == "\n" {
+		_, err := io.WriteString(w, tokText)
+		return err
+	}
+	return p.HTMLPrinter.Print(w, kind, tokText)
+}
+
+func (p htmlLinePrinter) BeginLine(w io.Writer, line int) error {
+	if p.AsTable {
+		var hidden string
+		if p.Accessible {
+			hidden = ` aria-hidden="true"`
+		}
+		_, err := fmt.Fprintf(w, `<tr><td class="gutter" data-line="%d"%s style="user-select:none">%d</td><td class="code">`, line, hidden, line)
 		if err != nil {
 			return err
 		}
-		_, err = io.WriteString(w, class)
-		if err != nil {
+	} else if p.AsOrderedList {
+		if _, err := io.WriteString(w, "<li>"); err != nil {
 			return err
 		}
-		_, err = w.Write([]byte(`">`))
-		if err != nil {
+	}
+	if p.LineAnchors {
+		_, err := fmt.Fprintf(w, `<span class="line" id="L%d" data-line="%d">`, line, line)
+		return err
+	}
+	return nil
+}
+
+func (p htmlLinePrinter) EndLine(w io.Writer, line int) error {
+	if p.LineAnchors {
+		if _, err := io.WriteString(w, "</span>"); err != nil {
 			return err
 		}
 	}
-	template.HTMLEscape(w, []byte(tokText))
-	if class != "" {
-		_, err := w.Write([]byte(`</span>`))
-		if err != nil {
+	if p.AsTable {
+		_, err := io.WriteString(w, "</td></tr>")
+		return err
+	}
+	if p.AsOrderedList {
+		if _, err := io.WriteString(w, "</li>"); err != nil {
 			return err
 		}
 	}
@@ -147,17 +501,41 @@ type Annotator interface {
 type HTMLAnnotator HTMLConfig
 
 func (a HTMLAnnotator) Annotate(start int, kind Kind, tokText string) (*annotate.Annotation, error) {
-	class := ((HTMLConfig)(a)).Class(kind)
-	if class != "" {
-		left := []byte(`<span class="`)
-		left = append(left, []byte(class)...)
-		left = append(left, []byte(`">`)...)
-		return &annotate.Annotation{
-			Start: start, End: start + len(tokText),
-			Left: left, Right: []byte("</span>"),
-		}, nil
+	open, close, ok := ((HTMLConfig)(a)).markup(kind)
+	if !ok {
+		return nil, nil
 	}
-	return nil, nil
+	return &annotate.Annotation{
+		Start: start, End: start + len(tokText),
+		Left: open, Right: close,
+	}, nil
+}
+
+// AnnotateLine returns the markup that wraps a line according to the list,
+// table and anchor options of a, as printed by AsHTML.
+func (a HTMLAnnotator) AnnotateLine(start, end, line int) (*annotate.Annotation, error) {
+	var left, right bytes.Buffer
+	p := htmlLinePrinter{HTMLPrinter(a)}
+	if err := p.BeginLine(&left, line); err != nil {
+		return nil, err
+	}
+	if err := p.EndLine(&right, line); err != nil {
+		return nil, err
+	}
+	if left.Len() == 0 && right.Len() == 0 {
+		return nil, nil
+	}
+	return &annotate.Annotation{Start: start, End: end, Left: left.Bytes(), Right: right.Bytes()}, nil
+}
+
+// AnnotateBlock returns the markup that wraps the whole source according
+// to the list and table options of a, as printed by AsHTML.
+func (a HTMLAnnotator) AnnotateBlock(start, end int) (*annotate.Annotation, error) {
+	open, close := ((HTMLConfig)(a)).wrapper(1)
+	if open == "" && close == "" {
+		return nil, nil
+	}
+	return &annotate.Annotation{Start: start, End: end, Left: []byte(open), Right: []byte(close)}, nil
 }
 
 // Option is a type of the function that can modify
@@ -175,8 +553,249 @@ func OrderedList() Option {
 	}
 }
 
+// Table formats the output as a two-column table with line numbers in the
+// first column and code in the second. The line number column is marked
+// user-select:none so that selecting and copying the code leaves the
+// numbers out. Table takes precedence over OrderedList.
+//
+// Example:
+// AsHTML(input, Table())
+func Table() Option {
+	return func(o *HTMLConfig) {
+		o.AsTable = true
+	}
+}
+
+// Interpolation highlights expressions embedded in strings, such as
+// "${x + 1}", as code.
+//
+// Example:
+// AsHTML(input, Interpolation())
+func Interpolation() Option {
+	return func(o *HTMLConfig) {
+		o.Interpolate = true
+	}
+}
+
+// EmbeddedSQL highlights string literals that hold SQL statements, such as
+// "SELECT name FROM users", as SQL (see Scanner.EmbeddedSQL).
+//
+// Example:
+// AsHTML(input, EmbeddedSQL())
+func EmbeddedSQL() Option {
+	return func(o *HTMLConfig) {
+		o.EmbeddedSQL = true
+	}
+}
+
+// Lang makes the source be highlighted with the rules of the registered
+// language with the given name, alias, media type or extension (see
+// LookupLanguage), such as "#" line comments for "python". Highlighting
+// fails with an *ErrUnsupportedLanguage error if there is no such language.
+//
+// Example:
+// AsHTML(input, Lang("python"))
+func Lang(name string) Option {
+	return func(o *HTMLConfig) {
+		o.Language = name
+	}
+}
+
+// Markup makes tokens of kind written between open and close, instead of in
+// a span with the kind's class, so that other elements, such as <b> or
+// custom elements, can be used. open and close are written as they are, so
+// they must be trusted markup. Markup may be given for several kinds.
+//
+// Example:
+// AsHTML(input, Markup(Keyword, "<b>", "</b>"), Markup(Comment, "<i>", "</i>"))
+func Markup(kind Kind, open, close string) Option {
+	return func(o *HTMLConfig) {
+		m := make(map[Kind]KindMarkup, len(o.Markup)+1)
+		for k, v := range o.Markup {
+			m[k] = v
+		}
+		m[kind] = KindMarkup{Open: []byte(open), Close: []byte(close)}
+		o.Markup = m
+	}
+}
+
+// Escape sets the function used to escape token text, replacing the default
+// HTML escaping.
+//
+// Example:
+// AsHTML(input, Escape(NoEscape))
+func Escape(f EscapeFunc) Option {
+	return func(o *HTMLConfig) {
+		o.Escape = f
+	}
+}
+
+// NumericEntities makes the output pure ASCII by writing non-ASCII
+// characters in the source as numeric character references (see
+// HTMLEscapeASCII). It replaces any function set with Escape.
+//
+// Example:
+// AsHTML(input, NumericEntities())
+func NumericEntities() Option {
+	return Escape(HTMLEscapeASCII)
+}
+
+// LineAnchors wraps each line of the output in a
+// <span class="line" id="L42" data-line="42"> element, so that
+// fragments like #L42 can be used to link to a line.
+//
+// Example:
+// AsHTML(input, LineAnchors())
+func LineAnchors() Option {
+	return func(o *HTMLConfig) {
+		o.LineAnchors = true
+	}
+}
+
+// Accessible makes the output friendlier to screen readers by adding ARIA
+// attributes: the table made by Table is marked as presentational and its
+// line numbers are hidden, so that lines are read as plain text, and
+// diagnostic icons are labeled with their messages. Pair it with a theme
+// that has enough contrast, such as HighContrastTheme.
+//
+// Example:
+// AsHTML(input, Table(), Accessible())
+func Accessible() Option {
+	return func(o *HTMLConfig) {
+		o.Accessible = true
+	}
+}
+
+// Plain leaves out the <span> elements around tokens, so that the output is
+// only the escaped source text plus the markup of other options such as
+// OrderedList. It suits screen readers and other plain renderings that would
+// announce or otherwise trip over highlighting markup.
+//
+// Example:
+// AsHTML(input, Plain())
+func Plain() Option {
+	return func(o *HTMLConfig) {
+		o.Plain = true
+	}
+}
+
+// Sanitize passes the output through f before it is returned, so that it is
+// guaranteed to conform to an HTML sanitizer's policy. The signature of f
+// matches that of the SanitizeBytes method of bluemonday policies, which
+// should allow the span, ol, li, table, tr, td and mark elements with the
+// attributes used by the options in use.
+//
+// Example:
+// AsHTML(input, Sanitize(policy.SanitizeBytes))
+func Sanitize(f func(html []byte) []byte) Option {
+	return func(o *HTMLConfig) {
+		o.Sanitize = f
+	}
+}
+
+// MaxSize limits highlighting to sources of at most n bytes. Larger sources
+// are written as escaped text without lexing them, as with Plain; markup
+// from other options such as OrderedList is still written.
+//
+// Example:
+// AsHTML(input, MaxSize(1<<20), OnFallback(func(reason error) { ... }))
+func MaxSize(n int) Option {
+	return func(o *HTMLConfig) {
+		o.MaxSize = n
+	}
+}
+
+// MaxLineLength limits highlighting to sources whose lines are at most n
+// bytes long. Sources with longer lines, which are likely to be minified or
+// generated, are written like sources that exceed MaxSize.
+//
+// Example:
+// AsHTML(input, MaxLineLength(1000))
+func MaxLineLength(n int) Option {
+	return func(o *HTMLConfig) {
+		o.MaxLineLength = n
+	}
+}
+
+// WorkBudget limits the work spent lexing the source to looking at about n
+// bytes of it (see Scanner.Budget), which guards against pathological
+// sources that take much longer to highlight than their size suggests. Once
+// the budget is spent, the rest of the source is written as with Plain.
+//
+// Example:
+// AsHTML(input, WorkBudget(8<<20), OnFallback(func(reason error) { ... }))
+func WorkBudget(n int) Option {
+	return func(o *HTMLConfig) {
+		o.WorkBudget = n
+	}
+}
+
+// OnFallback sets a function to be called when the source is written
+// without highlighting because it exceeds MaxSize or MaxLineLength, or when
+// the rest of it is because highlighting it exceeds WorkBudget. The reason
+// it is passed wraps ErrTooLarge or ErrBudgetExceeded respectively.
+//
+// Example:
+// AsHTML(input, MaxSize(1<<20), OnFallback(func(reason error) { log.Print(reason) }))
+func OnFallback(f func(reason error)) Option {
+	return func(o *HTMLConfig) {
+		o.OnFallback = f
+	}
+}
+
+// Coalesce merges consecutive tokens of the same kind before they are
+// printed, so that, for example, a run of punctuation is wrapped in a
+// single span rather than one per character (see Scanner.Coalesce).
+//
+// Example:
+// AsHTML(input, Coalesce())
+func Coalesce() Option {
+	return func(o *HTMLConfig) {
+		o.Coalesce = true
+	}
+}
+
+// GroupPunctuation merges runs of punctuation into single tokens before
+// they are printed, so that, for example, "});" is wrapped in one span
+// rather than three (see Scanner.GroupPunctuation).
+//
+// Example:
+// AsHTML(input, GroupPunctuation())
+func GroupPunctuation() Option {
+	return func(o *HTMLConfig) {
+		o.GroupPunctuation = true
+	}
+}
+
+// BarePlaintext writes Whitespace and Plaintext tokens, which carry no
+// highlighting of their own, as text without spans. Runs of such tokens are
+// merged and written at once, which saves both output size and work on
+// large files.
+//
+// Example:
+// AsHTML(input, BarePlaintext())
+func BarePlaintext() Option {
+	return func(o *HTMLConfig) {
+		o.BarePlaintext = true
+	}
+}
+
+// Strict makes highlighting fail with an *ErrUnterminatedString error at the
+// first unterminated string literal, instead of highlighting it as well as
+// possible (see Scanner.Strict).
+//
+// Example:
+// AsHTML(input, Strict())
+func Strict() Option {
+	return func(o *HTMLConfig) {
+		o.Strict = true
+	}
+}
+
 // DefaultHTMLConfig provides class names that match those of google-code-prettify
-// (https://code.google.com/p/google-code-prettify/).
+// (https://code.google.com/p/google-code-prettify/). Kinds that prettify does
+// not know about also get the class of the prettify kind closest to them, so
+// that prettify stylesheets apply to them.
 var DefaultHTMLConfig = HTMLConfig{
 	String:        "str",
 	Keyword:       "kwd",
@@ -190,35 +809,157 @@ var DefaultHTMLConfig = HTMLConfig{
 	HTMLAttrName:  "atn",
 	HTMLAttrValue: "atv",
 	Decimal:       "dec",
+	DocComment:    "com doc",
+	Shebang:       "com shb",
+	Label:         "pln lbl",
+	Namespace:     "pln nsp",
+	Variable:      "pln var",
+	Confusable:    "pln cfs",
+	LogError:      "kwd lvl err",
+	LogWarning:    "kwd lvl wrn",
+	LogInfo:       "kwd lvl inf",
+	XMLNamespace:  "atn nsd",
+	ProcInst:      "dec pi",
+	Doctype:       "dec dtd",
 	Whitespace:    "",
+
+	Ellipsis: "&#8230;",
 }
 
-func Print(s *scanner.Scanner, w io.Writer, p Printer) error {
-	tok := s.Scan()
-	for tok != scanner.EOF {
-		tokText := s.TokenText()
-		err := p.Print(w, tokenKind(tok, tokText), tokText)
+// Print scans all tokens from s and prints them to w using p. If p is a
+// LinePrinter, every line is additionally wrapped by its BeginLine and
+// EndLine methods. The output is buffered so that w receives a single Write
+// per token, whatever number of writes the printer makes.
+func Print(s *Scanner, w io.Writer, p Printer) error {
+	return printStream(s, w, p)
+}
+
+// tokenStream is a sequence of tokens, as read by a Scanner.
+type tokenStream interface {
+	Scan() bool
+	Kind() Kind
+	Text() string
+	Err() error
+}
+
+// printStream prints the tokens of s like Print.
+func printStream(s tokenStream, w io.Writer, p Printer) error {
+	if buf, ok := w.(*bytes.Buffer); ok {
+		// Nothing to gain from buffering.
+		return printTokens(s, buf, p, func() error { return nil })
+	}
+	tw := &tokenWriter{w: w}
+	err := printTokens(s, tw, p, tw.flush)
+	if ferr := tw.flush(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// printTokens prints all tokens from s to w using p, calling flush after
+// each token.
+func printTokens(s tokenStream, w io.Writer, p Printer, flush func() error) error {
+	if lp, ok := p.(LinePrinter); ok {
+		return printLines(s, w, lp, flush)
+	}
+
+	for s.Scan() {
+		err := p.Print(w, s.Kind(), s.Text())
 		if err != nil {
 			return err
 		}
+		if err := flush(); err != nil {
+			return err
+		}
+	}
 
-		tok = s.Scan()
+	return s.Err()
+}
+
+func printLines(s tokenStream, w io.Writer, p LinePrinter, flush func() error) error {
+	line := 1
+	if err := p.BeginLine(w, line); err != nil {
+		return err
 	}
 
-	return nil
+	for s.Scan() {
+		tokText, kind := s.Text(), s.Kind()
+		for {
+			i := strings.IndexByte(tokText, '\n')
+			if i < 0 {
+				break
+			}
+			if err := p.Print(w, kind, tokText[:i]); err != nil {
+				return err
+			}
+			if err := p.EndLine(w, line); err != nil {
+				return err
+			}
+			if err := p.Print(w, Whitespace, "\n"); err != nil {
+				return err
+			}
+			line++
+			if err := p.BeginLine(w, line); err != nil {
+				return err
+			}
+			tokText = tokText[i+1:]
+		}
+		if err := p.Print(w, kind, tokText); err != nil {
+			return err
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+
+	return p.EndLine(w, line)
+}
+
+// tokenWriter collects the writes made while printing a token, so that they
+// reach w in a single Write when flushed.
+type tokenWriter struct {
+	w   io.Writer
+	buf bytes.Buffer
+}
+
+func (t *tokenWriter) Write(p []byte) (int, error) {
+	return t.buf.Write(p)
 }
 
+func (t *tokenWriter) flush() error {
+	if t.buf.Len() == 0 {
+		return nil
+	}
+	_, err := t.w.Write(t.buf.Bytes())
+	t.buf.Reset()
+	return err
+}
+
+// Annotate returns the annotations a makes for the tokens of src, in the
+// language set with Lang if a is an HTMLAnnotator. If a is an
+// HTMLAnnotator with the Coalesce option, adjacent annotations with the same
+// markup are merged (see CoalesceAnnotations). If a is a LineAnnotator, the
+// annotations of its lines and of the whole source are added as well.
 func Annotate(src []byte, a Annotator) (annotate.Annotations, error) {
+	lang, err := annotatorLanguage(a)
+	if err != nil {
+		return nil, err
+	}
 	s := NewScanner(src)
+	if lang != nil {
+		s.UseLanguage(lang)
+	}
 
 	var anns annotate.Annotations
 	read := 0
 
-	tok := s.Scan()
-	for tok != scanner.EOF {
-		tokText := s.TokenText()
+	for s.Scan() {
+		tokText := s.Text()
 
-		ann, err := a.Annotate(read, tokenKind(tok, tokText), tokText)
+		ann, err := a.Annotate(read, s.Kind(), tokText)
 		if err != nil {
 			return nil, err
 		}
@@ -226,70 +967,212 @@ func Annotate(src []byte, a Annotator) (annotate.Annotations, error) {
 		if ann != nil {
 			anns = append(anns, ann)
 		}
-
-		tok = s.Scan()
 	}
 
+	if h, ok := a.(HTMLAnnotator); ok && h.Coalesce {
+		anns = CoalesceAnnotations(anns)
+	}
+	if err := s.Err(); err != nil {
+		return anns, err
+	}
+	if la, ok := a.(LineAnnotator); ok {
+		return annotateLines(src, anns, la)
+	}
 	return anns, nil
 }
 
 // AsHTML converts source code into an HTML-highlighted version;
 // It accepts optional configuration parameters to control rendering
-// (see OrderedList as one example)
+// (see OrderedList, Table and LineAnchors as examples)
 func AsHTML(src []byte, options ...Option) ([]byte, error) {
-	opt := DefaultHTMLConfig
-	for _, f := range options {
-		f(&opt)
+	opt := newHTMLConfig(options)
+	s, err := opt.scanner(src)
+	if err != nil {
+		return nil, err
+	}
+	out, err := opt.render(s, opt.printer())
+	if err != nil {
+		return nil, err
 	}
+	return out, nil
+}
 
+// AsText scans src and returns its text as reassembled from the tokens,
+// normalized: a leading byte order mark is dropped and line endings are
+// converted to "\n". It returns an error if src cannot be scanned or if the
+// tokens do not add up to src, so it can be used to check that the lexer
+// handles some input.
+func AsText(src []byte) ([]byte, error) {
 	var buf bytes.Buffer
-	if opt.AsOrderedList {
-		buf.Write([]byte("<ol>\n<li>"))
+	s := NewScanner(src)
+	for s.Scan() {
+		buf.Write(s.Bytes())
 	}
-	err := Print(NewScanner(src), &buf, HTMLPrinter(opt))
-	if opt.AsOrderedList {
-		buf.Write([]byte("</li>\n</ol>"))
+	if err := s.Err(); err != nil {
+		return nil, err
 	}
+	if !bytes.Equal(buf.Bytes(), src) {
+		return nil, errors.New("syntaxhighlight: tokens do not match source")
+	}
+	text := bytes.TrimPrefix(buf.Bytes(), []byte("\uFEFF"))
+	text = bytes.Replace(text, []byte("\r\n"), []byte("\n"), -1)
+	return bytes.Replace(text, []byte("\r"), []byte("\n"), -1), nil
+}
+
+// scanner returns a Scanner for src set up for configuration c. If src
+// exceeds the limits of c, c is switched to unhighlighted output first, so
+// scanner must be called before printer.
+func (c *HTMLConfig) scanner(src []byte) (*Scanner, error) {
+	lang, err := c.language()
 	if err != nil {
 		return nil, err
 	}
-	return buf.Bytes(), nil
+	c.limit(src)
+
+	s := NewScanner(src)
+	c.configure(s, lang)
+	return s, nil
 }
 
-// NewScanner is a helper that takes a []byte src, wraps it in a reader and creates a Scanner.
-func NewScanner(src []byte) *scanner.Scanner {
-	return NewScannerReader(bytes.NewReader(src))
+// language returns the language set in c, or nil if none is.
+func (c *HTMLConfig) language() (*Language, error) {
+	if c.Language == "" {
+		return nil, nil
+	}
+	lang := LookupLanguage(c.Language)
+	if lang == nil {
+		return nil, &ErrUnsupportedLanguage{Name: c.Language}
+	}
+	return lang, nil
 }
 
-// NewScannerReader takes a reader src and creates a Scanner.
-func NewScannerReader(src io.Reader) *scanner.Scanner {
-	var s scanner.Scanner
-	s.Init(src)
-	s.Error = func(_ *scanner.Scanner, _ string) {}
-	s.Whitespace = 0
-	s.Mode = s.Mode ^ scanner.SkipComments
-	return &s
+// configure applies the scanning options of c and language lang to s.
+func (c *HTMLConfig) configure(s *Scanner, lang *Language) {
+	if c.plainText {
+		s.PlainText()
+	}
+	if c.Interpolate {
+		s.Interpolate()
+	}
+	if c.EmbeddedSQL {
+		s.EmbeddedSQL()
+	}
+	if c.Strict {
+		s.Strict()
+	}
+	if c.Coalesce {
+		s.Coalesce()
+	}
+	if c.GroupPunctuation {
+		s.GroupPunctuation()
+	}
+	s.mergeBare = c.BarePlaintext
+	if c.FlagConfusables {
+		s.OnToken(ConfusableKind)
+	}
+	if lang != nil {
+		s.UseLanguage(lang)
+	}
+	if c.WorkBudget > 0 {
+		s.Budget(c.WorkBudget, func(pos Position) {
+			c.fallback(fmt.Errorf("%w: at %s", ErrBudgetExceeded, pos))
+		})
+	}
 }
 
-func tokenKind(tok rune, tokText string) Kind {
-	switch tok {
-	case scanner.Ident:
-		if _, isKW := keywords[tokText]; isKW {
-			return Keyword
-		}
-		if r, _ := utf8.DecodeRuneInString(tokText); unicode.IsUpper(r) {
-			return Type
+// limit switches c to unhighlighted output if src exceeds its limits, and
+// reports the fallback.
+func (c *HTMLConfig) limit(src []byte) {
+	var reason error
+	if c.MaxSize > 0 && len(src) > c.MaxSize {
+		reason = fmt.Errorf("%w: larger than %d bytes", ErrTooLarge, c.MaxSize)
+	} else if c.MaxLineLength > 0 {
+		for rest := src; ; {
+			i := bytes.IndexByte(rest, '\n')
+			if i < 0 {
+				i = len(rest)
+			}
+			if i > c.MaxLineLength {
+				reason = fmt.Errorf("%w: lines longer than %d bytes", ErrTooLarge, c.MaxLineLength)
+				break
+			}
+			if i == len(rest) {
+				break
+			}
+			rest = rest[i+1:]
 		}
-		return Plaintext
-	case scanner.Float, scanner.Int:
-		return Decimal
-	case scanner.Char, scanner.String, scanner.RawString:
-		return String
-	case scanner.Comment:
-		return Comment
-	}
-	if unicode.IsSpace(tok) {
-		return Whitespace
-	}
-	return Punctuation
+	}
+	if reason == nil {
+		return
+	}
+	c.Plain, c.plainText = true, true
+	c.fallback(reason)
+}
+
+// newHTMLConfig returns DefaultHTMLConfig modified by options.
+func newHTMLConfig(options []Option) HTMLConfig {
+	opt := DefaultHTMLConfig
+	for _, f := range options {
+		f(&opt)
+	}
+	return opt
+}
+
+// printer returns the Printer used to render tokens with configuration c.
+func (c HTMLConfig) printer() Printer {
+	var p Printer = HTMLPrinter(c)
+	if c.AsOrderedList || c.AsTable || c.LineAnchors {
+		p = htmlLinePrinter{HTMLPrinter(c)}
+	}
+	if len(c.Coverage) > 0 {
+		p = newCoveragePrinter(asLinePrinter(p), c.Coverage)
+	}
+	if len(c.Diagnostics) > 0 {
+		d := newDiagnosticPrinter(asLinePrinter(p), c.Diagnostics)
+		d.accessible = c.Accessible
+		p = d
+	}
+	if c.WrapWidth > 0 {
+		p = WrapLines(p, c.WrapWidth)
+	}
+	return p
+}
+
+// render prints all tokens from s using p, surrounded by the document-level
+// markup for configuration c. The output is returned even if printing
+// failed.
+func (c HTMLConfig) render(s tokenStream, p Printer) ([]byte, error) {
+	return c.renderFrom(s, p, 1)
+}
+
+// renderFrom is like render for output whose first line is line firstLine
+// of the source, as in excerpts.
+func (c HTMLConfig) renderFrom(s tokenStream, p Printer, firstLine int) ([]byte, error) {
+	var buf bytes.Buffer
+	open, close := c.wrapper(firstLine)
+	buf.WriteString(open)
+	s, done := c.observe(s)
+	err := printStream(s, &buf, p)
+	done()
+	buf.WriteString(close)
+	if c.Sanitize != nil {
+		return c.Sanitize(buf.Bytes()), err
+	}
+	return buf.Bytes(), err
+}
+
+// wrapper returns the document-level markup that surrounds the output for
+// configuration c, whose first line is line firstLine of the source.
+func (c HTMLConfig) wrapper(firstLine int) (open, close string) {
+	switch {
+	case c.AsTable && c.Accessible:
+		return `<table class="highlight" role="presentation" style="white-space:pre">` + "\n", "\n</table>"
+	case c.AsTable:
+		return `<table class="highlight" style="white-space:pre">` + "\n", "\n</table>"
+	case c.AsOrderedList && firstLine > 1:
+		return fmt.Sprintf(`<ol start="%d">`, firstLine) + "\n", "\n</ol>"
+	case c.AsOrderedList:
+		return "<ol>\n", "\n</ol>"
+	}
+	return "", ""
 }
@@ -2,10 +2,14 @@ package syntaxhighlight
 
 import (
 	"bytes"
+	"encoding/xml"
+	"errors"
 	"flag"
+	"io"
 	"io/ioutil"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -84,6 +88,406 @@ func TestAsHTML(t *testing.T) {
 	}
 }
 
+func TestLineAnchors(t *testing.T) {
+	src := []byte("a := 1\n/* b\nc */")
+	want := `<span class="line" id="L1" data-line="1"><span class="pln">a</span> <span class="pun">:</span><span class="pun">=</span> <span class="dec">1</span></span>
+<span class="line" id="L2" data-line="2"><span class="com">/* b</span></span>
+<span class="line" id="L3" data-line="3"><span class="com">c */</span></span>`
+
+	got, err := AsHTML(src, LineAnchors())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("want ==========\n%s\ngot ===========\n%s", want, got)
+	}
+
+	got, err = AsHTML([]byte("a\nb"), OrderedList(), LineAnchors())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "<ol>\n" +
+		`<li><span class="line" id="L1" data-line="1"><span class="pln">a</span></span></li>` + "\n" +
+		`<li><span class="line" id="L2" data-line="2"><span class="pln">b</span></span></li>` + "\n</ol>"
+	if string(got) != want {
+		t.Errorf("want ==========\n%s\ngot ===========\n%s", want, got)
+	}
+}
+
+func TestTable(t *testing.T) {
+	got, err := AsHTML([]byte("a\nb"), Table(), OrderedList())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<table class="highlight" style="white-space:pre">
+<tr><td class="gutter" data-line="1" style="user-select:none">1</td><td class="code"><span class="pln">a</span></td></tr>
+<tr><td class="gutter" data-line="2" style="user-select:none">2</td><td class="code"><span class="pln">b</span></td></tr>
+</table>`
+	if string(got) != want {
+		t.Errorf("want ==========\n%s\ngot ===========\n%s", want, got)
+	}
+}
+
+func TestAccessible(t *testing.T) {
+	got, err := AsHTML([]byte("a\nb"), Table(), Accessible(), Diagnostics(Diagnostic{Line: 2, Col: 1, Message: "bad"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<table class="highlight" role="presentation" style="white-space:pre">
+<tr><td class="gutter" data-line="1" aria-hidden="true" style="user-select:none">1</td><td class="code"><span class="pln">a</span></td></tr>
+<tr><td class="gutter" data-line="2" aria-hidden="true" style="user-select:none">2</td><td class="code"><span class="diagnostic-icon error" title="bad" role="img" aria-label="error: bad" style="user-select:none;color:red">&#9679;</span><span class="diagnostic error" title="bad" style="text-decoration:underline wavy red"><span class="pln">b</span></span></td></tr>
+</table>`
+	if string(got) != want {
+		t.Errorf("want ==========\n%s\ngot ===========\n%s", want, got)
+	}
+}
+
+func TestPlain(t *testing.T) {
+	got, err := AsHTML([]byte("x := \"<\"\ny"), Plain(), OrderedList())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<ol>\n<li>x := &#34;&lt;&#34;</li>\n<li>y</li>\n</ol>"; string(got) != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	anns, err := Annotate([]byte("x := 1"), HTMLAnnotator(newHTMLConfig([]Option{Plain()})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(anns) != 0 {
+		t.Errorf("want no annotations, got %d", len(anns))
+	}
+}
+
+// writeRecorder records the writes made to it.
+type writeRecorder struct {
+	writes []string
+}
+
+func (r *writeRecorder) Write(p []byte) (int, error) {
+	r.writes = append(r.writes, string(p))
+	return len(p), nil
+}
+
+func TestPrintSingleWritePerToken(t *testing.T) {
+	src := []byte("a := \"x\"\nb")
+	tests := []struct {
+		name    string
+		options []Option
+		want    []string
+	}{
+		{
+			name: "spans",
+			want: []string{`<span class="pln">a</span>`, " ", `<span class="pun">:</span>`, `<span class="pun">=</span>`, " ", `<span class="str">&#34;x&#34;</span>`, "\n", `<span class="pln">b</span>`},
+		},
+		{
+			name:    "lines",
+			options: []Option{LineAnchors(), Diagnostics(Diagnostic{Line: 2, Col: 1, Message: "m"})},
+			want: []string{
+				`<span class="line" id="L1" data-line="1"><span class="pln">a</span>`, " ", `<span class="pun">:</span>`, `<span class="pun">=</span>`, " ", `<span class="str">&#34;x&#34;</span>`,
+				"</span>\n" + `<span class="line" id="L2" data-line="2"><span class="diagnostic-icon error" title="m" style="user-select:none;color:red">&#9679;</span>`,
+				`<span class="diagnostic error" title="m" style="text-decoration:underline wavy red"><span class="pln">b</span></span>`,
+				"</span>",
+			},
+		},
+	}
+	for _, test := range tests {
+		var r writeRecorder
+		if err := Print(NewScanner(src), &r, newHTMLConfig(test.options).printer()); err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		if !reflect.DeepEqual(r.writes, test.want) {
+			t.Errorf("%s: want writes %q, got %q", test.name, test.want, r.writes)
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	src := []byte(`"<b>"`)
+	got, err := AsHTML(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<span class="str">&#34;&lt;b&gt;&#34;</span>`; string(got) != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	got, err = AsHTML(src, Escape(NoEscape))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<span class="str">"<b>"</span>`; string(got) != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestNumericEntities(t *testing.T) {
+	got, err := AsHTML([]byte("\"é→\xff<\""), NumericEntities())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<span class="str">&#34;&#233;&#8594;&#65533;&lt;&#34;</span>`; string(got) != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	strip := func(html []byte) []byte {
+		return regexp.MustCompile(`</?span[^>]*>`).ReplaceAll(html, nil)
+	}
+	got, err := AsHTML([]byte("a <b>"), OrderedList(), Sanitize(strip))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<ol>\n<li>a &lt;b&gt;</li>\n</ol>"; string(got) != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+// TestNoMarkupInjection checks that source text cannot break out of the
+// generated markup: the output must only contain the elements and
+// attributes that the package writes itself, and its text must be exactly
+// the source.
+func TestNoMarkupInjection(t *testing.T) {
+	srcs := []string{
+		`</span><script>alert(1)</script>`,
+		`"</span><img src=x onerror=alert(1)>"`,
+		`'"><svg onload=alert(1)>'`,
+		`/* --></span><iframe> */`,
+		"// <!-- \n<a href=\"javascript:alert(1)\">",
+		"<div class=\"x\" title='&lt;'>&amp; &#60; ]]></div>",
+		"`${\"</span>\"}` </li></ol></td></tr></table>",
+		`#!/bin/sh <b>`,
+	}
+	allowed := map[string]bool{"root": true, "span": true, "ol": true, "li": true, "mark": true}
+	optionSets := [][]Option{
+		nil,
+		{OrderedList()},
+		{LineAnchors(), Interpolation()},
+		{Accessible(), Diagnostics(Diagnostic{Line: 1, Message: `</span><b a="`})},
+	}
+	for _, src := range srcs {
+		for _, options := range optionSets {
+			out, err := AsHTML([]byte(src), options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d := xml.NewDecoder(strings.NewReader("<root>" + string(out) + "</root>"))
+			var text bytes.Buffer
+			for {
+				tok, err := d.Token()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("%q: invalid output %q: %s", src, out, err)
+				}
+				switch tok := tok.(type) {
+				case xml.StartElement:
+					if !allowed[tok.Name.Local] {
+						t.Errorf("%q: unexpected element %q in %q", src, tok.Name.Local, out)
+					}
+					for _, attr := range tok.Attr {
+						switch attr.Name.Local {
+						case "class", "id", "data-line", "title", "style", "role", "aria-label":
+						default:
+							t.Errorf("%q: unexpected attribute %q in %q", src, attr.Name.Local, out)
+						}
+					}
+				case xml.CharData:
+					text.Write(tok)
+				}
+			}
+			// Leave out the diagnostic icon and the newlines around list items.
+			got := strings.TrimSpace(strings.Replace(text.String(), "\u25cf", "", -1))
+			if got != src {
+				t.Errorf("%q: output text is %q", src, got)
+			}
+		}
+	}
+}
+
+func TestHostileClassNames(t *testing.T) {
+	hostile := DefaultHTMLConfig
+	hostile.Keyword = `kwd" onmouseover="alert(1)`
+	hostile.String = `str'><script>`
+
+	got, err := AsHTML([]byte(`if "a"`), func(o *HTMLConfig) { *o = hostile })
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<span class="kwd&#34; onmouseover=&#34;alert(1)">if</span> <span class="str&#39;&gt;&lt;script&gt;">&#34;a&#34;</span>`
+	if string(got) != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	anns, err := Annotate([]byte("if"), HTMLAnnotator(hostile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<span class="kwd&#34; onmouseover=&#34;alert(1)">`; len(anns) != 1 || string(anns[0].Left) != want {
+		t.Errorf("want annotation %q, got %v", want, anns)
+	}
+
+	if err := hostile.Validate(); err == nil {
+		t.Error("want Validate error for hostile config")
+	}
+	if err := DefaultHTMLConfig.Validate(); err != nil {
+		t.Errorf("DefaultHTMLConfig: %s", err)
+	}
+}
+
+func TestMaxSize(t *testing.T) {
+	src := []byte("if x {\n\treturn \"<\"\n}")
+	tests := []struct {
+		options []Option
+		want    string
+		reason  string
+	}{
+		{
+			options: []Option{MaxSize(100), MaxLineLength(20)},
+			want:    `<span class="kwd">if</span> <span class="pln">x</span> <span class="pun">{</span>` + "\n\t" + `<span class="kwd">return</span> <span class="str">&#34;&lt;&#34;</span>` + "\n" + `<span class="pun">}</span>`,
+		},
+		{
+			options: []Option{MaxSize(10)},
+			want:    "if x {\n\treturn &#34;&lt;&#34;\n}",
+			reason:  "syntaxhighlight: source too large: larger than 10 bytes",
+		},
+		{
+			options: []Option{MaxLineLength(10), OrderedList()},
+			want:    "<ol>\n<li>if x {</li>\n<li>\treturn &#34;&lt;&#34;</li>\n<li>}</li>\n</ol>",
+			reason:  "syntaxhighlight: source too large: lines longer than 10 bytes",
+		},
+	}
+	for _, test := range tests {
+		var reason string
+		options := append(test.options, OnFallback(func(err error) {
+			if !errors.Is(err, ErrTooLarge) {
+				t.Errorf("want reason to wrap ErrTooLarge, got %v", err)
+			}
+			reason = err.Error()
+		}))
+		got, err := AsHTML(src, options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != test.want {
+			t.Errorf("want %q, got %q", test.want, got)
+		}
+		if reason != test.reason {
+			t.Errorf("want fallback reason %q, got %q", test.reason, reason)
+		}
+	}
+}
+
+func TestWorkBudget(t *testing.T) {
+	var reason error
+	got, err := AsHTML([]byte("if x {\n\treturn \"<\"\n}"), WorkBudget(6), OnFallback(func(err error) { reason = err }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<span class="kwd">if</span> <span class="pln">x</span> <span class="pun">{</span>` + "\n\t" + `<span class="pln">return &#34;&lt;&#34;</span>` + "\n" + `<span class="pln">}</span>`
+	if string(got) != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if !errors.Is(reason, ErrBudgetExceeded) {
+		t.Errorf("want reason to wrap ErrBudgetExceeded, got %v", reason)
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	got, err := AsHTML([]byte("f(a[0]);\n"), Coalesce(), OrderedList())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<ol>
+<li><span class="pln">f</span><span class="pun">(</span><span class="pln">a</span><span class="pun">[</span><span class="dec">0</span><span class="pun">]);</span></li>
+<li></li>
+</ol>`
+	if string(got) != want {
+		t.Errorf("want ==========\n%s\ngot ===========\n%s", want, got)
+	}
+}
+
+func TestBarePlaintext(t *testing.T) {
+	src := []byte("if a && b {\n\treturn c\n}")
+	var r writeRecorder
+	p := newHTMLConfig([]Option{BarePlaintext()})
+	s, err := p.scanner(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Print(s, &r, p.printer()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`<span class="kwd">if</span>`, " a ", `<span class="pun">&amp;</span>`, `<span class="pun">&amp;</span>`, " b ",
+		`<span class="pun">{</span>`, "\n\t", `<span class="kwd">return</span>`, " c\n", `<span class="pun">}</span>`,
+	}
+	if !reflect.DeepEqual(r.writes, want) {
+		t.Errorf("want writes %q, got %q", want, r.writes)
+	}
+
+	anns, err := Annotate([]byte("a b"), HTMLAnnotator(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(anns) != 0 {
+		t.Errorf("want no annotations, got %d", len(anns))
+	}
+}
+
+func TestStrict(t *testing.T) {
+	tests := []struct {
+		src string
+		err error
+	}{
+		{"x := \"a\" + `b` // \"c", nil},
+		{"a /* b */ x = 'c\ny", &ErrUnterminatedString{Pos: Position{Offset: 14, Line: 1, Column: 15}}},
+		{"a\n\tb = \"\"\"c", &ErrUnterminatedString{Pos: Position{Offset: 7, Line: 2, Column: 6}}},
+		{"`raw", &ErrUnterminatedString{Pos: Position{Offset: 0, Line: 1, Column: 1}}},
+		{"/* open", nil},
+	}
+	for _, test := range tests {
+		_, err := AsHTML([]byte(test.src), Strict())
+		if !reflect.DeepEqual(err, test.err) {
+			t.Errorf("%q: want error %v, got %v", test.src, test.err, err)
+		}
+		if test.err != nil && !errors.Is(err, &ErrUnterminatedString{}) {
+			t.Errorf("%q: want errors.Is to match any ErrUnterminatedString", test.src)
+		}
+	}
+
+	if _, err := AsHTML([]byte(`"open`)); err != nil {
+		t.Errorf("not strict: want no error, got %v", err)
+	}
+}
+
+func TestUnsupportedLanguage(t *testing.T) {
+	_, err := AsHTML([]byte("x"), Lang("klingon"))
+	var langErr *ErrUnsupportedLanguage
+	if !errors.As(err, &langErr) || langErr.Name != "klingon" {
+		t.Fatalf("want *ErrUnsupportedLanguage for klingon, got %v", err)
+	}
+	if !errors.Is(err, &ErrUnsupportedLanguage{}) || errors.Is(err, &ErrUnsupportedLanguage{Name: "go"}) {
+		t.Errorf("errors.Is does not match by name: %v", err)
+	}
+	if _, err := AsHTMLTruncated([]byte("x"), 1, 1, Lang("klingon")); !errors.Is(err, &ErrUnsupportedLanguage{Name: "klingon"}) {
+		t.Errorf("AsHTMLTruncated: want ErrUnsupportedLanguage, got %v", err)
+	}
+}
+
+func TestAsText(t *testing.T) {
+	got, err := AsText([]byte("\uFEFFa := \"b\"\r\n// c\rd\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a := \"b\"\n// c\nd\n"; string(got) != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
 func TestAnnotate(t *testing.T) {
 	src := []byte(`a:=2`)
 	want := annotate.Annotations{
@@ -119,3 +523,62 @@ func BenchmarkAnnotate(b *testing.B) {
 		}
 	}
 }
+
+func TestMarkup(t *testing.T) {
+	src := []byte("if x { // y\n}")
+	options := []Option{Markup(Keyword, "<b>", "</b>"), Markup(Comment, "<i>", "</i>"), Markup(Punctuation, "", "")}
+	got, err := AsHTML(src, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<b>if</b> <span class="pln">x</span> { <i>// y</i>` + "\n}"
+	if string(got) != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if DefaultHTMLConfig.Markup != nil {
+		t.Errorf("DefaultHTMLConfig was modified: %v", DefaultHTMLConfig.Markup)
+	}
+
+	anns, err := Annotate(src, HTMLAnnotator(newHTMLConfig(options)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := annotate.Annotate(src, anns, nil); err != nil || string(got) != want {
+		t.Errorf("Annotate: want %q, got %q (%v)", want, got, err)
+	}
+}
+
+func TestKinds(t *testing.T) {
+	kinds := Kinds()
+	if kinds[0] != Whitespace || kinds[len(kinds)-1] != Doctype {
+		t.Errorf("want Whitespace through Doctype, got %#v", kinds)
+	}
+}
+
+func TestGroupPunctuation(t *testing.T) {
+	got, err := AsHTML([]byte("f(x)});"), GroupPunctuation())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<span class="pln">f</span><span class="pun">(</span><span class="pln">x</span><span class="pun">)});</span>`
+	if string(got) != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	input, err := ioutil.ReadFile("testdata/minified.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := AsHTML(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grouped, err := AsHTML(input, GroupPunctuation())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("minified.js: %d bytes, %d bytes of HTML, %d bytes with GroupPunctuation", len(input), len(plain), len(grouped))
+	if len(grouped) >= len(plain) {
+		t.Errorf("GroupPunctuation did not shrink the output: %d >= %d bytes", len(grouped), len(plain))
+	}
+}
@@ -0,0 +1,77 @@
+package syntaxhighlight
+
+import (
+	"regexp"
+	"strings"
+)
+
+// httpRequestLineRE matches the request line of an HTTP request, such as
+// "GET /index.html HTTP/1.1", with a group for each of its tokens (see
+// httpRequestLineKinds).
+var httpRequestLineRE = regexp.MustCompile(`^([A-Z]+)([ \t]+)(\S+)(?:([ \t]+)(HTTP)(/)(\d+(?:\.\d+)?))?([ \t\r]*)$`)
+
+// httpRequestLineKinds are the kinds of the groups of httpRequestLineRE:
+// the method, the target and the protocol version.
+var httpRequestLineKinds = []Kind{Keyword, Whitespace, Namespace, Whitespace, Keyword, Punctuation, Decimal, Whitespace}
+
+// httpStatusLineRE matches the status line of an HTTP response, such as
+// "HTTP/1.1 404 Not Found", with a group for each of its tokens (see
+// httpStatusLineKinds).
+var httpStatusLineRE = regexp.MustCompile(`^(HTTP)(/)(\d+(?:\.\d+)?)([ \t]+)(\d{3})(?:([ \t]+)(.*?))?([ \t\r]*)$`)
+
+// httpStatusLineKinds are the kinds of the groups of httpStatusLineRE: the
+// protocol version, the status code and the reason phrase.
+var httpStatusLineKinds = []Kind{Keyword, Punctuation, Decimal, Whitespace, Decimal, Whitespace, String, Whitespace}
+
+// newHTTPLexer returns a Lexer for HTTP messages, which highlights methods
+// and protocol names as Keyword, request targets as Namespace, versions and
+// status codes as Decimal, header field names as Variable and their values
+// as in headerField. Bodies are highlighted in the language of their media
+// type, such as JSON for "application/json", or as plain text if it is not
+// known.
+func newHTTPLexer() Lexer {
+	return newHeaderLexer(httpStartLine)
+}
+
+// httpStartLine adds the tokens of line if it is the request line of an
+// HTTP request or the status line of a response, and reports whether it is.
+func httpStartLine(toks *lineTokens, line []byte) bool {
+	if m := httpStatusLineRE.FindSubmatchIndex(line); m != nil {
+		addSubmatches(toks, m, httpStatusLineKinds)
+		return true
+	}
+	if m := httpRequestLineRE.FindSubmatchIndex(line); m != nil {
+		addSubmatches(toks, m, httpRequestLineKinds)
+		return true
+	}
+	return false
+}
+
+// addSubmatches adds a token of the given kind for each group of the match
+// m, as returned by FindSubmatchIndex; groups that did not match are
+// skipped.
+func addSubmatches(toks *lineTokens, m []int, kinds []Kind) {
+	for i, kind := range kinds {
+		if start, end := m[2*i+2], m[2*i+3]; start >= 0 {
+			toks.add(end-start, kind)
+		}
+	}
+}
+
+// httpBodyLanguage returns the language of a body with the given media
+// type, or nil if it is not known. Media types with a structured syntax
+// suffix, such as "application/problem+json", are looked up by their
+// suffix.
+func httpBodyLanguage(contentType string) *Language {
+	if l := LookupLanguage(contentType); l != nil {
+		return l
+	}
+	mediaType := contentType
+	if i := strings.IndexByte(mediaType, ';'); i >= 0 {
+		mediaType = mediaType[:i]
+	}
+	if i := strings.LastIndexByte(mediaType, '+'); i >= 0 {
+		return LookupLanguage("application/" + strings.TrimSpace(mediaType[i+1:]))
+	}
+	return nil
+}
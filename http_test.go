@@ -0,0 +1,42 @@
+package syntaxhighlight
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHTTPLexer(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []token
+	}{
+		{"POST /api/items?x=1 HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/json; charset=utf-8\r\n\r\n{\"n\": 1}\n", []token{
+			{Keyword, "POST"}, {Whitespace, " "}, {Namespace, "/api/items?x=1"}, {Whitespace, " "},
+			{Keyword, "HTTP"}, {Punctuation, "/"}, {Decimal, "1.1"}, {Whitespace, "\r"}, {Whitespace, "\n"},
+			{Variable, "Host"}, {Punctuation, ":"}, {Whitespace, " "}, {String, "example.com"}, {Whitespace, "\r"}, {Whitespace, "\n"},
+			{Variable, "Content-Type"}, {Punctuation, ":"}, {Whitespace, " "}, {String, "application/json; charset=utf-8"}, {Whitespace, "\r"}, {Whitespace, "\n"},
+			{Whitespace, "\r"}, {Whitespace, "\n"},
+			{Punctuation, "{"}, {Variable, `"n"`}, {Punctuation, ":"}, {Whitespace, " "}, {Decimal, "1"}, {Punctuation, "}"}, {Whitespace, "\n"},
+		}},
+		{"HTTP/2 404 Not Found\nX-Trace:\n  folded\n\n{\"n\": 1}", []token{
+			{Keyword, "HTTP"}, {Punctuation, "/"}, {Decimal, "2"}, {Whitespace, " "}, {Decimal, "404"}, {Whitespace, " "}, {String, "Not Found"}, {Whitespace, "\n"},
+			{Variable, "X-Trace"}, {Punctuation, ":"}, {Whitespace, "\n"},
+			{Whitespace, "  "}, {String, "folded"}, {Whitespace, "\n"},
+			{Whitespace, "\n"},
+			{Plaintext, `{"n": 1}`},
+		}},
+		{"HTTP/1.1 400 Bad Request\nContent-Type: application/problem+json\n\n{}", []token{
+			{Keyword, "HTTP"}, {Punctuation, "/"}, {Decimal, "1.1"}, {Whitespace, " "}, {Decimal, "400"}, {Whitespace, " "}, {String, "Bad Request"}, {Whitespace, "\n"},
+			{Variable, "Content-Type"}, {Punctuation, ":"}, {Whitespace, " "}, {String, "application/problem+json"}, {Whitespace, "\n"},
+			{Whitespace, "\n"},
+			{Punctuation, "{"}, {Punctuation, "}"},
+		}},
+	}
+	for _, test := range tests {
+		s := NewScanner([]byte(test.src))
+		s.UseLanguage(LookupLanguage("http"))
+		if got := scanAll(t, s); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q:\nwant %v\ngot  %v", test.src, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package syntaxhighlight
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"unicode/utf8"
+)
+
+// invisibles holds the names of the bidirectional control characters and
+// zero-width characters that are marked by FlagInvisible. They can make
+// source code read differently from how it is compiled ("Trojan Source",
+// CVE-2021-42574).
+var invisibles = map[rune]string{
+	'\u061c': "ARABIC LETTER MARK",
+	'\u200e': "LEFT-TO-RIGHT MARK",
+	'\u200f': "RIGHT-TO-LEFT MARK",
+	'\u202a': "LEFT-TO-RIGHT EMBEDDING",
+	'\u202b': "RIGHT-TO-LEFT EMBEDDING",
+	'\u202c': "POP DIRECTIONAL FORMATTING",
+	'\u202d': "LEFT-TO-RIGHT OVERRIDE",
+	'\u202e': "RIGHT-TO-LEFT OVERRIDE",
+	'\u2066': "LEFT-TO-RIGHT ISOLATE",
+	'\u2067': "RIGHT-TO-LEFT ISOLATE",
+	'\u2068': "FIRST STRONG ISOLATE",
+	'\u2069': "POP DIRECTIONAL ISOLATE",
+	'\u180e': "MONGOLIAN VOWEL SEPARATOR",
+	'\u200b': "ZERO WIDTH SPACE",
+	'\u200c': "ZERO WIDTH NON-JOINER",
+	'\u200d': "ZERO WIDTH JOINER",
+	'\u2060': "WORD JOINER",
+	'\ufeff': "ZERO WIDTH NO-BREAK SPACE",
+}
+
+// FlagInvisible replaces bidirectional control characters and zero-width
+// characters in the source, which are invisible but can change how code
+// reads, with visible markers such as
+//
+//	<span class="invisible" title="RIGHT-TO-LEFT OVERRIDE">U+202E</span>
+//
+// using the given class, so that code reviewers can spot them. The markers
+// are written inside the spans of the tokens that contain the characters.
+//
+// Example:
+// AsHTML(input, FlagInvisible("invisible"))
+func FlagInvisible(class string) Option {
+	return func(o *HTMLConfig) {
+		o.Invisible = class
+	}
+}
+
+// escapeFlaggingInvisibles writes text with escape, writing markers with
+// the given class in place of invisible characters.
+func escapeFlaggingInvisibles(w io.Writer, text []byte, class string, escape func(io.Writer, []byte)) {
+	start := 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRune(text[i:])
+		name, ok := invisibles[r]
+		if !ok {
+			i += size
+			continue
+		}
+		if i > start {
+			escape(w, text[start:i])
+		}
+		fmt.Fprintf(w, `<span class="%s" title="%s">U+%04X</span>`, template.HTMLEscapeString(class), name, r)
+		i += size
+		start = i
+	}
+	if start < len(text) {
+		escape(w, text[start:])
+	}
+}
@@ -0,0 +1,25 @@
+package syntaxhighlight
+
+import "testing"
+
+func TestFlagInvisible(t *testing.T) {
+	src := []byte("s := \"user\u202e \u2066// admin\u2069\u2066\"\nx\u200by := 1")
+	got, err := AsHTML(src, FlagInvisible("inv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<span class="pln">s</span> <span class="pun">:</span><span class="pun">=</span> <span class="str">&#34;user<span class="inv" title="RIGHT-TO-LEFT OVERRIDE">U+202E</span> <span class="inv" title="LEFT-TO-RIGHT ISOLATE">U+2066</span>// admin<span class="inv" title="POP DIRECTIONAL ISOLATE">U+2069</span><span class="inv" title="LEFT-TO-RIGHT ISOLATE">U+2066</span>&#34;</span>` + "\n" +
+		`<span class="pln">x</span><span class="pun"><span class="inv" title="ZERO WIDTH SPACE">U+200B</span></span><span class="pln">y</span> <span class="pun">:</span><span class="pun">=</span> <span class="dec">1</span>`
+	if string(got) != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	// without the option, the characters are written as they are
+	got, err = AsHTML([]byte("a\u202eb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<span class=\"pln\">a</span><span class=\"pun\">\u202e</span><span class=\"pln\">b</span>"; string(got) != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
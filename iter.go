@@ -0,0 +1,38 @@
+//go:build go1.23
+// +build go1.23
+
+package syntaxhighlight
+
+import "iter"
+
+// Tokens returns an iterator over the tokens of src, scanned with the
+// scanning options among options, such as Lang or Coalesce. If scanning
+// fails, the last pair holds the error. Breaking out of the loop stops
+// scanning.
+//
+// Example:
+//
+//	for tok, err := range Tokens(src) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Printf("%#v %q\n", tok.Kind, tok.Text)
+//	}
+func Tokens(src []byte, options ...Option) iter.Seq2[Token, error] {
+	return func(yield func(Token, error) bool) {
+		c := newHTMLConfig(options)
+		s, err := c.scanner(src)
+		if err != nil {
+			yield(Token{}, err)
+			return
+		}
+		for s.Scan() {
+			if !yield(Token{Kind: s.Kind(), Text: s.Text()}, nil) {
+				return
+			}
+		}
+		if err := s.Err(); err != nil {
+			yield(Token{}, err)
+		}
+	}
+}
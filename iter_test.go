@@ -0,0 +1,45 @@
+//go:build go1.23
+// +build go1.23
+
+package syntaxhighlight
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTokens(t *testing.T) {
+	var got []token
+	for tok, err := range Tokens([]byte("a := 1 // b"), Coalesce()) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, token{tok.Kind, tok.Text})
+		if tok.Kind == Decimal {
+			break
+		}
+	}
+	want := []token{{Plaintext, "a"}, {Whitespace, " "}, {Punctuation, ":="}, {Whitespace, " "}, {Decimal, "1"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	var errs []error
+	for tok, err := range Tokens([]byte("x = 'a"), Strict()) {
+		if err != nil {
+			errs = append(errs, err)
+		} else if tok.Text == "" {
+			t.Errorf("empty token %#v", tok)
+		}
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], &ErrUnterminatedString{}) {
+		t.Errorf("want one *ErrUnterminatedString, got %v", errs)
+	}
+
+	for _, err := range Tokens(nil, Lang("klingon")) {
+		if !errors.Is(err, &ErrUnsupportedLanguage{}) {
+			t.Errorf("want *ErrUnsupportedLanguage, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// jsonNumberRE matches the numbers of JSON.
+var jsonNumberRE = regexp.MustCompile(`^-?\d+(\.\d+)?([eE][+-]?\d+)?$`)
+
+// json5NumberRE matches the numbers of JSON5, which may also have a plus
+// sign, be hexadecimal, start or end with a decimal point, or be Infinity
+// or NaN.
+var json5NumberRE = regexp.MustCompile(`^[+-]?(0[xX][0-9a-fA-F]+|(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?|Infinity|NaN)$`)
+
+// json5KeyRE matches the identifiers that JSON5 allows as unquoted object
+// keys.
+var json5KeyRE = regexp.MustCompile(`^[\pL$_][\pL\pN$_]*$`)
+
+// jsonLexer is a Lexer for JSON and its dialects. It keeps track of the
+// objects and arrays it is in to tell object keys from values.
+type jsonLexer struct {
+	json5 bool   // the source is JSON5
+	stack []byte // opening brackets of the enclosing objects and arrays
+	key   bool   // the next string is an object key
+}
+
+// newJSONLexer returns a Lexer for JSON and JSONC (JSON with comments, as
+// in tsconfig.json), which highlights object keys as Variable, other
+// strings as String, numbers as Decimal, true, false and null as Literal
+// and comments as Comment. Comments are not valid JSON, but are
+// highlighted as such in JSON too since configuration files often have
+// them. Trailing commas need no special handling.
+func newJSONLexer() Lexer {
+	return &jsonLexer{}
+}
+
+// newJSON5Lexer returns a Lexer for JSON5, which additionally allows
+// single-quoted strings, unquoted object keys and more forms of numbers.
+func newJSON5Lexer() Lexer {
+	return &jsonLexer{json5: true}
+}
+
+func (l *jsonLexer) Lex(data []byte, atEOF bool) (int, Kind) {
+	c := data[0]
+	if c == '/' && len(data) == 1 && !atEOF {
+		return 0, 0
+	}
+	switch {
+	case isBlank(c) || c == '\n':
+		i := 0
+		for i < len(data) && (isBlank(data[i]) || data[i] == '\n') {
+			i++
+		}
+		if i == len(data) && !atEOF {
+			return 0, 0
+		}
+		return i, Whitespace
+	case hasPrefix(data, "//"):
+		return scanLine(data, atEOF), Comment
+	case hasPrefix(data, "/*"):
+		i := bytes.Index(data[2:], []byte("*/"))
+		if i < 0 {
+			if !atEOF {
+				return 0, 0
+			}
+			return len(data), Comment
+		}
+		return i + 4, Comment
+	case c == '"' || c == '\'' && l.json5:
+		n := l.string(data, atEOF)
+		if n == 0 {
+			return 0, 0
+		}
+		if l.key {
+			return n, Variable
+		}
+		return n, String
+	case bytes.IndexByte([]byte("{}[],:"), c) >= 0:
+		l.punctuation(c)
+		return 1, Punctuation
+	}
+
+	n := 0
+	for n < len(data) && !isBlank(data[n]) && bytes.IndexByte([]byte("\n{}[],:\"'/"), data[n]) < 0 {
+		n++
+	}
+	if n == len(data) && !atEOF {
+		return 0, 0
+	}
+	if n == 0 {
+		return 1, Plaintext
+	}
+	word := data[:n]
+	switch {
+	case string(word) == "true" || string(word) == "false" || string(word) == "null":
+		return n, Literal
+	case jsonNumberRE.Match(word) || l.json5 && json5NumberRE.Match(word):
+		return n, Decimal
+	case l.json5 && l.key && json5KeyRE.Match(word):
+		return n, Variable
+	}
+	return n, Plaintext
+}
+
+// punctuation updates the state of l after the punctuation c.
+func (l *jsonLexer) punctuation(c byte) {
+	switch c {
+	case '{', '[':
+		l.stack = append(l.stack, c)
+		l.key = c == '{'
+	case '}', ']':
+		if len(l.stack) > 0 {
+			l.stack = l.stack[:len(l.stack)-1]
+		}
+		l.key = false
+	case ',':
+		l.key = len(l.stack) > 0 && l.stack[len(l.stack)-1] == '{'
+	case ':':
+		l.key = false
+	}
+}
+
+// string returns the length of the string at the start of data, or 0 if
+// more data is needed. Unterminated strings end at the end of their line;
+// in JSON5, strings may continue on the next line after a backslash.
+func (l *jsonLexer) string(data []byte, atEOF bool) int {
+	for i := 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			if i+1 < len(data) && (data[i+1] != '\n' || l.json5) {
+				i++
+			}
+		case '\n':
+			return i
+		case data[0]:
+			return i + 1
+		}
+	}
+	if !atEOF {
+		return 0
+	}
+	return len(data)
+}
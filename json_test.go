@@ -0,0 +1,41 @@
+package syntaxhighlight
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJSONLexer(t *testing.T) {
+	tests := []struct {
+		lang, src string
+		want      []token
+	}{
+		{"json", `{"a": [1, -2.5e3, "x"], "b": {"c": null}}`, []token{
+			{Punctuation, "{"}, {Variable, `"a"`}, {Punctuation, ":"}, {Whitespace, " "},
+			{Punctuation, "["}, {Decimal, "1"}, {Punctuation, ","}, {Whitespace, " "}, {Decimal, "-2.5e3"}, {Punctuation, ","}, {Whitespace, " "}, {String, `"x"`}, {Punctuation, "]"},
+			{Punctuation, ","}, {Whitespace, " "}, {Variable, `"b"`}, {Punctuation, ":"}, {Whitespace, " "},
+			{Punctuation, "{"}, {Variable, `"c"`}, {Punctuation, ":"}, {Whitespace, " "}, {Literal, "null"}, {Punctuation, "}"}, {Punctuation, "}"},
+		}},
+		{"jsonc", "{\n  // strict\n  \"strict\": true, /* for now */\n}\n", []token{
+			{Punctuation, "{"}, {Whitespace, "\n  "}, {Comment, "// strict"}, {Whitespace, "\n  "},
+			{Variable, `"strict"`}, {Punctuation, ":"}, {Whitespace, " "}, {Literal, "true"}, {Punctuation, ","}, {Whitespace, " "},
+			{Comment, "/* for now */"}, {Whitespace, "\n"}, {Punctuation, "}"}, {Whitespace, "\n"},
+		}},
+		{"json5", "{unquoted: 'single', hex: 0xFF, n: +.5, inf: -Infinity,}", []token{
+			{Punctuation, "{"}, {Variable, "unquoted"}, {Punctuation, ":"}, {Whitespace, " "}, {String, "'single'"}, {Punctuation, ","}, {Whitespace, " "},
+			{Variable, "hex"}, {Punctuation, ":"}, {Whitespace, " "}, {Decimal, "0xFF"}, {Punctuation, ","}, {Whitespace, " "},
+			{Variable, "n"}, {Punctuation, ":"}, {Whitespace, " "}, {Decimal, "+.5"}, {Punctuation, ","}, {Whitespace, " "},
+			{Variable, "inf"}, {Punctuation, ":"}, {Whitespace, " "}, {Decimal, "-Infinity"}, {Punctuation, ","}, {Punctuation, "}"},
+		}},
+		{"json", "{key: 'no'}", []token{
+			{Punctuation, "{"}, {Plaintext, "key"}, {Punctuation, ":"}, {Whitespace, " "}, {Plaintext, "'"}, {Plaintext, "no"}, {Plaintext, "'"}, {Punctuation, "}"},
+		}},
+	}
+	for _, test := range tests {
+		s := NewScanner([]byte(test.src))
+		s.UseLanguage(LookupLanguage(test.lang))
+		if got := scanAll(t, s); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s %q:\nwant %v\ngot  %v", test.lang, test.src, test.want, got)
+		}
+	}
+}
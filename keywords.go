@@ -175,3 +175,177 @@ var keywords = map[string]struct{}{
 	"with":             {},
 	"yield":            {},
 }
+
+// objcKeywords are the keywords of Objective-C in addition to those of C:
+// its directives, the types and constants of its runtime and the attributes
+// of properties.
+var objcKeywords = []string{
+	"@autoreleasepool", "@catch", "@class", "@compatibility_alias", "@dynamic", "@encode",
+	"@end", "@finally", "@implementation", "@import", "@interface", "@optional", "@package",
+	"@private", "@property", "@protected", "@protocol", "@public", "@required", "@selector",
+	"@synchronized", "@synthesize", "@throw", "@try",
+	"id", "instancetype", "BOOL", "SEL", "IMP", "Class", "YES", "NO", "Nil",
+	"atomic", "nonatomic", "strong", "weak", "assign", "copy", "retain", "readonly", "readwrite",
+	"nullable", "nonnull", "__block", "__weak", "__strong", "IBOutlet", "IBAction",
+}
+
+// groovyKeywords are the keywords of Groovy that are not among the
+// language-independent ones, including "it", the implicit parameter of
+// closures.
+var groovyKeywords = []string{"it", "trait", "threadsafe", "println"}
+
+// vbKeywords are the keywords of Visual Basic, VBA and VBScript.
+var vbKeywords = []string{
+	"AddHandler", "AddressOf", "Alias", "And", "AndAlso", "As", "ByRef", "ByVal", "Call", "Case",
+	"Catch", "Class", "Compare", "Const", "Continue", "Declare", "Default", "Delegate", "Dim", "Do",
+	"Each", "Else", "ElseIf", "End", "Enum", "Erase", "Error", "Event", "Exit", "Explicit", "False",
+	"Finally", "For", "Friend", "Function", "Get", "GetType", "Global", "GoSub", "GoTo", "Handles",
+	"If", "Implements", "Imports", "In", "Inherits", "Interface", "Is", "IsNot", "Let", "Lib", "Like",
+	"Loop", "Me", "Mod", "Module", "MustInherit", "MustOverride", "MyBase", "MyClass", "Namespace",
+	"New", "Next", "Not", "Nothing", "NotInheritable", "NotOverridable", "Of", "On", "Operator",
+	"Option", "Optional", "Or", "OrElse", "Overloads", "Overridable", "Overrides", "ParamArray",
+	"Partial", "Preserve", "Private", "Property", "Protected", "Public", "RaiseEvent", "ReadOnly",
+	"ReDim", "RemoveHandler", "Resume", "Return", "Select", "Set", "Shadows", "Shared", "Static",
+	"Step", "Stop", "Structure", "Sub", "SyncLock", "Then", "Throw", "To", "True", "Try", "TryCast",
+	"TypeOf", "Until", "Using", "Wend", "When", "While", "With", "WithEvents", "WriteOnly", "Xor",
+}
+
+// vbTypes are the built-in types of Visual Basic, VBA and VBScript.
+var vbTypes = []string{
+	"Boolean", "Byte", "Char", "Currency", "Date", "Decimal", "Double", "Integer", "Long", "LongPtr",
+	"Object", "SByte", "Short", "Single", "String", "UInteger", "ULong", "UShort", "Variant",
+}
+
+// pascalKeywords are the keywords of Pascal and Delphi.
+var pascalKeywords = []string{
+	"absolute", "abstract", "and", "array", "as", "asm", "begin", "case", "class", "const",
+	"constructor", "destructor", "div", "do", "downto", "else", "end", "except", "exports",
+	"external", "false", "file", "finalization", "finally", "for", "forward", "function", "goto",
+	"if", "implementation", "in", "inherited", "initialization", "inline", "interface", "is",
+	"label", "library", "mod", "nil", "not", "object", "of", "on", "operator", "or", "out",
+	"overload", "override", "packed", "private", "procedure", "program", "property", "protected",
+	"public", "published", "raise", "record", "reintroduce", "repeat", "resourcestring", "result",
+	"self", "set", "shl", "shr", "strict", "then", "threadvar", "to", "true", "try", "type", "unit",
+	"until", "uses", "var", "virtual", "while", "with", "xor",
+}
+
+// pascalTypes are the built-in types of Pascal and Delphi.
+var pascalTypes = []string{
+	"AnsiChar", "AnsiString", "Boolean", "Byte", "Cardinal", "Char", "Currency", "Double",
+	"Extended", "Int64", "Integer", "LongInt", "LongWord", "PChar", "Pointer", "Real", "ShortInt",
+	"ShortString", "Single", "SmallInt", "String", "UInt64", "UnicodeString", "Variant", "WideChar",
+	"WideString", "Word",
+}
+
+// adaKeywords are the reserved words of Ada.
+var adaKeywords = []string{
+	"abort", "abs", "abstract", "accept", "access", "aliased", "all", "and", "array", "at",
+	"begin", "body", "case", "constant", "declare", "delay", "delta", "digits", "do", "else",
+	"elsif", "end", "entry", "exception", "exit", "false", "for", "function", "generic", "goto",
+	"if", "in", "interface", "is", "limited", "loop", "mod", "new", "not", "null", "of", "or",
+	"others", "out", "overriding", "package", "pragma", "private", "procedure", "protected",
+	"raise", "range", "record", "rem", "renames", "requeue", "return", "reverse", "select",
+	"separate", "some", "subtype", "synchronized", "tagged", "task", "terminate", "then", "true",
+	"type", "until", "use", "when", "while", "with", "xor",
+}
+
+// adaTypes are the types of Ada's package Standard.
+var adaTypes = []string{
+	"Boolean", "Character", "Duration", "Float", "Integer", "Long_Float", "Long_Integer",
+	"Long_Long_Integer", "Natural", "Positive", "Short_Integer", "String", "Wide_Character",
+	"Wide_String", "Wide_Wide_Character", "Wide_Wide_String",
+}
+
+// matlabKeywords are the keywords of MATLAB and Octave that are not among
+// the language-independent ones.
+var matlabKeywords = []string{
+	"classdef", "elseif", "end_try_catch", "endfor", "endfunction", "endif", "endwhile",
+	"enumeration", "events", "methods", "otherwise", "parfor", "persistent", "properties", "spmd",
+}
+
+// crystalKeywords are the keywords of Crystal that are not among the
+// language-independent ones.
+var crystalKeywords = []string{
+	"annotation", "fun", "getter", "include", "extend", "lib", "macro", "of", "out", "pointerof",
+	"previous_def", "select", "setter", "uninitialized", "verbatim",
+}
+
+// rakuKeywords are the keywords of Raku that are not among the
+// language-independent ones.
+var rakuKeywords = []string{
+	"andthen", "but", "does", "gather", "given", "grammar", "has", "loop", "method", "multi",
+	"orelse", "proto", "react", "regex", "repeat", "role", "rule", "rx", "say", "so", "start",
+	"submethod", "supply", "take", "token", "unit", "whenever", "without",
+}
+
+// cudaKeywords are the function and variable qualifiers of CUDA C++ and the
+// built-in variables of its kernels.
+var cudaKeywords = []string{
+	"__constant__", "__device__", "__forceinline__", "__global__", "__grid_constant__", "__host__",
+	"__launch_bounds__", "__managed__", "__noinline__", "__restrict__", "__shared__", "__syncthreads",
+	"blockDim", "blockIdx", "gridDim", "threadIdx", "warpSize",
+}
+
+// cudaTypes are the built-in vector and runtime types of CUDA.
+var cudaTypes = append(vectorTypes([]string{"1", "2", "3", "4"},
+	"char", "uchar", "short", "ushort", "int", "uint", "long", "ulong", "longlong", "ulonglong", "float", "double"),
+	"dim3", "half", "half2", "__half", "__half2", "__nv_bfloat16", "cudaError_t", "cudaEvent_t", "cudaStream_t",
+)
+
+// openclKeywords are the qualifiers of OpenCL C.
+var openclKeywords = []string{
+	"__constant", "__generic", "__global", "__kernel", "__local", "__private", "__read_only",
+	"__read_write", "__write_only", "constant", "generic", "global", "kernel", "local", "private",
+	"read_only", "read_write", "write_only",
+}
+
+// openclTypes are the built-in scalar, vector and image types of OpenCL C.
+var openclTypes = append(vectorTypes([]string{"2", "3", "4", "8", "16"},
+	"char", "uchar", "short", "ushort", "int", "uint", "long", "ulong", "half", "float", "double"),
+	"uchar", "ushort", "uint", "ulong", "half", "size_t", "ptrdiff_t", "intptr_t", "uintptr_t",
+	"image1d_t", "image2d_t", "image3d_t", "image2d_array_t", "sampler_t", "event_t", "queue_t",
+)
+
+// glslKeywords are the qualifiers and statements of GLSL that are not among
+// the language-independent ones.
+var glslKeywords = []string{
+	"attribute", "buffer", "centroid", "coherent", "discard", "flat", "highp", "in", "inout",
+	"invariant", "layout", "lowp", "mediump", "noperspective", "out", "patch", "precise", "precision",
+	"readonly", "restrict", "sample", "shared", "smooth", "subroutine", "uniform", "varying",
+	"writeonly",
+}
+
+// glslTypes are the built-in vector, matrix, sampler and image types of
+// GLSL.
+var glslTypes = append(append(
+	vectorTypes([]string{"2", "3", "4"}, "vec", "ivec", "uvec", "bvec", "dvec", "mat", "dmat"),
+	vectorTypes([]string{"2x2", "2x3", "2x4", "3x2", "3x3", "3x4", "4x2", "4x3", "4x4"}, "mat", "dmat")...),
+	vectorTypes([]string{"1D", "2D", "3D", "Cube", "2DRect", "1DArray", "2DArray", "CubeArray", "Buffer", "2DMS", "2DMSArray"},
+		"sampler", "isampler", "usampler", "image", "iimage", "uimage")...,
+)
+
+// hlslKeywords are the storage classes, qualifiers and statements of HLSL
+// that are not among the language-independent ones.
+var hlslKeywords = []string{
+	"cbuffer", "centroid", "column_major", "discard", "groupshared", "in", "inout", "linear",
+	"nointerpolation", "noperspective", "out", "packoffset", "precise", "register", "row_major",
+	"sample", "shared", "snorm", "tbuffer", "uniform", "unorm",
+}
+
+// hlslTypes are the built-in scalar, vector and matrix types of HLSL.
+var hlslTypes = append(vectorTypes([]string{"1", "2", "3", "4", "1x1", "1x2", "1x3", "1x4", "2x1", "2x2", "2x3", "2x4", "3x1", "3x2", "3x3", "3x4", "4x1", "4x2", "4x3", "4x4"},
+	"bool", "int", "uint", "dword", "half", "float", "double", "min16float", "min10float", "min16int", "min12int", "min16uint"),
+	"dword", "half", "matrix", "vector", "min16float", "min10float", "min16int", "min12int", "min16uint",
+)
+
+// vectorTypes returns the names of the vector types made of each of the base
+// types and each of the sizes, such as "float4" for "float" and "4".
+func vectorTypes(sizes []string, bases ...string) []string {
+	var types []string
+	for _, base := range bases {
+		for _, size := range sizes {
+			types = append(types, base+size)
+		}
+	}
+	return types
+}
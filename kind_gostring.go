@@ -4,9 +4,9 @@ package syntaxhighlight
 
 import "fmt"
 
-const _Kind_name = "WhitespaceStringKeywordCommentTypeLiteralPunctuationPlaintextTagHTMLTagHTMLAttrNameHTMLAttrValueDecimal"
+const _Kind_name = "WhitespaceStringKeywordCommentTypeLiteralPunctuationPlaintextTagHTMLTagHTMLAttrNameHTMLAttrValueDecimalDocCommentShebangLabelNamespaceVariableConfusableLogErrorLogWarningLogInfoXMLNamespaceProcInstDoctype"
 
-var _Kind_index = [...]uint8{0, 10, 16, 23, 30, 34, 41, 52, 61, 64, 71, 83, 96, 103}
+var _Kind_index = [...]uint8{0, 10, 16, 23, 30, 34, 41, 52, 61, 64, 71, 83, 96, 103, 113, 120, 125, 134, 142, 152, 160, 170, 177, 189, 197, 204}
 
 func (i Kind) GoString() string {
 	if i+1 >= Kind(len(_Kind_index)) {
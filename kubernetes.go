@@ -0,0 +1,93 @@
+package syntaxhighlight
+
+import "bytes"
+
+// kubernetesKeys are the keys of the objects of Kubernetes that are
+// highlighted wherever they are, as objects nest, as in the templates of
+// Deployments or the items of Lists.
+var kubernetesKeys = setOf("apiVersion", "kind", "metadata", "spec", "status")
+
+// kubernetesTopKeys are the keys that are only highlighted at the top level
+// of an object, as those of ConfigMaps and Secrets.
+var kubernetesTopKeys = setOf("data", "stringData", "binaryData", "items")
+
+// kubernetesMode specializes YAML for Kubernetes manifests: the keys every
+// object has, such as apiVersion, kind and metadata, are Keyword, the kind
+// of objects Type, and image references, such as nginx:1.25, are split
+// into their name, Namespace, and tag or digest, Literal.
+var kubernetesMode = &yamlMode{
+	key: func(path []string) (Kind, bool) {
+		if len(path) == 0 {
+			return 0, false
+		}
+		key := path[len(path)-1]
+		return Keyword, kubernetesKeys[key] || len(path) == 1 && kubernetesTopKeys[key]
+	},
+	value: func(toks *lineTokens, path []string, value []byte) bool {
+		if len(path) == 0 || len(value) == 0 {
+			return false
+		}
+		switch path[len(path)-1] {
+		case "kind":
+			if value[0] == '"' || value[0] == '\'' {
+				return false
+			}
+			toks.add(len(value), Type)
+			return true
+		case "image":
+			return kubernetesImage(toks, value)
+		}
+		return false
+	},
+}
+
+// newKubernetesLexer returns a Lexer for Kubernetes manifests, which are
+// YAML (see kubernetesMode).
+func newKubernetesLexer() Lexer {
+	return newYAMLModeLexer(kubernetesMode)()
+}
+
+// kubernetesImage adds the tokens of value, a reference to an image, such
+// as "registry.io/app:v1@sha256:...", which may be quoted, and reports
+// whether it is one.
+func kubernetesImage(toks *lineTokens, value []byte) bool {
+	if len(value) == 0 {
+		return false
+	}
+	ref := value
+	quoted := value[0] == '"' || value[0] == '\''
+	if quoted {
+		if len(value) < 2 || value[len(value)-1] != value[0] {
+			return false
+		}
+		ref = value[1 : len(value)-1]
+	}
+	if len(ref) == 0 || bytes.ContainsAny(ref, " \t{}$") {
+		return false
+	}
+	name, digest := ref, []byte(nil)
+	if i := bytes.IndexByte(ref, '@'); i >= 0 {
+		name, digest = ref[:i], ref[i+1:]
+	}
+	tag := []byte(nil)
+	if i := bytes.LastIndexByte(name, ':'); i > bytes.LastIndexByte(name, '/') {
+		name, tag = name[:i], name[i+1:]
+	}
+
+	if quoted {
+		toks.add(1, String)
+	}
+	toks.add(len(name), Namespace)
+	if tag != nil {
+		toks.add(1, Punctuation)
+		toks.add(len(tag), Literal)
+	}
+	if digest != nil {
+		toks.add(1, Punctuation)
+		toks.add(len(digest), Literal)
+	}
+	if quoted {
+		toks.add(1, String)
+	}
+	return true
+}
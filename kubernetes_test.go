@@ -0,0 +1,38 @@
+package syntaxhighlight
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKubernetesLexer(t *testing.T) {
+	src := "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  template:\n    spec:\n      containers:\n        - name: app\n          image: ghcr.io/org/app:1.4\n        - image: \"nginx@sha256:ab12\"\n          args: [\"--kind\", x]\n"
+	want := []token{
+		{Keyword, "apiVersion"}, {Punctuation, ":"}, {Whitespace, " "}, {String, "apps/v1"}, {Whitespace, "\n"},
+		{Keyword, "kind"}, {Punctuation, ":"}, {Whitespace, " "}, {Type, "Deployment"}, {Whitespace, "\n"},
+		{Keyword, "metadata"}, {Punctuation, ":"}, {Whitespace, "\n"},
+		{Whitespace, "  "}, {Variable, "name"}, {Punctuation, ":"}, {Whitespace, " "}, {String, "web"}, {Whitespace, "\n"},
+		{Keyword, "spec"}, {Punctuation, ":"}, {Whitespace, "\n"},
+		{Whitespace, "  "}, {Variable, "template"}, {Punctuation, ":"}, {Whitespace, "\n"},
+		{Whitespace, "    "}, {Keyword, "spec"}, {Punctuation, ":"}, {Whitespace, "\n"},
+		{Whitespace, "      "}, {Variable, "containers"}, {Punctuation, ":"}, {Whitespace, "\n"},
+		{Whitespace, "        "}, {Punctuation, "-"}, {Whitespace, " "}, {Variable, "name"}, {Punctuation, ":"}, {Whitespace, " "}, {String, "app"}, {Whitespace, "\n"},
+		{Whitespace, "          "}, {Variable, "image"}, {Punctuation, ":"}, {Whitespace, " "},
+		{Namespace, "ghcr.io/org/app"}, {Punctuation, ":"}, {Literal, "1.4"}, {Whitespace, "\n"},
+		{Whitespace, "        "}, {Punctuation, "-"}, {Whitespace, " "}, {Variable, "image"}, {Punctuation, ":"}, {Whitespace, " "},
+		{String, `"`}, {Namespace, "nginx"}, {Punctuation, "@"}, {Literal, "sha256:ab12"}, {String, `"`}, {Whitespace, "\n"},
+		{Whitespace, "          "}, {Variable, "args"}, {Punctuation, ":"}, {Whitespace, " "}, {Punctuation, "["}, {String, `"--kind"`},
+		{Punctuation, ","}, {Whitespace, " "}, {String, "x"}, {Punctuation, "]"}, {Whitespace, "\n"},
+	}
+	s := NewScanner([]byte(src))
+	s.UseLanguage(LookupLanguage("k8s"))
+	if got := scanAll(t, s); !reflect.DeepEqual(got, want) {
+		t.Errorf("%q:\nwant %v\ngot  %v", src, want, got)
+	}
+
+	// an image with a registry port and no tag
+	var toks lineTokens
+	if !kubernetesImage(&toks, []byte("localhost:5000/app")) || len(toks) != 1 || toks[0] != (lexToken{18, Namespace}) {
+		t.Errorf("got %v, want the whole reference as Namespace", toks)
+	}
+}
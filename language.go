@@ -0,0 +1,747 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Language holds the rules of a language that differ from the
+// language-independent ones the scanner uses by default.
+type Language struct {
+	// Name is the canonical, lower-case name of the language, such as "go"
+	// or "python".
+	Name string
+
+	// Aliases are other names of the language, such as "c++" for "cpp".
+	Aliases []string
+
+	// MIMETypes are the media types of source in the language, such as
+	// "text/x-python".
+	MIMETypes []string
+
+	// LineComments are the prefixes that start a line comment, such as "#"
+	// or "--". If nil, the default "//" (and "///" for doc comments) is used.
+	LineComments []string
+
+	// DocComments are the prefixes of line comments that are
+	// documentation, such as the "#'" of R's roxygen, highlighted as
+	// DocComment. They are checked before LineComments.
+	DocComments []string
+
+	// BlockComments are the opening and closing delimiters of block
+	// comments, such as {"(*", "*)"}. If nil, the default "/*" and "*/" (and
+	// "/**" for doc comments) is used.
+	BlockComments [][2]string
+
+	// JSX is set if expressions may contain JSX elements, as in React
+	// code, which are then scanned as markup.
+	JSX bool
+
+	// Keywords are keywords of the language in addition to the
+	// language-independent ones, such as "YES" of Objective-C. They may
+	// start with "@", as do the directives of Objective-C such as
+	// "@interface".
+	Keywords []string
+
+	// Types are the names of the built-in types of the language, such as
+	// "Integer" of Visual Basic, highlighted as Type.
+	Types []string
+
+	// CaseInsensitive is set if keywords are not case-sensitive, as in
+	// Visual Basic. Only the Keywords and Types of the language are then
+	// recognized, in any case, and capitalized identifiers are not taken to
+	// be types.
+	CaseInsensitive bool
+
+	// StringPrefixes are prefixes that may come right before the opening
+	// quote of a string, such as "@" of Objective-C's @"string" literals.
+	// They are highlighted as part of the string.
+	StringPrefixes []string
+
+	// Preprocessor is set if lines starting with "#" are preprocessor
+	// directives, as in C. The directive, such as "#include", is highlighted
+	// as Keyword, and the header name in angle brackets after "#include"
+	// or "#import" as String.
+	Preprocessor bool
+
+	// DoubledQuotes is set if strings have no backslash escapes, and a
+	// quote is written as two, as in Visual Basic's "say ""hi""".
+	DoubledQuotes bool
+
+	// Ticks is set if a quote right after an operand is an operator rather
+	// than the start of a string, such as the attribute tick of Ada's
+	// Integer'Image(n) or the transpose of MATLAB's A'. It is highlighted
+	// as Punctuation.
+	Ticks bool
+
+	// CommandSyntax is set if an identifier at the start of a line that is
+	// followed by a space and a word is a command taking the rest of the
+	// statement as text, as in MATLAB's "hold on". The text is highlighted
+	// as String.
+	CommandSyntax bool
+
+	// LineContinuation, if set, is the token that continues a statement
+	// on the next line when it ends a line, such as "_" of Visual Basic.
+	// It is highlighted as Punctuation.
+	LineContinuation string
+
+	// Sigils are the characters that prefix the names of variables, such as
+	// "$@%&" of Raku. If empty, the default "$" and "@" (and "@@") are used.
+	// Sigils other than "$" and "@" right after an operand are operators.
+	Sigils string
+
+	// Twigils are the characters that may come between a sigil and the
+	// name of a variable, such as "!" in Raku's $!attribute.
+	Twigils string
+
+	// DashedIdentifiers is set if identifiers may contain dashes between
+	// letters, as in Raku's my-variable.
+	DashedIdentifiers bool
+
+	// Symbols is set if a colon followed by a name, as in Ruby's :name, is
+	// a symbol, highlighted as Literal, unless it directly follows an
+	// operand, as in {a:1}. The scope operator "::" is then one token.
+	Symbols bool
+
+	// RegexLiterals is set if a slash that does not follow an operand
+	// starts a regular expression literal, as in Ruby's /\d+/, highlighted
+	// as String. So does a slash after "rx" or "m", as in Raku's rx/\d+/.
+	RegexLiterals bool
+
+	// Pod is set if lines starting with "=" and a word are Pod
+	// documentation, as in Raku, highlighted as DocComment. Blocks started
+	// by "=begin name" run to "=end name"; other directives, such as "=head1
+	// Title", are highlighted to the end of their line.
+	Pod bool
+
+	// DollarTemplates is set if double-quoted strings, including
+	// triple-quoted ones, may embed "${expr}" and "$name", as Groovy's
+	// GStrings and Kotlin's string templates do. With Interpolate, the
+	// names are highlighted as Variable.
+	DollarTemplates bool
+
+	// Selectors is set if, inside square brackets, identifiers followed by
+	// a colon are the parts of the selector of a message, as in
+	// Objective-C's [dict setObject:x forKey:y]. They are highlighted as
+	// Label.
+	Selectors bool
+
+	// CSSTemplates is set if template literals tagged with css, keyframes,
+	// createGlobalStyle or injectGlobal, or with styled.div or
+	// styled(Component) as in styled-components, contain CSS, which is then
+	// highlighted as such.
+	CSSTemplates bool
+
+	// NewLexer, if set, returns a Lexer that scans source in the language
+	// in place of the language-independent rules of the scanner, for
+	// languages that do not look like code, such as configuration files. A
+	// new Lexer is made for every source.
+	NewLexer func() Lexer
+
+	// sets of Keywords and Types, in lower case if CaseInsensitive, made
+	// by RegisterLanguage
+	keywords, types map[string]bool
+}
+
+// languages holds the registered languages by name.
+var languages = map[string]*Language{}
+
+// RegisterLanguage registers l under its name, replacing any language
+// registered under the same name. Like changes to Extensions, it should be
+// done before highlighting starts, such as in an init function.
+func RegisterLanguage(l *Language) {
+	l.keywords, l.types = l.wordSet(l.Keywords), l.wordSet(l.Types)
+	languages[l.Name] = l
+}
+
+func init() {
+	hash := []string{"#"}
+	for _, l := range []*Language{
+		{Name: "c", MIMETypes: []string{"text/x-c", "text/x-csrc", "text/x-chdr"}, Preprocessor: true},
+		{Name: "cpp", Aliases: []string{"c++", "cxx"}, MIMETypes: []string{"text/x-c++src", "text/x-c++hdr", "text/x-c++"}, Preprocessor: true},
+		{Name: "objective-c", Aliases: []string{"objc", "obj-c", "objectivec"}, MIMETypes: []string{"text/x-objective-c", "text/x-objcsrc"}, Keywords: objcKeywords, StringPrefixes: []string{"@"}, Preprocessor: true, Selectors: true},
+		{Name: "objective-c++", Aliases: []string{"objc++", "obj-c++", "objectivec++"}, MIMETypes: []string{"text/x-objective-c++", "text/x-objc++src"}, Keywords: objcKeywords, StringPrefixes: []string{"@"}, Preprocessor: true, Selectors: true},
+		{Name: "cuda", Aliases: []string{"cu"}, MIMETypes: []string{"text/x-cuda"}, Keywords: cudaKeywords, Types: cudaTypes, Preprocessor: true},
+		{Name: "opencl", Aliases: []string{"opencl-c"}, MIMETypes: []string{"text/x-opencl-src"}, Keywords: openclKeywords, Types: openclTypes, Preprocessor: true},
+		{Name: "glsl", MIMETypes: []string{"text/x-glsl"}, Keywords: glslKeywords, Types: glslTypes, Preprocessor: true},
+		{Name: "hlsl", MIMETypes: []string{"text/x-hlsl"}, Keywords: hlslKeywords, Types: hlslTypes, Preprocessor: true},
+		{Name: "csharp", Aliases: []string{"c#", "cs"}, MIMETypes: []string{"text/x-csharp"}},
+		{Name: "go", Aliases: []string{"golang"}, MIMETypes: []string{"text/x-go"}},
+		{Name: "java", MIMETypes: []string{"text/x-java", "text/x-java-source"}},
+		{Name: "javascript", Aliases: []string{"js", "node"}, MIMETypes: []string{"text/javascript", "application/javascript", "application/x-javascript"}, JSX: true, CSSTemplates: true},
+		{Name: "jsx", Aliases: []string{"react"}, MIMETypes: []string{"text/jsx"}, JSX: true, CSSTemplates: true},
+		{Name: "typescript", Aliases: []string{"ts"}, MIMETypes: []string{"text/typescript", "application/typescript"}, CSSTemplates: true},
+		{Name: "tsx", MIMETypes: []string{"text/tsx"}, JSX: true, CSSTemplates: true},
+		{Name: "rust", Aliases: []string{"rs"}, MIMETypes: []string{"text/x-rust"}},
+		{Name: "swift", MIMETypes: []string{"text/x-swift"}},
+		{Name: "kotlin", Aliases: []string{"kt"}, MIMETypes: []string{"text/x-kotlin"}, DollarTemplates: true},
+		{Name: "groovy", Aliases: []string{"gradle"}, MIMETypes: []string{"text/x-groovy"}, Keywords: groovyKeywords, DollarTemplates: true},
+		{Name: "scala", MIMETypes: []string{"text/x-scala"}},
+		{Name: "php", MIMETypes: []string{"application/x-php", "text/x-php"}, LineComments: []string{"//", "#"}},
+		{Name: "python", Aliases: []string{"py", "python3"}, MIMETypes: []string{"text/x-python", "text/x-script.python"}, LineComments: hash},
+		{Name: "ruby", Aliases: []string{"rb"}, MIMETypes: []string{"text/x-ruby", "application/x-ruby"}, LineComments: hash},
+		{Name: "crystal", Aliases: []string{"cr"}, MIMETypes: []string{"text/x-crystal"}, LineComments: hash, Keywords: crystalKeywords, Symbols: true, RegexLiterals: true},
+		{Name: "raku", Aliases: []string{"perl6", "rakumod"}, MIMETypes: []string{"text/x-raku", "text/x-perl6"}, LineComments: hash, Keywords: rakuKeywords, Sigils: "$@%&", Twigils: "!.*^?:=~", DashedIdentifiers: true, RegexLiterals: true, Pod: true},
+		{Name: "perl", Aliases: []string{"pl"}, MIMETypes: []string{"text/x-perl", "application/x-perl"}, LineComments: hash},
+		{Name: "shell", Aliases: []string{"sh", "bash", "zsh"}, MIMETypes: []string{"application/x-sh", "text/x-sh", "text/x-shellscript"}, LineComments: hash},
+		{Name: "r", MIMETypes: []string{"text/x-r"}, LineComments: hash, DocComments: []string{"#'"}},
+		{Name: "yaml", Aliases: []string{"yml"}, MIMETypes: []string{"application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml"}, NewLexer: newYAMLLexer},
+		{Name: "kubernetes", Aliases: []string{"k8s"}, NewLexer: newKubernetesLexer},
+		{Name: "openapi", Aliases: []string{"swagger"}, NewLexer: newOpenAPILexer},
+		{Name: "toml", MIMETypes: []string{"application/toml"}, LineComments: hash},
+		{Name: "makefile", Aliases: []string{"make", "mk"}, MIMETypes: []string{"text/x-makefile"}, LineComments: hash},
+		{Name: "vb", Aliases: []string{"vbnet", "vb.net", "visualbasic", "vba", "vbscript", "vbs"}, MIMETypes: []string{"text/x-vb", "text/x-vbnet", "text/vbscript"}, LineComments: []string{"'", "REM ", "Rem ", "rem "}, Keywords: vbKeywords, Types: vbTypes, CaseInsensitive: true, DoubledQuotes: true, LineContinuation: "_"},
+		{Name: "pascal", Aliases: []string{"delphi", "objectpascal", "freepascal"}, MIMETypes: []string{"text/x-pascal"}, BlockComments: [][2]string{{"{", "}"}, {"(*", "*)"}}, Keywords: pascalKeywords, Types: pascalTypes, CaseInsensitive: true, DoubledQuotes: true},
+		{Name: "ada", Aliases: []string{"ada95", "ada2005", "ada2012"}, MIMETypes: []string{"text/x-ada"}, LineComments: []string{"--"}, Keywords: adaKeywords, Types: adaTypes, CaseInsensitive: true, DoubledQuotes: true, Ticks: true},
+		{Name: "matlab", Aliases: []string{"octave"}, MIMETypes: []string{"text/x-matlab", "text/x-octave"}, LineComments: []string{"%"}, BlockComments: [][2]string{{"%{", "%}"}}, Keywords: matlabKeywords, DoubledQuotes: true, Ticks: true, CommandSyntax: true, LineContinuation: "..."},
+		{Name: "sql", MIMETypes: []string{"application/sql", "text/x-sql"}, NewLexer: newSQLLexer(sqlSyntax)},
+		{Name: "plpgsql", Aliases: []string{"postgresql", "postgres", "pgsql"}, MIMETypes: []string{"text/x-pgsql"}, NewLexer: newSQLLexer(plpgsqlSyntax)},
+		{Name: "tsql", Aliases: []string{"t-sql", "mssql", "sqlserver"}, MIMETypes: []string{"text/x-mssql"}, NewLexer: newSQLLexer(tsqlSyntax)},
+		{Name: "mysql", Aliases: []string{"mariadb"}, MIMETypes: []string{"text/x-mysql", "text/x-mariadb"}, NewLexer: newSQLLexer(mysqlSyntax)},
+		{Name: "mongo", Aliases: []string{"mongosh", "mongodb"}, NewLexer: newMongoLexer},
+		{Name: "lua", MIMETypes: []string{"text/x-lua"}, LineComments: []string{"--"}},
+		{Name: "haskell", Aliases: []string{"hs"}, MIMETypes: []string{"text/x-haskell"}, LineComments: []string{"--"}},
+		{Name: "dockerfile", Aliases: []string{"docker"}, MIMETypes: []string{"text/x-dockerfile"}, LineComments: hash},
+		{Name: "cmake", MIMETypes: []string{"text/x-cmake"}, LineComments: hash},
+		{Name: "starlark", Aliases: []string{"bazel", "bzl", "skylark"}, LineComments: hash},
+		{Name: "common-lisp", Aliases: []string{"lisp", "cl"}, MIMETypes: []string{"text/x-common-lisp"}, NewLexer: newLispLexer(commonLispForms)},
+		{Name: "scheme", Aliases: []string{"scm"}, MIMETypes: []string{"text/x-scheme"}, NewLexer: newLispLexer(schemeForms)},
+		{Name: "racket", Aliases: []string{"rkt"}, MIMETypes: []string{"text/x-racket"}, NewLexer: newLispLexer(racketForms)},
+		{Name: "clojure", Aliases: []string{"clj", "cljs", "clojurescript", "cljc"}, MIMETypes: []string{"text/x-clojure", "application/x-clojure"}, NewLexer: newClojureLexer(clojureForms)},
+		{Name: "edn", MIMETypes: []string{"application/edn"}, NewLexer: newClojureLexer(nil)},
+		{Name: "latex", Aliases: []string{"tex"}, MIMETypes: []string{"text/x-tex", "text/x-latex", "application/x-tex"}, NewLexer: newTeXLexer},
+		{Name: "bison", Aliases: []string{"yacc"}, MIMETypes: []string{"text/x-bison", "text/x-yacc"}, NewLexer: newGrammarLexer(bisonSyntax)},
+		{Name: "antlr", Aliases: []string{"antlr4", "g4"}, MIMETypes: []string{"text/x-antlr"}, NewLexer: newGrammarLexer(antlrSyntax)},
+		{Name: "ebnf", Aliases: []string{"bnf"}, MIMETypes: []string{"text/x-ebnf"}, NewLexer: newGrammarLexer(ebnfSyntax)},
+		{Name: "prolog", Aliases: []string{"swi-prolog", "swipl"}, MIMETypes: []string{"text/x-prolog"}, NewLexer: newPrologLexer},
+		{Name: "datalog", Aliases: []string{"souffle"}, MIMETypes: []string{"text/x-datalog"}, NewLexer: newDatalogLexer},
+		{Name: "smalltalk", Aliases: []string{"squeak", "pharo", "st"}, MIMETypes: []string{"text/x-stsrc"}, NewLexer: newSmalltalkLexer},
+		{Name: "gomod", Aliases: []string{"go.mod"}, NewLexer: newGoModLexer},
+		{Name: "gowork", Aliases: []string{"go.work"}, NewLexer: newGoModLexer},
+		{Name: "gosum", Aliases: []string{"go.sum"}, NewLexer: newGoSumLexer},
+		{Name: "dotenv", Aliases: []string{"env"}, NewLexer: newDotenvLexer},
+		{Name: "properties", Aliases: []string{"java-properties", "jproperties"}, MIMETypes: []string{"text/x-java-properties"}, NewLexer: newJavaPropertiesLexer},
+		{Name: "csv", MIMETypes: []string{"text/csv"}, NewLexer: newCSVLexer},
+		{Name: "tsv", MIMETypes: []string{"text/tab-separated-values"}, NewLexer: newTSVLexer},
+		{Name: "log", NewLexer: newLogLexer},
+		{Name: "json", MIMETypes: []string{"application/json", "text/json"}, NewLexer: newJSONLexer},
+		{Name: "jsonc", Aliases: []string{"json-with-comments"}, NewLexer: newJSONLexer},
+		{Name: "json5", MIMETypes: []string{"application/json5"}, NewLexer: newJSON5Lexer},
+		{Name: "openapi-json", Aliases: []string{"swagger-json"}, NewLexer: newOpenAPIJSONLexer},
+		{Name: "css", MIMETypes: []string{"text/css"}, NewLexer: newCSSLexer},
+		{Name: "http", MIMETypes: []string{"message/http"}, NewLexer: newHTTPLexer},
+		{Name: "email", Aliases: []string{"eml", "mime", "mbox"}, MIMETypes: []string{"message/rfc822"}, NewLexer: newEmailLexer},
+		{Name: "xml", Aliases: []string{"svg", "xsd", "xsl", "xslt"}, MIMETypes: []string{"application/xml", "text/xml", "image/svg+xml"}, NewLexer: newXMLLexer},
+		{Name: "html", Aliases: []string{"htm", "xhtml"}, MIMETypes: []string{"text/html", "application/xhtml+xml"}, NewLexer: newHTMLLexer},
+		{Name: "gotemplate", Aliases: []string{"go-template", "gotmpl"}, NewLexer: newTemplateLexer(goTemplateSyntax, "")},
+		{Name: "html+gotemplate", NewLexer: newTemplateLexer(goTemplateSyntax, "html")},
+		{Name: "yaml+gotemplate", Aliases: []string{"helm"}, NewLexer: newTemplateLexer(goTemplateSyntax, "yaml")},
+		{Name: "jinja", Aliases: []string{"jinja2", "django"}, NewLexer: newTemplateLexer(jinjaSyntax, "")},
+		{Name: "html+jinja", Aliases: []string{"html+django"}, NewLexer: newTemplateLexer(jinjaSyntax, "html")},
+		{Name: "yaml+jinja", Aliases: []string{"salt", "sls"}, NewLexer: newTemplateLexer(jinjaSyntax, "yaml")},
+		{Name: "ansible", Aliases: []string{"ansible-playbook"}, NewLexer: newAnsibleLexer},
+		{Name: "erb", NewLexer: newTemplateLexer(erbSyntax, "")},
+		{Name: "html+erb", Aliases: []string{"rhtml"}, NewLexer: newTemplateLexer(erbSyntax, "html")},
+		{Name: "systemd", Aliases: []string{"systemd-unit"}, NewLexer: newSystemdLexer},
+		{Name: "crontab", Aliases: []string{"cron"}, NewLexer: newCrontabLexer},
+		{Name: "ssh-config", Aliases: []string{"ssh_config", "sshd_config", "sshconfig", "sshdconfig"}, NewLexer: newSSHConfigLexer},
+		{Name: "gitignore", Aliases: []string{"dockerignore"}, NewLexer: newGitignoreLexer},
+		{Name: "gitconfig", Aliases: []string{"git-config"}, NewLexer: newGitConfigLexer},
+		{Name: "git-commit", Aliases: []string{"gitcommit", "commit-message"}, NewLexer: newGitCommitLexer},
+		{Name: "git-rebase-todo", Aliases: []string{"git-rebase", "rebase-todo"}, NewLexer: newGitRebaseTodoLexer},
+		{Name: "console", Aliases: []string{"shell-session", "shellsession"}, MIMETypes: []string{"text/x-shell-session"}, NewLexer: newConsoleLexer},
+	} {
+		RegisterLanguage(l)
+	}
+}
+
+// LanguageInfo describes a registered language (see Languages).
+type LanguageInfo struct {
+	Name    string
+	Aliases []string
+	// Extensions are the file name extensions mapped to the language in
+	// Extensions, in order.
+	Extensions []string
+	// Filenames are the file names mapped to the language in Filenames, in
+	// order.
+	Filenames []string
+}
+
+// Languages returns the registered languages, ordered by name.
+func Languages() []LanguageInfo {
+	exts := map[string][]string{}
+	for ext, name := range Extensions {
+		exts[name] = append(exts[name], ext)
+	}
+	files := map[string][]string{}
+	for file, name := range Filenames {
+		files[name] = append(files[name], file)
+	}
+	infos := make([]LanguageInfo, 0, len(languages))
+	for name, l := range languages {
+		sort.Strings(exts[name])
+		sort.Strings(files[name])
+		infos = append(infos, LanguageInfo{
+			Name:       name,
+			Aliases:    append([]string(nil), l.Aliases...),
+			Extensions: exts[name],
+			Filenames:  files[name],
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// LookupLanguage returns the registered language with the given name,
+// alias, media type or file name extension, such as "cpp", "c++",
+// "text/x-c++src" or ".cc". Names, aliases and media types are matched
+// without regard to case, and parameters of media types, such as
+// "; charset=utf-8", are ignored, so that Content-Type headers can be
+// passed as they are. It returns nil if no language matches.
+func LookupLanguage(nameOrAliasOrMIME string) *Language {
+	key := strings.ToLower(strings.TrimSpace(nameOrAliasOrMIME))
+	if strings.HasPrefix(key, ".") {
+		key = Extensions[key]
+	} else if i := strings.IndexByte(key, ';'); i >= 0 {
+		key = strings.TrimSpace(key[:i])
+	}
+	if l, ok := languages[key]; ok {
+		return l
+	}
+	for _, l := range languages {
+		for _, alias := range l.Aliases {
+			if strings.ToLower(alias) == key {
+				return l
+			}
+		}
+		for _, mime := range l.MIMETypes {
+			if strings.ToLower(mime) == key {
+				return l
+			}
+		}
+	}
+	return nil
+}
+
+// Extensions maps file name extensions, including the dot, to the names of
+// languages; names of languages that are not registered are ignored.
+// Entries may be added or changed to extend language detection (see
+// DetectLanguage).
+var Extensions = map[string]string{
+	".c":     "c",
+	".h":     "c",
+	".cc":    "cpp",
+	".cpp":   "cpp",
+	".cxx":   "cpp",
+	".hh":    "cpp",
+	".hpp":   "cpp",
+	".m":     "objective-c",
+	".mm":    "objective-c++",
+	".cu":    "cuda",
+	".cuh":   "cuda",
+	".glsl":  "glsl",
+	".vert":  "glsl",
+	".frag":  "glsl",
+	".geom":  "glsl",
+	".comp":  "glsl",
+	".tesc":  "glsl",
+	".tese":  "glsl",
+	".hlsl":  "hlsl",
+	".hlsli": "hlsl",
+	".fx":    "hlsl",
+	".fxh":   "hlsl",
+	".cs":    "csharp",
+	".go":    "go",
+	".java":  "java",
+	".js":    "javascript",
+	".mjs":   "javascript",
+	".cjs":   "javascript",
+	".ts":    "typescript",
+	".jsx":   "jsx",
+	".tsx":   "tsx",
+	".rs":    "rust",
+	".swift": "swift",
+	".kt":    "kotlin",
+	".kts":   "kotlin",
+	".scala": "scala",
+	".php":   "php",
+	".py":    "python",
+	".pyw":   "python",
+	".rb":    "ruby",
+	".pl":    "perl",
+	".pm":    "perl",
+	".cr":    "crystal",
+	".raku":  "raku",
+	".p6":    "raku",
+	".pm6":   "raku",
+	".sh":    "shell",
+	".bash":  "shell",
+	".zsh":   "shell",
+	".r":     "r",
+	".yaml":  "yaml",
+	".yml":   "yaml",
+	".toml":  "toml",
+	".mk":    "makefile",
+	".sql":   "sql",
+	".pgsql": "plpgsql",
+	".vb":    "vb",
+	".vba":   "vb",
+	".vbs":   "vb",
+	".bas":   "vb",
+	".frm":   "vb",
+	".pas":   "pascal",
+	".pp":    "pascal",
+	".dpr":   "pascal",
+	".lpr":   "pascal",
+	".adb":   "ada",
+	".ads":   "ada",
+	".ada":   "ada",
+	".lua":   "lua",
+	".hs":    "haskell",
+	".cmake": "cmake",
+	".bzl":   "starlark",
+	".star":  "starlark",
+	".lisp":  "common-lisp",
+	".lsp":   "common-lisp",
+	".cl":    "common-lisp",
+	".scm":   "scheme",
+	".ss":    "scheme",
+	".sld":   "scheme",
+	".rkt":   "racket",
+	".rktl":  "racket",
+	".clj":   "clojure",
+	".cljs":  "clojure",
+	".cljc":  "clojure",
+	".bb":    "clojure",
+	".edn":   "edn",
+	".st":    "smalltalk",
+	".y":     "bison",
+	".yy":    "bison",
+	".ypp":   "bison",
+	".g4":    "antlr",
+	".ebnf":  "ebnf",
+	".bnf":   "ebnf",
+	".pro":   "prolog",
+	".tex":   "latex",
+	".ltx":   "latex",
+	".sty":   "latex",
+	".cls":   "latex",
+	".dl":    "datalog",
+
+	// Groovy, including Gradle build scripts
+	".groovy": "groovy",
+	".gvy":    "groovy",
+	".gy":     "groovy",
+	".gsh":    "groovy",
+	".gradle": "groovy",
+
+	// Raku modules and tests
+	".rakumod":  "raku",
+	".rakutest": "raku",
+
+	// configuration files
+	".env":        "dotenv",
+	".properties": "properties",
+	".jsonc":      "jsonc",
+	".json5":      "json5",
+
+	// systemd units
+	".service":   "systemd",
+	".socket":    "systemd",
+	".timer":     "systemd",
+	".target":    "systemd",
+	".mount":     "systemd",
+	".automount": "systemd",
+	".path":      "systemd",
+	".slice":     "systemd",
+	".swap":      "systemd",
+	".network":   "systemd",
+	".netdev":    "systemd",
+	".cron":      "crontab",
+
+	// data
+	".csv":  "csv",
+	".tsv":  "tsv",
+	".log":  "log",
+	".json": "json",
+	".http": "http",
+	".eml":  "email",
+	".mbox": "email",
+
+	// markup
+	".xml":   "xml",
+	".xsd":   "xml",
+	".xsl":   "xml",
+	".xslt":  "xml",
+	".svg":   "xml",
+	".wsdl":  "xml",
+	".html":  "html",
+	".htm":   "html",
+	".xhtml": "html",
+	".css":   "css",
+
+	// templates
+	".tmpl":   "gotemplate",
+	".gotmpl": "gotemplate",
+	".j2":     "jinja",
+	".jinja":  "jinja",
+	".jinja2": "jinja",
+	".erb":    "erb",
+	".rhtml":  "html+erb",
+
+	// terminal sessions
+	".sh-session":    "console",
+	".shell-session": "console",
+}
+
+// Filenames maps file names that tell the language of a file better than
+// their extensions, or that have none, to the names of languages; names of
+// languages that are not registered are ignored. File names are matched
+// exactly, without their directories, before extensions are looked up.
+// Entries may be added or changed to extend language detection.
+var Filenames = map[string]string{
+	"Makefile":       "makefile",
+	"makefile":       "makefile",
+	"GNUmakefile":    "makefile",
+	"Dockerfile":     "dockerfile",
+	"Containerfile":  "dockerfile",
+	"CMakeLists.txt": "cmake",
+	"BUILD":          "starlark",
+	"BUILD.bazel":    "starlark",
+	"WORKSPACE":      "starlark",
+	"Tiltfile":       "starlark",
+	".bashrc":        "shell",
+	".bash_profile":  "shell",
+	".bash_logout":   "shell",
+	".profile":       "shell",
+	".zshrc":         "shell",
+	".zprofile":      "shell",
+	"PKGBUILD":       "shell",
+	"Gemfile":        "ruby",
+	"Rakefile":       "ruby",
+	"Vagrantfile":    "ruby",
+	"Jenkinsfile":    "groovy",
+	"go.mod":         "gomod",
+	"go.work":        "gowork",
+	"go.sum":         "gosum",
+	"tsconfig.json":  "jsonc",
+	"jsconfig.json":  "jsonc",
+	".eslintrc.json": "jsonc",
+	".babelrc":       "jsonc",
+	"crontab":        "crontab",
+	"ssh_config":     "ssh-config",
+	"sshd_config":    "ssh-config",
+	".gitignore":     "gitignore",
+	".dockerignore":  "gitignore",
+	".gitconfig":     "gitconfig",
+	".gitmodules":    "gitconfig",
+	"COMMIT_EDITMSG": "git-commit",
+	"MERGE_MSG":      "git-commit",
+	"TAG_EDITMSG":    "git-commit",
+
+	".env.local":       "dotenv",
+	".env.example":     "dotenv",
+	".env.test":        "dotenv",
+	".env.development": "dotenv",
+	".env.production":  "dotenv",
+	"git-rebase-todo":  "git-rebase-todo",
+}
+
+// Interpreters maps the names of interpreters in #! lines and of modes in
+// editor mode lines (see Scanner.Interpreter) to the names of languages.
+// Version numbers are ignored, so "python3" and "python3.12" both match
+// "python". Entries may be added or changed to extend language detection.
+var Interpreters = map[string]string{
+	"python":  "python",
+	"ruby":    "ruby",
+	"crystal": "crystal",
+	"raku":    "raku",
+	"perl6":   "raku",
+	"perl":    "perl",
+	"sh":      "shell",
+	"bash":    "shell",
+	"zsh":     "shell",
+	"ksh":     "shell",
+	"dash":    "shell",
+	"node":    "javascript",
+	"nodejs":  "javascript",
+	"deno":    "typescript",
+	"php":     "php",
+	"lua":     "lua",
+	"rscript": "r",
+	"c++":     "cpp",
+	"groovy":  "groovy",
+	"make":    "makefile",
+	"sbcl":    "common-lisp",
+	"clisp":   "common-lisp",
+	"guile":   "scheme",
+	"chez":    "scheme",
+	"csi":     "scheme",
+	"clojure": "clojure",
+	"clj":     "clojure",
+	"bb":      "clojure",
+	"swipl":   "prolog",
+	"gprolog": "prolog",
+	"souffle": "datalog",
+}
+
+// DetectLanguage returns the name of the registered language of the file
+// with the given name and contents, or "" if it is not known. The name is
+// looked up in Filenames and its extension in Extensions first, with the
+// languages that share an extension, such as C, C++ and Objective-C for
+// ".h", told apart by patterns typical of each in src; if that fails, the
+// interpreter in the #! line or the mode in an editor mode line of src is
+// looked up in Interpreters and among the registered languages. Built with
+// the enry build tag, GitHub Linguist is consulted when it has a single
+// registered candidate, to tell apart the languages of a shared extension
+// or to name that of a file not known otherwise (see linguistLanguage).
+func DetectLanguage(filename string, src []byte) string {
+	lang := sourceFileLanguage(filename, src)
+	if l := linguistLanguage(filename, src, lang); l != "" {
+		return l
+	}
+	if lang != "" {
+		return lang
+	}
+
+	s := NewScanner(src)
+	for s.line < maxModeLine && s.Scan() {
+		if interp := s.Interpreter(); interp != "" {
+			return interpreterLanguage(interp)
+		}
+	}
+	return ""
+}
+
+// fileLanguage returns the name of the registered language of the file with
+// the given name according to Filenames and Extensions, or "" if it is not
+// known.
+func fileLanguage(filename string) string {
+	if lang, ok := Filenames[filepath.Base(filename)]; ok && languages[lang] != nil {
+		return lang
+	}
+	if lang, ok := Extensions[strings.ToLower(filepath.Ext(filename))]; ok && languages[lang] != nil {
+		return lang
+	}
+	return ""
+}
+
+// interpreterLanguage returns the name of the language run by interpreter
+// interp, or "" if it is not known.
+func interpreterLanguage(interp string) string {
+	interp = strings.ToLower(interp)
+	for _, name := range []string{interp, strings.TrimRight(interp, "0123456789.")} {
+		if lang, ok := Interpreters[name]; ok && languages[lang] != nil {
+			return lang
+		}
+		if _, ok := languages[name]; ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// wordSet returns the set of words, or nil if there are none. The words
+// are in lower case if l is CaseInsensitive.
+func (l *Language) wordSet(words []string) map[string]bool {
+	if words == nil {
+		return nil
+	}
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		if l.CaseInsensitive {
+			w = strings.ToLower(w)
+		}
+		set[w] = true
+	}
+	return set
+}
+
+// isKeyword reports whether word is one of the Keywords of l.
+func (l *Language) isKeyword(word []byte) bool {
+	return l.isWord(word, l.keywords, l.Keywords)
+}
+
+// isType reports whether word is one of the Types of l.
+func (l *Language) isType(word []byte) bool {
+	return l.isWord(word, l.types, l.Types)
+}
+
+// isWord reports whether word is in set, or among words if l is not
+// registered and so has no sets.
+func (l *Language) isWord(word []byte, set map[string]bool, words []string) bool {
+	if set != nil {
+		if l.CaseInsensitive {
+			word = bytes.ToLower(word)
+		}
+		return set[string(word)]
+	}
+	for _, w := range words {
+		if string(word) == w || l.CaseInsensitive && strings.EqualFold(string(word), w) {
+			return true
+		}
+	}
+	return false
+}
+
+// stringPrefix returns the length of the string prefix (see StringPrefixes)
+// at the start of data, or 0 if there is none. ok is false if more data is
+// needed.
+func (l *Language) stringPrefix(data []byte, atEOF bool) (n int, ok bool) {
+	for _, prefix := range l.StringPrefixes {
+		if len(data) <= len(prefix) && !atEOF && hasPrefix([]byte(prefix), string(data)) {
+			return 0, false
+		}
+		if len(data) > len(prefix) && hasPrefix(data, prefix) && (data[len(prefix)] == '"' || data[len(prefix)] == '\'') {
+			return len(prefix), true
+		}
+	}
+	return 0, true
+}
+
+// lineContinuation returns the length of the line continuation (see
+// LineContinuation) at the start of data, or 0 if there is none. ok is
+// false if more data is needed.
+func (l *Language) lineContinuation(data []byte, atEOF bool) (n int, ok bool) {
+	lc := l.LineContinuation
+	if len(data) < len(lc) && !atEOF && hasPrefix([]byte(lc), string(data)) {
+		return 0, false
+	}
+	if !hasPrefix(data, lc) {
+		return 0, true
+	}
+	end := bytes.IndexByte(data[len(lc):], '\n')
+	if end < 0 {
+		if !atEOF {
+			return 0, false
+		}
+		end = len(data) - len(lc)
+	}
+	if rest := bytes.TrimRight(data[len(lc):len(lc)+end], "\r"); blanks(rest) < len(rest) {
+		return 0, true
+	}
+	return len(lc), true
+}
+
+// lineComment returns the length of the line comment that starts at data[0]
+// according to the rules of l, or 0 if there is none, and its kind, Comment
+// or DocComment. ok is false if more data is needed.
+func (l *Language) lineComment(data []byte, atEOF bool) (n int, kind Kind, ok bool) {
+	for _, prefixes := range [...]struct {
+		list []string
+		kind Kind
+	}{{l.DocComments, DocComment}, {l.LineComments, Comment}} {
+		for _, prefix := range prefixes.list {
+			if len(data) < len(prefix) && !atEOF && hasPrefix([]byte(prefix), string(data)) {
+				return 0, 0, false
+			}
+			if hasPrefix(data, prefix) {
+				n := scanLine(data, atEOF)
+				return n, prefixes.kind, n > 0
+			}
+		}
+	}
+	return 0, 0, true
+}
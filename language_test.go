@@ -0,0 +1,157 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		filename, src string
+		want          string
+	}{
+		{"main.go", "package main", "go"},
+		{"dir/Script.PY", "", "python"},
+		{"run", "#!/usr/bin/env python3.12\n", "python"},
+		{"run", "#!/bin/bash\n", "shell"},
+		{"run", "\n\n# vim: ft=ruby\n", "ruby"},
+		{"run", "#!/usr/bin/env lua5.4", "lua"},
+		{"x.rb", "#!/bin/sh\n", "ruby"},
+		{"README", "hello", ""},
+		{"run", "#!/usr/bin/frobnicate\n", ""},
+		{"src/Makefile", "all:\n", "makefile"},
+		{"Dockerfile", "FROM scratch\n", "dockerfile"},
+		{"CMakeLists.txt", "project(x)\n", "cmake"},
+		{"BUILD", "", "starlark"},
+		{"/home/u/.bashrc", "", "shell"},
+		{"BUILD.txt", "", ""},
+		{"x.h", "#include <stdio.h>\nint f(void);\n", "c"},
+		{"x.h", "#import <UIKit/UIKit.h>\n@interface V : UIView\n@end\n", "objective-c"},
+		{"x.h", "namespace x {\nstd::string f();\n}\n", "cpp"},
+		{"x.h", "", "c"},
+		{"x.m", "", "objective-c"},
+		{"x.mm", "", "objective-c++"},
+		{"x.m", "% plot it\nfunction y = f(x)\n  y = zeros(1, x);\nend\n", "matlab"},
+		{"x.m", "#import \"x.h\"\n@implementation X\n@end\n", "objective-c"},
+		{"app/build.gradle", "", "groovy"},
+		{"Jenkinsfile", "", "groovy"},
+		{"x.pl", "use strict;\nmy $x = 1;\n", "perl"},
+		{"x.pl", ":- use_module(library(lists)).\n\nmain :-\n    findall(X, member(X, [1]), L),\n    writeln(L).\n", "prolog"},
+		{"rules.dl", "", "datalog"},
+		{"x.cl", "(in-package :x)\n(defun f () 1)\n", "common-lisp"},
+		{"x.cl", "__kernel void add(__global const float *a) {\n  int i = get_global_id(0);\n}\n", "opencl"},
+		{"x.cu", "", "cuda"},
+		{"shader.frag", "", "glsl"},
+	}
+	for _, test := range tests {
+		if got := DetectLanguage(test.filename, []byte(test.src)); got != test.want {
+			t.Errorf("%q, %q: want %q, got %q", test.filename, test.src, test.want, got)
+		}
+	}
+}
+
+func TestLookupLanguage(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"cpp", "cpp"},
+		{"C++", "cpp"},
+		{"text/x-c++src", "cpp"},
+		{".cc", "cpp"},
+		{".CPP", "cpp"},
+		{"text/x-python; charset=utf-8", "python"},
+		{" Golang ", "go"},
+		{"application/octet-stream", ""},
+		{".unknown", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		var got string
+		if l := LookupLanguage(test.key); l != nil {
+			got = l.Name
+		}
+		if got != test.want {
+			t.Errorf("%q: want %q, got %q", test.key, test.want, got)
+		}
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	infos := Languages()
+	if len(infos) != len(languages) {
+		t.Errorf("want %d languages, got %d", len(languages), len(infos))
+	}
+	for i, info := range infos {
+		if i > 0 && infos[i-1].Name >= info.Name {
+			t.Errorf("languages not in order: %q before %q", infos[i-1].Name, info.Name)
+		}
+		if info.Name == "cpp" {
+			want := LanguageInfo{
+				Name:       "cpp",
+				Aliases:    []string{"c++", "cxx"},
+				Extensions: []string{".cc", ".cpp", ".cxx", ".hh", ".hpp"},
+			}
+			if !reflect.DeepEqual(info, want) {
+				t.Errorf("want %+v, got %+v", want, info)
+			}
+		}
+		if info.Name == "makefile" {
+			want := LanguageInfo{
+				Name:       "makefile",
+				Aliases:    []string{"make", "mk"},
+				Extensions: []string{".mk"},
+				Filenames:  []string{"GNUmakefile", "Makefile", "makefile"},
+			}
+			if !reflect.DeepEqual(info, want) {
+				t.Errorf("want %+v, got %+v", want, info)
+			}
+		}
+	}
+}
+
+func TestScannerLanguage(t *testing.T) {
+	tests := []struct {
+		lang string
+		src  string
+		want []token
+	}{
+		{"python", "x // 2 # half\n", []token{
+			{Plaintext, "x"}, {Whitespace, " "}, {Punctuation, "/"}, {Punctuation, "/"}, {Whitespace, " "},
+			{Decimal, "2"}, {Whitespace, " "}, {Comment, "# half"}, {Whitespace, "\n"},
+		}},
+		{"sql", "a - 1 -- b", []token{
+			{Plaintext, "a"}, {Whitespace, " "}, {Punctuation, "-"}, {Whitespace, " "}, {Decimal, "1"},
+			{Whitespace, " "}, {Comment, "-- b"},
+		}},
+		{"php", "// a\n# b", []token{{Comment, "// a"}, {Whitespace, "\n"}, {Comment, "# b"}}},
+		{"go", "/// a", []token{{DocComment, "/// a"}}},
+	}
+	for _, test := range tests {
+		for _, oneByte := range []bool{false, true} {
+			s := NewScanner([]byte(test.src))
+			if oneByte {
+				s = NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(test.src))))
+			}
+			s.UseLanguage(languages[test.lang])
+			if got := scanAll(t, s); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%s: %q: want %v, got %v", test.lang, test.src, test.want, got)
+			}
+		}
+	}
+}
+
+func TestScannerUnregisteredLanguage(t *testing.T) {
+	l := &Language{Name: "x", Keywords: []string{"Sub"}, Types: []string{"Integer"}, CaseInsensitive: true}
+	s := NewScanner([]byte("SUB f integer Foo"))
+	s.UseLanguage(l)
+	want := []token{
+		{Keyword, "SUB"}, {Whitespace, " "}, {Plaintext, "f"}, {Whitespace, " "}, {Type, "integer"}, {Whitespace, " "},
+		{Plaintext, "Foo"},
+	}
+	if got := scanAll(t, s); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,118 @@
+package syntaxhighlight
+
+import "unicode/utf8"
+
+// Lexer scans the tokens of a language with rules of its own (see
+// Language.NewLexer).
+type Lexer interface {
+	// Lex returns the length and kind of the token at the start of data,
+	// which is never empty. If atEOF is set, data holds the rest of the
+	// source; otherwise, Lex may return a length of 0 to be called again
+	// with more data. Tokens are returned as they are scanned, so a Lexer
+	// that keeps state updates it only when it returns a token.
+	Lex(data []byte, atEOF bool) (n int, kind Kind)
+}
+
+// lexToken is the length and kind of a token.
+type lexToken struct {
+	n    int
+	kind Kind
+}
+
+// lineLexer is a Lexer for languages that are lexed a line at a time by
+// lexLine. Newlines are returned as Whitespace tokens of their own.
+type lineLexer struct {
+	// lexLine returns the tokens of line, which does not include its
+	// newline. Their lengths must add up to that of line, and none may be
+	// empty.
+	lexLine func(line []byte) []lexToken
+
+	queue []lexToken // remaining tokens of the current line
+}
+
+func (l *lineLexer) Lex(data []byte, atEOF bool) (int, Kind) {
+	if len(l.queue) == 0 {
+		if data[0] == '\n' {
+			return 1, Whitespace
+		}
+		n := scanLine(data, atEOF)
+		if n == 0 {
+			return 0, 0
+		}
+		l.queue = l.lexLine(data[:n])
+	}
+	tok := l.queue[0]
+	l.queue = l.queue[1:]
+	return tok.n, tok.kind
+}
+
+// lineTokens collects the tokens of a line for a lineLexer, merging
+// adjacent ones of the same kind.
+type lineTokens []lexToken
+
+// add adds a token of length n and the given kind; empty ones are dropped.
+func (t *lineTokens) add(n int, kind Kind) {
+	if n == 0 {
+		return
+	}
+	if last := len(*t) - 1; last >= 0 && (*t)[last].kind == kind {
+		(*t)[last].n += n
+		return
+	}
+	*t = append(*t, lexToken{n, kind})
+}
+
+// isBlank reports whether b is a space, a tab or a carriage return.
+func isBlank(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\r'
+}
+
+// blanks returns the length of the run of blanks at the start of b.
+func blanks(b []byte) int {
+	i := 0
+	for i < len(b) && isBlank(b[i]) {
+		i++
+	}
+	return i
+}
+
+// languageLexer is a Lexer that scans with the rules of a language, for
+// lexers that hand parts of their source over to other languages.
+type languageLexer struct {
+	s *Scanner
+}
+
+// newLanguageLexer returns a Lexer for source in language l, or for plain
+// text if l is nil.
+func newLanguageLexer(l *Language) Lexer {
+	return &languageLexer{&Scanner{lang: l, plainText: l == nil}}
+}
+
+func (l *languageLexer) Lex(data []byte, atEOF bool) (int, Kind) {
+	n, _, _ := l.s.split(data, atEOF)
+	if n == 0 {
+		return 0, 0
+	}
+	return n, l.s.kind
+}
+
+// addLanguageTokens adds the tokens of src, scanned on its own as source in
+// the named language, to toks.
+func addLanguageTokens(toks *lineTokens, src []byte, lang string) {
+	addLexerTokens(toks, src, newLanguageLexer(LookupLanguage(lang)))
+}
+
+// addLexerTokens adds the tokens of src, as lexed by l at the end of the
+// source, to toks. A rune that l returns no token for is Plaintext, so
+// that a Lexer that fails to make progress cannot stall it.
+func addLexerTokens(toks *lineTokens, src []byte, l Lexer) {
+	for len(src) > 0 {
+		n, kind := l.Lex(src, true)
+		if n == 0 {
+			_, n = utf8.DecodeRune(src)
+			kind = Plaintext
+		}
+		toks.add(n, kind)
+		src = src[n:]
+	}
+}
@@ -0,0 +1,26 @@
+package syntaxhighlight
+
+import (
+	"reflect"
+	"testing"
+)
+
+// stuckLexer returns tokens of one byte, except for non-ASCII runes, for
+// which it returns none.
+type stuckLexer struct{}
+
+func (stuckLexer) Lex(data []byte, atEOF bool) (int, Kind) {
+	if data[0] >= 0x80 {
+		return 0, 0
+	}
+	return 1, Keyword
+}
+
+func TestAddLexerTokens(t *testing.T) {
+	var toks lineTokens
+	addLexerTokens(&toks, []byte("abéc"), stuckLexer{})
+	want := lineTokens{{2, Keyword}, {2, Plaintext}, {1, Keyword}}
+	if !reflect.DeepEqual(toks, want) {
+		t.Errorf("want %v, got %v", want, toks)
+	}
+}
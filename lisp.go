@@ -0,0 +1,286 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// lispNumberRE matches the numbers of Lisp dialects: integers, ratios,
+// decimals with exponents and, after "#", numbers in other radixes.
+var lispNumberRE = regexp.MustCompile(`^([+-]?(\d+(/\d+)?|\d*\.\d+|\d+\.\d*)([eEdDfFsSlL][+-]?\d+)?|#[xXbBoO][+-]?[0-9a-fA-F]+(/[0-9a-fA-F]+)?)$`)
+
+// commonLispForms are the special operators and standard macros of Common
+// Lisp.
+var commonLispForms = setOf(
+	"and", "block", "case", "catch", "cond", "declare", "defclass", "defconstant", "defgeneric",
+	"define-condition", "defmacro", "defmethod", "defpackage", "defparameter", "defsetf", "defstruct",
+	"deftype", "defun", "defvar", "destructuring-bind", "do", "do*", "dolist", "dotimes", "ecase",
+	"etypecase", "eval-when", "flet", "function", "go", "handler-bind", "handler-case", "if",
+	"ignore-errors", "in-package", "labels", "lambda", "let", "let*", "loop", "macrolet",
+	"multiple-value-bind", "or", "prog1", "prog2", "progn", "quote", "return", "return-from",
+	"setf", "setq", "tagbody", "the", "throw", "typecase", "unless", "unwind-protect", "when",
+	"with-open-file", "with-slots",
+)
+
+// schemeForms are the syntactic keywords of Scheme.
+var schemeForms = setOf(
+	"and", "begin", "case", "case-lambda", "cond", "define", "define-record-type", "define-syntax",
+	"define-values", "delay", "do", "else", "guard", "if", "import", "lambda", "let", "let*",
+	"let-syntax", "let-values", "letrec", "letrec*", "library", "or", "parameterize", "quasiquote",
+	"quote", "set!", "syntax-rules", "unless", "unquote", "unquote-splicing", "when",
+)
+
+// racketForms are the syntactic forms of Racket, which extend those of
+// Scheme.
+var racketForms = func() map[string]bool {
+	forms := setOf(
+		"define/contract", "for", "for*", "for/fold", "for/list", "for/vector", "for/hash", "match",
+		"match-define", "module", "module+", "provide", "require", "struct", "with-handlers",
+	)
+	for form := range schemeForms {
+		forms[form] = true
+	}
+	return forms
+}()
+
+// clojureNumberRE matches the numbers of Clojure and EDN, which may have a
+// suffix for arbitrary precision, as in 42N and 1.5M, or a radix, as in
+// 2r1010.
+var clojureNumberRE = regexp.MustCompile(`^[+-]?(\d+(/\d+|N)?|0[xX][0-9a-fA-F]+N?|\d+[rR][0-9a-zA-Z]+|\d+(\.\d*)?([eE][+-]?\d+)?M?)$`)
+
+// clojureForms are the special forms and core macros of Clojure.
+var clojureForms = setOf(
+	"and", "binding", "case", "catch", "comment", "cond", "cond->", "cond->>", "condp", "def", "defmacro",
+	"defmethod", "defmulti", "defn", "defn-", "defonce", "defprotocol", "defrecord", "defstruct", "deftype",
+	"do", "doseq", "dotimes", "doto", "extend-protocol", "extend-type", "finally", "fn", "for", "if",
+	"if-let", "if-not", "if-some", "import", "let", "letfn", "loop", "new", "ns", "or", "quote", "recur",
+	"reify", "require", "set!", "some->", "some->>", "throw", "try", "var", "when", "when-first",
+	"when-let", "when-not", "when-some", "while", "with-open", "->", "->>",
+)
+
+// lispLexer is a Lexer for the dialects of Lisp.
+type lispLexer struct {
+	forms   map[string]bool // special forms and macros of the dialect
+	clojure bool            // the source is Clojure or EDN
+	quoted  bool            // the next atom is quoted
+	meta    bool            // the next atom is metadata, after "^"
+}
+
+// newLispLexer returns a function making Lexers for a dialect of Lisp with
+// the given special forms and macros, which are highlighted as Keyword.
+// Comments, including nested "#|...|#" block comments, are Comment, quote
+// marks such as "'" and "#'" Punctuation, and the symbols they quote
+// Literal, as are keywords such as ":key", characters such as "#\a" and
+// booleans such as "#t". Numbers are Decimal and global variables with
+// earmuffs, such as *print-base*, Variable.
+func newLispLexer(forms map[string]bool) func() Lexer {
+	return func() Lexer {
+		return &lispLexer{forms: forms}
+	}
+}
+
+// newClojureLexer returns a function making Lexers for Clojure, or for EDN
+// if forms is nil, which are lexed as Lisp with the reader syntax of
+// Clojure: commas are Whitespace, reader macros such as "#{", "#_" and "@"
+// Punctuation, except that "#_" discards the next form and so is Comment,
+// regular expressions such as #"\d+" String and characters such as \a
+// Literal. Type hints after "^", as in ^String, are Type, and other
+// metadata, as in ^:private, Literal.
+func newClojureLexer(forms map[string]bool) func() Lexer {
+	return func() Lexer {
+		return &lispLexer{forms: forms, clojure: true}
+	}
+}
+
+func (l *lispLexer) Lex(data []byte, atEOF bool) (int, Kind) {
+	if len(data) < 2 && !atEOF && (data[0] == '#' || data[0] == ',' || data[0] == '~' || data[0] == '\\') {
+		return 0, 0
+	}
+	if l.clojure {
+		if n, kind, ok := l.lexClojure(data, atEOF); ok {
+			return n, kind
+		}
+	}
+	switch c := data[0]; {
+	case isBlank(c) || c == '\n':
+		n := markupSpace(data)
+		if n == len(data) && !atEOF {
+			return 0, 0
+		}
+		return n, Whitespace
+	case c == ';':
+		return scanLine(data, atEOF), Comment
+	case hasPrefix(data, "#|") && !l.clojure:
+		return lispBlockComment(data, atEOF), Comment
+	case hasPrefix(data, "#;"):
+		return 2, Comment
+	case c == '"':
+		return lispString(data, atEOF), String
+	case bytes.IndexByte([]byte("()[]{}"), c) >= 0:
+		l.quoted, l.meta = false, false
+		return 1, Punctuation
+	case c == '\'' || c == '`':
+		l.quoted = true
+		return 1, Punctuation
+	case hasPrefix(data, ",@"):
+		return 2, Punctuation
+	case c == ',':
+		return 1, Punctuation
+	case hasPrefix(data, "#'"):
+		l.quoted = true
+		return 2, Punctuation
+	case hasPrefix(data, "#(") || hasPrefix(data, "#["):
+		return 2, Punctuation
+	case hasPrefix(data, "#\\"):
+		// a character, such as #\a, #\( or #\space
+		n := 3
+		for n < len(data) && !isLispDelimiter(data[n]) {
+			n++
+		}
+		if n >= len(data) && !atEOF {
+			return 0, 0
+		}
+		if n > len(data) {
+			n = len(data)
+		}
+		return n, Literal
+	}
+
+	n := 0
+	for n < len(data) && !isLispDelimiter(data[n]) {
+		n++
+	}
+	if n == len(data) && !atEOF {
+		return 0, 0
+	}
+	if n == 0 {
+		return 1, Punctuation
+	}
+	quoted, meta := l.quoted, l.meta
+	l.quoted, l.meta = false, false
+	atom := data[:n]
+	switch {
+	case lispNumberRE.Match(atom) || l.clojure && clojureNumberRE.Match(atom):
+		return n, Decimal
+	case atom[0] == ':' || hasPrefix(atom, "#:"):
+		return n, Literal
+	case atom[0] == '#':
+		// booleans such as #t and reader directives such as #lang
+		if string(atom) == "#lang" {
+			return n, Keyword
+		}
+		return n, Literal
+	case l.clojure && (string(atom) == "true" || string(atom) == "false"):
+		return n, Literal
+	case meta:
+		return n, Type
+	case quoted || string(atom) == "t" && !l.clojure || string(atom) == "nil":
+		return n, Literal
+	case l.forms[string(bytes.ToLower(atom))] || atom[0] == '&':
+		return n, Keyword
+	case n > 2 && atom[0] == '*' && atom[n-1] == '*':
+		return n, Variable
+	}
+	return n, Plaintext
+}
+
+// lexClojure lexes the reader syntax that Clojure does not share with other
+// dialects of Lisp. It returns false if there is none at the start of data.
+func (l *lispLexer) lexClojure(data []byte, atEOF bool) (int, Kind, bool) {
+	switch c := data[0]; {
+	case c == ',' || isBlank(c) || c == '\n':
+		n := 0
+		for n < len(data) && (data[n] == ',' || isBlank(data[n]) || data[n] == '\n') {
+			n++
+		}
+		if n == len(data) && !atEOF {
+			return 0, 0, true
+		}
+		return n, Whitespace, true
+	case hasPrefix(data, "#_"):
+		return 2, Comment, true
+	case hasPrefix(data, "#\""):
+		n := lispString(data[1:], atEOF)
+		if n == 0 {
+			return 0, 0, true
+		}
+		return 1 + n, String, true
+	case hasPrefix(data, "#{") || hasPrefix(data, "#?@") || hasPrefix(data, "#?"):
+		if hasPrefix(data, "#?") && len(data) < 3 && !atEOF {
+			return 0, 0, true
+		}
+		n := 2
+		if hasPrefix(data, "#?@") {
+			n = 3
+		}
+		return n, Punctuation, true
+	case hasPrefix(data, "~@"):
+		return 2, Punctuation, true
+	case c == '~' || c == '@':
+		return 1, Punctuation, true
+	case c == '^':
+		l.meta = true
+		return 1, Punctuation, true
+	case c == '\\':
+		// a character, such as \a, \( or \newline
+		n := 2
+		for n < len(data) && !isLispDelimiter(data[n]) {
+			n++
+		}
+		if n >= len(data) && !atEOF {
+			return 0, 0, true
+		}
+		if n > len(data) {
+			n = len(data)
+		}
+		return n, Literal, true
+	}
+	return 0, 0, false
+}
+
+// lispBlockComment returns the length of the block comment at the start of
+// data, in which block comments may be nested, or 0 if more data is needed.
+// An unterminated comment runs to the end of the source.
+func lispBlockComment(data []byte, atEOF bool) int {
+	depth := 0
+	for i := 0; i+1 < len(data); i++ {
+		switch {
+		case data[i] == '#' && data[i+1] == '|':
+			depth++
+			i++
+		case data[i] == '|' && data[i+1] == '#':
+			depth--
+			i++
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	if !atEOF {
+		return 0
+	}
+	return len(data)
+}
+
+// lispString returns the length of the string at the start of data, which
+// may span lines, or 0 if more data is needed. An unterminated string runs
+// to the end of the source.
+func lispString(data []byte, atEOF bool) int {
+	for i := 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	if !atEOF {
+		return 0
+	}
+	return len(data)
+}
+
+// isLispDelimiter reports whether b ends an atom.
+func isLispDelimiter(b byte) bool {
+	return isBlank(b) || bytes.IndexByte([]byte("\n()[]{}\"';`,"), b) >= 0
+}
@@ -0,0 +1,72 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"testing/iotest"
+)
+
+func TestLispLexer(t *testing.T) {
+	tests := []struct {
+		lang, src string
+		want      []token
+	}{
+		{"common-lisp", "(defun f (x &optional (y 2)) ; doc\n  (list :key 'sym #'car *base* #\\a t 1/2))", []token{
+			{Punctuation, "("}, {Keyword, "defun"}, {Whitespace, " "}, {Plaintext, "f"}, {Whitespace, " "},
+			{Punctuation, "("}, {Plaintext, "x"}, {Whitespace, " "}, {Keyword, "&optional"}, {Whitespace, " "},
+			{Punctuation, "("}, {Plaintext, "y"}, {Whitespace, " "}, {Decimal, "2"}, {Punctuation, ")"}, {Punctuation, ")"},
+			{Whitespace, " "}, {Comment, "; doc"}, {Whitespace, "\n  "},
+			{Punctuation, "("}, {Plaintext, "list"}, {Whitespace, " "}, {Literal, ":key"}, {Whitespace, " "},
+			{Punctuation, "'"}, {Literal, "sym"}, {Whitespace, " "}, {Punctuation, "#'"}, {Literal, "car"}, {Whitespace, " "},
+			{Variable, "*base*"}, {Whitespace, " "}, {Literal, `#\a`}, {Whitespace, " "}, {Literal, "t"}, {Whitespace, " "},
+			{Decimal, "1/2"}, {Punctuation, ")"}, {Punctuation, ")"},
+		}},
+		{"common-lisp", "#| outer #| inner |# still |# `(a ,b ,@c)", []token{
+			{Comment, "#| outer #| inner |# still |#"}, {Whitespace, " "},
+			{Punctuation, "`"}, {Punctuation, "("}, {Plaintext, "a"}, {Whitespace, " "}, {Punctuation, ","}, {Plaintext, "b"},
+			{Whitespace, " "}, {Punctuation, ",@"}, {Plaintext, "c"}, {Punctuation, ")"},
+		}},
+		{"scheme", "(define (f) \"s\\\"\" #t #x1F)", []token{
+			{Punctuation, "("}, {Keyword, "define"}, {Whitespace, " "}, {Punctuation, "("}, {Plaintext, "f"}, {Punctuation, ")"},
+			{Whitespace, " "}, {String, `"s\""`}, {Whitespace, " "}, {Literal, "#t"}, {Whitespace, " "}, {Decimal, "#x1F"}, {Punctuation, ")"},
+		}},
+		{"racket", "#lang racket\n(for/list ([i 3]) #;(skip) i)", []token{
+			{Keyword, "#lang"}, {Whitespace, " "}, {Plaintext, "racket"}, {Whitespace, "\n"},
+			{Punctuation, "("}, {Keyword, "for/list"}, {Whitespace, " "}, {Punctuation, "("}, {Punctuation, "["}, {Plaintext, "i"},
+			{Whitespace, " "}, {Decimal, "3"}, {Punctuation, "]"}, {Punctuation, ")"}, {Whitespace, " "},
+			{Comment, "#;"}, {Punctuation, "("}, {Plaintext, "skip"}, {Punctuation, ")"}, {Whitespace, " "}, {Plaintext, "i"}, {Punctuation, ")"},
+		}},
+		{"clojure", "(defn- ^String f [x, y] #_(ignored) @a `(~x ~@y) #{:a ::b} #\"\\d+\" \\a 42N nil)", []token{
+			{Punctuation, "("}, {Keyword, "defn-"}, {Whitespace, " "}, {Punctuation, "^"}, {Type, "String"}, {Whitespace, " "},
+			{Plaintext, "f"}, {Whitespace, " "}, {Punctuation, "["}, {Plaintext, "x"}, {Whitespace, ", "}, {Plaintext, "y"}, {Punctuation, "]"},
+			{Whitespace, " "}, {Comment, "#_"}, {Punctuation, "("}, {Plaintext, "ignored"}, {Punctuation, ")"}, {Whitespace, " "},
+			{Punctuation, "@"}, {Plaintext, "a"}, {Whitespace, " "}, {Punctuation, "`"}, {Punctuation, "("}, {Punctuation, "~"}, {Plaintext, "x"},
+			{Whitespace, " "}, {Punctuation, "~@"}, {Plaintext, "y"}, {Punctuation, ")"}, {Whitespace, " "},
+			{Punctuation, "#{"}, {Literal, ":a"}, {Whitespace, " "}, {Literal, "::b"}, {Punctuation, "}"}, {Whitespace, " "},
+			{String, `#"\d+"`}, {Whitespace, " "}, {Literal, `\a`}, {Whitespace, " "}, {Decimal, "42N"}, {Whitespace, " "}, {Literal, "nil"}, {Punctuation, ")"},
+		}},
+		{"clojure", "(def ^:private t true)", []token{
+			{Punctuation, "("}, {Keyword, "def"}, {Whitespace, " "}, {Punctuation, "^"}, {Literal, ":private"}, {Whitespace, " "},
+			{Plaintext, "t"}, {Whitespace, " "}, {Literal, "true"}, {Punctuation, ")"},
+		}},
+		{"edn", "{:id 1, :tags #{\"a\"} :at #inst \"2020-01-01\" :ratio 1.5M}", []token{
+			{Punctuation, "{"}, {Literal, ":id"}, {Whitespace, " "}, {Decimal, "1"}, {Whitespace, ", "},
+			{Literal, ":tags"}, {Whitespace, " "}, {Punctuation, "#{"}, {String, `"a"`}, {Punctuation, "}"}, {Whitespace, " "},
+			{Literal, ":at"}, {Whitespace, " "}, {Literal, "#inst"}, {Whitespace, " "}, {String, `"2020-01-01"`}, {Whitespace, " "},
+			{Literal, ":ratio"}, {Whitespace, " "}, {Decimal, "1.5M"}, {Punctuation, "}"},
+		}},
+	}
+	for _, test := range tests {
+		for _, oneByte := range []bool{false, true} {
+			s := NewScanner([]byte(test.src))
+			if oneByte {
+				s = NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(test.src))))
+			}
+			s.UseLanguage(LookupLanguage(test.lang))
+			if got := scanAll(t, s); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%s %q:\nwant %v\ngot  %v", test.lang, test.src, test.want, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"net"
+	"regexp"
+)
+
+// logTimestampRE matches the timestamps of common log formats: ISO 8601
+// dates and times, Go's log package dates, bare times, syslog dates and
+// Apache's common log format dates.
+var logTimestampRE = regexp.MustCompile(`^(?:` +
+	`\d{4}-\d{2}-\d{2}(?:[T ]\d{2}:\d{2}(?::\d{2}(?:[.,]\d+)?)?(?:Z|[+-]\d{2}:?\d{2})?)?|` +
+	`\d{4}/\d{2}/\d{2}(?: \d{2}:\d{2}:\d{2}(?:\.\d+)?)?|` +
+	`\d{2}:\d{2}:\d{2}(?:[.,]\d+)?|` +
+	`(?:Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec) [ \d]\d \d{2}:\d{2}:\d{2}|` +
+	`\d{2}/(?:Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec)/\d{4}:\d{2}:\d{2}:\d{2}(?: [+-]\d{4})?` +
+	`)`)
+
+// logIPv4RE matches an IPv4 address, with an optional port.
+var logIPv4RE = regexp.MustCompile(`^\d{1,3}(?:\.\d{1,3}){3}(?::\d{1,5})?`)
+
+// logIPv6RE matches the characters an IPv6 address may be made of; the
+// match is then checked with net.ParseIP.
+var logIPv6RE = regexp.MustCompile(`^[0-9A-Fa-f]*:[0-9A-Fa-f:.]*`)
+
+// logLevels maps the upper-case names of log levels to their kinds.
+var logLevels = map[string]Kind{
+	"FATAL":    LogError,
+	"PANIC":    LogError,
+	"CRIT":     LogError,
+	"CRITICAL": LogError,
+	"ALERT":    LogError,
+	"EMERG":    LogError,
+	"SEVERE":   LogError,
+	"ERR":      LogError,
+	"ERROR":    LogError,
+	"WARN":     LogWarning,
+	"WARNING":  LogWarning,
+	"NOTICE":   LogInfo,
+	"INFO":     LogInfo,
+	"DEBUG":    Comment,
+	"DBG":      Comment,
+	"TRACE":    Comment,
+	"VERBOSE":  Comment,
+}
+
+// newLogLexer returns a Lexer for log files. Log levels are highlighted as
+// LogError, LogWarning or LogInfo, and debug and trace levels as Comment;
+// timestamps are Literal, IP addresses Decimal and quoted strings String.
+// Levels are recognized when they are in upper case, or in any case when
+// they follow "[", "<" or "=", as in "[error]" or "level=warn", so that
+// words such as "error" in messages are left alone.
+func newLogLexer() Lexer {
+	return &lineLexer{lexLine: func(line []byte) []lexToken {
+		var toks lineTokens
+		for i := 0; i < len(line); {
+			if n := blanks(line[i:]); n > 0 {
+				toks.add(n, Whitespace)
+				i += n
+				continue
+			}
+			if i > 0 && isLogWordByte(line[i-1]) {
+				n := logWord(line[i:])
+				if n == 0 {
+					n = 1
+				}
+				toks.add(n, Plaintext)
+				i += n
+				continue
+			}
+			n, kind := logToken(line, i)
+			toks.add(n, kind)
+			i += n
+		}
+		return toks
+	}}
+}
+
+// logToken returns the length and kind of the token at line[i:], which
+// starts a word.
+func logToken(line []byte, i int) (int, Kind) {
+	rest := line[i:]
+	switch c := rest[0]; {
+	case c == '"':
+		return logQuoted(rest), String
+	case c == '\'':
+		if n := logQuoted(rest); n > 1 && rest[n-1] == '\'' {
+			return n, String
+		}
+	}
+	if n := logMatch(logTimestampRE, rest); n > 0 {
+		return n, Literal
+	}
+	if n := logMatch(logIPv4RE, rest); n > 0 {
+		return n, Decimal
+	}
+	if n := logMatch(logIPv6RE, rest); n > 0 && net.ParseIP(string(rest[:n])) != nil {
+		return n, Decimal
+	}
+	n := logWord(rest)
+	if n == 0 {
+		return 1, Plaintext
+	}
+	word := rest[:n]
+	upper := bytes.ToUpper(word)
+	if kind, ok := logLevels[string(upper)]; ok {
+		if bytes.Equal(word, upper) || i > 0 && bytes.IndexByte([]byte("[<="), line[i-1]) >= 0 {
+			return n, kind
+		}
+	}
+	return n, Plaintext
+}
+
+// logMatch returns the length of the match of re at the start of b, or 0
+// if there is none or it ends in the middle of a word.
+func logMatch(re *regexp.Regexp, b []byte) int {
+	loc := re.FindIndex(b)
+	if loc == nil || loc[1] < len(b) && isLogWordByte(b[loc[1]]) {
+		return 0
+	}
+	return loc[1]
+}
+
+// logQuoted returns the length of the string quoted with the quote at the
+// start of b, which runs to the end of b if it is not closed.
+func logQuoted(b []byte) int {
+	for i := 1; i < len(b); i++ {
+		switch b[i] {
+		case '\\':
+			i++
+		case b[0]:
+			return i + 1
+		}
+	}
+	return len(b)
+}
+
+// logWord returns the length of the run of word bytes at the start of b.
+func logWord(b []byte) int {
+	i := 0
+	for i < len(b) && isLogWordByte(b[i]) {
+		i++
+	}
+	return i
+}
+
+// isLogWordByte reports whether b is a letter, a digit or an underscore.
+func isLogWordByte(b byte) bool {
+	return 'a' <= lower(rune(b)) && lower(rune(b)) <= 'z' || '0' <= b && b <= '9' || b == '_'
+}
@@ -0,0 +1,40 @@
+package syntaxhighlight
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLogLexer(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []token
+	}{
+		{"2024-01-02T15:04:05.123Z ERROR failed to connect to 10.0.0.1:5432\n", []token{
+			{Literal, "2024-01-02T15:04:05.123Z"}, {Whitespace, " "}, {LogError, "ERROR"}, {Whitespace, " "},
+			{Plaintext, "failed"}, {Whitespace, " "}, {Plaintext, "to"}, {Whitespace, " "}, {Plaintext, "connect"},
+			{Whitespace, " "}, {Plaintext, "to"}, {Whitespace, " "}, {Decimal, "10.0.0.1:5432"}, {Whitespace, "\n"},
+		}},
+		{"2024/01/02 15:04:05 [warn] retrying \"GET /\"", []token{
+			{Literal, "2024/01/02 15:04:05"}, {Whitespace, " "}, {Plaintext, "["}, {LogWarning, "warn"}, {Plaintext, "]"},
+			{Whitespace, " "}, {Plaintext, "retrying"}, {Whitespace, " "}, {String, `"GET /"`},
+		}},
+		{"level=info msg='started' addr=::1 an error", []token{
+			{Plaintext, "level="}, {LogInfo, "info"}, {Whitespace, " "},
+			{Plaintext, "msg="}, {String, "'started'"}, {Whitespace, " "},
+			{Plaintext, "addr="}, {Decimal, "::1"}, {Whitespace, " "},
+			{Plaintext, "an"}, {Whitespace, " "}, {Plaintext, "error"},
+		}},
+		{"Jan  2 15:04:05 host DEBUG don't", []token{
+			{Literal, "Jan  2 15:04:05"}, {Whitespace, " "}, {Plaintext, "host"}, {Whitespace, " "},
+			{Comment, "DEBUG"}, {Whitespace, " "}, {Plaintext, "don't"},
+		}},
+	}
+	for _, test := range tests {
+		s := NewScanner([]byte(test.src))
+		s.UseLanguage(LookupLanguage("log"))
+		if got := scanAll(t, s); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q:\nwant %v\ngot  %v", test.src, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// markupEntityRE matches character and entity references, such as "&amp;"
+// or "&#x20;".
+var markupEntityRE = regexp.MustCompile(`^&(#[0-9]+|#[xX][0-9a-fA-F]+|[A-Za-z][A-Za-z0-9]*);`)
+
+// markupLexer is a Lexer for XML and HTML.
+type markupLexer struct {
+	html bool // the source is HTML
+
+	inTag   bool   // in a tag, after its "<" or "</"
+	name    bool   // the element name of the tag is next
+	value   bool   // an attribute value is next, after its "="
+	element []byte // name of the element of the current tag
+	raw     Lexer  // lexer of the raw text of an HTML script or style element
+	rest    int    // length of the rest of the raw text
+}
+
+// newXMLLexer returns a Lexer for XML, which highlights element names as Tag,
+// attribute names as HTMLAttrName and their values as HTMLAttrValue. The
+// prefixes of qualified names, as in "soap:Envelope", are Namespace, and
+// namespace declarations, the "xmlns" and "xmlns:prefix" attributes, are
+// XMLNamespace. Processing instructions, including the XML declaration, are
+// ProcInst and document type declarations Doctype; comments are Comment,
+// CDATA sections String and character references Literal.
+func newXMLLexer() Lexer {
+	return &markupLexer{}
+}
+
+// newHTMLLexer returns a Lexer for HTML, which is lexed as XML with element
+// names highlighted as HTMLTag. The contents of script elements are
+// highlighted as JavaScript, and those of style elements as CSS.
+func newHTMLLexer() Lexer {
+	return &markupLexer{html: true}
+}
+
+func (l *markupLexer) Lex(data []byte, atEOF bool) (int, Kind) {
+	if l.raw != nil {
+		return l.lexRaw(data, atEOF)
+	}
+	if n := markupSpace(data); n > 0 {
+		if n == len(data) && !atEOF {
+			return 0, 0
+		}
+		return n, Whitespace
+	}
+	if l.inTag {
+		return l.lexTag(data, atEOF)
+	}
+
+	if data[0] == '<' && len(data) < len("<![CDATA[") && !atEOF {
+		return 0, 0
+	}
+	switch {
+	case hasPrefix(data, "<!--"):
+		return markupUntil(data, 4, "-->", atEOF), Comment
+	case hasPrefix(data, "<![CDATA["):
+		return markupUntil(data, 9, "]]>", atEOF), String
+	case hasPrefix(data, "<?"):
+		return markupUntil(data, 2, "?>", atEOF), ProcInst
+	case hasPrefix(data, "<!"):
+		return markupDeclaration(data, atEOF), Doctype
+	case hasPrefix(data, "</"):
+		l.inTag, l.name, l.element = true, true, nil
+		return 2, Punctuation
+	case len(data) > 1 && data[0] == '<' && markupNameByte(data[1]):
+		l.inTag, l.name, l.element = true, true, nil
+		return 1, Punctuation
+	case data[0] == '&':
+		if m := markupEntityRE.Find(data); m != nil {
+			return len(m), Literal
+		}
+		if len(data) < 12 && !atEOF {
+			return 0, 0
+		}
+	}
+
+	// text, up to the next tag, reference or space
+	n := 1
+	for n < len(data) && data[n] != '<' && data[n] != '&' && markupSpace(data[n:n+1]) == 0 {
+		n++
+	}
+	if n == len(data) && !atEOF {
+		return 0, 0
+	}
+	return n, Plaintext
+}
+
+// lexTag lexes the name, the attributes and the end of a tag.
+func (l *markupLexer) lexTag(data []byte, atEOF bool) (int, Kind) {
+	switch {
+	case data[0] == '>' || hasPrefix(data, "/>") && !l.value:
+		n := 1
+		if data[0] == '/' {
+			n = 2
+		}
+		l.inTag, l.value = false, false
+		if l.html && n == 1 && l.element != nil {
+			var lang *Language
+			switch string(bytes.ToLower(l.element)) {
+			case "script":
+				lang = LookupLanguage("javascript")
+			case "style":
+				lang = LookupLanguage("css")
+			default:
+				return n, Punctuation
+			}
+			l.raw = newLanguageLexer(lang)
+			l.rest = -1
+		}
+		return n, Punctuation
+	case data[0] == '/' && !l.value || data[0] == '=':
+		l.value = data[0] == '='
+		return 1, Punctuation
+	case data[0] == '"' || data[0] == '\'':
+		i := bytes.IndexByte(data[1:], data[0])
+		if i < 0 {
+			if !atEOF {
+				return 0, 0
+			}
+			return len(data), HTMLAttrValue
+		}
+		l.value = false
+		return i + 2, HTMLAttrValue
+	}
+
+	n := 0
+	for n < len(data) && data[n] != '>' && data[n] != '=' && !(data[n] == '/' && !l.value) && markupSpace(data[n:n+1]) == 0 {
+		n++
+	}
+	if n == len(data) && !atEOF {
+		return 0, 0
+	}
+	if n == 0 {
+		return 1, Plaintext
+	}
+	word := data[:n]
+	if l.value {
+		l.value = false
+		return n, HTMLAttrValue
+	}
+	if !l.html {
+		// the prefix of a qualified name is returned on its own, and
+		// the rest of the name is lexed again
+		if i := bytes.IndexByte(word, ':'); i > 0 && string(word[:i]) != "xmlns" {
+			return i, Namespace
+		} else if i == 0 {
+			return 1, Punctuation
+		}
+	}
+	if l.name {
+		l.name = false
+		l.element = append(l.element[:0], word...)
+		if l.html {
+			return n, HTMLTag
+		}
+		return n, Tag
+	}
+	if string(word) == "xmlns" || hasPrefix(word, "xmlns:") {
+		return n, XMLNamespace
+	}
+	return n, HTMLAttrName
+}
+
+// lexRaw lexes the raw text of an HTML script or style element, which runs
+// to its end tag.
+func (l *markupLexer) lexRaw(data []byte, atEOF bool) (int, Kind) {
+	if l.rest < 0 {
+		end := []byte("</" + string(bytes.ToLower(l.element)))
+		i := bytes.Index(bytes.ToLower(data), end)
+		if i < 0 {
+			if !atEOF {
+				return 0, 0
+			}
+			i = len(data)
+		}
+		l.rest = i
+	}
+	if l.rest == 0 {
+		l.raw = nil
+		return l.Lex(data, atEOF)
+	}
+	if l.rest > len(data) {
+		return 0, 0
+	}
+	n, kind := l.raw.Lex(data[:l.rest], true)
+	l.rest -= n
+	return n, kind
+}
+
+// markupUntil returns the length of the construct at the start of data
+// that ends with end, searched for from offset i. An unterminated
+// construct runs to the end of the source.
+func markupUntil(data []byte, i int, end string, atEOF bool) int {
+	j := bytes.Index(data[i:], []byte(end))
+	if j < 0 {
+		if !atEOF {
+			return 0
+		}
+		return len(data)
+	}
+	return i + j + len(end)
+}
+
+// markupDeclaration returns the length of the declaration, such as a
+// DOCTYPE, at the start of data. It ends at the first ">" that is neither
+// quoted nor in an internal subset in brackets.
+func markupDeclaration(data []byte, atEOF bool) int {
+	var quote byte
+	depth := 0
+	for i := 2; i < len(data); i++ {
+		switch c := data[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']' && depth > 0:
+			depth--
+		case c == '>' && depth == 0:
+			return i + 1
+		}
+	}
+	if !atEOF {
+		return 0
+	}
+	return len(data)
+}
+
+// markupSpace returns the length of the run of spaces, including newlines,
+// at the start of b.
+func markupSpace(b []byte) int {
+	i := 0
+	for i < len(b) && (isBlank(b[i]) || b[i] == '\n') {
+		i++
+	}
+	return i
+}
+
+// markupNameByte reports whether b may start the name of an element.
+func markupNameByte(b byte) bool {
+	return 'a' <= lower(rune(b)) && lower(rune(b)) <= 'z' || b == '_' || b == ':' || b >= 0x80
+}
@@ -0,0 +1,47 @@
+package syntaxhighlight
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMarkupLexer(t *testing.T) {
+	tests := []struct {
+		lang, src string
+		want      []token
+	}{
+		{"xml", `<?xml version="1.0"?>` + "\n" + `<!DOCTYPE note [<!ENTITY a "b>">]>` + "\n" + `<soap:Envelope xmlns:soap="urn:s" id='1'><!-- c --><x/>a &amp; b<![CDATA[<y>]]></soap:Envelope>`, []token{
+			{ProcInst, `<?xml version="1.0"?>`}, {Whitespace, "\n"},
+			{Doctype, `<!DOCTYPE note [<!ENTITY a "b>">]>`}, {Whitespace, "\n"},
+			{Punctuation, "<"}, {Namespace, "soap"}, {Punctuation, ":"}, {Tag, "Envelope"}, {Whitespace, " "},
+			{XMLNamespace, "xmlns:soap"}, {Punctuation, "="}, {HTMLAttrValue, `"urn:s"`}, {Whitespace, " "},
+			{HTMLAttrName, "id"}, {Punctuation, "="}, {HTMLAttrValue, "'1'"}, {Punctuation, ">"},
+			{Comment, "<!-- c -->"}, {Punctuation, "<"}, {Tag, "x"}, {Punctuation, "/>"},
+			{Plaintext, "a"}, {Whitespace, " "}, {Literal, "&amp;"}, {Whitespace, " "}, {Plaintext, "b"},
+			{String, "<![CDATA[<y>]]>"},
+			{Punctuation, "</"}, {Namespace, "soap"}, {Punctuation, ":"}, {Tag, "Envelope"}, {Punctuation, ">"},
+		}},
+		{"html", `<!doctype html><a href=/x>go</a><script>if (a<b) {}</script>`, []token{
+			{Doctype, "<!doctype html>"},
+			{Punctuation, "<"}, {HTMLTag, "a"}, {Whitespace, " "}, {HTMLAttrName, "href"}, {Punctuation, "="}, {HTMLAttrValue, "/x"}, {Punctuation, ">"},
+			{Plaintext, "go"}, {Punctuation, "</"}, {HTMLTag, "a"}, {Punctuation, ">"},
+			{Punctuation, "<"}, {HTMLTag, "script"}, {Punctuation, ">"},
+			{Keyword, "if"}, {Whitespace, " "}, {Punctuation, "("}, {Plaintext, "a"}, {Punctuation, "<"}, {Plaintext, "b"}, {Punctuation, ")"},
+			{Whitespace, " "}, {Punctuation, "{"}, {Punctuation, "}"},
+			{Punctuation, "</"}, {HTMLTag, "script"}, {Punctuation, ">"},
+		}},
+		{"html", `<style>p { margin: 0 }</style>`, []token{
+			{Punctuation, "<"}, {HTMLTag, "style"}, {Punctuation, ">"},
+			{Tag, "p"}, {Whitespace, " "}, {Punctuation, "{"}, {Whitespace, " "}, {Variable, "margin"}, {Punctuation, ":"}, {Whitespace, " "},
+			{Decimal, "0"}, {Whitespace, " "}, {Punctuation, "}"},
+			{Punctuation, "</"}, {HTMLTag, "style"}, {Punctuation, ">"},
+		}},
+	}
+	for _, test := range tests {
+		s := NewScanner([]byte(test.src))
+		s.UseLanguage(LookupLanguage(test.lang))
+		if got := scanAll(t, s); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s %q:\nwant %v\ngot  %v", test.lang, test.src, test.want, got)
+		}
+	}
+}
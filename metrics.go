@@ -0,0 +1,92 @@
+package syntaxhighlight
+
+import "time"
+
+// MetricsObserver records the highlighting done with the Metrics option, so
+// that services can export lexing latency and token counts, such as to
+// Prometheus, without instrumenting every call site.
+type MetricsObserver interface {
+	// ObserveTokens is called once per highlighted source with the name of
+	// its language, or "" if none was set, the number of tokens scanned,
+	// and the time taken to scan and print them.
+	ObserveTokens(lang string, n int, d time.Duration)
+}
+
+// FallbackObserver is implemented by a MetricsObserver that also records
+// sources, or the rest of them, written without highlighting (see
+// OnFallback), so that fallback rates can be tracked.
+type FallbackObserver interface {
+	ObserveFallback(lang string, reason error)
+}
+
+// Metrics makes AsHTML and the functions built on it report each source they
+// highlight to m.
+//
+// Example:
+// AsHTML(input, Metrics(observer))
+func Metrics(m MetricsObserver) Option {
+	return func(o *HTMLConfig) {
+		o.Metrics = m
+	}
+}
+
+// languageName returns the canonical name of the language of c, or "" if
+// none is set or it is not supported.
+func (c HTMLConfig) languageName() string {
+	if c.Language == "" {
+		return ""
+	}
+	if l := LookupLanguage(c.Language); l != nil {
+		return l.Name
+	}
+	return ""
+}
+
+// observe returns s counting its tokens for the Metrics of c, which they are
+// reported to by done, or when s is exhausted. It returns s unchanged if c
+// has no Metrics.
+func (c HTMLConfig) observe(s tokenStream) (observed tokenStream, done func()) {
+	if c.Metrics == nil {
+		return s, func() {}
+	}
+	o := &observedStream{tokenStream: s, m: c.Metrics, lang: c.languageName(), start: time.Now()}
+	return o, o.finish
+}
+
+// observedStream counts the tokens of a tokenStream.
+type observedStream struct {
+	tokenStream
+	m     MetricsObserver
+	lang  string
+	start time.Time
+	n     int
+	done  bool
+}
+
+func (s *observedStream) Scan() bool {
+	if s.tokenStream.Scan() {
+		s.n++
+		return true
+	}
+	s.finish()
+	return false
+}
+
+// finish reports the tokens scanned so far, once.
+func (s *observedStream) finish() {
+	if !s.done {
+		s.done = true
+		s.m.ObserveTokens(s.lang, s.n, time.Since(s.start))
+	}
+}
+
+// fallback reports that the source, or the rest of it, is written without
+// highlighting for the given reason.
+func (c *HTMLConfig) fallback(reason error) {
+	if c.OnFallback != nil {
+		c.OnFallback(reason)
+	}
+	if f, ok := c.Metrics.(FallbackObserver); ok {
+		f.ObserveFallback(c.languageName(), reason)
+	}
+}
@@ -0,0 +1,54 @@
+package syntaxhighlight
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type observation struct {
+	lang string
+	n    int
+}
+
+type testObserver struct {
+	tokens    []observation
+	fallbacks []error
+}
+
+func (o *testObserver) ObserveTokens(lang string, n int, d time.Duration) {
+	if d < 0 {
+		panic("negative duration")
+	}
+	o.tokens = append(o.tokens, observation{lang, n})
+}
+
+func (o *testObserver) ObserveFallback(lang string, reason error) {
+	o.fallbacks = append(o.fallbacks, reason)
+}
+
+func TestMetrics(t *testing.T) {
+	var o testObserver
+	if _, err := AsHTML([]byte("x = 1 # y\n"), Lang("py"), Metrics(&o)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AsHTMLTruncated([]byte("a\nb\nc\n"), 1, 0, Metrics(&o)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AsHTML([]byte("abc"), MaxSize(2), Metrics(&o)); err != nil {
+		t.Fatal(err)
+	}
+	// truncation stops scanning at the first token past the first line
+	want := []observation{{"python", 8}, {"", 3}, {"", 1}}
+	if len(o.tokens) != len(want) {
+		t.Fatalf("want observations %v, got %v", want, o.tokens)
+	}
+	for i := range want {
+		if o.tokens[i] != want[i] {
+			t.Errorf("want observation %v, got %v", want[i], o.tokens[i])
+		}
+	}
+	if len(o.fallbacks) != 1 || !errors.Is(o.fallbacks[0], ErrTooLarge) {
+		t.Errorf("want one fallback for ErrTooLarge, got %v", o.fallbacks)
+	}
+}
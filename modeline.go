@@ -0,0 +1,65 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// maxModeLine is the number of lines at the start of the source that are
+// checked for a #! line or an editor mode line.
+const maxModeLine = 5
+
+var (
+	emacsModeLineRE = regexp.MustCompile(`-\*-\s*(.*?)\s*-\*-`)
+	emacsModeVarRE  = regexp.MustCompile(`(?i)(?:^|;)\s*mode\s*:\s*([^;\s]+)`)
+	vimModeLineRE   = regexp.MustCompile(`(?:^|\s)(?:vi|vim|ex):.*?\b(?:ft|filetype)=([\w.+-]+)`)
+)
+
+// parseModeLine reports whether line is a #! line or an editor mode line, and
+// returns the interpreter or mode it names. A #! line is only recognized at
+// the start of the source; Emacs mode lines only on the first two lines, and
+// vim mode lines on the first maxModeLine lines.
+func (s *Scanner) parseModeLine(line []byte) (string, bool) {
+	if s.off == 0 && bytes.HasPrefix(line, []byte("#!")) && !bytes.HasPrefix(line, []byte("#![")) {
+		return shebangInterpreter(string(line[2:])), true
+	}
+	if s.line < 2 {
+		if m := emacsModeLineRE.FindSubmatch(line); m != nil {
+			vars := string(m[1])
+			if !strings.Contains(vars, ":") {
+				return vars, true
+			}
+			if m := emacsModeVarRE.FindStringSubmatch(vars); m != nil {
+				return m[1], true
+			}
+			return "", true
+		}
+	}
+	if m := vimModeLineRE.FindSubmatch(line); m != nil {
+		return string(m[1]), true
+	}
+	return "", false
+}
+
+// shebangInterpreter returns the name of the interpreter in the #! line
+// whose text after the #! is cmd.
+func shebangInterpreter(cmd string) string {
+	fields := strings.Fields(cmd)
+	if len(fields) == 0 {
+		return ""
+	}
+	interp := path.Base(fields[0])
+	if interp == "env" {
+		// skip options and variable assignments
+		interp = ""
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") && !strings.Contains(f, "=") {
+				interp = path.Base(f)
+				break
+			}
+		}
+	}
+	return interp
+}
@@ -0,0 +1,34 @@
+package syntaxhighlight
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScannerModeLines(t *testing.T) {
+	tests := []struct {
+		src         string
+		interpreter string
+		first       token
+	}{
+		{"#!/usr/bin/env python3\nprint(1)", "python3", token{Shebang, "#!/usr/bin/env python3"}},
+		{"#!/usr/bin/env -S FOO=1 node --harmony\n", "node", token{Shebang, "#!/usr/bin/env -S FOO=1 node --harmony"}},
+		{"#!/bin/bash", "bash", token{Shebang, "#!/bin/bash"}},
+		{"# -*- mode: ruby; coding: utf-8 -*-\n", "ruby", token{Shebang, "# -*- mode: ruby; coding: utf-8 -*-"}},
+		{"#!/bin/sh\n// -*- C++ -*-\n", "sh", token{Shebang, "#!/bin/sh"}},
+		{"\n// -*- C++ -*-\n", "C++", token{Whitespace, "\n"}},
+		{"/* vim: set ts=4 ft=c: */", "c", token{Shebang, "/* vim: set ts=4 ft=c: */"}},
+		{"#![allow(dead_code)]", "", token{Punctuation, "#"}},
+		{"a\n#!/bin/sh", "", token{Plaintext, "a"}},
+	}
+	for _, test := range tests {
+		s := NewScanner([]byte(test.src))
+		toks := scanAll(t, s)
+		if !reflect.DeepEqual(toks[0], test.first) {
+			t.Errorf("%q: want first token %v, got %v", test.src, test.first, toks[0])
+		}
+		if got := s.Interpreter(); got != test.interpreter {
+			t.Errorf("%q: want interpreter %q, got %q", test.src, test.interpreter, got)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package syntaxhighlight
+
+import "unicode/utf8"
+
+// bsonConstructors are the functions of the Mongo shell that make BSON
+// values that JavaScript lacks, such as ObjectId("...").
+var bsonConstructors = setOf(
+	"ObjectId", "ISODate", "NumberLong", "NumberInt", "NumberDecimal", "Decimal128", "Timestamp", "BinData",
+	"UUID", "MinKey", "MaxKey", "DBRef",
+)
+
+// mongoLexer is a Lexer for Mongo shell snippets, which are JavaScript
+// with the BSON constructors of the shell and query documents with
+// unquoted keys.
+type mongoLexer struct {
+	js Lexer
+
+	bson    int  // 1 after a BSON constructor, 2 after its "("
+	ternary bool // a "?" has not been matched by its ":" yet
+}
+
+// newMongoLexer returns a Lexer for Mongo shell snippets, which are
+// highlighted as JavaScript, with the unquoted keys of documents, as in
+// {name: 1}, as Variable, or Keyword for query and update operators such
+// as $gt and $set. BSON constructors, such as ObjectId and ISODate, are
+// Type, and the string they are called with Literal.
+func newMongoLexer() Lexer {
+	return &mongoLexer{js: newLanguageLexer(LookupLanguage("javascript"))}
+}
+
+func (l *mongoLexer) Lex(data []byte, atEOF bool) (int, Kind) {
+	// the name of a key is followed by a colon, which is looked for first,
+	// as the JavaScript lexer cannot take its tokens back
+	name, key := 0, false
+	if r, _ := utf8.DecodeRune(data); r == '$' || r == '_' || isIdentRune(r) && !isDecimal(r) {
+		for name < len(data) {
+			r, size := utf8.DecodeRune(data[name:])
+			if r != '$' && !isIdentRune(r) {
+				break
+			}
+			name += size
+		}
+		i := name + blanks(data[name:])
+		if i+1 >= len(data) && !atEOF {
+			return 0, 0
+		}
+		key = i < len(data) && data[i] == ':' && !hasPrefix(data[i:], "::") && !l.ternary
+	}
+
+	n, kind := l.js.Lex(data, atEOF)
+	if n == 0 || kind == Whitespace || kind == Comment || kind == DocComment {
+		return n, kind
+	}
+	tok := string(data[:n])
+	bson := l.bson
+	l.bson = 0
+	switch {
+	case n == name && key && tok[0] == '$':
+		kind = Keyword
+	case n == name && key:
+		kind = Variable
+	case n == name && bsonConstructors[tok]:
+		kind, l.bson = Type, 1
+	case tok == "(" && bson == 1:
+		l.bson = 2
+	case kind == String && bson == 2:
+		kind = Literal
+	case tok == "?":
+		l.ternary = true
+	case tok == ":":
+		l.ternary = false
+	}
+	return n, kind
+}
@@ -0,0 +1,44 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"testing/iotest"
+)
+
+func TestMongoLexer(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []token
+	}{
+		{`db.users.find({_id: ObjectId("5f1d"), age: {$gte: 18}})`, []token{
+			{Plaintext, "db"}, {Punctuation, "."}, {Plaintext, "users"}, {Punctuation, "."}, {Plaintext, "find"}, {Punctuation, "("},
+			{Punctuation, "{"}, {Variable, "_id"}, {Punctuation, ":"}, {Whitespace, " "}, {Type, "ObjectId"}, {Punctuation, "("},
+			{Literal, `"5f1d"`}, {Punctuation, ")"}, {Punctuation, ","}, {Whitespace, " "}, {Variable, "age"}, {Punctuation, ":"},
+			{Whitespace, " "}, {Punctuation, "{"}, {Keyword, "$gte"}, {Punctuation, ":"}, {Whitespace, " "}, {Decimal, "18"},
+			{Punctuation, "}"}, {Punctuation, "}"}, {Punctuation, ")"},
+		}},
+		{"db.logs.updateOne({ at: ISODate('2024-01-01') }, { $set: { n : x ? a : \"b\" } })", []token{
+			{Plaintext, "db"}, {Punctuation, "."}, {Plaintext, "logs"}, {Punctuation, "."}, {Plaintext, "updateOne"}, {Punctuation, "("},
+			{Punctuation, "{"}, {Whitespace, " "}, {Variable, "at"}, {Punctuation, ":"}, {Whitespace, " "}, {Type, "ISODate"}, {Punctuation, "("},
+			{Literal, "'2024-01-01'"}, {Punctuation, ")"}, {Whitespace, " "}, {Punctuation, "}"}, {Punctuation, ","}, {Whitespace, " "},
+			{Punctuation, "{"}, {Whitespace, " "}, {Keyword, "$set"}, {Punctuation, ":"}, {Whitespace, " "}, {Punctuation, "{"}, {Whitespace, " "},
+			{Variable, "n"}, {Whitespace, " "}, {Punctuation, ":"}, {Whitespace, " "}, {Plaintext, "x"}, {Whitespace, " "}, {Punctuation, "?"},
+			{Whitespace, " "}, {Plaintext, "a"}, {Whitespace, " "}, {Punctuation, ":"}, {Whitespace, " "}, {String, `"b"`}, {Whitespace, " "},
+			{Punctuation, "}"}, {Whitespace, " "}, {Punctuation, "}"}, {Punctuation, ")"},
+		}},
+	}
+	for _, test := range tests {
+		for _, oneByte := range []bool{false, true} {
+			s := NewScanner([]byte(test.src))
+			if oneByte {
+				s = NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(test.src))))
+			}
+			s.UseLanguage(LookupLanguage("mongosh"))
+			if got := scanAll(t, s); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%q:\nwant %v\ngot  %v", test.src, test.want, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package syntaxhighlight
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// openapiTopKeys are the keys at the top level of OpenAPI and Swagger
+// documents.
+var openapiTopKeys = setOf(
+	"openapi", "swagger", "info", "servers", "paths", "webhooks", "components", "security", "tags", "externalDocs",
+	"host", "basePath", "schemes", "consumes", "produces", "definitions", "parameters", "responses",
+	"securityDefinitions",
+)
+
+// openapiMethods are the keys of the operations of a path.
+var openapiMethods = setOf("get", "put", "post", "delete", "options", "head", "patch", "trace")
+
+// openapiKey returns the kind of the key at the end of path in an OpenAPI
+// document: Keyword for the keys at the top level, the HTTP methods of
+// paths and $ref, and Namespace for the paths themselves, as in
+// /users/{id}.
+func openapiKey(path []string) (Kind, bool) {
+	if len(path) == 0 {
+		return 0, false
+	}
+	key := path[len(path)-1]
+	switch {
+	case key == "$ref" || len(path) == 1 && openapiTopKeys[key]:
+		return Keyword, true
+	case len(path) == 2 && (path[0] == "paths" || path[0] == "webhooks"):
+		return Namespace, true
+	case len(path) == 3 && path[0] == "paths" && openapiMethods[key]:
+		return Keyword, true
+	}
+	return 0, false
+}
+
+// openapiMode specializes YAML for OpenAPI documents (see openapiKey). The
+// values of $ref, references such as '#/components/schemas/User', are
+// Label, quotes included, so that they can be made links with
+// OpenAPIRefLink.
+var openapiMode = &yamlMode{
+	key: openapiKey,
+	value: func(toks *lineTokens, path []string, value []byte) bool {
+		if len(path) == 0 || path[len(path)-1] != "$ref" {
+			return false
+		}
+		toks.add(len(value), Label)
+		return true
+	},
+}
+
+// newOpenAPILexer returns a Lexer for OpenAPI documents in YAML.
+func newOpenAPILexer() Lexer {
+	return newYAMLModeLexer(openapiMode)()
+}
+
+// openapiJSONLexer is a Lexer for OpenAPI documents in JSON, which keeps
+// track of the path of keys to the current value.
+type openapiJSONLexer struct {
+	json jsonLexer
+
+	path   []string // keys of the enclosing objects and arrays, "-" for items
+	object []bool   // whether each of the enclosing collections is an object
+	key    string   // the last key of the current object
+}
+
+// newOpenAPIJSONLexer returns a Lexer for OpenAPI documents in JSON, which
+// are highlighted as JSON with the keys and values of openapiMode.
+func newOpenAPIJSONLexer() Lexer {
+	return &openapiJSONLexer{}
+}
+
+func (l *openapiJSONLexer) Lex(data []byte, atEOF bool) (int, Kind) {
+	n, kind := l.json.Lex(data, atEOF)
+	if n == 0 {
+		return n, kind
+	}
+	// the path of the token, without the key or "-" of the top level
+	name := "-"
+	if len(l.object) > 0 && l.object[len(l.object)-1] {
+		name = l.key
+	}
+	path := append(l.path[:len(l.path):len(l.path)], name)
+	if len(l.object) > 0 {
+		path = path[1:]
+	}
+
+	switch kind {
+	case Variable:
+		l.key = strings.Trim(string(data[:n]), `"'`)
+		path[len(path)-1] = l.key
+		if k, ok := openapiKey(path); ok {
+			kind = k
+		}
+	case Punctuation:
+		switch data[0] {
+		case '{', '[':
+			l.path = append(l.path, name)
+			l.object = append(l.object, data[0] == '{')
+			l.key = ""
+		case '}', ']':
+			if len(l.object) > 0 {
+				l.path = l.path[:len(l.path)-1]
+				l.object = l.object[:len(l.object)-1]
+			}
+		}
+	case String:
+		if name == "$ref" {
+			kind = Label
+		}
+	}
+	return n, kind
+}
+
+// OpenAPIRefLink returns a function for the Link field of a LinkAnnotator
+// that links the $ref values of OpenAPI documents, such as
+// "#/components/schemas/User" or "common.yaml#/Error", to the document
+// they refer to, resolved against base, which may be empty.
+func OpenAPIRefLink(base string) func(kind Kind, tokText string) string {
+	return func(kind Kind, tokText string) string {
+		if kind != Label {
+			return ""
+		}
+		ref := strings.Trim(tokText, `"'`)
+		doc := ref
+		if i := strings.IndexByte(ref, '#'); i >= 0 {
+			doc = ref[:i]
+		}
+		if ref == "" || doc != "" && !strings.HasSuffix(doc, ".json") && !strings.HasSuffix(doc, ".yaml") && !strings.HasSuffix(doc, ".yml") {
+			return ""
+		}
+		b, err := url.Parse(base)
+		if err != nil {
+			return ""
+		}
+		r, err := url.Parse(ref)
+		if err != nil {
+			return ""
+		}
+		if b.Scheme == "" && b.Host == "" && !strings.HasPrefix(b.Path, "/") && !r.IsAbs() && r.Host == "" && !strings.HasPrefix(r.Path, "/") {
+			// ResolveReference would make the path absolute
+			if r.Path == "" {
+				r.Path = b.Path
+			} else {
+				r.Path = path.Join(path.Dir(b.Path), r.Path)
+			}
+			return r.String()
+		}
+		return b.ResolveReference(r).String()
+	}
+}
@@ -0,0 +1,76 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"testing/iotest"
+)
+
+func TestOpenAPILexer(t *testing.T) {
+	tests := []struct {
+		lang string
+		src  string
+		want []token
+	}{
+		{"openapi", "openapi: 3.0.0\npaths:\n  /users/{id}:\n    get:\n      responses:\n        '200':\n          $ref: '#/components/responses/User'\n", []token{
+			{Keyword, "openapi"}, {Punctuation, ":"}, {Whitespace, " "}, {String, "3.0.0"}, {Whitespace, "\n"},
+			{Keyword, "paths"}, {Punctuation, ":"}, {Whitespace, "\n"},
+			{Whitespace, "  "}, {Namespace, "/users/{id}"}, {Punctuation, ":"}, {Whitespace, "\n"},
+			{Whitespace, "    "}, {Keyword, "get"}, {Punctuation, ":"}, {Whitespace, "\n"},
+			{Whitespace, "      "}, {Variable, "responses"}, {Punctuation, ":"}, {Whitespace, "\n"},
+			{Whitespace, "        "}, {Variable, "'200'"}, {Punctuation, ":"}, {Whitespace, "\n"},
+			{Whitespace, "          "}, {Keyword, "$ref"}, {Punctuation, ":"}, {Whitespace, " "}, {Label, "'#/components/responses/User'"}, {Whitespace, "\n"},
+		}},
+		{"openapi-json", `{"paths": {"/pets": {"post": {"tags": ["get"], "schema": {"$ref": "#/definitions/Pet"}}}}}`, []token{
+			{Punctuation, "{"}, {Keyword, `"paths"`}, {Punctuation, ":"}, {Whitespace, " "}, {Punctuation, "{"}, {Namespace, `"/pets"`}, {Punctuation, ":"},
+			{Whitespace, " "}, {Punctuation, "{"}, {Keyword, `"post"`}, {Punctuation, ":"}, {Whitespace, " "}, {Punctuation, "{"}, {Variable, `"tags"`},
+			{Punctuation, ":"}, {Whitespace, " "}, {Punctuation, "["}, {String, `"get"`}, {Punctuation, "]"}, {Punctuation, ","}, {Whitespace, " "}, {Variable, `"schema"`},
+			{Punctuation, ":"}, {Whitespace, " "}, {Punctuation, "{"}, {Keyword, `"$ref"`}, {Punctuation, ":"}, {Whitespace, " "},
+			{Label, `"#/definitions/Pet"`}, {Punctuation, "}"}, {Punctuation, "}"}, {Punctuation, "}"}, {Punctuation, "}"}, {Punctuation, "}"},
+		}},
+	}
+	for _, test := range tests {
+		for _, oneByte := range []bool{false, true} {
+			s := NewScanner([]byte(test.src))
+			if oneByte {
+				s = NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(test.src))))
+			}
+			s.UseLanguage(LookupLanguage(test.lang))
+			if got := scanAll(t, s); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%s %q:\nwant %v\ngot  %v", test.lang, test.src, test.want, got)
+			}
+		}
+	}
+}
+
+func TestOpenAPIKey(t *testing.T) {
+	if _, ok := openapiKey(nil); ok {
+		t.Error("got a kind for an empty path")
+	}
+	var toks lineTokens
+	if openapiMode.value(&toks, nil, []byte("a")) || len(toks) != 0 {
+		t.Errorf("got %v for a top-level scalar", toks)
+	}
+}
+
+func TestOpenAPIRefLink(t *testing.T) {
+	tests := []struct {
+		base string
+		kind Kind
+		tok  string
+		want string
+	}{
+		{"", Label, `"#/components/schemas/User"`, "#/components/schemas/User"},
+		{"https://example.com/api/openapi.yaml", Label, "'common.yaml#/Error'", "https://example.com/api/common.yaml#/Error"},
+		{"/specs/api.json", Label, "../shared/pet.json", "/shared/pet.json"},
+		{"specs/api.yaml", Label, "common.yaml#/Error", "specs/common.yaml#/Error"},
+		{"", Label, "*base", ""},
+		{"", String, `"#/components/schemas/User"`, ""},
+	}
+	for _, test := range tests {
+		if got := OpenAPIRefLink(test.base)(test.kind, test.tok); got != test.want {
+			t.Errorf("%q, %v %q: got %q, want %q", test.base, test.kind, test.tok, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Package otelhighlight traces highlighting with OpenTelemetry, so that slow
+// highlights show up in distributed traces. It is a package of its own to
+// keep OpenTelemetry out of the dependencies of syntaxhighlight.
+package otelhighlight
+
+import (
+	"context"
+	"io"
+
+	"github.com/sourcegraph/annotate"
+	"github.com/sourcegraph/syntaxhighlight"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracer spans are started with.
+const instrumentationName = "github.com/sourcegraph/syntaxhighlight/otelhighlight"
+
+// Tracer wraps the functions of syntaxhighlight in spans. Spans are named
+// after the functions, such as "syntaxhighlight.AsHTML", and are children
+// of the span in the context they are passed.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// New returns a Tracer that starts spans with a tracer of provider tp, or of
+// the global provider if tp is nil.
+func New(tp trace.TracerProvider) *Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return &Tracer{tracer: tp.Tracer(instrumentationName)}
+}
+
+// AsHTML calls syntaxhighlight.AsHTML in a span recording the language and
+// the size of src.
+func (t *Tracer) AsHTML(ctx context.Context, src []byte, options ...syntaxhighlight.Option) ([]byte, error) {
+	var c syntaxhighlight.HTMLConfig
+	for _, o := range options {
+		o(&c)
+	}
+	_, span := t.start(ctx, "syntaxhighlight.AsHTML", src,
+		attribute.String("syntaxhighlight.language", c.Language))
+	out, err := syntaxhighlight.AsHTML(src, options...)
+	end(span, err)
+	return out, err
+}
+
+// Print calls syntaxhighlight.Print in a span.
+func (t *Tracer) Print(ctx context.Context, s *syntaxhighlight.Scanner, w io.Writer, p syntaxhighlight.Printer) error {
+	_, span := t.start(ctx, "syntaxhighlight.Print", nil)
+	err := syntaxhighlight.Print(s, w, p)
+	end(span, err)
+	return err
+}
+
+// Annotate calls syntaxhighlight.Annotate in a span recording the size of
+// src and the number of annotations.
+func (t *Tracer) Annotate(ctx context.Context, src []byte, a syntaxhighlight.Annotator) (annotate.Annotations, error) {
+	_, span := t.start(ctx, "syntaxhighlight.Annotate", src)
+	anns, err := syntaxhighlight.Annotate(src, a)
+	span.SetAttributes(attribute.Int("syntaxhighlight.annotations", len(anns)))
+	end(span, err)
+	return anns, err
+}
+
+// DetectLanguage calls syntaxhighlight.DetectLanguage in a span recording
+// the file name and the detected language.
+func (t *Tracer) DetectLanguage(ctx context.Context, filename string, src []byte) string {
+	_, span := t.start(ctx, "syntaxhighlight.DetectLanguage", src,
+		attribute.String("syntaxhighlight.filename", filename))
+	lang := syntaxhighlight.DetectLanguage(filename, src)
+	span.SetAttributes(attribute.String("syntaxhighlight.language", lang))
+	end(span, nil)
+	return lang
+}
+
+// start starts a span with the given name and attributes, and the size of
+// src unless it is nil.
+func (t *Tracer) start(ctx context.Context, name string, src []byte, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if src != nil {
+		attrs = append(attrs, attribute.Int("syntaxhighlight.bytes", len(src)))
+	}
+	return t.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// end ends span, marking it as failed if err is not nil.
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
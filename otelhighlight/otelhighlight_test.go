@@ -0,0 +1,79 @@
+package otelhighlight
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/sourcegraph/syntaxhighlight"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	tr := New(tp)
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	src := []byte("#!/usr/bin/env python\nx = 1\n")
+	lang := tr.DetectLanguage(ctx, "script", src)
+	if lang != "python" {
+		t.Errorf("want language python, got %q", lang)
+	}
+	if _, err := tr.AsHTML(ctx, src, syntaxhighlight.Lang(lang)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tr.AsHTML(ctx, src, syntaxhighlight.Lang("klingon")); err == nil {
+		t.Error("want error for unsupported language")
+	}
+	if _, err := tr.Annotate(ctx, src, syntaxhighlight.HTMLAnnotator(syntaxhighlight.DefaultHTMLConfig)); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := tr.Print(ctx, syntaxhighlight.NewScanner(src), &buf, syntaxhighlight.HTMLPrinter(syntaxhighlight.DefaultHTMLConfig)); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	spans := rec.Ended()
+	want := []struct {
+		name   string
+		status codes.Code
+		attr   attribute.KeyValue
+	}{
+		{"syntaxhighlight.DetectLanguage", codes.Unset, attribute.String("syntaxhighlight.language", "python")},
+		{"syntaxhighlight.AsHTML", codes.Unset, attribute.String("syntaxhighlight.language", "python")},
+		{"syntaxhighlight.AsHTML", codes.Error, attribute.Int("syntaxhighlight.bytes", len(src))},
+		{"syntaxhighlight.Annotate", codes.Unset, attribute.Int("syntaxhighlight.bytes", len(src))},
+		{"syntaxhighlight.Print", codes.Unset, attribute.KeyValue{}},
+		{"parent", codes.Unset, attribute.KeyValue{}},
+	}
+	if len(spans) != len(want) {
+		t.Fatalf("want %d spans, got %d", len(want), len(spans))
+	}
+	for i, w := range want {
+		span := spans[i]
+		if span.Name() != w.name {
+			t.Errorf("span %d: want name %q, got %q", i, w.name, span.Name())
+		}
+		if span.Status().Code != w.status {
+			t.Errorf("%s: want status %v, got %v", w.name, w.status, span.Status().Code)
+		}
+		if w.name != "parent" && span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("%s: not a child of the span in the context", w.name)
+		}
+		if w.attr.Key == "" {
+			continue
+		}
+		found := false
+		for _, a := range span.Attributes() {
+			found = found || a == w.attr
+		}
+		if !found {
+			t.Errorf("%s: want attribute %v, got %v", w.name, w.attr, span.Attributes())
+		}
+	}
+}
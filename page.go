@@ -0,0 +1,73 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// PageOptions configures the HTML document made by RenderPage.
+type PageOptions struct {
+	// Title is the title of the document.
+	Title string
+
+	// Theme is the color scheme of the page; if it has no styles,
+	// DefaultTheme is used.
+	Theme Theme
+
+	// LineNumbers shows line numbers next to the code (see Table).
+	LineNumbers bool
+
+	// WrapToggle adds a checkbox that switches line wrapping on and off.
+	WrapToggle bool
+
+	// Options are further options for the highlighted code, as for AsHTML.
+	Options []Option
+}
+
+// RenderPage returns a complete, standalone HTML document showing src
+// highlighted. The stylesheet of the theme is embedded in the document, so
+// that it can be shared as a single file.
+func RenderPage(src []byte, opts PageOptions) ([]byte, error) {
+	theme := opts.Theme
+	if theme.Styles == nil {
+		theme = DefaultTheme
+	}
+	options := opts.Options
+	if opts.LineNumbers {
+		options = append(options[:len(options):len(options)], Table())
+	}
+	c := newHTMLConfig(options)
+	code, err := AsHTML(src, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+body { margin: 0; }
+.highlight { margin: 0; padding: 1em; font-family: monospace; border-spacing: 0; }
+.highlight .gutter { padding-right: 1em; text-align: right; }
+#wrap:checked ~ .highlight { white-space: pre-wrap !important; }
+%s</style>
+</head>
+<body>
+`, template.HTMLEscapeString(opts.Title), theme.CSS(".highlight", c))
+	if opts.WrapToggle {
+		buf.WriteString(`<input type="checkbox" id="wrap"><label for="wrap">Wrap lines</label>` + "\n")
+	}
+	if c.AsTable {
+		buf.Write(code)
+	} else {
+		buf.WriteString(`<pre class="highlight">`)
+		buf.Write(code)
+		buf.WriteString(`</pre>`)
+	}
+	buf.WriteString("\n</body>\n</html>\n")
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,59 @@
+package syntaxhighlight
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderPage(t *testing.T) {
+	got, err := RenderPage([]byte("if x {\n}"), PageOptions{Title: "<main.go>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>&lt;main.go&gt;</title>
+<style>
+body { margin: 0; }
+.highlight { margin: 0; padding: 1em; font-family: monospace; border-spacing: 0; }
+.highlight .gutter { padding-right: 1em; text-align: right; }
+#wrap:checked ~ .highlight { white-space: pre-wrap !important; }
+` + DefaultTheme.CSS(".highlight", DefaultHTMLConfig) + `</style>
+</head>
+<body>
+<pre class="highlight"><span class="kwd">if</span> <span class="pln">x</span> <span class="pun">{</span>
+<span class="pun">}</span></pre>
+</body>
+</html>
+`
+	if string(got) != want {
+		t.Errorf("want ==========\n%s\ngot ===========\n%s", want, got)
+	}
+}
+
+func TestRenderPageOptions(t *testing.T) {
+	got, err := RenderPage([]byte("a"), PageOptions{
+		Theme:       HighContrastTheme,
+		LineNumbers: true,
+		WrapToggle:  true,
+		Options:     []Option{Accessible()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		".highlight { color: #ffffff; background-color: #000000; }",
+		`<input type="checkbox" id="wrap"><label for="wrap">Wrap lines</label>
+<table class="highlight" role="presentation" style="white-space:pre">`,
+		`<td class="gutter" data-line="1" aria-hidden="true" style="user-select:none">1</td>`,
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("want output to contain %q, got\n%s", want, got)
+		}
+	}
+	if strings.Contains(string(got), "<pre") {
+		t.Errorf("want no <pre> around table, got\n%s", got)
+	}
+}
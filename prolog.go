@@ -0,0 +1,156 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+)
+
+// prologNumberRE matches the numbers of Prolog: integers, which may have a
+// radix, as in 0x1F or 16'1F, floats, and character codes such as 0'a.
+var prologNumberRE = regexp.MustCompile(`^(0'(\\.|''|[^\\])|0[xob][0-9a-fA-F]+|\d+'[0-9a-zA-Z]+|\d+(_\d+)*(\.\d+)?([eE][+-]?\d+)?)`)
+
+// prologBuiltins are the built-in predicates, directives and arithmetic
+// operators of Prolog written as words.
+var prologBuiltins = setOf(
+	"abolish", "asserta", "assertz", "assert", "atom", "atomic", "call", "catch", "consult",
+	"discontiguous", "dynamic", "ensure_loaded", "findall", "format", "forall", "functor", "initialization",
+	"is", "meta_predicate", "mod", "module", "multifile", "nl", "not", "once", "rem", "retract", "setof",
+	"bagof", "throw", "use_module", "var", "nonvar", "write", "writeln", "xor",
+)
+
+// datalogBuiltins are the words with a meaning of their own in Datalog, as
+// in the directives of Soufflé, such as ".decl".
+var datalogBuiltins = setOf(
+	"count", "decl", "functor", "include", "input", "max", "min", "number", "output", "printsize",
+	"sum", "symbol", "type", "comp", "init", "override",
+)
+
+// prologSymbolChars are the characters of which Prolog makes atoms, such as
+// ":-" and "=..", that are operators.
+const prologSymbolChars = `+-*/\^<>=~:.?@#&$`
+
+// prologLexer is a Lexer for Prolog and Datalog. It is stateless.
+type prologLexer struct {
+	builtins map[string]bool // built-in predicates and operators
+	datalog  bool            // the source is Datalog
+}
+
+// newPrologLexer returns a Lexer for Prolog. Variables, whose names start
+// with a capital letter or an underscore, are Variable, and other names,
+// which are atoms, Plaintext, or Keyword for built-ins such as "is" and
+// "use_module"; true, false and fail are Literal. Quoted atoms, strings and
+// back-quoted strings are String and numbers, including character codes
+// such as 0'a, Decimal. Comments, after "%" or in "/* */", are Comment, and
+// operators made of symbol characters, such as ":-" and "-->", are a
+// single Punctuation token.
+func newPrologLexer() Lexer {
+	return prologLexer{builtins: prologBuiltins}
+}
+
+// newDatalogLexer returns a Lexer for Datalog, which is lexed as Prolog
+// with "//" comments and with directives such as ".decl" highlighted as
+// Keyword, as in Soufflé.
+func newDatalogLexer() Lexer {
+	return prologLexer{builtins: datalogBuiltins, datalog: true}
+}
+
+func (l prologLexer) Lex(data []byte, atEOF bool) (int, Kind) {
+	if !atEOF && (len(data) < 3 || !utf8.FullRune(data)) {
+		return 0, 0
+	}
+	switch c := data[0]; {
+	case isBlank(c) || c == '\n':
+		n := markupSpace(data)
+		if n == len(data) && !atEOF {
+			return 0, 0
+		}
+		return n, Whitespace
+	case c == '%' || l.datalog && hasPrefix(data, "//"):
+		return scanLine(data, atEOF), Comment
+	case hasPrefix(data, "/*"):
+		return markupUntil(data, 2, "*/", atEOF), Comment
+	case c == '"' || c == '\'' || c == '`':
+		return prologQuoted(data, atEOF), String
+	case '0' <= c && c <= '9':
+		n := len(prologNumberRE.Find(data))
+		if n+1 >= len(data) && !atEOF {
+			return 0, 0
+		}
+		return n, Decimal
+	case l.datalog && c == '.' && len(data) > 1 && 'a' <= data[1] && data[1] <= 'z':
+		n := 1 + prologName(data[1:])
+		if n == len(data) && !atEOF {
+			return 0, 0
+		}
+		return n, Keyword
+	case bytes.IndexByte([]byte(prologSymbolChars), c) >= 0:
+		n := 1
+		for n < len(data) && bytes.IndexByte([]byte(prologSymbolChars), data[n]) >= 0 {
+			n++
+		}
+		if n == len(data) && !atEOF {
+			return 0, 0
+		}
+		return n, Punctuation
+	}
+
+	r, size := utf8.DecodeRune(data)
+	if r != '_' && !unicode.IsLetter(r) {
+		return size, Punctuation
+	}
+	n := prologName(data)
+	if n == len(data) && !atEOF {
+		return 0, 0
+	}
+	switch name := string(data[:n]); {
+	case r == '_' || unicode.IsUpper(r):
+		return n, Variable
+	case name == "true" || name == "false" || name == "fail":
+		return n, Literal
+	case l.builtins[name]:
+		return n, Keyword
+	}
+	return n, Plaintext
+}
+
+// prologName returns the length of the name at the start of data, which is
+// made of letters, digits and underscores.
+func prologName(data []byte) int {
+	n := 0
+	for n < len(data) {
+		r, size := utf8.DecodeRune(data[n:])
+		if !isIdentRune(r) {
+			break
+		}
+		n += size
+	}
+	return n
+}
+
+// prologQuoted returns the length of the quoted atom or string at the start
+// of data, in which quotes may be doubled or escaped with a backslash, or 0
+// if more data is needed. An unterminated one runs to the end of the
+// source.
+func prologQuoted(data []byte, atEOF bool) int {
+	for i := 1; i < len(data); i++ {
+		switch {
+		case data[i] == '\\':
+			i++
+		case data[i] == data[0]:
+			if i+1 == len(data) && !atEOF {
+				return 0
+			}
+			if i+1 < len(data) && data[i+1] == data[0] {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	if !atEOF {
+		return 0
+	}
+	return len(data)
+}
@@ -0,0 +1,49 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"testing/iotest"
+)
+
+func TestPrologLexer(t *testing.T) {
+	tests := []struct {
+		lang, src string
+		want      []token
+	}{
+		{"prolog", "% facts\nparent(tom, 'Bob Jr').\nanc(X, _Y) :- X is 0'a + 1.5e3, \\+ fail, !.", []token{
+			{Comment, "% facts"}, {Whitespace, "\n"},
+			{Plaintext, "parent"}, {Punctuation, "("}, {Plaintext, "tom"}, {Punctuation, ","}, {Whitespace, " "}, {String, "'Bob Jr'"},
+			{Punctuation, ")"}, {Punctuation, "."}, {Whitespace, "\n"},
+			{Plaintext, "anc"}, {Punctuation, "("}, {Variable, "X"}, {Punctuation, ","}, {Whitespace, " "}, {Variable, "_Y"}, {Punctuation, ")"},
+			{Whitespace, " "}, {Punctuation, ":-"}, {Whitespace, " "}, {Variable, "X"}, {Whitespace, " "}, {Keyword, "is"}, {Whitespace, " "},
+			{Decimal, "0'a"}, {Whitespace, " "}, {Punctuation, "+"}, {Whitespace, " "}, {Decimal, "1.5e3"}, {Punctuation, ","}, {Whitespace, " "},
+			{Punctuation, `\+`}, {Whitespace, " "}, {Literal, "fail"}, {Punctuation, ","}, {Whitespace, " "}, {Punctuation, "!"}, {Punctuation, "."},
+		}},
+		{"prolog", "s --> [a], /* more */ \"str\".", []token{
+			{Plaintext, "s"}, {Whitespace, " "}, {Punctuation, "-->"}, {Whitespace, " "}, {Punctuation, "["}, {Plaintext, "a"}, {Punctuation, "]"},
+			{Punctuation, ","}, {Whitespace, " "}, {Comment, "/* more */"}, {Whitespace, " "}, {String, `"str"`}, {Punctuation, "."},
+		}},
+		{"datalog", ".decl edge(x: number, y: number)\npath(X, Y) :- edge(X, Y). // base", []token{
+			{Keyword, ".decl"}, {Whitespace, " "}, {Plaintext, "edge"}, {Punctuation, "("}, {Plaintext, "x"}, {Punctuation, ":"}, {Whitespace, " "},
+			{Keyword, "number"}, {Punctuation, ","}, {Whitespace, " "}, {Plaintext, "y"}, {Punctuation, ":"}, {Whitespace, " "}, {Keyword, "number"},
+			{Punctuation, ")"}, {Whitespace, "\n"},
+			{Plaintext, "path"}, {Punctuation, "("}, {Variable, "X"}, {Punctuation, ","}, {Whitespace, " "}, {Variable, "Y"}, {Punctuation, ")"},
+			{Whitespace, " "}, {Punctuation, ":-"}, {Whitespace, " "}, {Plaintext, "edge"}, {Punctuation, "("}, {Variable, "X"}, {Punctuation, ","},
+			{Whitespace, " "}, {Variable, "Y"}, {Punctuation, ")"}, {Punctuation, "."}, {Whitespace, " "}, {Comment, "// base"},
+		}},
+	}
+	for _, test := range tests {
+		for _, oneByte := range []bool{false, true} {
+			s := NewScanner([]byte(test.src))
+			if oneByte {
+				s = NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(test.src))))
+			}
+			s.UseLanguage(LookupLanguage(test.lang))
+			if got := scanAll(t, s); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%s %q:\nwant %v\ngot  %v", test.lang, test.src, test.want, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package syntaxhighlight
+
+import "bytes"
+
+// newDotenvLexer returns a Lexer for .env files, which highlights keys as
+// Variable, values as String and references to variables in them, such as
+// "${HOME}", as Variable.
+func newDotenvLexer() Lexer {
+	return newPropertiesLexer(true)
+}
+
+// newJavaPropertiesLexer returns a Lexer for Java properties files, which
+// highlights keys as Variable and values as String.
+func newJavaPropertiesLexer() Lexer {
+	return newPropertiesLexer(false)
+}
+
+// newPropertiesLexer returns a Lexer for "key=value" lines, following the
+// rules of .env files if dotenv is set and those of Java properties files
+// otherwise.
+func newPropertiesLexer(dotenv bool) Lexer {
+	continued := false // the line continues the value of the one before
+	return &lineLexer{lexLine: func(line []byte) []lexToken {
+		var toks lineTokens
+		i := blanks(line)
+		toks.add(i, Whitespace)
+		rest := line[i:]
+
+		switch {
+		case continued:
+			continued = !dotenv && endsWithBackslash(rest)
+			toks.add(len(rest), String)
+			return toks
+		case len(rest) > 0 && (rest[0] == '#' || !dotenv && rest[0] == '!'):
+			toks.add(len(rest), Comment)
+			return toks
+		case dotenv && hasPrefix(rest, "export") && len(rest) > len("export") && isBlank(rest[len("export")]):
+			toks.add(len("export"), Keyword)
+			n := blanks(rest[len("export"):])
+			toks.add(n, Whitespace)
+			rest = rest[len("export")+n:]
+		}
+
+		key := propertiesKey(rest, dotenv)
+		toks.add(key, Variable)
+		rest = rest[key:]
+		n := blanks(rest)
+		toks.add(n, Whitespace)
+		rest = rest[n:]
+		if len(rest) > 0 && (rest[0] == '=' || !dotenv && rest[0] == ':') {
+			toks.add(1, Punctuation)
+			n := blanks(rest[1:])
+			toks.add(n, Whitespace)
+			rest = rest[1+n:]
+		}
+
+		if !dotenv {
+			continued = endsWithBackslash(rest)
+			toks.add(len(rest), String)
+			return toks
+		}
+		dotenvValue(&toks, rest)
+		return toks
+	}}
+}
+
+// propertiesKey returns the length of the key at the start of line, which
+// ends at a separator or a blank.
+func propertiesKey(line []byte, dotenv bool) int {
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && !dotenv:
+			i++
+		case line[i] == '=' || !dotenv && line[i] == ':' || isBlank(line[i]):
+			return i
+		}
+	}
+	return len(line)
+}
+
+// dotenvValue adds the tokens of the value of a .env line. Values may be
+// quoted, and those that are not may be followed by a comment.
+func dotenvValue(toks *lineTokens, value []byte) {
+	quote := byte(0)
+	if len(value) > 0 && (value[0] == '"' || value[0] == '\'' || value[0] == '`') {
+		quote = value[0]
+	}
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch {
+		case quote != 0 && i > 0 && value[i] == quote && value[i-1] != '\\':
+			toks.add(i+1-start, String)
+			rest := value[i+1:]
+			n := blanks(rest)
+			toks.add(n, Whitespace)
+			toks.add(len(rest)-n, Comment)
+			return
+		case quote == 0 && value[i] == '#' && (i == 0 || isBlank(value[i-1])):
+			end := bytes.TrimRight(value[:i], " \t")
+			toks.add(len(end)-start, String)
+			toks.add(i-len(end), Whitespace)
+			toks.add(len(value)-i, Comment)
+			return
+		case quote != '\'' && value[i] == '$':
+			if n := variableReference(value[i:]); n > 0 {
+				toks.add(i-start, String)
+				toks.add(n, Variable)
+				start = i + n
+				i += n - 1
+			}
+		}
+	}
+	toks.add(len(value)-start, String)
+}
+
+// variableReference returns the length of the reference to a variable, such
+// as "$HOME" or "${HOME}", at the start of b, or 0 if there is none.
+func variableReference(b []byte) int {
+	if hasPrefix(b, "${") {
+		if i := bytes.IndexByte(b, '}'); i > 2 {
+			return i + 1
+		}
+		return 0
+	}
+	i := 1
+	for i < len(b) && (b[i] == '_' || 'a' <= lower(rune(b[i])) && lower(rune(b[i])) <= 'z' || i > 1 && isDecimal(rune(b[i]))) {
+		i++
+	}
+	if i == 1 {
+		return 0
+	}
+	return i
+}
+
+// endsWithBackslash reports whether line is continued on the next line, as
+// lines of Java properties files and shell commands are by an unescaped
+// backslash at their end.
+func endsWithBackslash(line []byte) bool {
+	line = bytes.TrimRight(line, "\r")
+	n := len(line) - len(bytes.TrimRight(line, "\\"))
+	return n%2 == 1
+}
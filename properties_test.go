@@ -0,0 +1,41 @@
+package syntaxhighlight
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPropertiesLexer(t *testing.T) {
+	tests := []struct {
+		lang, src string
+		want      []token
+	}{
+		{"dotenv", "# db\nexport DB_URL=\"postgres://${HOST}/db\"\nDEBUG=true # on\nKEY='$x'", []token{
+			{Comment, "# db"}, {Whitespace, "\n"},
+			{Keyword, "export"}, {Whitespace, " "}, {Variable, "DB_URL"}, {Punctuation, "="},
+			{String, `"postgres://`}, {Variable, "${HOST}"}, {String, `/db"`}, {Whitespace, "\n"},
+			{Variable, "DEBUG"}, {Punctuation, "="}, {String, "true"}, {Whitespace, " "}, {Comment, "# on"}, {Whitespace, "\n"},
+			{Variable, "KEY"}, {Punctuation, "="}, {String, "'$x'"},
+		}},
+		{"properties", "! note\napp.name : Demo\nlist = a, \\\n  b\nkey\\ with\\ spaces=1\n", []token{
+			{Comment, "! note"}, {Whitespace, "\n"},
+			{Variable, "app.name"}, {Whitespace, " "}, {Punctuation, ":"}, {Whitespace, " "}, {String, "Demo"}, {Whitespace, "\n"},
+			{Variable, "list"}, {Whitespace, " "}, {Punctuation, "="}, {Whitespace, " "}, {String, "a, \\"}, {Whitespace, "\n"},
+			{Whitespace, "  "}, {String, "b"}, {Whitespace, "\n"},
+			{Variable, `key\ with\ spaces`}, {Punctuation, "="}, {String, "1"}, {Whitespace, "\n"},
+		}},
+	}
+	for _, test := range tests {
+		s := NewScanner([]byte(test.src))
+		s.UseLanguage(LookupLanguage(test.lang))
+		if got := scanAll(t, s); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s %q:\nwant %v\ngot  %v", test.lang, test.src, test.want, got)
+		}
+	}
+
+	for name, want := range map[string]string{".env": "dotenv", "deploy/.env.production": "dotenv", "app.properties": "properties"} {
+		if got := DetectLanguage(name, nil); got != want {
+			t.Errorf("%s: want %q, got %q", name, want, got)
+		}
+	}
+}
@@ -0,0 +1,1583 @@
+package syntaxhighlight
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Scanner reads tokens from source code and classifies them by Kind. Its
+// interface follows bufio.Scanner: call Scan until it returns false, use
+// Bytes or Text and Kind to get the current token, and check Err afterwards.
+//
+// The scanner is an explicit state machine. Outside of tokens it is in
+// normal mode; when it meets the start of a string, character literal,
+// triple-quoted string, block comment or raw string it switches to the mode
+// for that construct, which scans up to its closing delimiter and returns to
+// normal mode. With SplitLines or Interpolate, a mode may also last over
+// several tokens, as do the modes for the tags and children of JSX elements.
+type Scanner struct {
+	sc        *bufio.Scanner
+	splitFunc bufio.SplitFunc
+
+	// reader and buf are reused by Reset.
+	reader *bytes.Reader
+	buf    []byte
+
+	state scanState
+	kind  Kind // kind of the current token
+	off   int  // offset of the next token
+	line  int  // number of newlines before the next token
+
+	lineStart int      // offset of the line of the next token
+	pos       Position // position of the current token
+
+	// midLine is set if a token other than whitespace has been scanned
+	// since the last newline.
+	midLine bool
+	// afterJump is set if the last token other than whitespace on the
+	// current line is a goto, break or continue.
+	afterJump bool
+	// afterOperand is set if the last token other than whitespace and
+	// comments ends an operand, such as an identifier or ")", after which
+	// "<" is a comparison rather than the start of a JSX element.
+	afterOperand bool
+	// afterInclude is set if the last token other than whitespace and
+	// comments is an #include or #import directive, after which "<" starts
+	// a header name.
+	afterInclude bool
+	// brackets is the number of square brackets the scanner is in, for
+	// languages with Selectors.
+	brackets int
+	// cssTag is the progress of the scanner through the tag of a template
+	// literal of CSS, for languages with CSSTemplates.
+	cssTag cssTagState
+
+	splitLines  bool
+	interpolate bool
+	embeddedSQL bool
+	plainText   bool
+	strict      bool
+	lang        *Language
+	lexer       Lexer // of lang, made on the first call to split
+	onToken     func(tok []byte, kind Kind) Kind
+
+	// With a budget, work counts the bytes split has looked at, and
+	// overBudget is set once it exceeds budget.
+	budget     int
+	onBudget   func(pos Position)
+	work       int
+	overBudget bool
+
+	// unterminated is set by the scan functions when they return a string
+	// that lacks its closing delimiter.
+	unterminated bool
+
+	// With Coalesce, the current token is merged, and ahead is set if the
+	// bufio.Scanner holds the token after it. mergeBare merges runs of
+	// Whitespace and Plaintext tokens, and groupPunct runs of Punctuation
+	// tokens, even without Coalesce.
+	coalesce   bool
+	mergeBare  bool
+	groupPunct bool
+	merged     []byte
+	mergedKind Kind
+	mergedPos  Position
+	ahead      bool
+
+	interpreter string
+
+	// sql is the lexer of the SQL statement in the current string literal,
+	// with EmbeddedSQL, of which sqlRest bytes are left before the closing
+	// delimiter of length sqlClose.
+	sql      Lexer
+	sqlRest  int
+	sqlClose int
+}
+
+// Position is a position in the source.
+type Position struct {
+	Offset int // byte offset, starting at 0
+	Line   int // line number, starting at 1
+	Column int // byte offset in the line, starting at 1
+}
+
+// String returns the position as "line:column".
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// scanMode is the mode of a Scanner.
+type scanMode uint8
+
+const (
+	modeNormal     scanMode = iota // between tokens
+	modeString                     // in a string or character literal
+	modeLongString                 // in a triple-quoted string
+	modeComment                    // in a block comment
+	modeRaw                        // in a raw string
+	modeJSXTag                     // in the start tag of a JSX element
+	modeJSXClose                   // in the end tag of a JSX element
+	modeJSXText                    // in the children of a JSX element
+)
+
+// scanState is the state a Scanner is in between two tokens.
+type scanState struct {
+	mode  scanMode
+	quote byte   // closing quote in modeString and modeLongString
+	kind  Kind   // kind of the tokens in modeLongString, modeComment and modeRaw
+	close string // closing delimiter in modeComment, if not "*/"
+
+	command bool // in modeNormal, the arguments of a command are next
+
+	fprefix bool           // in modeNormal, the next string is an f-string
+	fstring bool           // in modeString, the string is an f-string
+	interp  *interpolation // innermost interpolation the scanner is in
+
+	jsx     *jsxElement // in the JSX modes, the element of the tag or children
+	jsxName bool        // in modeJSXTag, the element name is next
+
+	css Lexer // in modeRaw, the lexer of the contents of a template of CSS
+}
+
+// end returns the state after the construct of st has been closed.
+func (st scanState) end() scanState {
+	return scanState{interp: st.interp}
+}
+
+// interpolation is an expression embedded in a string, such as "${x}",
+// which is scanned in normal mode. Interpolations form a stack through the
+// state of their enclosing strings.
+type interpolation struct {
+	str   scanState // state of the enclosing string after the closing brace
+	depth int       // number of braces opened in the expression
+}
+
+// jsxElement is a JSX element the scanner is in. Nested elements form a
+// stack through their parents, and the outermost one holds the state to
+// return to after it. Expressions in braces in tags and children are
+// interpolations whose enclosing state is that of the tag or children.
+type jsxElement struct {
+	parent *jsxElement
+	outer  scanState // state after the element, if it is the outermost
+}
+
+// end returns the state after element e has been closed.
+func (e *jsxElement) end() scanState {
+	if e.parent == nil {
+		return e.outer
+	}
+	return scanState{mode: modeJSXText, jsx: e.parent}
+}
+
+// atNewline returns the interpolations that remain open after a newline.
+// Strings other than raw strings end at newlines, and so do any
+// interpolations in them.
+func (in *interpolation) atNewline() *interpolation {
+	for in != nil && in.str.mode == modeString {
+		in = in.str.interp
+	}
+	return in
+}
+
+// NewScanner is a helper that takes a []byte src, wraps it in a reader and creates a Scanner.
+func NewScanner(src []byte) *Scanner {
+	return NewScannerReader(bytes.NewReader(src))
+}
+
+// NewScannerReader takes a reader src and creates a Scanner.
+func NewScannerReader(src io.Reader) *Scanner {
+	s := &Scanner{sc: bufio.NewScanner(src)}
+	s.splitFunc = s.split
+	s.sc.Buffer(nil, math.MaxInt32)
+	s.sc.Split(s.splitFunc)
+	return s
+}
+
+// Reset makes s scan src from the start, as a new Scanner would, so that a
+// Scanner can be reused for many sources. The buffers of s are reused, and
+// the options set on s, such as with SplitLines or UseLanguage, are kept.
+// Tokens of the previous source must not be used after Reset.
+func (s *Scanner) Reset(src []byte) {
+	if s.reader == nil {
+		s.reader = new(bytes.Reader)
+		s.buf = make([]byte, 4096)
+	}
+	s.reader.Reset(src)
+	s.sc = bufio.NewScanner(s.reader)
+	s.sc.Buffer(s.buf, math.MaxInt32)
+	s.sc.Split(s.splitFunc)
+
+	s.state = scanState{}
+	s.kind = 0
+	s.off, s.line, s.lineStart = 0, 0, 0
+	s.pos = Position{}
+	s.midLine, s.afterJump, s.afterOperand, s.afterInclude = false, false, false, false
+	s.brackets, s.cssTag = 0, cssTagNone
+	s.unterminated = false
+	s.work, s.overBudget = 0, false
+	s.merged, s.mergedKind, s.mergedPos, s.ahead = s.merged[:0], 0, Position{}, false
+	s.interpreter = ""
+	s.lexer = nil
+	s.sql, s.sqlRest, s.sqlClose = nil, 0, 0
+}
+
+// Scan advances the scanner to the next token, which is then available
+// through Bytes, Text and Kind. It returns false at the end of the input or
+// when an error occurs.
+func (s *Scanner) Scan() bool {
+	if s.merging() {
+		return s.scanMerged()
+	}
+	if s.sc.Scan() {
+		return true
+	}
+	s.pos = s.next()
+	return false
+}
+
+// scanMerged scans the next run of tokens that are to be merged and merges
+// them into the current token.
+func (s *Scanner) scanMerged() bool {
+	if !s.ahead && !s.sc.Scan() {
+		s.mergedPos = s.next()
+		return false
+	}
+	s.merged = append(s.merged[:0], s.sc.Bytes()...)
+	s.mergedKind, s.mergedPos, s.ahead = s.kind, s.pos, false
+	for s.sc.Scan() {
+		switch {
+		case s.mergeBare && isBare(s.kind) && isBare(s.mergedKind):
+			if s.kind == Plaintext {
+				s.mergedKind = Plaintext
+			}
+		case s.coalesce && s.kind == s.mergedKind:
+		case s.groupPunct && s.kind == Punctuation && s.mergedKind == Punctuation:
+		default:
+			s.ahead = true
+			return true
+		}
+		s.merged = append(s.merged, s.sc.Bytes()...)
+	}
+	return true
+}
+
+// merging reports whether the scanner merges tokens.
+func (s *Scanner) merging() bool {
+	return s.coalesce || s.mergeBare || s.groupPunct
+}
+
+// isBare reports whether tokens of kind are plain text that a mergeBare
+// scanner merges.
+func isBare(kind Kind) bool {
+	return kind == Whitespace || kind == Plaintext
+}
+
+// Pos returns the position of the current token. Once Scan has returned
+// false, it returns the position at which scanning stopped, which is the end
+// of the input or, if Err returns an error, the start of the first token
+// that could not be read.
+func (s *Scanner) Pos() Position {
+	if s.merging() {
+		return s.mergedPos
+	}
+	return s.pos
+}
+
+// next returns the position of the next token.
+func (s *Scanner) next() Position {
+	return Position{Offset: s.off, Line: s.line + 1, Column: s.off - s.lineStart + 1}
+}
+
+// Bytes returns the current token. The underlying array may be overwritten by
+// the next call to Scan.
+func (s *Scanner) Bytes() []byte {
+	if s.merging() {
+		return s.merged
+	}
+	return s.sc.Bytes()
+}
+
+// Text returns the current token as a string.
+func (s *Scanner) Text() string {
+	if s.merging() {
+		return string(s.merged)
+	}
+	return s.sc.Text()
+}
+
+// Kind returns the Kind of the current token.
+func (s *Scanner) Kind() Kind {
+	if s.merging() {
+		return s.mergedKind
+	}
+	return s.kind
+}
+
+// Err returns the first error that was encountered while reading the input.
+// Malformed source code is not an error; it is highlighted as well as
+// possible.
+func (s *Scanner) Err() error {
+	return s.sc.Err()
+}
+
+// Interpreter returns the name of the interpreter in the #! line of the
+// source, such as "python3" for "#!/usr/bin/env python3", or else the
+// major mode or file type named in an editor mode line, such as "ruby" for
+// "# -*- mode: ruby -*-". It returns "" if no such line has been scanned
+// yet.
+func (s *Scanner) Interpreter() string {
+	return s.interpreter
+}
+
+// SplitLines makes the scanner end tokens at newlines, and return each
+// newline as a Whitespace token of its own. Block comments and raw strings
+// that span several lines are then returned as one token per line, with the
+// scanner staying in comment or raw string mode in between, so that it never
+// has to buffer more than a line of input. SplitLines must be called before
+// the first call to Scan.
+func (s *Scanner) SplitLines() {
+	s.splitLines = true
+}
+
+// Interpolate makes the scanner highlight expressions embedded in strings,
+// such as "${x + 1}", "#{user.name}", f"{x!r}" or `${x}`, as code. The
+// string parts around them are returned as separate String tokens, and the
+// delimiters of the expression as Punctuation. Interpolate must be called
+// before the first call to Scan.
+func (s *Scanner) Interpolate() {
+	s.interpolate = true
+}
+
+// EmbeddedSQL makes the scanner highlight string literals that hold SQL
+// statements, such as "SELECT * FROM t WHERE id = ?", as SQL, with their
+// quotes as String. Only literals that are returned as a single token are
+// looked at, so with SplitLines, statements that span several lines are
+// left as strings. EmbeddedSQL must be called before the first call to
+// Scan.
+func (s *Scanner) EmbeddedSQL() {
+	s.embeddedSQL = true
+}
+
+// PlainText makes the scanner return the source without lexing it, as one
+// Plaintext token per line with each newline as a Whitespace token of its
+// own. PlainText must be called before the first call to Scan.
+func (s *Scanner) PlainText() {
+	s.plainText = true
+}
+
+// Coalesce makes the scanner merge consecutive tokens of the same kind, such
+// as the spaces and newlines between two lines of code or the characters of
+// "::", into one token, which makes for much smaller output. Coalesce must
+// be called before the first call to Scan.
+func (s *Scanner) Coalesce() {
+	s.coalesce = true
+}
+
+// GroupPunctuation makes the scanner merge runs of punctuation, such as
+// "});" or "=>", into one token. Unlike Coalesce, it leaves tokens of other
+// kinds alone. Minified code, which is mostly identifiers and punctuation,
+// then makes for much smaller output. GroupPunctuation must be called
+// before the first call to Scan.
+func (s *Scanner) GroupPunctuation() {
+	s.groupPunct = true
+}
+
+// Strict makes the scanner stop at the first string literal that is not
+// terminated, whether by the end of the line or of the input, with an
+// *ErrUnterminatedString error. Note that this includes lone apostrophes,
+// such as those of Rust lifetimes. Strict must be called before the first
+// call to Scan.
+func (s *Scanner) Strict() {
+	s.strict = true
+}
+
+// OnToken makes the scanner call f with every token and its kind, and
+// return the token with the kind f returns instead, so that tokens can be
+// reclassified with knowledge the scanner lacks, such as names of
+// deprecated functions. The scanner's own state is unaffected, so the
+// tokens that follow are scanned as before. With Coalesce, tokens are
+// merged by the kinds f returns. tok must not be retained or modified.
+// OnToken must be called before the first call to Scan.
+func (s *Scanner) OnToken(f func(tok []byte, kind Kind) Kind) {
+	s.onToken = f
+}
+
+// Budget limits the work of the scanner to looking at n bytes of input,
+// counting the bytes it looks at again when a token turns out to need more
+// input than it has buffered. Pathological sources, such as huge tokens or
+// long runs of escapes, can take much more work than their size suggests.
+// Once the budget is exceeded, the rest of the source is returned as with
+// PlainText, and f, if not nil, is called with the position of the next
+// token. Budget must be called before the first call to Scan.
+func (s *Scanner) Budget(n int, f func(pos Position)) {
+	s.budget, s.onBudget = n, f
+}
+
+// UseLanguage makes the scanner apply the rules of language l on top of its
+// language-independent ones. UseLanguage must be called before the first
+// call to Scan.
+func (s *Scanner) UseLanguage(l *Language) {
+	s.lang = l
+}
+
+// split is the bufio.SplitFunc of the scanner. It dispatches on the mode of
+// the scanner and only updates its state once a token is complete, as it is
+// called again with more data if a token may extend past the end of data.
+func (s *Scanner) split(data []byte, atEOF bool) (int, []byte, error) {
+	if len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	var (
+		n    int
+		kind Kind
+		next scanState
+	)
+	s.unterminated = false
+	if s.plainText || s.overBudget {
+		n, kind = scanLine(data, atEOF), Plaintext
+		if len(data) > 0 && data[0] == '\n' {
+			n, kind = 1, Whitespace
+		}
+	} else if s.sql != nil {
+		n, kind = s.scanSQL(data)
+		next = s.state
+	} else if s.lang != nil && s.lang.NewLexer != nil {
+		if s.lexer == nil {
+			s.lexer = s.lang.NewLexer()
+		}
+		n, kind = s.lexer.Lex(data, atEOF)
+	} else if s.state.mode == modeNormal {
+		n, kind, next = s.scanNormal(data, atEOF)
+	} else {
+		n, kind, next = s.scanRest(s.state, data, 0, atEOF)
+	}
+	if n == 0 {
+		// request more data, which means looking at data again
+		s.spend(len(data))
+		return 0, nil, nil
+	}
+	if s.unterminated && s.strict {
+		return 0, nil, &ErrUnterminatedString{Pos: s.next()}
+	}
+	if s.embeddedSQL && kind == String && !s.unterminated && s.sql == nil {
+		if open, close, ok := sqlString(data[:n]); ok {
+			// the quote, then the statement as SQL (see scanSQL)
+			s.sql = newLanguageLexer(LookupLanguage("sql"))
+			s.sqlRest, s.sqlClose = n-open-close, close
+			n = open
+		}
+	}
+
+	s.state, s.kind, s.pos = next, kind, s.next()
+	if i := bytes.LastIndexByte(data[:n], '\n'); i >= 0 {
+		s.line += bytes.Count(data[:n], []byte("\n"))
+		s.lineStart = s.off + i + 1
+	}
+	s.off += n
+	if data[n-1] == '\n' || kind == Whitespace && bytes.IndexByte(data[:n], '\n') >= 0 {
+		s.midLine = false
+	} else if kind != Whitespace {
+		s.midLine = true
+	}
+	if kind != Whitespace || !s.midLine {
+		s.afterJump = kind == Keyword && isJump(data[:n])
+	}
+	if kind != Whitespace && kind != Comment && kind != DocComment {
+		s.afterOperand = endsOperand(data[:n], kind)
+		s.afterInclude = kind == Keyword && isInclude(data[:n])
+		if s.lang != nil && s.lang.CSSTemplates {
+			s.cssTag = s.cssTag.next(data[:n])
+		}
+	}
+	if kind == Punctuation && s.lang != nil && s.lang.Selectors {
+		s.brackets += bytes.Count(data[:n], []byte("[")) - bytes.Count(data[:n], []byte("]"))
+		if s.brackets < 0 {
+			s.brackets = 0
+		}
+	}
+	if s.onToken != nil {
+		s.kind = s.onToken(data[:n], kind)
+	}
+	s.spend(n)
+	return n, data[:n], nil
+}
+
+// spend adds n bytes to the work of the scanner, and switches it to plain
+// text once its budget is exceeded.
+func (s *Scanner) spend(n int) {
+	if s.budget == 0 || s.overBudget {
+		return
+	}
+	s.work += n
+	if s.work > s.budget {
+		s.overBudget = true
+		if s.onBudget != nil {
+			s.onBudget(s.next())
+		}
+	}
+}
+
+// scanNormal scans a token starting in normal mode. If the token opens a
+// construct with a mode of its own, the rest of the token is scanned in that
+// mode. It returns 0 if more data is needed.
+func (s *Scanner) scanNormal(data []byte, atEOF bool) (int, Kind, scanState) {
+	normal := scanState{interp: s.state.interp}
+	if !atEOF && !utf8.FullRune(data) {
+		return 0, 0, normal
+	}
+	r, size := utf8.DecodeRune(data)
+
+	if !s.midLine && s.line < maxModeLine && !unicode.IsSpace(r) {
+		n := scanLine(data, atEOF)
+		if n == 0 {
+			return 0, 0, normal
+		}
+		if interp, ok := s.parseModeLine(data[:n]); ok {
+			if s.interpreter == "" {
+				s.interpreter = interp
+			}
+			return n, Shebang, normal
+		}
+	}
+
+	if s.state.command && !unicode.IsSpace(r) && bytes.IndexByte([]byte(";,%"), data[0]) < 0 {
+		n := commandArgs(data, atEOF)
+		if n == 0 {
+			return 0, 0, normal
+		}
+		return n, String, normal
+	}
+
+	if s.lang != nil && s.lang.BlockComments != nil {
+		for _, delims := range s.lang.BlockComments {
+			if len(data) < len(delims[0]) && !atEOF && hasPrefix([]byte(delims[0]), string(data)) {
+				return 0, 0, normal
+			}
+			if hasPrefix(data, delims[0]) {
+				st := scanState{mode: modeComment, kind: Comment, close: delims[1], interp: normal.interp}
+				return s.scanRest(st, data, len(delims[0]), atEOF)
+			}
+		}
+	}
+
+	if s.lang != nil && s.lang.LineComments != nil {
+		n, kind, ok := s.lang.lineComment(data, atEOF)
+		if !ok {
+			return 0, 0, normal
+		}
+		if n > 0 {
+			return n, kind, normal
+		}
+	}
+
+	if s.lang != nil && s.lang.LineContinuation != "" {
+		n, ok := s.lang.lineContinuation(data, atEOF)
+		if !ok {
+			return 0, 0, normal
+		}
+		if n > 0 {
+			return n, Punctuation, normal
+		}
+	}
+
+	if s.lang != nil && s.lang.StringPrefixes != nil {
+		n, ok := s.lang.stringPrefix(data, atEOF)
+		if !ok {
+			return 0, 0, normal
+		}
+		if n > 0 {
+			return n, String, normal
+		}
+	}
+
+	switch {
+	case r == '{' && normal.interp != nil:
+		in := *normal.interp
+		in.depth++
+		return 1, Punctuation, scanState{interp: &in}
+
+	case r == '}' && normal.interp != nil:
+		if normal.interp.depth == 0 {
+			// back to the enclosing string
+			return 1, Punctuation, normal.interp.str
+		}
+		in := *normal.interp
+		in.depth--
+		return 1, Punctuation, scanState{interp: &in}
+
+	case r == '\uFEFF' && s.off == 0:
+		// byte order mark
+		return size, Whitespace, normal
+
+	case r == '\n' && s.splitLines:
+		return 1, Whitespace, normal
+
+	case unicode.IsSpace(r):
+		isSpace := unicode.IsSpace
+		if s.splitLines {
+			isSpace = func(r rune) bool { return r != '\n' && unicode.IsSpace(r) }
+		}
+		n := scanRunes(data, atEOF, isSpace)
+		if normal.interp != nil && bytes.IndexByte(data[:n], '\n') >= 0 {
+			normal.interp = normal.interp.atNewline()
+		}
+		normal.command = s.state.command && bytes.IndexByte(data[:n], '\n') < 0
+		return n, Whitespace, normal
+
+	case r == '_' || unicode.IsLetter(r):
+		n := s.scanIdent(data, atEOF)
+		if n == 0 {
+			return 0, 0, normal
+		}
+		if s.lang != nil && s.lang.RegexLiterals && (string(data[:n]) == "rx" || string(data[:n]) == "m") {
+			if len(data) == n && !atEOF {
+				return 0, 0, normal
+			}
+			if len(data) > n && data[n] == '/' {
+				return n, Keyword, normal
+			}
+		}
+		if s.interpolate && isFPrefix(data[:n]) {
+			if len(data) == n && !atEOF {
+				return 0, 0, normal
+			}
+			if len(data) > n && (data[n] == '"' || data[n] == '\'') {
+				normal.fprefix = true
+				return n, String, normal
+			}
+		}
+		kind := s.identKind(data[:n])
+		if kind != Keyword && s.brackets > 0 {
+			if len(data) < n+2 && !atEOF {
+				return 0, 0, normal
+			}
+			if len(data) > n && data[n] == ':' && !hasPrefix(data[n:], "::") {
+				return n, Label, normal
+			}
+		}
+		if kind == Plaintext || kind == Type {
+			isNamespace, ok := isNamespace(data[n:], atEOF)
+			if !ok {
+				return 0, 0, normal
+			}
+			if isNamespace {
+				return n, Namespace, normal
+			}
+		}
+		if kind != Keyword && !s.midLine && s.lang != nil && s.lang.CommandSyntax {
+			isCommand, ok := isCommand(data[n:], atEOF)
+			if !ok {
+				return 0, 0, normal
+			}
+			if isCommand {
+				return n, kind, scanState{interp: normal.interp, command: true}
+			}
+		}
+		if kind != Keyword {
+			if s.afterJump {
+				kind = Label
+			} else if !s.midLine {
+				isLabel, ok := isLabelDef(data[n:], atEOF)
+				if !ok {
+					return 0, 0, normal
+				}
+				if isLabel {
+					kind = Label
+				}
+			}
+		}
+		return n, kind, normal
+
+	case isDecimal(r):
+		return scanNumber(data, 0, atEOF), Decimal, normal
+
+	case r == '@' && s.lang != nil && s.lang.Keywords != nil:
+		n := 0
+		if len(data) > 1 {
+			n = scanRunes(data[1:], atEOF, isIdentRune)
+		} else if !atEOF {
+			return 0, 0, normal
+		}
+		if n == 0 && len(data) > 1 && isIdentRune(rune(data[1])) && !atEOF {
+			return 0, 0, normal
+		}
+		if n > 0 && s.lang.isKeyword(data[:1+n]) {
+			return 1 + n, Keyword, normal
+		}
+		fallthrough
+
+	case s.isSigil(r):
+		n, ok := s.scanVariable(data, atEOF)
+		if !ok {
+			return 0, 0, normal
+		}
+		if n > 0 {
+			return n, Variable, normal
+		}
+
+	case r == ':' && s.lang != nil && s.lang.Symbols:
+		if len(data) < 2 && !atEOF {
+			return 0, 0, normal
+		}
+		if hasPrefix(data, "::") {
+			return 2, Punctuation, normal
+		}
+		if len(data) > 1 && !atEOF && !utf8.FullRune(data[1:]) {
+			return 0, 0, normal
+		}
+		if next, _ := utf8.DecodeRune(data[1:]); len(data) > 1 && (next == '_' || unicode.IsLetter(next)) && !s.touchesOperand() {
+			n := s.scanIdent(data[1:], atEOF)
+			if n == 0 && !atEOF {
+				return 0, 0, normal
+			}
+			// without an identifier, the colon is punctuation
+			if n > 0 {
+				n++
+				if n < len(data) && (data[n] == '?' || data[n] == '!') {
+					n++
+				} else if n == len(data) && !atEOF {
+					return 0, 0, normal
+				}
+				return n, Literal, normal
+			}
+		}
+
+	case r == '=' && !s.midLine && s.lang != nil && s.lang.Pod:
+		if len(data) < 2 && !atEOF {
+			return 0, 0, normal
+		}
+		if len(data) > 1 && unicode.IsLetter(rune(data[1])) {
+			n := scanLine(data, atEOF)
+			if n == 0 {
+				return 0, 0, normal
+			}
+			if f := bytes.Fields(data[:n]); len(f) > 1 && string(f[0]) == "=begin" {
+				st := scanState{mode: modeComment, kind: DocComment, close: "=end " + string(f[1]), interp: normal.interp}
+				return s.scanRest(st, data, n, atEOF)
+			}
+			return n, DocComment, normal
+		}
+
+	case r == '.':
+		if len(data) < 2 && !atEOF {
+			return 0, 0, normal
+		}
+		if len(data) > 1 && isDecimal(rune(data[1])) {
+			return scanNumber(data, 1, atEOF), Decimal, normal
+		}
+
+	case r == '#' && !s.midLine && s.lang != nil && s.lang.Preprocessor:
+		n := 1 + blanks(data[1:])
+		m := scanRunes(data[n:], atEOF, isIdentRune)
+		if m == 0 && (len(data) == n || isIdentRune(rune(data[n]))) && !atEOF {
+			return 0, 0, normal
+		}
+		if m > 0 {
+			return n + m, Keyword, normal
+		}
+
+	case r == '<' && s.afterInclude:
+		n := scanLine(data, atEOF)
+		if n == 0 && !atEOF {
+			return 0, 0, normal
+		}
+		if i := bytes.IndexByte(data[:n], '>'); i > 0 {
+			return i + 1, String, normal
+		}
+
+	case r == '/' || r == '#':
+		if len(data) < 4 && !atEOF {
+			return 0, 0, normal
+		}
+		slashes := s.lang == nil || s.lang.LineComments == nil
+		stars := s.lang == nil || s.lang.BlockComments == nil
+		switch {
+		case slashes && hasPrefix(data, "///") && !hasPrefix(data, "////"), hasPrefix(data, "#'"):
+			return scanLine(data, atEOF), DocComment, normal
+		case slashes && hasPrefix(data, "//"):
+			return scanLine(data, atEOF), Comment, normal
+		case stars && hasPrefix(data, "/**") && !hasPrefix(data, "/**/"):
+			return s.scanRest(scanState{mode: modeComment, kind: DocComment, interp: normal.interp}, data, 3, atEOF)
+		case stars && hasPrefix(data, "/*"):
+			return s.scanRest(scanState{mode: modeComment, kind: Comment, interp: normal.interp}, data, 2, atEOF)
+		}
+		if r == '/' && s.lang != nil && s.lang.RegexLiterals && !s.afterOperand {
+			return s.scanRest(scanState{mode: modeString, quote: '/', interp: normal.interp}, data, 1, atEOF)
+		}
+
+	case r == '\'' && s.lang != nil && s.lang.Ticks && s.touchesOperand():
+		return 1, Punctuation, normal
+
+	case r == '"' || r == '\'':
+		if len(data) < 3 && !atEOF {
+			return 0, 0, normal
+		}
+		if hasPrefix(data, strings.Repeat(string(r), 3)) && (s.lang == nil || !s.lang.DoubledQuotes) {
+			// triple-quoted strings in statement position are docstrings
+			kind := String
+			if !s.midLine {
+				kind = DocComment
+			}
+			return s.scanRest(scanState{mode: modeLongString, quote: byte(r), kind: kind, interp: normal.interp}, data, 3, atEOF)
+		}
+		st := scanState{mode: modeString, quote: byte(r), fstring: s.state.fprefix, interp: normal.interp}
+		return s.scanRest(st, data, 1, atEOF)
+
+	case r == '`':
+		st := scanState{mode: modeRaw, kind: String, interp: normal.interp}
+		if s.cssTag == cssTagDone {
+			st.css = newCSSLexer()
+			return 1, String, st
+		}
+		return s.scanRest(st, data, 1, atEOF)
+
+	case r == '<' && s.lang != nil && s.lang.JSX && !s.afterOperand:
+		if len(data) < 2 && !atEOF {
+			return 0, 0, normal
+		}
+		if len(data) > 1 && (data[1] == '>' || data[1] == '_' || data[1] == '$' || unicode.IsLetter(rune(data[1])) || data[1] >= utf8.RuneSelf) {
+			return 1, Punctuation, scanState{mode: modeJSXTag, jsxName: true, jsx: &jsxElement{outer: normal}}
+		}
+	}
+
+	return size, Punctuation, normal
+}
+
+// scanRest scans the rest of a token in mode st.mode, starting at data[i].
+// It returns the end of the token, its Kind and the state after it, or 0 if
+// more data is needed.
+func (s *Scanner) scanRest(st scanState, data []byte, i int, atEOF bool) (int, Kind, scanState) {
+	normal := st.end()
+	switch st.mode {
+	case modeString:
+		for ; i < len(data); i++ {
+			if s.interpolate {
+				n, ok := st.interpolationAt(data, i, atEOF)
+				if !ok {
+					return 0, 0, st
+				}
+				if n > 0 {
+					if i > 0 {
+						return i, String, st
+					}
+					return n, Punctuation, scanState{interp: &interpolation{str: st}}
+				}
+				if st.fstring && hasPrefix(data[i:], "{{") {
+					i++
+					continue
+				}
+				n, ok = s.templateVariable(st, data, i, atEOF)
+				if !ok {
+					return 0, 0, st
+				}
+				if n > 0 {
+					if i > 0 {
+						return i, String, st
+					}
+					return n, Variable, st
+				}
+			}
+			doubled := s.lang != nil && s.lang.DoubledQuotes
+			switch data[i] {
+			case st.quote:
+				if doubled {
+					if i+1 == len(data) && !atEOF {
+						return 0, 0, st
+					}
+					if i+1 < len(data) && data[i+1] == st.quote {
+						i++
+						continue
+					}
+				}
+				return i + 1, String, normal
+			case '\n':
+				// an unterminated literal ends at the end of the line
+				s.unterminated = true
+				if s.splitLines {
+					if i == 0 {
+						// the string resumes after an interpolation
+						return 1, Whitespace, normal
+					}
+					return i, String, normal
+				}
+				return i + 1, String, scanState{interp: st.interp.atNewline()}
+			case '\\':
+				if doubled {
+					break
+				}
+				if i+1 < len(data) {
+					if data[i+1] == '\n' {
+						if s.splitLines {
+							return i + 1, String, normal
+						}
+						return i + 2, String, scanState{interp: st.interp.atNewline()}
+					}
+					i++
+				} else if !atEOF {
+					return 0, 0, st
+				}
+			}
+		}
+		if !atEOF {
+			return 0, 0, st
+		}
+		s.unterminated = true
+		return len(data), String, normal
+
+	case modeLongString:
+		return s.scanDelimited(st, data, i, atEOF, bytes.Repeat([]byte{st.quote}, 3), true)
+
+	case modeComment:
+		end := "*/"
+		if st.close != "" {
+			end = st.close
+		}
+		return s.scanDelimited(st, data, i, atEOF, []byte(end), false)
+
+	case modeRaw:
+		if st.css != nil {
+			return s.scanCSS(st, data[i:], atEOF)
+		}
+		return s.scanDelimited(st, data, i, atEOF, []byte("`"), false)
+
+	case modeJSXTag, modeJSXClose, modeJSXText:
+		return s.scanJSX(st, data[i:], atEOF)
+	}
+	panic("unreachable")
+}
+
+// scanJSX scans a token in the tag or the children of a JSX element. Element
+// names are HTMLTag, or Type for components, whose names are capitalized or
+// qualified, attribute names HTMLAttrName and attribute strings
+// HTMLAttrValue. Expressions in braces are scanned in normal mode as
+// interpolations, and "<" in children starts a nested element.
+func (s *Scanner) scanJSX(st scanState, data []byte, atEOF bool) (int, Kind, scanState) {
+	if !atEOF && (!utf8.FullRune(data) || len(data) < 2) {
+		return 0, 0, st
+	}
+	r, size := utf8.DecodeRune(data)
+	switch {
+	case r == '\n' && s.splitLines:
+		return 1, Whitespace, st
+	case unicode.IsSpace(r):
+		isSpace := unicode.IsSpace
+		if s.splitLines {
+			isSpace = func(r rune) bool { return r != '\n' && unicode.IsSpace(r) }
+		}
+		return scanRunes(data, atEOF, isSpace), Whitespace, st
+	}
+
+	if st.mode == modeJSXText {
+		switch {
+		case hasPrefix(data, "</"):
+			return 2, Punctuation, scanState{mode: modeJSXClose, jsx: st.jsx}
+		case r == '<':
+			return 1, Punctuation, scanState{mode: modeJSXTag, jsxName: true, jsx: &jsxElement{parent: st.jsx}}
+		case r == '{':
+			return 1, Punctuation, scanState{interp: &interpolation{str: st}}
+		}
+		n := 0
+		for n < len(data) && data[n] != '<' && data[n] != '{' {
+			if !atEOF && !utf8.FullRune(data[n:]) {
+				return 0, 0, st
+			}
+			r, size := utf8.DecodeRune(data[n:])
+			if unicode.IsSpace(r) {
+				break
+			}
+			n += size
+		}
+		if n == len(data) && !atEOF {
+			return 0, 0, st
+		}
+		return n, Plaintext, st
+	}
+
+	switch {
+	case hasPrefix(data, "/>"):
+		return 2, Punctuation, st.jsx.end()
+	case r == '>' && st.mode == modeJSXClose:
+		return 1, Punctuation, st.jsx.end()
+	case r == '>':
+		return 1, Punctuation, scanState{mode: modeJSXText, jsx: st.jsx}
+	case r == '{':
+		return 1, Punctuation, scanState{interp: &interpolation{str: scanState{mode: st.mode, jsx: st.jsx}}}
+	case r == '"' || r == '\'':
+		i := bytes.IndexByte(data[1:], byte(r))
+		if i < 0 {
+			if !atEOF {
+				return 0, 0, st
+			}
+			s.unterminated = true
+			return len(data), HTMLAttrValue, st
+		}
+		return i + 2, HTMLAttrValue, st
+	case r == '_' || r == '$' || unicode.IsLetter(r):
+		n := scanRunes(data, atEOF, isJSXNameRune)
+		if n == 0 {
+			return 0, 0, st
+		}
+		kind := HTMLAttrName
+		if st.jsxName || st.mode == modeJSXClose {
+			kind = HTMLTag
+			if unicode.IsUpper(r) || bytes.IndexByte(data[:n], '.') >= 0 {
+				kind = Type
+			}
+		}
+		st.jsxName = false
+		return n, kind, st
+	}
+	return size, Punctuation, st
+}
+
+// isJSXNameRune reports whether r may be part of the name of a JSX element
+// or attribute, such as "Foo.Bar", "aria-label" or "xlink:href".
+func isJSXNameRune(r rune) bool {
+	return isIdentRune(r) || r == '$' || r == '-' || r == '.' || r == ':'
+}
+
+// endsOperand reports whether tok, a token of the given kind other than
+// whitespace or a comment, ends an operand of an expression.
+func endsOperand(tok []byte, kind Kind) bool {
+	switch kind {
+	case Keyword:
+		return false
+	case Punctuation:
+		c := tok[len(tok)-1]
+		return c == ')' || c == ']' || c == '}'
+	}
+	return true
+}
+
+// scanDelimited scans the rest of a token of kind st.kind up to and
+// including the closing delimiter end, starting at data[i]. If escapes is
+// set, a backslash escapes the following byte. If the scanner splits at
+// newlines, the token ends before the next newline instead, and the scanner
+// stays in mode st.mode.
+func (s *Scanner) scanDelimited(st scanState, data []byte, i int, atEOF bool, end []byte, escapes bool) (int, Kind, scanState) {
+	for ; i < len(data); i++ {
+		if s.interpolate && (st.mode == modeRaw || s.isTemplate(st)) {
+			n, ok := st.interpolationAt(data, i, atEOF)
+			if !ok {
+				return 0, 0, st
+			}
+			if n > 0 {
+				if i > 0 {
+					return i, st.kind, st
+				}
+				return n, Punctuation, scanState{interp: &interpolation{str: st}}
+			}
+			n, ok = s.templateVariable(st, data, i, atEOF)
+			if !ok {
+				return 0, 0, st
+			}
+			if n > 0 {
+				if i > 0 {
+					return i, st.kind, st
+				}
+				return n, Variable, st
+			}
+		}
+		switch {
+		case s.splitLines && data[i] == '\n':
+			if i == 0 {
+				return 1, Whitespace, st
+			}
+			return i, st.kind, st
+		case escapes && data[i] == '\\':
+			if i+1 < len(data) && data[i+1] != '\n' {
+				i++
+			}
+		case data[i] == end[0]:
+			if len(data)-i < len(end) && !atEOF {
+				return 0, 0, st
+			}
+			if bytes.HasPrefix(data[i:], end) {
+				return i + len(end), st.kind, st.end()
+			}
+		}
+	}
+	if !atEOF {
+		return 0, 0, st
+	}
+	s.unterminated = st.mode != modeComment
+	return len(data), st.kind, st.end()
+}
+
+// scanCSS scans a token of a template literal of CSS with state st: the
+// closing backtick, which is String, an interpolation, or else a token of
+// the CSS up to the next of them, as lexed by st.css. Without Interpolate,
+// interpolations are String as a whole.
+func (s *Scanner) scanCSS(st scanState, data []byte, atEOF bool) (int, Kind, scanState) {
+	switch {
+	case data[0] == '`':
+		return 1, String, st.end()
+	case hasPrefix(data, "${") && s.interpolate:
+		return 2, Punctuation, scanState{interp: &interpolation{str: st}}
+	case hasPrefix(data, "${"):
+		depth := 0
+		for i := 2; i < len(data); i++ {
+			switch data[i] {
+			case '{':
+				depth++
+			case '}':
+				if depth == 0 {
+					return i + 1, String, st
+				}
+				depth--
+			}
+		}
+		if !atEOF {
+			return 0, 0, st
+		}
+		s.unterminated = true
+		return len(data), String, st.end()
+	}
+
+	end := -1
+	for i := 0; i < len(data) && end < 0; i++ {
+		switch {
+		case data[i] == '\\':
+			i++
+		case data[i] == '`' || hasPrefix(data[i:], "${"):
+			end = i
+		}
+	}
+	if end < 0 {
+		if !atEOF {
+			return 0, 0, st
+		}
+		end = len(data)
+	}
+	n, kind := st.css.Lex(data[:end], true)
+	if end == len(data) && n == end && atEOF {
+		s.unterminated = true
+		return n, kind, st.end()
+	}
+	return n, kind, st
+}
+
+// isTemplate reports whether a string with state st is a template of a
+// language with DollarTemplates.
+func (s *Scanner) isTemplate(st scanState) bool {
+	return s.lang != nil && s.lang.DollarTemplates && st.quote == '"'
+}
+
+// templateVariable returns the length of the variable, such as "$name",
+// embedded at data[i] in a string with state st, or 0 if there is none or
+// the string is not a template. ok is false if more data is needed.
+func (s *Scanner) templateVariable(st scanState, data []byte, i int, atEOF bool) (n int, ok bool) {
+	if data[i] != '$' || !s.isTemplate(st) {
+		return 0, true
+	}
+	rest := data[i+1:]
+	if !atEOF && !utf8.FullRune(rest) {
+		return 0, false
+	}
+	if r, _ := utf8.DecodeRune(rest); r != '_' && !unicode.IsLetter(r) {
+		return 0, true
+	}
+	n = scanRunes(rest, atEOF, isIdentRune)
+	if n == 0 {
+		return 0, false
+	}
+	return 1 + n, true
+}
+
+// interpolationAt returns the length of the delimiter that opens an
+// interpolation at data[i] in a string with state st, or 0 if there is none.
+// Double-quoted and raw strings interpolate "${" and "#{", and f-strings
+// "{" unless it is doubled. ok is false if more data is needed.
+func (st scanState) interpolationAt(data []byte, i int, atEOF bool) (n int, ok bool) {
+	c := data[i]
+	if c != '{' && c != '$' && c != '#' {
+		return 0, true
+	}
+	if i+1 == len(data) {
+		return 0, atEOF
+	}
+	switch {
+	case st.fstring:
+		if c == '{' && data[i+1] != '{' {
+			return 1, true
+		}
+	case st.quote == '"' || st.mode == modeRaw:
+		if c != '{' && data[i+1] == '{' {
+			return 2, true
+		}
+	}
+	return 0, true
+}
+
+// isFPrefix reports whether ident is the prefix of a Python f-string.
+func isFPrefix(ident []byte) bool {
+	switch strings.ToLower(string(ident)) {
+	case "f", "rf", "fr":
+		return true
+	}
+	return false
+}
+
+// scanLine returns the end of the line comment at the start of data, which
+// does not include the newline, or 0 if more data is needed.
+func scanLine(data []byte, atEOF bool) int {
+	i := bytes.IndexByte(data, '\n')
+	if i < 0 {
+		if !atEOF {
+			return 0
+		}
+		i = len(data)
+	}
+	return i
+}
+
+func hasPrefix(data []byte, prefix string) bool {
+	return len(data) >= len(prefix) && string(data[:len(prefix)]) == prefix
+}
+
+// scanRunes returns the length of the run of runes at the start of data
+// that satisfy f, or 0 if more data is needed.
+func scanRunes(data []byte, atEOF bool, f func(rune) bool) int {
+	i := 0
+	for i < len(data) {
+		if !atEOF && !utf8.FullRune(data[i:]) {
+			return 0
+		}
+		r, size := utf8.DecodeRune(data[i:])
+		if !f(r) {
+			return i
+		}
+		i += size
+	}
+	if !atEOF {
+		return 0
+	}
+	return i
+}
+
+// scanNumber returns the end of the number starting at data[0], or 0 if more
+// data is needed. If i is 1, data[0] is a decimal point. Numbers are scanned
+// like Go number literals, but invalid ones are accepted as well.
+func scanNumber(data []byte, i int, atEOF bool) int {
+	short := false
+	peek := func(i int) rune {
+		if i >= len(data) {
+			short = true
+			return -1
+		}
+		return rune(data[i])
+	}
+	digits := func(i, base int) int {
+		for c := peek(i); c == '_' || isDecimal(c) || base == 16 && isHex(c); c = peek(i) {
+			i++
+		}
+		return i
+	}
+
+	base, seenDot := 10, i > 0
+	if !seenDot {
+		if data[0] == '0' {
+			i++
+			switch lower(peek(i)) {
+			case 'x':
+				i, base = i+1, 16
+			case 'o', 'b':
+				i++
+			}
+		}
+		i = digits(i, base)
+		if peek(i) == '.' {
+			i, seenDot = i+1, true
+		}
+	}
+	if seenDot {
+		i = digits(i, base)
+	}
+	if e := lower(peek(i)); e == 'e' || e == 'p' {
+		i++
+		if c := peek(i); c == '+' || c == '-' {
+			i++
+		}
+		i = digits(i, 10)
+	}
+
+	if short && !atEOF {
+		return 0
+	}
+	return i
+}
+
+// scanVariable returns the length of the sigil-prefixed variable, such as $x
+// or @ivar, at the start of data, or 0 if there is none. Variables with
+// the sigils $, @ and @@ are recognized, as well as $ followed by digits. An
+// @ at the start of a line that is followed by an identifier and then the end
+// of the line, "(" or "." is taken to be an annotation or decorator rather
+// than a variable. ok is false if more data is needed.
+func (s *Scanner) scanVariable(data []byte, atEOF bool) (n int, ok bool) {
+	if data[0] != '$' && data[0] != '@' && s.touchesOperand() {
+		// an operator, such as "%" in "a%b"
+		return 0, true
+	}
+	sigil := 1
+	if hasPrefix(data, "@@") {
+		sigil = 2
+	} else if s.lang != nil && s.lang.Twigils != "" {
+		if len(data) < 3 && !atEOF {
+			return 0, false
+		}
+		if len(data) > 2 && strings.IndexByte(s.lang.Twigils, data[1]) >= 0 && (data[2] == '_' || unicode.IsLetter(rune(data[2]))) {
+			sigil = 2
+		}
+	}
+	rest := data[sigil:]
+	if !atEOF && !utf8.FullRune(rest) {
+		return 0, false
+	}
+	r, _ := utf8.DecodeRune(rest)
+	switch {
+	case data[0] == '$' && isDecimal(r):
+		n = scanRunes(rest, atEOF, isDecimal)
+	case r == '_' || unicode.IsLetter(r):
+		n = s.scanIdent(rest, atEOF)
+	default:
+		return 0, true
+	}
+	if n == 0 {
+		return 0, false
+	}
+
+	if data[0] == '@' && !s.midLine {
+		end := bytes.IndexByte(rest[n:], '\n')
+		if end < 0 {
+			if !atEOF {
+				return 0, false
+			}
+			end = len(rest[n:])
+		}
+		line := bytes.TrimSpace(rest[n : n+end])
+		if len(line) == 0 || line[0] == '(' || line[0] == '.' {
+			return 0, true
+		}
+	}
+	return sigil + n, true
+}
+
+// isSigil reports whether r is a sigil of variables in the language of the
+// scanner.
+func (s *Scanner) isSigil(r rune) bool {
+	if s.lang == nil || s.lang.Sigils == "" {
+		return r == '$' || r == '@'
+	}
+	return strings.ContainsRune(s.lang.Sigils, r)
+}
+
+// touchesOperand reports whether the next token directly follows an
+// operand, with no space in between.
+func (s *Scanner) touchesOperand() bool {
+	return s.afterOperand && s.kind != Whitespace && s.kind != Comment
+}
+
+// scanIdent returns the length of the identifier at the start of data, or 0
+// if more data is needed. In languages with DashedIdentifiers, dashes
+// between letters are part of identifiers.
+func (s *Scanner) scanIdent(data []byte, atEOF bool) int {
+	n := scanRunes(data, atEOF, isIdentRune)
+	for n > 0 && s.lang != nil && s.lang.DashedIdentifiers && n < len(data) && data[n] == '-' {
+		if n+1 == len(data) {
+			if !atEOF {
+				return 0
+			}
+			break
+		}
+		if !atEOF && !utf8.FullRune(data[n+1:]) {
+			return 0
+		}
+		if r, _ := utf8.DecodeRune(data[n+1:]); r != '_' && !unicode.IsLetter(r) {
+			break
+		}
+		m := scanRunes(data[n+1:], atEOF, isIdentRune)
+		if m == 0 {
+			return 0
+		}
+		n += 1 + m
+	}
+	return n
+}
+
+// isLabelDef reports whether an identifier at the start of a line that is
+// followed by rest is a label definition, that is, whether it is followed by
+// a single colon and nothing but a comment on the rest of the line. Labels
+// followed by code on the same line are not recognized, as they cannot be
+// told apart from keys of object literals and the like. ok is false if more
+// data is needed.
+func isLabelDef(rest []byte, atEOF bool) (isLabel, ok bool) {
+	if len(rest) == 0 {
+		return false, atEOF
+	}
+	if rest[0] != ':' {
+		return false, true
+	}
+	end := bytes.IndexByte(rest, '\n')
+	if end < 0 {
+		if !atEOF {
+			return false, false
+		}
+		end = len(rest)
+	}
+	line := bytes.TrimSpace(rest[1:end])
+	if len(line) == 0 {
+		return true, true
+	}
+	for _, comment := range []string{"//", "/*", "#", ";"} {
+		if hasPrefix(line, comment) {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// isCommand reports whether an identifier at the start of a line that is
+// followed by rest is a command with arguments, as in "format long", that
+// is, whether it is followed by blanks and then a word, an option such as
+// "-all" or a quoted string rather than an operator. ok is false if more
+// data is needed.
+func isCommand(rest []byte, atEOF bool) (isCommand, ok bool) {
+	end := bytes.IndexByte(rest, '\n')
+	if end < 0 {
+		if !atEOF {
+			return false, false
+		}
+		end = len(rest)
+	}
+	line := rest[:end]
+	i := blanks(line)
+	if i == 0 || i == len(line) {
+		return false, true
+	}
+	switch c := line[i]; {
+	case c == '-':
+		return i+1 < len(line) && !isBlank(line[i+1]) && line[i+1] != '=', true
+	case c == '\'':
+		return true, true
+	}
+	r, _ := utf8.DecodeRune(line[i:])
+	return isIdentRune(r), true
+}
+
+// commandArgs returns the length of the arguments of a command at the start
+// of data, which run to the end of the statement, or 0 if more data is
+// needed.
+func commandArgs(data []byte, atEOF bool) int {
+	i := bytes.IndexAny(data, ";,%\n")
+	if i < 0 {
+		if !atEOF {
+			return 0
+		}
+		i = len(data)
+	}
+	return len(bytes.TrimRight(data[:i], " \t\r"))
+}
+
+// isNamespace reports whether an identifier followed by rest is the
+// namespace part of a qualified name. That is the case if it is followed by
+// "::", as in std::vector, or if it is followed by a chain of "." and
+// identifiers that ends with a capitalized identifier, as in fmt.Println or
+// java.util.List. Chains of lower-case identifiers such as obj.field are not
+// considered qualified names, as they are usually field or method accesses.
+// ok is false if more data is needed.
+func isNamespace(rest []byte, atEOF bool) (isNamespace, ok bool) {
+	if len(rest) < 2 && !atEOF {
+		return false, false
+	}
+	if hasPrefix(rest, "::") {
+		return true, true
+	}
+	for hasPrefix(rest, ".") {
+		rest = rest[1:]
+		if !atEOF && !utf8.FullRune(rest) {
+			return false, false
+		}
+		r, _ := utf8.DecodeRune(rest)
+		if r != '_' && !unicode.IsLetter(r) {
+			return false, true
+		}
+		n := scanRunes(rest, atEOF, isIdentRune)
+		if n == 0 {
+			return false, false
+		}
+		rest = rest[n:]
+		if len(rest) == 0 && !atEOF {
+			return false, false
+		}
+		if !hasPrefix(rest, ".") {
+			return unicode.IsUpper(r), true
+		}
+	}
+	return false, true
+}
+
+// isInclude reports whether the preprocessor directive tok includes a
+// header.
+func isInclude(tok []byte) bool {
+	if tok[0] != '#' {
+		return false
+	}
+	switch string(bytes.TrimLeft(tok[1:], " \t")) {
+	case "include", "include_next", "import":
+		return true
+	}
+	return false
+}
+
+func isJump(keyword []byte) bool {
+	switch string(keyword) {
+	case "goto", "break", "continue":
+		return true
+	}
+	return false
+}
+
+// identKind returns the Kind of the identifier ident according to the
+// rules of the language of the scanner.
+func (s *Scanner) identKind(ident []byte) Kind {
+	switch l := s.lang; {
+	case l == nil:
+	case l.isKeyword(ident):
+		return Keyword
+	case l.isType(ident):
+		return Type
+	case l.CaseInsensitive:
+		return Plaintext
+	}
+	return identKind(ident)
+}
+
+// identKind returns the Kind of the identifier ident.
+func identKind(ident []byte) Kind {
+	if _, isKW := keywords[string(ident)]; isKW {
+		return Keyword
+	}
+	if r, _ := utf8.DecodeRune(ident); unicode.IsUpper(r) {
+		return Type
+	}
+	return Plaintext
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isDecimal(r rune) bool { return '0' <= r && r <= '9' }
+
+func isHex(r rune) bool {
+	return '0' <= r && r <= '9' || 'a' <= lower(r) && lower(r) <= 'f'
+}
+
+func lower(r rune) rune { return ('a' - 'A') | r }
@@ -0,0 +1,811 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+type token struct {
+	kind Kind
+	text string
+}
+
+func scanAll(t *testing.T, s *Scanner) []token {
+	var toks []token
+	for s.Scan() {
+		toks = append(toks, token{s.Kind(), s.Text()})
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return toks
+}
+
+func testScanner(t *testing.T, tests map[string][]token) {
+	for src, want := range tests {
+		got := scanAll(t, NewScanner([]byte(src)))
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %v, got %v", src, want, got)
+		}
+
+		// tokens must not depend on how the input is read
+		got = scanAll(t, NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(src)))))
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q (one byte reads): want %v, got %v", src, want, got)
+		}
+	}
+}
+
+func TestScannerNormalMode(t *testing.T) {
+	testScanner(t, map[string][]token{
+		"func Foo(_x1)": {
+			{Keyword, "func"}, {Whitespace, " "}, {Type, "Foo"}, {Punctuation, "("},
+			{Plaintext, "_x1"}, {Punctuation, ")"},
+		},
+		"αβ \t\n x": {{Plaintext, "αβ"}, {Whitespace, " \t\n "}, {Plaintext, "x"}},
+		"1.5e10 0x1F 1_000 .5 1. 0b101 0x1p-2 1e": {
+			{Decimal, "1.5e10"}, {Whitespace, " "}, {Decimal, "0x1F"}, {Whitespace, " "},
+			{Decimal, "1_000"}, {Whitespace, " "}, {Decimal, ".5"}, {Whitespace, " "},
+			{Decimal, "1."}, {Whitespace, " "}, {Decimal, "0b101"}, {Whitespace, " "},
+			{Decimal, "0x1p-2"}, {Whitespace, " "}, {Decimal, "1e"},
+		},
+		"1.2.3 x..y": {
+			{Decimal, "1.2"}, {Decimal, ".3"}, {Whitespace, " "},
+			{Plaintext, "x"}, {Punctuation, "."}, {Punctuation, "."}, {Plaintext, "y"},
+		},
+		"a/b // c\n": {
+			{Plaintext, "a"}, {Punctuation, "/"}, {Plaintext, "b"}, {Whitespace, " "},
+			{Comment, "// c"}, {Whitespace, "\n"},
+		},
+		"\xff\xe2\x82": {{Punctuation, "\xff"}, {Punctuation, "\xe2"}, {Punctuation, "\x82"}},
+		"\uFEFFx":      {{Whitespace, "\uFEFF"}, {Plaintext, "x"}},
+	})
+}
+
+func TestScannerStringMode(t *testing.T) {
+	testScanner(t, map[string][]token{
+		`"a\"b" 'c'`:    {{String, `"a\"b"`}, {Whitespace, " "}, {String, "'c'"}},
+		`'it''s'`:       {{String, "'it'"}, {String, "'s'"}},
+		`"\\" x`:        {{String, `"\\"`}, {Whitespace, " "}, {Plaintext, "x"}},
+		"\"open\nx":     {{String, "\"open\n"}, {Plaintext, "x"}},
+		"\"a\\\nb\"":    {{String, "\"a\\\n"}, {Plaintext, "b"}, {String, `"`}},
+		`"unterminated`: {{String, `"unterminated`}},
+		`"\`:            {{String, `"\`}},
+	})
+}
+
+func TestScannerCommentMode(t *testing.T) {
+	testScanner(t, map[string][]token{
+		"/* a\n * b */x": {{Comment, "/* a\n * b */"}, {Plaintext, "x"}},
+		"/*/ x */":       {{Comment, "/*/ x */"}},
+		"/**/":           {{Comment, "/**/"}},
+		"/* open":        {{Comment, "/* open"}},
+	})
+}
+
+func TestScannerRawMode(t *testing.T) {
+	testScanner(t, map[string][]token{
+		"`a\\`b`": {{String, "`a\\`"}, {Plaintext, "b"}, {String, "`"}},
+		"`a\nb`":  {{String, "`a\nb`"}},
+		"`open":   {{String, "`open"}},
+	})
+}
+
+func TestScannerSplitLines(t *testing.T) {
+	tests := map[string][]token{
+		"/* a\n\n * b */x": {
+			{Comment, "/* a"}, {Whitespace, "\n"}, {Whitespace, "\n"}, {Comment, " * b */"}, {Plaintext, "x"},
+		},
+		"/*\n*/": {{Comment, "/*"}, {Whitespace, "\n"}, {Comment, "*/"}},
+		"`a\nb` \n\t\"c\nd": {
+			{String, "`a"}, {Whitespace, "\n"}, {String, "b`"}, {Whitespace, " "}, {Whitespace, "\n"},
+			{Whitespace, "\t"}, {String, `"c`}, {Whitespace, "\n"}, {Plaintext, "d"},
+		},
+	}
+	for src, want := range tests {
+		s := NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(src))))
+		s.SplitLines()
+		if got := scanAll(t, s); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %v, got %v", src, want, got)
+		}
+	}
+
+	// a string that ends at a newline right after an interpolation
+	for _, src := range []string{"\"${}\nx := 1\n", "\"a${b}\n\"${c}d\ny"} {
+		s := NewScanner([]byte(src))
+		s.Interpolate()
+		s.SplitLines()
+		var text strings.Builder
+		for _, tok := range scanAll(t, s) {
+			text.WriteString(tok.text)
+		}
+		if text.String() != src {
+			t.Errorf("%q: tokens add up to %q", src, text.String())
+		}
+	}
+}
+
+func TestScannerPos(t *testing.T) {
+	s := NewScannerReader(io.MultiReader(strings.NewReader("ab /* c\nd */ é\nf"), iotest.ErrReader(errors.New("boom"))))
+	var got []string
+	for s.Scan() {
+		got = append(got, fmt.Sprintf("%s@%d", s.Text(), s.Pos().Offset))
+		got = append(got, s.Pos().String())
+	}
+	want := []string{
+		"ab@0", "1:1", " @2", "1:3", "/* c\nd */@3", "1:4", " @12", "2:5", "é@13", "2:6", "\n@15", "2:8", "f@16", "3:1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if s.Err() == nil {
+		t.Fatal("want error")
+	}
+	if want := (Position{Offset: 17, Line: 3, Column: 2}); s.Pos() != want {
+		t.Errorf("want final position %+v, got %+v", want, s.Pos())
+	}
+}
+
+func TestScannerReset(t *testing.T) {
+	srcs := []string{
+		"#!/bin/sh\necho 'a' # b\n",
+		"/* unterminated\n",
+		"x := \"${y}\"",
+		"",
+		"a::b()",
+	}
+	s := NewScanner([]byte("/* left in a comment"))
+	s.Interpolate()
+	s.Scan()
+	for _, src := range srcs {
+		s.Reset([]byte(src))
+		got := scanAll(t, s)
+
+		fresh := NewScanner([]byte(src))
+		fresh.Interpolate()
+		if want := scanAll(t, fresh); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %v, got %v", src, want, got)
+		}
+		if s.Interpreter() != fresh.Interpreter() {
+			t.Errorf("%q: want interpreter %q, got %q", src, fresh.Interpreter(), s.Interpreter())
+		}
+	}
+
+	src := []byte("func f() { return 1 }\n")
+	newAllocs := testing.AllocsPerRun(100, func() {
+		s := NewScanner(src)
+		for s.Scan() {
+		}
+	})
+	resetAllocs := testing.AllocsPerRun(100, func() {
+		s.Reset(src)
+		for s.Scan() {
+		}
+	})
+	if resetAllocs >= newAllocs {
+		t.Errorf("Reset allocates as much as NewScanner: %v >= %v allocations", resetAllocs, newAllocs)
+	}
+}
+
+func TestScannerOnToken(t *testing.T) {
+	deprecated := func(tok []byte, kind Kind) Kind {
+		if kind == Type && (string(tok) == "ReadAll" || string(tok) == "ReadFile") {
+			return Label
+		}
+		return kind
+	}
+
+	s := NewScanner([]byte("ioutil.ReadAll(r) // ReadAll"))
+	s.OnToken(deprecated)
+	want := []token{
+		{Namespace, "ioutil"}, {Punctuation, "."}, {Label, "ReadAll"}, {Punctuation, "("}, {Plaintext, "r"},
+		{Punctuation, ")"}, {Whitespace, " "}, {Comment, "// ReadAll"},
+	}
+	if got := scanAll(t, s); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	// coalescing uses the new kinds
+	s = NewScanner([]byte("ReadAll ReadFile"))
+	s.OnToken(func(tok []byte, kind Kind) Kind {
+		if kind == Whitespace {
+			return Label
+		}
+		return deprecated(tok, kind)
+	})
+	s.Coalesce()
+	want = []token{{Label, "ReadAll ReadFile"}}
+	if got := scanAll(t, s); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestScannerCoalesce(t *testing.T) {
+	tests := map[string][]token{
+		"a::b();  \n\n  // c\n// d": {
+			{Namespace, "a"}, {Punctuation, "::"}, {Plaintext, "b"}, {Punctuation, "();"}, {Whitespace, "  \n\n  "},
+			{Comment, "// c"}, {Whitespace, "\n"}, {Comment, "// d"},
+		},
+		"x": {{Plaintext, "x"}},
+		"":  nil,
+	}
+	for src, want := range tests {
+		for _, oneByte := range []bool{false, true} {
+			s := NewScanner([]byte(src))
+			if oneByte {
+				s = NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(src))))
+			}
+			s.Coalesce()
+			if got := scanAll(t, s); !reflect.DeepEqual(got, want) {
+				t.Errorf("%q: want %v, got %v", src, want, got)
+			}
+		}
+	}
+
+	s := NewScanner([]byte("a\n();"))
+	s.Coalesce()
+	var pos []Position
+	for s.Scan() {
+		pos = append(pos, s.Pos())
+	}
+	pos = append(pos, s.Pos())
+	want := []Position{{0, 1, 1}, {1, 1, 2}, {2, 2, 1}, {5, 2, 4}}
+	if !reflect.DeepEqual(pos, want) {
+		t.Errorf("want positions %v, got %v", want, pos)
+	}
+}
+
+func TestScannerPlainText(t *testing.T) {
+	src := "a /* b\n\nc */ \"d"
+	want := []token{{Plaintext, "a /* b"}, {Whitespace, "\n"}, {Whitespace, "\n"}, {Plaintext, "c */ \"d"}}
+	s := NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(src))))
+	s.PlainText()
+	if got := scanAll(t, s); !reflect.DeepEqual(got, want) {
+		t.Errorf("%q: want %v, got %v", src, want, got)
+	}
+}
+
+func TestScannerDocComments(t *testing.T) {
+	testScanner(t, map[string][]token{
+		"/// doc\n// c\n//// c": {
+			{DocComment, "/// doc"}, {Whitespace, "\n"}, {Comment, "// c"}, {Whitespace, "\n"}, {Comment, "//// c"},
+		},
+		"/** doc */ /**/ /* c */": {
+			{DocComment, "/** doc */"}, {Whitespace, " "}, {Comment, "/**/"}, {Whitespace, " "}, {Comment, "/* c */"},
+		},
+		"#' @param x\n": {{DocComment, "#' @param x"}, {Whitespace, "\n"}},
+		"def f():\n    \"\"\"Doc \\\"\"\" \"quoted\".\"\"\"\n": {
+			{Keyword, "def"}, {Whitespace, " "}, {Plaintext, "f"}, {Punctuation, "("}, {Punctuation, ")"},
+			{Punctuation, ":"}, {Whitespace, "\n    "}, {DocComment, `"""Doc \""" "quoted"."""`}, {Whitespace, "\n"},
+		},
+		"x = '''a\nb'''": {
+			{Plaintext, "x"}, {Whitespace, " "}, {Punctuation, "="}, {Whitespace, " "}, {String, "'''a\nb'''"},
+		},
+		`"" ''`: {{String, `""`}, {Whitespace, " "}, {String, "''"}},
+	})
+}
+
+func TestScannerLabels(t *testing.T) {
+	testScanner(t, map[string][]token{
+		"Loop: // outer\n\tbreak Loop": {
+			{Label, "Loop"}, {Punctuation, ":"}, {Whitespace, " "}, {Comment, "// outer"}, {Whitespace, "\n\t"},
+			{Keyword, "break"}, {Whitespace, " "}, {Label, "Loop"},
+		},
+		"err:\n\tgoto err;": {
+			{Label, "err"}, {Punctuation, ":"}, {Whitespace, "\n\t"},
+			{Keyword, "goto"}, {Whitespace, " "}, {Label, "err"}, {Punctuation, ";"},
+		},
+		"default:\nfoo: 1,\nx := 1": {
+			{Keyword, "default"}, {Punctuation, ":"}, {Whitespace, "\n"},
+			{Plaintext, "foo"}, {Punctuation, ":"}, {Whitespace, " "}, {Decimal, "1"}, {Punctuation, ","}, {Whitespace, "\n"},
+			{Plaintext, "x"}, {Whitespace, " "}, {Punctuation, ":"}, {Punctuation, "="}, {Whitespace, " "}, {Decimal, "1"},
+		},
+		"break\nx": {{Keyword, "break"}, {Whitespace, "\n"}, {Plaintext, "x"}},
+	})
+}
+
+func TestScannerNamespaces(t *testing.T) {
+	testScanner(t, map[string][]token{
+		"fmt.Println": {{Namespace, "fmt"}, {Punctuation, "."}, {Type, "Println"}},
+		"std::vector": {{Namespace, "std"}, {Punctuation, ":"}, {Punctuation, ":"}, {Plaintext, "vector"}},
+		"java.util.List": {
+			{Namespace, "java"}, {Punctuation, "."}, {Namespace, "util"}, {Punctuation, "."}, {Type, "List"},
+		},
+		"obj.field.Method": {
+			{Namespace, "obj"}, {Punctuation, "."}, {Namespace, "field"}, {Punctuation, "."}, {Type, "Method"},
+		},
+		"obj.method()": {{Plaintext, "obj"}, {Punctuation, "."}, {Plaintext, "method"}, {Punctuation, "("}, {Punctuation, ")"}},
+		"this.Foo":     {{Keyword, "this"}, {Punctuation, "."}, {Type, "Foo"}},
+		"a. B":         {{Plaintext, "a"}, {Punctuation, "."}, {Whitespace, " "}, {Type, "B"}},
+	})
+}
+
+func TestScannerVariables(t *testing.T) {
+	testScanner(t, map[string][]token{
+		"echo $HOME $1": {
+			{Plaintext, "echo"}, {Whitespace, " "}, {Variable, "$HOME"}, {Whitespace, " "}, {Variable, "$1"},
+		},
+		"@name = name; @@count += 1": {
+			{Variable, "@name"}, {Whitespace, " "}, {Punctuation, "="}, {Whitespace, " "}, {Plaintext, "name"},
+			{Punctuation, ";"}, {Whitespace, " "}, {Variable, "@@count"}, {Whitespace, " "},
+			{Punctuation, "+"}, {Punctuation, "="}, {Whitespace, " "}, {Decimal, "1"},
+		},
+		"@Override\n@app.route('/')": {
+			{Punctuation, "@"}, {Type, "Override"}, {Whitespace, "\n"},
+			{Punctuation, "@"}, {Plaintext, "app"}, {Punctuation, "."}, {Plaintext, "route"},
+			{Punctuation, "("}, {String, "'/'"}, {Punctuation, ")"},
+		},
+		"$('#x') $ @": {
+			{Punctuation, "$"}, {Punctuation, "("}, {String, "'#x'"}, {Punctuation, ")"},
+			{Whitespace, " "}, {Punctuation, "$"}, {Whitespace, " "}, {Punctuation, "@"},
+		},
+	})
+}
+
+func TestScannerInterpolate(t *testing.T) {
+	tests := map[string][]token{
+		`"a${x+1}b" '${y}'`: {
+			{String, `"a`}, {Punctuation, "${"}, {Plaintext, "x"}, {Punctuation, "+"}, {Decimal, "1"},
+			{Punctuation, "}"}, {String, `b"`}, {Whitespace, " "}, {String, "'${y}'"},
+		},
+		`"#{user.name} #{h({a: 1})}"`: {
+			{String, `"`}, {Punctuation, "#{"}, {Plaintext, "user"}, {Punctuation, "."}, {Plaintext, "name"},
+			{Punctuation, "}"}, {String, " "}, {Punctuation, "#{"}, {Plaintext, "h"}, {Punctuation, "("},
+			{Punctuation, "{"}, {Plaintext, "a"}, {Punctuation, ":"}, {Whitespace, " "}, {Decimal, "1"},
+			{Punctuation, "}"}, {Punctuation, ")"}, {Punctuation, "}"}, {String, `"`},
+		},
+		`f"{x!r} {{y}}" "\${z}"`: {
+			{String, "f"}, {String, `"`}, {Punctuation, "{"}, {Plaintext, "x"}, {Punctuation, "!"},
+			{Plaintext, "r"}, {Punctuation, "}"}, {String, ` {{y}}"`}, {Whitespace, " "}, {String, `"\${z}"`},
+		},
+		"`a ${`b ${c}`}\nd`": {
+			{String, "`a "}, {Punctuation, "${"}, {String, "`b "}, {Punctuation, "${"}, {Plaintext, "c"},
+			{Punctuation, "}"}, {String, "`"}, {Punctuation, "}"}, {String, "\nd`"},
+		},
+		"\"${x\ny}": {
+			{String, `"`}, {Punctuation, "${"}, {Plaintext, "x"}, {Whitespace, "\n"}, {Plaintext, "y"}, {Punctuation, "}"},
+		},
+	}
+	for src, want := range tests {
+		s := NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(src))))
+		s.Interpolate()
+		if got := scanAll(t, s); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %v, got %v", src, want, got)
+		}
+	}
+}
+
+func TestScannerJSX(t *testing.T) {
+	tests := map[string][]token{
+		`return <Foo.Bar a="1" {...p}>hi {x > 0 && <b/>}</Foo.Bar>`: {
+			{Keyword, "return"}, {Whitespace, " "}, {Punctuation, "<"}, {Type, "Foo.Bar"}, {Whitespace, " "},
+			{HTMLAttrName, "a"}, {Punctuation, "="}, {HTMLAttrValue, `"1"`}, {Whitespace, " "},
+			{Punctuation, "{"}, {Punctuation, "."}, {Punctuation, "."}, {Punctuation, "."}, {Plaintext, "p"}, {Punctuation, "}"},
+			{Punctuation, ">"}, {Plaintext, "hi"}, {Whitespace, " "},
+			{Punctuation, "{"}, {Plaintext, "x"}, {Whitespace, " "}, {Punctuation, ">"}, {Whitespace, " "}, {Decimal, "0"},
+			{Whitespace, " "}, {Punctuation, "&"}, {Punctuation, "&"}, {Whitespace, " "},
+			{Punctuation, "<"}, {HTMLTag, "b"}, {Punctuation, "/>"}, {Punctuation, "}"},
+			{Punctuation, "</"}, {Type, "Foo.Bar"}, {Punctuation, ">"},
+		},
+		"x = <>\n  <p onClick={() => f(a < b)}>ok</p>\n</>;": {
+			{Plaintext, "x"}, {Whitespace, " "}, {Punctuation, "="}, {Whitespace, " "}, {Punctuation, "<"}, {Punctuation, ">"},
+			{Whitespace, "\n  "}, {Punctuation, "<"}, {HTMLTag, "p"}, {Whitespace, " "}, {HTMLAttrName, "onClick"}, {Punctuation, "="},
+			{Punctuation, "{"}, {Punctuation, "("}, {Punctuation, ")"}, {Whitespace, " "}, {Punctuation, "="}, {Punctuation, ">"},
+			{Whitespace, " "}, {Plaintext, "f"}, {Punctuation, "("}, {Plaintext, "a"}, {Whitespace, " "}, {Punctuation, "<"},
+			{Whitespace, " "}, {Plaintext, "b"}, {Punctuation, ")"}, {Punctuation, "}"}, {Punctuation, ">"},
+			{Plaintext, "ok"}, {Punctuation, "</"}, {HTMLTag, "p"}, {Punctuation, ">"}, {Whitespace, "\n"},
+			{Punctuation, "</"}, {Punctuation, ">"}, {Punctuation, ";"},
+		},
+		"if (a <b) {}": {
+			{Keyword, "if"}, {Whitespace, " "}, {Punctuation, "("}, {Plaintext, "a"}, {Whitespace, " "}, {Punctuation, "<"},
+			{Plaintext, "b"}, {Punctuation, ")"}, {Whitespace, " "}, {Punctuation, "{"}, {Punctuation, "}"},
+		},
+	}
+	for src, want := range tests {
+		s := NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(src))))
+		s.UseLanguage(LookupLanguage("jsx"))
+		if got := scanAll(t, s); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %v, got %v", src, want, got)
+		}
+	}
+
+	// text with runes whose UTF-8 encoding has bytes that are spaces in
+	// Latin-1, such as 0x85 in "ą" and 0xA0 in a non-breaking space
+	src := "const x = <p>Będą\u00a0ok</p>;\nfoo();\n"
+	for _, oneByte := range []bool{false, true} {
+		s := NewScanner([]byte(src))
+		if oneByte {
+			s = NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(src))))
+		}
+		s.UseLanguage(LookupLanguage("jsx"))
+		var text strings.Builder
+		toks := scanAll(t, s)
+		for _, tok := range toks {
+			text.WriteString(tok.text)
+		}
+		if text.String() != src {
+			t.Errorf("%q: tokens add up to %q", src, text.String())
+		}
+		if !reflect.DeepEqual(toks[9:12], []token{{Plaintext, "Będą"}, {Whitespace, "\u00a0"}, {Plaintext, "ok"}}) {
+			t.Errorf("%q: got text %v", src, toks[9:12])
+		}
+	}
+}
+
+func TestScannerObjectiveC(t *testing.T) {
+	tests := map[string][]token{
+		"#import <Foundation/Foundation.h>\n@interface Foo : NSObject\n@end\n": {
+			{Keyword, "#import"}, {Whitespace, " "}, {String, "<Foundation/Foundation.h>"}, {Whitespace, "\n"},
+			{Keyword, "@interface"}, {Whitespace, " "}, {Type, "Foo"}, {Whitespace, " "}, {Punctuation, ":"}, {Whitespace, " "},
+			{Type, "NSObject"}, {Whitespace, "\n"}, {Keyword, "@end"}, {Whitespace, "\n"},
+		},
+		`[dict setObject:@"v" forKey:k];`: {
+			{Punctuation, "["}, {Plaintext, "dict"}, {Whitespace, " "}, {Label, "setObject"}, {Punctuation, ":"},
+			{String, "@"}, {String, `"v"`}, {Whitespace, " "}, {Label, "forKey"}, {Punctuation, ":"}, {Plaintext, "k"},
+			{Punctuation, "]"}, {Punctuation, ";"},
+		},
+		"a ? b:c; x[i] = YES;": {
+			{Plaintext, "a"}, {Whitespace, " "}, {Punctuation, "?"}, {Whitespace, " "}, {Plaintext, "b"}, {Punctuation, ":"},
+			{Plaintext, "c"}, {Punctuation, ";"}, {Whitespace, " "}, {Plaintext, "x"}, {Punctuation, "["}, {Plaintext, "i"},
+			{Punctuation, "]"}, {Whitespace, " "}, {Punctuation, "="}, {Whitespace, " "}, {Keyword, "YES"}, {Punctuation, ";"},
+		},
+		"  # define N 1 // n\nx = a < b;": {
+			{Whitespace, "  "}, {Keyword, "# define"}, {Whitespace, " "}, {Type, "N"}, {Whitespace, " "}, {Decimal, "1"},
+			{Whitespace, " "}, {Comment, "// n"}, {Whitespace, "\n"}, {Plaintext, "x"}, {Whitespace, " "}, {Punctuation, "="},
+			{Whitespace, " "}, {Plaintext, "a"}, {Whitespace, " "}, {Punctuation, "<"}, {Whitespace, " "}, {Plaintext, "b"},
+			{Punctuation, ";"},
+		},
+		"@property (nonatomic) id x; @synchronized": {
+			{Keyword, "@property"}, {Whitespace, " "}, {Punctuation, "("}, {Keyword, "nonatomic"}, {Punctuation, ")"},
+			{Whitespace, " "}, {Keyword, "id"}, {Whitespace, " "}, {Plaintext, "x"}, {Punctuation, ";"}, {Whitespace, " "},
+			{Keyword, "@synchronized"},
+		},
+	}
+	for src, want := range tests {
+		s := NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(src))))
+		s.UseLanguage(LookupLanguage("objc"))
+		if got := scanAll(t, s); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %v, got %v", src, want, got)
+		}
+	}
+}
+
+func TestScannerDollarTemplates(t *testing.T) {
+	tests := map[string][]token{
+		`"Hi $name, ${n + 1}$"`: {
+			{String, `"Hi `}, {Variable, "$name"}, {String, ", "}, {Punctuation, "${"}, {Plaintext, "n"}, {Whitespace, " "},
+			{Punctuation, "+"}, {Whitespace, " "}, {Decimal, "1"}, {Punctuation, "}"}, {String, `$"`},
+		},
+		"x = \"\"\"a $b\n\"\"\" + '$c'": {
+			{Plaintext, "x"}, {Whitespace, " "}, {Punctuation, "="}, {Whitespace, " "}, {String, `"""a `}, {Variable, "$b"},
+			{String, "\n\"\"\""}, {Whitespace, " "}, {Punctuation, "+"}, {Whitespace, " "}, {String, "'$c'"},
+		},
+		"list.each { println it }": {
+			{Plaintext, "list"}, {Punctuation, "."}, {Plaintext, "each"}, {Whitespace, " "}, {Punctuation, "{"}, {Whitespace, " "},
+			{Keyword, "println"}, {Whitespace, " "}, {Keyword, "it"}, {Whitespace, " "}, {Punctuation, "}"},
+		},
+	}
+	for src, want := range tests {
+		s := NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(src))))
+		s.UseLanguage(LookupLanguage("gradle"))
+		s.Interpolate()
+		if got := scanAll(t, s); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %v, got %v", src, want, got)
+		}
+	}
+}
+
+func TestScannerVisualBasic(t *testing.T) {
+	tests := map[string][]token{
+		"Dim s As String = \"C:\\\" ' path\nREM done": {
+			{Keyword, "Dim"}, {Whitespace, " "}, {Plaintext, "s"}, {Whitespace, " "}, {Keyword, "As"}, {Whitespace, " "},
+			{Type, "String"}, {Whitespace, " "}, {Punctuation, "="}, {Whitespace, " "}, {String, `"C:\"`}, {Whitespace, " "},
+			{Comment, "' path"}, {Whitespace, "\n"}, {Comment, "REM done"},
+		},
+		"IF x THEN MsgBox(\"say \"\"hi\"\"\") _\n  : end if": {
+			{Keyword, "IF"}, {Whitespace, " "}, {Plaintext, "x"}, {Whitespace, " "}, {Keyword, "THEN"}, {Whitespace, " "},
+			{Plaintext, "MsgBox"}, {Punctuation, "("}, {String, `"say ""hi"""`}, {Punctuation, ")"}, {Whitespace, " "},
+			{Punctuation, "_"}, {Whitespace, "\n  "}, {Punctuation, ":"}, {Whitespace, " "}, {Keyword, "end"}, {Whitespace, " "},
+			{Keyword, "if"},
+		},
+		"_x = Len(\"\")": {
+			{Plaintext, "_x"}, {Whitespace, " "}, {Punctuation, "="}, {Whitespace, " "}, {Plaintext, "Len"}, {Punctuation, "("},
+			{String, `""`}, {Punctuation, ")"},
+		},
+	}
+	for src, want := range tests {
+		s := NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(src))))
+		s.UseLanguage(LookupLanguage("vba"))
+		if got := scanAll(t, s); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %v, got %v", src, want, got)
+		}
+	}
+}
+
+func TestScannerPascal(t *testing.T) {
+	tests := map[string][]token{
+		"{ a }(* b\n*) // c\nBEGIN WriteLn('it''s'); End.": {
+			{Comment, "{ a }"}, {Comment, "(* b\n*)"}, {Whitespace, " "}, {Comment, "// c"}, {Whitespace, "\n"},
+			{Keyword, "BEGIN"}, {Whitespace, " "}, {Plaintext, "WriteLn"}, {Punctuation, "("}, {String, "'it''s'"},
+			{Punctuation, ")"}, {Punctuation, ";"}, {Whitespace, " "}, {Keyword, "End"}, {Punctuation, "."},
+		},
+		"var x: integer; (x) /* y */": {
+			{Keyword, "var"}, {Whitespace, " "}, {Plaintext, "x"}, {Punctuation, ":"}, {Whitespace, " "}, {Type, "integer"},
+			{Punctuation, ";"}, {Whitespace, " "}, {Punctuation, "("}, {Plaintext, "x"}, {Punctuation, ")"}, {Whitespace, " "},
+			{Punctuation, "/"}, {Punctuation, "*"}, {Whitespace, " "}, {Plaintext, "y"}, {Whitespace, " "}, {Punctuation, "*"},
+			{Punctuation, "/"},
+		},
+	}
+	for src, want := range tests {
+		s := NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(src))))
+		s.UseLanguage(LookupLanguage("delphi"))
+		if got := scanAll(t, s); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %v, got %v", src, want, got)
+		}
+	}
+}
+
+func TestScannerAda(t *testing.T) {
+	tests := map[string][]token{
+		"Put_Line (Integer'Image (N) & 'x'); -- done": {
+			{Plaintext, "Put_Line"}, {Whitespace, " "}, {Punctuation, "("}, {Type, "Integer"}, {Punctuation, "'"},
+			{Plaintext, "Image"}, {Whitespace, " "}, {Punctuation, "("}, {Plaintext, "N"}, {Punctuation, ")"}, {Whitespace, " "},
+			{Punctuation, "&"}, {Whitespace, " "}, {String, "'x'"}, {Punctuation, ")"}, {Punctuation, ";"}, {Whitespace, " "},
+			{Comment, "-- done"},
+		},
+		"for I in A'Range LOOP null; END LOOP;": {
+			{Keyword, "for"}, {Whitespace, " "}, {Plaintext, "I"}, {Whitespace, " "}, {Keyword, "in"}, {Whitespace, " "},
+			{Plaintext, "A"}, {Punctuation, "'"}, {Keyword, "Range"}, {Whitespace, " "}, {Keyword, "LOOP"}, {Whitespace, " "},
+			{Keyword, "null"}, {Punctuation, ";"}, {Whitespace, " "}, {Keyword, "END"}, {Whitespace, " "}, {Keyword, "LOOP"},
+			{Punctuation, ";"},
+		},
+	}
+	for src, want := range tests {
+		s := NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(src))))
+		s.UseLanguage(LookupLanguage("ada"))
+		if got := scanAll(t, s); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %v, got %v", src, want, got)
+		}
+	}
+}
+
+func TestScannerMATLAB(t *testing.T) {
+	tests := map[string][]token{
+		"%{\nblock\n%}\nB = A' * x'; % t\ns = 'it''s';": {
+			{Comment, "%{\nblock\n%}"}, {Whitespace, "\n"}, {Type, "B"}, {Whitespace, " "}, {Punctuation, "="}, {Whitespace, " "},
+			{Type, "A"}, {Punctuation, "'"}, {Whitespace, " "}, {Punctuation, "*"}, {Whitespace, " "}, {Plaintext, "x"},
+			{Punctuation, "'"}, {Punctuation, ";"}, {Whitespace, " "}, {Comment, "% t"}, {Whitespace, "\n"}, {Plaintext, "s"},
+			{Whitespace, " "}, {Punctuation, "="}, {Whitespace, " "}, {String, "'it''s'"}, {Punctuation, ";"},
+		},
+		"hold on;\nformat long g\nx = [1, ...\n  2];": {
+			{Plaintext, "hold"}, {Whitespace, " "}, {String, "on"}, {Punctuation, ";"}, {Whitespace, "\n"}, {Plaintext, "format"},
+			{Whitespace, " "}, {String, "long g"}, {Whitespace, "\n"}, {Plaintext, "x"}, {Whitespace, " "}, {Punctuation, "="},
+			{Whitespace, " "}, {Punctuation, "["}, {Decimal, "1"}, {Punctuation, ","}, {Whitespace, " "}, {Punctuation, "..."},
+			{Whitespace, "\n  "}, {Decimal, "2"}, {Punctuation, "]"}, {Punctuation, ";"},
+		},
+		"a - b\nfunction y = f(x)": {
+			{Plaintext, "a"}, {Whitespace, " "}, {Punctuation, "-"}, {Whitespace, " "}, {Plaintext, "b"}, {Whitespace, "\n"},
+			{Keyword, "function"}, {Whitespace, " "}, {Plaintext, "y"}, {Whitespace, " "}, {Punctuation, "="}, {Whitespace, " "},
+			{Plaintext, "f"}, {Punctuation, "("}, {Plaintext, "x"}, {Punctuation, ")"},
+		},
+	}
+	for src, want := range tests {
+		s := NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(src))))
+		s.UseLanguage(LookupLanguage("matlab"))
+		if got := scanAll(t, s); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %v, got %v", src, want, got)
+		}
+	}
+}
+
+func TestScannerCrystal(t *testing.T) {
+	tests := map[string][]token{
+		"def f(x : Int32) : String\n  x.to_s =~ /\\d+/ ? :yes : :no?\nend": {
+			{Keyword, "def"}, {Whitespace, " "}, {Plaintext, "f"}, {Punctuation, "("}, {Plaintext, "x"}, {Whitespace, " "},
+			{Punctuation, ":"}, {Whitespace, " "}, {Type, "Int32"}, {Punctuation, ")"}, {Whitespace, " "}, {Punctuation, ":"},
+			{Whitespace, " "}, {Type, "String"}, {Whitespace, "\n  "}, {Plaintext, "x"}, {Punctuation, "."}, {Plaintext, "to_s"},
+			{Whitespace, " "}, {Punctuation, "="}, {Punctuation, "~"}, {Whitespace, " "}, {String, `/\d+/`}, {Whitespace, " "},
+			{Punctuation, "?"}, {Whitespace, " "}, {Literal, ":yes"}, {Whitespace, " "}, {Punctuation, ":"}, {Whitespace, " "},
+			{Literal, ":no?"}, {Whitespace, "\n"}, {Keyword, "end"},
+		},
+		"a / b; {a:1}; A::B": {
+			{Plaintext, "a"}, {Whitespace, " "}, {Punctuation, "/"}, {Whitespace, " "}, {Plaintext, "b"}, {Punctuation, ";"},
+			{Whitespace, " "}, {Punctuation, "{"}, {Plaintext, "a"}, {Punctuation, ":"}, {Decimal, "1"}, {Punctuation, "}"},
+			{Punctuation, ";"}, {Whitespace, " "}, {Namespace, "A"}, {Punctuation, "::"}, {Type, "B"},
+		},
+	}
+	for src, want := range tests {
+		s := NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(src))))
+		s.UseLanguage(LookupLanguage("crystal"))
+		if got := scanAll(t, s); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %v, got %v", src, want, got)
+		}
+	}
+
+	// the colon before a rune that is not a letter, whose first byte is a
+	// letter in Latin-1, is punctuation
+	for _, src := range []string{"a = :×\nputs :été\n", "x = :€"} {
+		s := NewScanner([]byte(src))
+		s.UseLanguage(LookupLanguage("crystal"))
+		var text strings.Builder
+		toks := scanAll(t, s)
+		for _, tok := range toks {
+			text.WriteString(tok.text)
+		}
+		if text.String() != src {
+			t.Errorf("%q: tokens add up to %q", src, text.String())
+		}
+		if toks[4] != (token{Punctuation, ":"}) {
+			t.Errorf("%q: got %v, want the colon as Punctuation", src, toks[4])
+		}
+	}
+}
+
+func TestScannerRaku(t *testing.T) {
+	tests := map[string][]token{
+		"my %h = a => 1; has $!name; say @list[0] % 2;": {
+			{Keyword, "my"}, {Whitespace, " "}, {Variable, "%h"}, {Whitespace, " "}, {Punctuation, "="}, {Whitespace, " "},
+			{Plaintext, "a"}, {Whitespace, " "}, {Punctuation, "="}, {Punctuation, ">"}, {Whitespace, " "}, {Decimal, "1"},
+			{Punctuation, ";"}, {Whitespace, " "}, {Keyword, "has"}, {Whitespace, " "}, {Variable, "$!name"}, {Punctuation, ";"},
+			{Whitespace, " "}, {Keyword, "say"}, {Whitespace, " "}, {Variable, "@list"}, {Punctuation, "["}, {Decimal, "0"},
+			{Punctuation, "]"}, {Whitespace, " "}, {Punctuation, "%"}, {Whitespace, " "}, {Decimal, "2"}, {Punctuation, ";"},
+		},
+		"=begin pod\nDocs\n=end pod\n=head1 Name\nsub is-ok($x-y) { $x ~~ rx/\\w+/ }": {
+			{DocComment, "=begin pod\nDocs\n=end pod"}, {Whitespace, "\n"}, {DocComment, "=head1 Name"}, {Whitespace, "\n"},
+			{Keyword, "sub"}, {Whitespace, " "}, {Plaintext, "is-ok"}, {Punctuation, "("}, {Variable, "$x-y"}, {Punctuation, ")"},
+			{Whitespace, " "}, {Punctuation, "{"}, {Whitespace, " "}, {Variable, "$x"}, {Whitespace, " "}, {Punctuation, "~"},
+			{Punctuation, "~"}, {Whitespace, " "}, {Keyword, "rx"}, {String, `/\w+/`}, {Whitespace, " "}, {Punctuation, "}"},
+		},
+	}
+	for src, want := range tests {
+		s := NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(src))))
+		s.UseLanguage(LookupLanguage("perl6"))
+		if got := scanAll(t, s); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %v, got %v", src, want, got)
+		}
+	}
+}
+
+func TestScannerR(t *testing.T) {
+	tests := map[string][]token{
+		"#' Add\n#'\n# x\n": {
+			{DocComment, "#' Add"}, {Whitespace, "\n"}, {DocComment, "#'"}, {Whitespace, "\n"}, {Comment, "# x"}, {Whitespace, "\n"},
+		},
+		"f <- 1 #' y": {
+			{Plaintext, "f"}, {Whitespace, " "}, {Punctuation, "<"}, {Punctuation, "-"}, {Whitespace, " "}, {Decimal, "1"}, {Whitespace, " "}, {DocComment, "#' y"},
+		},
+	}
+	for src, want := range tests {
+		for _, oneByte := range []bool{false, true} {
+			s := NewScanner([]byte(src))
+			if oneByte {
+				s = NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(src))))
+			}
+			s.UseLanguage(LookupLanguage("r"))
+			if got := scanAll(t, s); !reflect.DeepEqual(got, want) {
+				t.Errorf("%q: want %v, got %v", src, want, got)
+			}
+		}
+	}
+}
+
+func TestScannerGPULanguages(t *testing.T) {
+	tests := []struct {
+		lang, src string
+		want      []token
+	}{
+		{"cuda", "__global__ void k(float4 *p) { p[threadIdx.x] = make_float4(0); }", []token{
+			{Keyword, "__global__"}, {Whitespace, " "}, {Keyword, "void"}, {Whitespace, " "}, {Plaintext, "k"}, {Punctuation, "("},
+			{Type, "float4"}, {Whitespace, " "}, {Punctuation, "*"}, {Plaintext, "p"}, {Punctuation, ")"}, {Whitespace, " "}, {Punctuation, "{"},
+			{Whitespace, " "}, {Plaintext, "p"}, {Punctuation, "["}, {Keyword, "threadIdx"}, {Punctuation, "."}, {Plaintext, "x"}, {Punctuation, "]"},
+			{Whitespace, " "}, {Punctuation, "="}, {Whitespace, " "}, {Plaintext, "make_float4"}, {Punctuation, "("}, {Decimal, "0"}, {Punctuation, ")"},
+			{Punctuation, ";"}, {Whitespace, " "}, {Punctuation, "}"},
+		}},
+		{"opencl", "__kernel void f(__global uint8 *x) {}", []token{
+			{Keyword, "__kernel"}, {Whitespace, " "}, {Keyword, "void"}, {Whitespace, " "}, {Plaintext, "f"}, {Punctuation, "("},
+			{Keyword, "__global"}, {Whitespace, " "}, {Type, "uint8"}, {Whitespace, " "}, {Punctuation, "*"}, {Plaintext, "x"}, {Punctuation, ")"},
+			{Whitespace, " "}, {Punctuation, "{"}, {Punctuation, "}"},
+		}},
+		{"glsl", "#version 450\nlayout(location = 0) in vec3 pos;\nuniform mat4x3 m;", []token{
+			{Keyword, "#version"}, {Whitespace, " "}, {Decimal, "450"}, {Whitespace, "\n"},
+			{Keyword, "layout"}, {Punctuation, "("}, {Plaintext, "location"}, {Whitespace, " "}, {Punctuation, "="}, {Whitespace, " "}, {Decimal, "0"},
+			{Punctuation, ")"}, {Whitespace, " "}, {Keyword, "in"}, {Whitespace, " "}, {Type, "vec3"}, {Whitespace, " "}, {Plaintext, "pos"}, {Punctuation, ";"},
+			{Whitespace, "\n"}, {Keyword, "uniform"}, {Whitespace, " "}, {Type, "mat4x3"}, {Whitespace, " "}, {Plaintext, "m"}, {Punctuation, ";"},
+		}},
+		{"hlsl", "cbuffer C : register(b0) { float4x4 wvp; half2 uv; };", []token{
+			{Keyword, "cbuffer"}, {Whitespace, " "}, {Type, "C"}, {Whitespace, " "}, {Punctuation, ":"}, {Whitespace, " "}, {Keyword, "register"},
+			{Punctuation, "("}, {Plaintext, "b0"}, {Punctuation, ")"}, {Whitespace, " "}, {Punctuation, "{"}, {Whitespace, " "}, {Type, "float4x4"},
+			{Whitespace, " "}, {Plaintext, "wvp"}, {Punctuation, ";"}, {Whitespace, " "}, {Type, "half2"}, {Whitespace, " "}, {Plaintext, "uv"},
+			{Punctuation, ";"}, {Whitespace, " "}, {Punctuation, "}"}, {Punctuation, ";"},
+		}},
+	}
+	for _, test := range tests {
+		s := NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(test.src))))
+		s.UseLanguage(LookupLanguage(test.lang))
+		if got := scanAll(t, s); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s %q:\nwant %v\ngot  %v", test.lang, test.src, test.want, got)
+		}
+	}
+}
+
+func TestScannerCSSTemplates(t *testing.T) {
+	tests := []struct {
+		src         string
+		interpolate bool
+		want        []token
+	}{
+		{"const B = styled.div`\n  color: ${c};\n  &:hover { margin: 0 }\n`", false, []token{
+			{Keyword, "const"}, {Whitespace, " "}, {Type, "B"}, {Whitespace, " "}, {Punctuation, "="}, {Whitespace, " "},
+			{Plaintext, "styled"}, {Punctuation, "."}, {Plaintext, "div"}, {String, "`"}, {Whitespace, "\n  "},
+			{Variable, "color"}, {Punctuation, ":"}, {Whitespace, " "}, {String, "${c}"}, {Punctuation, ";"}, {Whitespace, "\n  "},
+			{Punctuation, "&"}, {Keyword, ":hover"}, {Whitespace, " "}, {Punctuation, "{"}, {Whitespace, " "}, {Variable, "margin"},
+			{Punctuation, ":"}, {Whitespace, " "}, {Decimal, "0"}, {Whitespace, " "}, {Punctuation, "}"}, {Whitespace, "\n"}, {String, "`"},
+		}},
+		{"styled(Button)`gap: ${p => p.gap}px;`", true, []token{
+			{Plaintext, "styled"}, {Punctuation, "("}, {Type, "Button"}, {Punctuation, ")"}, {String, "`"},
+			{Variable, "gap"}, {Punctuation, ":"}, {Whitespace, " "}, {Punctuation, "${"}, {Plaintext, "p"}, {Whitespace, " "},
+			{Punctuation, "="}, {Punctuation, ">"}, {Whitespace, " "}, {Plaintext, "p"}, {Punctuation, "."}, {Plaintext, "gap"}, {Punctuation, "}"},
+			{Plaintext, "px"}, {Punctuation, ";"}, {String, "`"},
+		}},
+		{"html`<p>`; css`a{}`", false, []token{
+			{Plaintext, "html"}, {String, "`<p>`"}, {Punctuation, ";"}, {Whitespace, " "},
+			{Plaintext, "css"}, {String, "`"}, {Tag, "a"}, {Punctuation, "{"}, {Punctuation, "}"}, {String, "`"},
+		}},
+	}
+	for _, test := range tests {
+		for _, oneByte := range []bool{false, true} {
+			s := NewScanner([]byte(test.src))
+			if oneByte {
+				s = NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(test.src))))
+			}
+			s.UseLanguage(LookupLanguage("javascript"))
+			if test.interpolate {
+				s.Interpolate()
+			}
+			if got := scanAll(t, s); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%q:\nwant %v\ngot  %v", test.src, test.want, got)
+			}
+		}
+	}
+}
+
+func TestScannerBudget(t *testing.T) {
+	s := NewScanner([]byte("x := \"a\" // b\ny := 1\n"))
+	var at Position
+	s.Budget(7, func(pos Position) { at = pos })
+	got := scanAll(t, s)
+	want := []token{
+		{Plaintext, "x"},
+		{Whitespace, " "},
+		{Punctuation, ":"},
+		{Punctuation, "="},
+		{Whitespace, " "},
+		{String, `"a"`},
+		{Plaintext, " // b"},
+		{Whitespace, "\n"},
+		{Plaintext, "y := 1"},
+		{Whitespace, "\n"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if want := (Position{Offset: 8, Line: 1, Column: 9}); at != want {
+		t.Errorf("want budget exceeded at %v, got %v", want, at)
+	}
+
+	// a huge token is looked at again as the buffer grows
+	var exceeded bool
+	s = NewScanner([]byte(`"` + strings.Repeat(`\"`, 1<<16)))
+	s.Budget(1<<17+1, func(Position) { exceeded = true })
+	for s.Scan() {
+	}
+	if !exceeded {
+		t.Error("budget not exceeded by a huge token")
+	}
+}
@@ -0,0 +1,99 @@
+package syntaxhighlight
+
+// SemanticTokenTypes and SemanticTokenModifiers are the legend of the data
+// returned by SemanticTokens, to be sent by a language server in the
+// semanticTokensProvider capability. They are standard LSP token types and
+// modifiers, apart from "label".
+var (
+	SemanticTokenTypes = []string{
+		"namespace", "type", "variable", "keyword", "comment", "string",
+		"number", "operator", "property", "label",
+	}
+	SemanticTokenModifiers = []string{"documentation"}
+)
+
+// semanticTypes maps kinds to their index in SemanticTokenTypes, plus one
+// so that kinds that are not reported are zero.
+var semanticTypes = [...]uint32{
+	Namespace:     1,
+	Type:          2,
+	Tag:           2,
+	HTMLTag:       2,
+	Variable:      3,
+	Keyword:       4,
+	Comment:       5,
+	DocComment:    5,
+	Shebang:       5,
+	String:        6,
+	HTMLAttrValue: 6,
+	Literal:       7,
+	Decimal:       7,
+	Punctuation:   8,
+	HTMLAttrName:  9,
+	Label:         10,
+	Confusable:    3,
+	LogError:      4,
+	LogWarning:    4,
+	LogInfo:       4,
+	XMLNamespace:  1,
+	ProcInst:      4,
+	Doctype:       4,
+}
+
+// SemanticTokens returns the tokens from s as the data of an LSP
+// textDocument/semanticTokens response: five integers per token, holding
+// the line and start character of the token relative to the previous one,
+// its length, its type and its modifiers, as indexes into
+// SemanticTokenTypes and bit sets of SemanticTokenModifiers. Characters
+// are counted in UTF-16 code units, the default position encoding of LSP.
+// Whitespace and Plaintext tokens are left out, and tokens that span lines
+// are split into one token per line, since clients need not support
+// multi-line tokens.
+func SemanticTokens(s *Scanner) ([]uint32, error) {
+	var data []uint32
+	var line, char, prevLine, prevChar uint32
+	for s.Scan() {
+		kind := s.Kind()
+		var typ uint32
+		if int(kind) < len(semanticTypes) {
+			typ = semanticTypes[kind]
+		}
+		var mods uint32
+		if kind == DocComment {
+			mods = 1
+		}
+
+		start := char
+		for _, r := range s.Text() {
+			if r == '\n' {
+				if typ != 0 && char > start {
+					data = appendSemanticToken(data, line, start, char-start, typ-1, mods, &prevLine, &prevChar)
+				}
+				line++
+				char, start = 0, 0
+				continue
+			}
+			char++
+			if r >= 0x10000 {
+				char++ // surrogate pair
+			}
+		}
+		if typ != 0 && char > start {
+			data = appendSemanticToken(data, line, start, char-start, typ-1, mods, &prevLine, &prevChar)
+		}
+	}
+	return data, s.Err()
+}
+
+// appendSemanticToken appends a token to data, with its position encoded
+// relative to that of the previous token, *prevLine and *prevChar, which are
+// updated.
+func appendSemanticToken(data []uint32, line, char, length, typ, mods uint32, prevLine, prevChar *uint32) []uint32 {
+	deltaChar := char
+	if line == *prevLine {
+		deltaChar = char - *prevChar
+	}
+	data = append(data, line-*prevLine, deltaChar, length, typ, mods)
+	*prevLine, *prevChar = line, char
+	return data
+}
@@ -0,0 +1,34 @@
+package syntaxhighlight
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSemanticTokens(t *testing.T) {
+	src := []byte("/* é\n𝄞 */ if x {\n\treturn 42\n}\n")
+	got, err := SemanticTokens(NewScanner(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uint32{
+		0, 0, 4, 4, 0, // "/* é"
+		1, 0, 5, 4, 0, // "𝄞 */" is 5 UTF-16 code units
+		0, 6, 2, 3, 0, // if
+		0, 5, 1, 7, 0, // {
+		1, 1, 6, 3, 0, // return
+		0, 7, 2, 6, 0, // 42
+		1, 0, 1, 7, 0, // }
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	got, err = SemanticTokens(NewScanner([]byte("/// doc\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint32{0, 0, 7, 4, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,166 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+)
+
+// smalltalkNumberRE matches the numbers of Smalltalk, which may have a
+// radix, as in 16r1F, a fraction and an exponent.
+var smalltalkNumberRE = regexp.MustCompile(`^\d+(r[0-9A-Z]+)?(\.\d+)?(e-?\d+)?`)
+
+// smalltalkLexer is a Lexer for Smalltalk. It is stateless.
+type smalltalkLexer struct{}
+
+// newSmalltalkLexer returns a Lexer for Smalltalk. Comments, which are in
+// double quotes, are Comment; strings, in which quotes are doubled, String;
+// and symbols such as #at:put:, characters such as $a, nil, true and false
+// Literal. The keywords of keyword messages, such as "at:", are Label and
+// block parameters such as ":x" Variable. The pseudo-variables self, super
+// and thisContext are Keyword, and capitalized names, which name classes
+// and globals, Type.
+func newSmalltalkLexer() Lexer {
+	return smalltalkLexer{}
+}
+
+func (smalltalkLexer) Lex(data []byte, atEOF bool) (int, Kind) {
+	if !atEOF && (len(data) < 2 || !utf8.FullRune(data)) {
+		return 0, 0
+	}
+	switch c := data[0]; {
+	case isBlank(c) || c == '\n':
+		n := markupSpace(data)
+		if n == len(data) && !atEOF {
+			return 0, 0
+		}
+		return n, Whitespace
+	case c == '"':
+		return markupUntil(data, 1, `"`, atEOF), Comment
+	case c == '\'':
+		return smalltalkString(data, atEOF), String
+	case c == '$':
+		if !atEOF && !utf8.FullRune(data[1:]) {
+			return 0, 0
+		}
+		_, size := utf8.DecodeRune(data[1:])
+		return 1 + size, Literal
+	case hasPrefix(data, "#'"):
+		n := smalltalkString(data[1:], atEOF)
+		if n == 0 {
+			return 0, 0
+		}
+		return 1 + n, Literal
+	case hasPrefix(data, "#(") || hasPrefix(data, "#[") || hasPrefix(data, "#{"):
+		return 2, Punctuation
+	case c == '#':
+		n := 1
+		for n < len(data) && (isSmalltalkNameByte(data[n]) || data[n] == ':') {
+			n++
+		}
+		if n == 1 {
+			for n < len(data) && isSmalltalkBinaryByte(data[n]) {
+				n++
+			}
+		}
+		if n == len(data) && !atEOF {
+			return 0, 0
+		}
+		if n == 1 {
+			return 1, Punctuation
+		}
+		return n, Literal
+	case hasPrefix(data, ":="):
+		return 2, Punctuation
+	case c == ':' && isSmalltalkNameStart(data[1:]):
+		n := 2
+		for n < len(data) && isSmalltalkNameByte(data[n]) {
+			n++
+		}
+		if n == len(data) && !atEOF {
+			return 0, 0
+		}
+		return n, Variable
+	case '0' <= c && c <= '9':
+		// a radix, fraction or exponent is told by the byte after the
+		// digits and the one after that
+		n := len(smalltalkNumberRE.Find(data))
+		if n+1 >= len(data) && !atEOF {
+			return 0, 0
+		}
+		return n, Decimal
+	case isSmalltalkNameStart(data):
+		return smalltalkName(data, atEOF)
+	case isSmalltalkBinaryByte(c):
+		return 1, Punctuation
+	}
+	_, size := utf8.DecodeRune(data)
+	return size, Punctuation
+}
+
+// smalltalkName returns the length and kind of the name at the start of
+// data, which is a keyword of a message if it is followed by a colon.
+func smalltalkName(data []byte, atEOF bool) (int, Kind) {
+	n := 1
+	for n < len(data) && isSmalltalkNameByte(data[n]) {
+		n++
+	}
+	if n+1 >= len(data) && !atEOF {
+		return 0, 0
+	}
+	if n < len(data) && data[n] == ':' && !hasPrefix(data[n:], ":=") {
+		return n + 1, Label
+	}
+	switch string(data[:n]) {
+	case "self", "super", "thisContext":
+		return n, Keyword
+	case "nil", "true", "false":
+		return n, Literal
+	}
+	if r, _ := utf8.DecodeRune(data); unicode.IsUpper(r) {
+		return n, Type
+	}
+	return n, Plaintext
+}
+
+// smalltalkString returns the length of the string at the start of data,
+// in which quotes are doubled, or 0 if more data is needed. An unterminated
+// string runs to the end of the source.
+func smalltalkString(data []byte, atEOF bool) int {
+	for i := 1; i < len(data); i++ {
+		if data[i] != '\'' {
+			continue
+		}
+		if i+1 == len(data) && !atEOF {
+			return 0
+		}
+		if i+1 < len(data) && data[i+1] == '\'' {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	if !atEOF {
+		return 0
+	}
+	return len(data)
+}
+
+// isSmalltalkNameStart reports whether b starts with a letter or an
+// underscore, which start a name.
+func isSmalltalkNameStart(b []byte) bool {
+	r, _ := utf8.DecodeRune(b)
+	return r == '_' || unicode.IsLetter(r)
+}
+
+// isSmalltalkNameByte reports whether b may be part of a name, counting all
+// bytes of non-ASCII characters.
+func isSmalltalkNameByte(b byte) bool {
+	return b == '_' || 'a' <= lower(rune(b)) && lower(rune(b)) <= 'z' || '0' <= b && b <= '9' || b >= utf8.RuneSelf
+}
+
+// isSmalltalkBinaryByte reports whether b may be part of a binary selector.
+func isSmalltalkBinaryByte(b byte) bool {
+	return bytes.IndexByte([]byte("+-*/\\<>=~@%&?!,|"), b) >= 0
+}
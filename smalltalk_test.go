@@ -0,0 +1,45 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"testing/iotest"
+)
+
+func TestSmalltalkLexer(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []token
+	}{
+		{"\"a comment\" | x | x := Dictionary new. x at: #key put: 'it''s'.", []token{
+			{Comment, `"a comment"`}, {Whitespace, " "}, {Punctuation, "|"}, {Whitespace, " "}, {Plaintext, "x"}, {Whitespace, " "},
+			{Punctuation, "|"}, {Whitespace, " "}, {Plaintext, "x"}, {Whitespace, " "}, {Punctuation, ":="}, {Whitespace, " "},
+			{Type, "Dictionary"}, {Whitespace, " "}, {Plaintext, "new"}, {Punctuation, "."}, {Whitespace, " "},
+			{Plaintext, "x"}, {Whitespace, " "}, {Label, "at:"}, {Whitespace, " "}, {Literal, "#key"}, {Whitespace, " "},
+			{Label, "put:"}, {Whitespace, " "}, {String, "'it''s'"}, {Punctuation, "."},
+		}},
+		{"[:a | ^self foo: $x with: #at:put: and: 16r1F] value: nil", []token{
+			{Punctuation, "["}, {Variable, ":a"}, {Whitespace, " "}, {Punctuation, "|"}, {Whitespace, " "},
+			{Punctuation, "^"}, {Keyword, "self"}, {Whitespace, " "}, {Label, "foo:"}, {Whitespace, " "}, {Literal, "$x"}, {Whitespace, " "},
+			{Label, "with:"}, {Whitespace, " "}, {Literal, "#at:put:"}, {Whitespace, " "}, {Label, "and:"}, {Whitespace, " "},
+			{Decimal, "16r1F"}, {Punctuation, "]"}, {Whitespace, " "}, {Label, "value:"}, {Whitespace, " "}, {Literal, "nil"},
+		}},
+		{"#(1 #+ #'a b') , 'x'", []token{
+			{Punctuation, "#("}, {Decimal, "1"}, {Whitespace, " "}, {Literal, "#+"}, {Whitespace, " "}, {Literal, "#'a b'"},
+			{Punctuation, ")"}, {Whitespace, " "}, {Punctuation, ","}, {Whitespace, " "}, {String, "'x'"},
+		}},
+	}
+	for _, test := range tests {
+		for _, oneByte := range []bool{false, true} {
+			s := NewScanner([]byte(test.src))
+			if oneByte {
+				s = NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(test.src))))
+			}
+			s.UseLanguage(LookupLanguage("smalltalk"))
+			if got := scanAll(t, s); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%q:\nwant %v\ngot  %v", test.src, test.want, got)
+			}
+		}
+	}
+}
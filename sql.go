@@ -0,0 +1,355 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// sqlNumberRE matches the numbers of SQL, such as "42", "1.5e3" and
+// "0x1F".
+var sqlNumberRE = regexp.MustCompile(`^(0[xX][0-9a-fA-F]+|(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?)`)
+
+// sqlDollarQuoteRE matches the delimiters of the dollar-quoted strings of
+// PostgreSQL, such as "$$" and "$body$".
+var sqlDollarQuoteRE = regexp.MustCompile(`^\$([A-Za-z_]\w*)?\$`)
+
+// sqlLabelRE matches the labels of blocks and loops in PL/pgSQL, such as
+// "<<outer>>".
+var sqlLabelRE = regexp.MustCompile(`^<<\s*[A-Za-z_]\w*\s*>>`)
+
+// sqlVariablePrefixes are the prefixes that name variables and parameters
+// by convention in the routines of dialects with prefixedVariables, as in
+// "v_total" and "p_id".
+var sqlVariablePrefixes = []string{"v_", "p_", "l_"}
+
+// sqlKeywords are the keywords of SQL common to its dialects, in lower
+// case, including those of the blocks of procedural code.
+var sqlKeywords = setOf(
+	"add", "all", "alter", "analyze", "and", "any", "as", "asc", "begin", "between", "by", "call", "cascade",
+	"case", "check", "close", "column", "commit", "conflict", "constraint", "create", "cross", "cursor",
+	"database", "deallocate", "declare", "default", "delete", "desc", "distinct", "do", "drop", "else",
+	"end", "except", "exec", "execute", "exists", "explain", "fetch", "for", "foreign", "from", "full",
+	"function", "grant", "group", "having", "if", "in", "index", "inner", "insert", "intersect", "into",
+	"is", "join", "key", "language", "lateral", "left", "like", "limit", "loop", "matched", "merge",
+	"natural", "not", "nothing", "of", "offset", "on", "only", "open", "or", "order", "outer", "over",
+	"partition", "prepare", "primary", "procedure", "references", "replace", "return", "returning",
+	"returns", "revoke", "right", "rollback", "row", "rows", "savepoint", "schema", "select", "sequence",
+	"set", "table", "temp", "temporary", "then", "to", "transaction", "trigger", "truncate", "union",
+	"unique", "update", "use", "using", "values", "view", "when", "where", "while", "window", "with",
+)
+
+// sqlTypes are the names of the data types of SQL and its dialects, in
+// lower case.
+var sqlTypes = setOf(
+	"bigint", "bigserial", "binary", "bit", "blob", "bool", "boolean", "bytea", "char", "character",
+	"clob", "date", "datetime", "datetime2", "decimal", "double", "float", "int", "integer", "interval",
+	"json", "jsonb", "money", "nchar", "ntext", "numeric", "nvarchar", "precision", "real", "record",
+	"serial", "smallint", "text", "time", "timestamp", "timestamptz", "tinyint", "uniqueidentifier",
+	"uuid", "varbinary", "varchar", "xml",
+)
+
+// sqlLiterals are the literal values of SQL, in lower case.
+var sqlLiterals = setOf("true", "false", "null", "unknown")
+
+// sqlDialect describes a dialect of SQL.
+type sqlDialect struct {
+	// keywords are the keywords of the dialect in addition to sqlKeywords,
+	// in lower case.
+	keywords map[string]bool
+	// dollarQuotes enables the dollar-quoted strings of PostgreSQL, such
+	// as $$it's$$, and its positional parameters, such as $1.
+	dollarQuotes bool
+	// hashComments makes "#" start a line comment, as in MySQL.
+	hashComments bool
+	// backslashes makes backslashes escape characters in strings, as in
+	// MySQL.
+	backslashes bool
+	// brackets makes square brackets quote identifiers, as in T-SQL's
+	// [order details].
+	brackets bool
+	// delimiter enables MySQL's DELIMITER command, which changes the
+	// delimiter of statements so that routines can contain ";".
+	delimiter bool
+	// prefixedVariables makes names with the prefixes in
+	// sqlVariablePrefixes variables.
+	prefixedVariables bool
+}
+
+// sqlSyntax is the syntax of SQL in no particular dialect, which accepts
+// the constructs of the dialects that do not get in each other's way.
+var sqlSyntax = &sqlDialect{dollarQuotes: true, delimiter: true}
+
+// plpgsqlSyntax is the syntax of PostgreSQL and its procedural language,
+// PL/pgSQL.
+var plpgsqlSyntax = &sqlDialect{
+	keywords: setOf(
+		"alias", "array", "assert", "constant", "continue", "diagnostics", "elsif", "exception", "exit",
+		"extension", "foreach", "found", "get", "immutable", "listen", "notice", "notify", "perform",
+		"plpgsql", "raise", "reverse", "security", "setof", "stable", "strict", "volatile",
+	),
+	dollarQuotes:      true,
+	prefixedVariables: true,
+}
+
+// tsqlSyntax is the syntax of T-SQL, the dialect of SQL Server.
+var tsqlSyntax = &sqlDialect{
+	keywords: setOf(
+		"apply", "catch", "go", "goto", "identity", "nocount", "nolock", "output", "pivot", "print", "proc",
+		"raiserror", "throw", "top", "tran", "try", "unpivot", "waitfor",
+	),
+	brackets: true,
+}
+
+// mysqlSyntax is the syntax of MySQL and MariaDB.
+var mysqlSyntax = &sqlDialect{
+	keywords: setOf(
+		"auto_increment", "continue", "definer", "delimiter", "deterministic", "describe", "elseif",
+		"engine", "exit", "handler", "iterate", "leave", "repeat", "show", "signal", "sqlstate", "unsigned",
+		"until",
+	),
+	hashComments:      true,
+	backslashes:       true,
+	delimiter:         true,
+	prefixedVariables: true,
+}
+
+// sqlLexer is a Lexer for SQL.
+type sqlLexer struct {
+	dialect *sqlDialect
+
+	midLine      bool   // a token other than whitespace is on the line
+	delimiter    string // delimiter of statements set by DELIMITER
+	setDelimiter bool   // the new delimiter is next, after DELIMITER
+	body         bool   // the next dollar-quoted string is code, after AS or DO
+	closeBody    string // closing delimiter of the code being lexed, if any
+}
+
+// newSQLLexer returns a function making Lexers for the given dialect of
+// SQL. Keywords, in any case, are Keyword, data types Type and true, false
+// and null Literal. Strings are String, and so are identifiers in double
+// quotes; variables, such as T-SQL's @total, bind parameters such as :id
+// and $1 and, in dialects with prefixedVariables, names such as v_total
+// are Variable. The bodies of routines in dollar quotes, as in
+// "AS $$ BEGIN ... END $$", are highlighted as SQL, with their delimiters
+// as Punctuation, while other dollar-quoted strings are String. The
+// statement delimiter set by MySQL's DELIMITER command is Punctuation.
+// Comments, after "--" or in "/* */", are Comment.
+func newSQLLexer(dialect *sqlDialect) func() Lexer {
+	return func() Lexer {
+		return &sqlLexer{dialect: dialect}
+	}
+}
+
+func (l *sqlLexer) Lex(data []byte, atEOF bool) (int, Kind) {
+	if !atEOF && (len(data) < 3 || !utf8.FullRune(data)) {
+		return 0, 0
+	}
+	if c := data[0]; isBlank(c) || c == '\n' {
+		n := markupSpace(data)
+		if n == len(data) && !atEOF {
+			return 0, 0
+		}
+		if bytes.IndexByte(data[:n], '\n') >= 0 {
+			l.midLine = false
+		}
+		return n, Whitespace
+	}
+	n, kind := l.lex(data, atEOF)
+	if n > 0 {
+		l.midLine = true
+		if kind != Comment && kind != Whitespace {
+			l.body = kind == Keyword && (strings.EqualFold(string(data[:n]), "as") || strings.EqualFold(string(data[:n]), "do"))
+		}
+	}
+	return n, kind
+}
+
+// lex lexes a token other than whitespace.
+func (l *sqlLexer) lex(data []byte, atEOF bool) (int, Kind) {
+	d := l.dialect
+	switch c := data[0]; {
+	case l.setDelimiter:
+		n := 0
+		for n < len(data) && !isBlank(data[n]) && data[n] != '\n' && data[n] != '\r' {
+			n++
+		}
+		if n == len(data) && !atEOF {
+			return 0, 0
+		}
+		l.setDelimiter, l.delimiter = false, string(data[:n])
+		if l.delimiter == ";" {
+			l.delimiter = ""
+		}
+		return n, Punctuation
+	case l.delimiter != "" && hasPrefix(data, l.delimiter):
+		return len(l.delimiter), Punctuation
+	case l.delimiter != "" && !atEOF && len(data) < len(l.delimiter) && hasPrefix([]byte(l.delimiter), string(data)):
+		return 0, 0
+	case l.closeBody != "" && hasPrefix(data, l.closeBody):
+		n := len(l.closeBody)
+		l.closeBody = ""
+		return n, Punctuation
+	case hasPrefix(data, "--") || d.hashComments && c == '#':
+		return scanLine(data, atEOF), Comment
+	case hasPrefix(data, "/*"):
+		return markupUntil(data, 2, "*/", atEOF), Comment
+	case c == '\'' || c == '"':
+		return l.quoted(data, 0, atEOF), String
+	case c == '`' || d.brackets && c == '[':
+		end := byte('`')
+		if c == '[' {
+			end = ']'
+		}
+		i := bytes.IndexByte(data[1:], end)
+		if i < 0 {
+			if !atEOF {
+				return 0, 0
+			}
+			return len(data), Plaintext
+		}
+		return i + 2, Plaintext
+	case c == '$' && d.dollarQuotes:
+		if len(data) > 1 && isDecimal(rune(data[1])) {
+			n := 1 + len(sqlNumberRE.Find(data[1:]))
+			if n == len(data) && !atEOF {
+				return 0, 0
+			}
+			return n, Variable
+		}
+		m := sqlDollarQuoteRE.Find(data)
+		if m == nil {
+			if !atEOF && bytes.IndexByte(data[1:], '$') < 0 {
+				return 0, 0
+			}
+			return 1, Punctuation
+		}
+		if l.body && l.closeBody == "" {
+			l.closeBody = string(m)
+			return len(m), Punctuation
+		}
+		i := bytes.Index(data[len(m):], m)
+		if i < 0 {
+			if !atEOF {
+				return 0, 0
+			}
+			return len(data), String
+		}
+		return 2*len(m) + i, String
+	case c == '@':
+		n := 1
+		if len(data) > 1 && data[1] == '@' {
+			n = 2
+		}
+		n += sqlName(data[n:])
+		if n == len(data) && !atEOF {
+			return 0, 0
+		}
+		if n == 1 {
+			return 1, Punctuation
+		}
+		return n, Variable
+	case c == ':':
+		if hasPrefix(data, "::") {
+			return 2, Punctuation
+		}
+		n := 1 + sqlName(data[1:])
+		if n == len(data) && !atEOF {
+			return 0, 0
+		}
+		if n == 1 {
+			return 1, Punctuation
+		}
+		return n, Variable
+	case c == '<' && d.prefixedVariables && hasPrefix(data, "<<"):
+		if m := sqlLabelRE.Find(data); m != nil {
+			return len(m), Label
+		}
+		if !atEOF && !bytes.Contains(data, []byte(">>")) && bytes.IndexByte(data, '\n') < 0 {
+			return 0, 0
+		}
+		return 2, Punctuation
+	case isDecimal(rune(c)) || c == '.' && len(data) > 1 && isDecimal(rune(data[1])):
+		n := len(sqlNumberRE.Find(data))
+		if n == len(data) && !atEOF {
+			return 0, 0
+		}
+		return n, Decimal
+	}
+
+	r, size := utf8.DecodeRune(data)
+	if r != '_' && !unicode.IsLetter(r) {
+		return size, Punctuation
+	}
+	n := sqlName(data)
+	if n == len(data) && !atEOF {
+		return 0, 0
+	}
+	if n == 1 && len(data) > 1 && data[1] == '\'' {
+		// a prefixed string, such as N'text' or E'\n'
+		return l.quoted(data, 1, atEOF), String
+	}
+	name := strings.ToLower(string(data[:n]))
+	switch {
+	case name == "delimiter" && d.delimiter && !l.midLine:
+		l.setDelimiter = true
+		return n, Keyword
+	case sqlKeywords[name] || d.keywords[name]:
+		return n, Keyword
+	case sqlTypes[name]:
+		return n, Type
+	case sqlLiterals[name]:
+		return n, Literal
+	case d.prefixedVariables:
+		for _, p := range sqlVariablePrefixes {
+			if strings.HasPrefix(name, p) && len(name) > len(p) {
+				return n, Variable
+			}
+		}
+	}
+	return n, Plaintext
+}
+
+// quoted returns the length of the string or quoted identifier whose
+// opening quote is at data[i], in which quotes are doubled, or escaped with
+// a backslash in dialects with backslashes or after the prefix E, or 0 if
+// more data is needed. An unterminated one runs to the end of the source.
+func (l *sqlLexer) quoted(data []byte, i int, atEOF bool) int {
+	q := data[i]
+	escapes := l.dialect.backslashes || i == 1 && lower(rune(data[0])) == 'e'
+	for i++; i < len(data); i++ {
+		switch {
+		case data[i] == '\\' && escapes:
+			i++
+		case data[i] == q:
+			if i+1 == len(data) && !atEOF {
+				return 0
+			}
+			if i+1 < len(data) && data[i+1] == q {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	if !atEOF {
+		return 0
+	}
+	return len(data)
+}
+
+// sqlName returns the length of the name at the start of data, which is
+// made of letters, digits, underscores and dollar signs.
+func sqlName(data []byte) int {
+	n := 0
+	for n < len(data) {
+		r, size := utf8.DecodeRune(data[n:])
+		if !isIdentRune(r) && (r != '$' || n == 0) {
+			break
+		}
+		n += size
+	}
+	return n
+}
@@ -0,0 +1,64 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"testing/iotest"
+)
+
+func TestSQLLexer(t *testing.T) {
+	tests := []struct {
+		lang, src string
+		want      []token
+	}{
+		{"sql", "select id, name::text from users where ok = TRUE and id = $1; -- x", []token{
+			{Keyword, "select"}, {Whitespace, " "}, {Plaintext, "id"}, {Punctuation, ","}, {Whitespace, " "}, {Plaintext, "name"},
+			{Punctuation, "::"}, {Type, "text"}, {Whitespace, " "}, {Keyword, "from"}, {Whitespace, " "}, {Plaintext, "users"},
+			{Whitespace, " "}, {Keyword, "where"}, {Whitespace, " "}, {Plaintext, "ok"}, {Whitespace, " "}, {Punctuation, "="},
+			{Whitespace, " "}, {Literal, "TRUE"}, {Whitespace, " "}, {Keyword, "and"}, {Whitespace, " "}, {Plaintext, "id"},
+			{Whitespace, " "}, {Punctuation, "="}, {Whitespace, " "}, {Variable, "$1"}, {Punctuation, ";"}, {Whitespace, " "}, {Comment, "-- x"},
+		}},
+		{"plpgsql", "CREATE FUNCTION f(p_id int) RETURNS int AS $$\n<<main>>\nDECLARE v_n int := 0;\nBEGIN\n  RAISE NOTICE $m$it's$m$;\nEND;\n$$ LANGUAGE plpgsql;", []token{
+			{Keyword, "CREATE"}, {Whitespace, " "}, {Keyword, "FUNCTION"}, {Whitespace, " "}, {Plaintext, "f"}, {Punctuation, "("},
+			{Variable, "p_id"}, {Whitespace, " "}, {Type, "int"}, {Punctuation, ")"}, {Whitespace, " "}, {Keyword, "RETURNS"}, {Whitespace, " "},
+			{Type, "int"}, {Whitespace, " "}, {Keyword, "AS"}, {Whitespace, " "}, {Punctuation, "$$"}, {Whitespace, "\n"},
+			{Label, "<<main>>"}, {Whitespace, "\n"},
+			{Keyword, "DECLARE"}, {Whitespace, " "}, {Variable, "v_n"}, {Whitespace, " "}, {Type, "int"}, {Whitespace, " "},
+			{Punctuation, ":"}, {Punctuation, "="}, {Whitespace, " "}, {Decimal, "0"}, {Punctuation, ";"}, {Whitespace, "\n"},
+			{Keyword, "BEGIN"}, {Whitespace, "\n  "},
+			{Keyword, "RAISE"}, {Whitespace, " "}, {Keyword, "NOTICE"}, {Whitespace, " "}, {String, "$m$it's$m$"}, {Punctuation, ";"}, {Whitespace, "\n"},
+			{Keyword, "END"}, {Punctuation, ";"}, {Whitespace, "\n"},
+			{Punctuation, "$$"}, {Whitespace, " "}, {Keyword, "LANGUAGE"}, {Whitespace, " "}, {Keyword, "plpgsql"}, {Punctuation, ";"},
+		}},
+		{"tsql", "DECLARE @n int = @@ROWCOUNT;\nSELECT TOP 1 [order id] FROM t WHERE s = N'x'\nGO", []token{
+			{Keyword, "DECLARE"}, {Whitespace, " "}, {Variable, "@n"}, {Whitespace, " "}, {Type, "int"}, {Whitespace, " "}, {Punctuation, "="},
+			{Whitespace, " "}, {Variable, "@@ROWCOUNT"}, {Punctuation, ";"}, {Whitespace, "\n"},
+			{Keyword, "SELECT"}, {Whitespace, " "}, {Keyword, "TOP"}, {Whitespace, " "}, {Decimal, "1"}, {Whitespace, " "}, {Plaintext, "[order id]"},
+			{Whitespace, " "}, {Keyword, "FROM"}, {Whitespace, " "}, {Plaintext, "t"}, {Whitespace, " "}, {Keyword, "WHERE"}, {Whitespace, " "},
+			{Plaintext, "s"}, {Whitespace, " "}, {Punctuation, "="}, {Whitespace, " "}, {String, "N'x'"}, {Whitespace, "\n"},
+			{Keyword, "GO"},
+		}},
+		{"mysql", "DELIMITER //\nCREATE PROCEDURE p()\nBEGIN\n  SELECT 'a\\'b'; # c\nEND //\nDELIMITER ;\nSELECT 1;", []token{
+			{Keyword, "DELIMITER"}, {Whitespace, " "}, {Punctuation, "//"}, {Whitespace, "\n"},
+			{Keyword, "CREATE"}, {Whitespace, " "}, {Keyword, "PROCEDURE"}, {Whitespace, " "}, {Plaintext, "p"}, {Punctuation, "("}, {Punctuation, ")"}, {Whitespace, "\n"},
+			{Keyword, "BEGIN"}, {Whitespace, "\n  "},
+			{Keyword, "SELECT"}, {Whitespace, " "}, {String, `'a\'b'`}, {Punctuation, ";"}, {Whitespace, " "}, {Comment, "# c"}, {Whitespace, "\n"},
+			{Keyword, "END"}, {Whitespace, " "}, {Punctuation, "//"}, {Whitespace, "\n"},
+			{Keyword, "DELIMITER"}, {Whitespace, " "}, {Punctuation, ";"}, {Whitespace, "\n"},
+			{Keyword, "SELECT"}, {Whitespace, " "}, {Decimal, "1"}, {Punctuation, ";"},
+		}},
+	}
+	for _, test := range tests {
+		for _, oneByte := range []bool{false, true} {
+			s := NewScanner([]byte(test.src))
+			if oneByte {
+				s = NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(test.src))))
+			}
+			s.UseLanguage(LookupLanguage(test.lang))
+			if got := scanAll(t, s); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%s %q:\nwant %v\ngot  %v", test.lang, test.src, test.want, got)
+			}
+		}
+	}
+}
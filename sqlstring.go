@@ -0,0 +1,46 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// sqlStatementRE matches the start of SQL statements in string literals:
+// a statement keyword in upper case, or in any case followed by the words
+// that make up a statement, so that text such as "select a file" is not
+// taken for SQL.
+var sqlStatementRE = regexp.MustCompile(`^\s*(?:(?:SELECT|INSERT|UPDATE|DELETE|WITH|CREATE|ALTER|DROP|TRUNCATE|MERGE|REPLACE|UPSERT|GRANT|REVOKE|EXPLAIN)\s|(?is:select\s.*\sfrom\s|insert\s+into\s|update\s+\S+\s+set\s|delete\s+from\s|(?:create|alter|drop)\s+(?:table|index|view|schema|database|sequence|trigger|function|procedure)\s|with\s+\w+\s+as\s*\())`)
+
+// sqlString returns the lengths of the opening and closing delimiters of
+// the string literal tok, such as `"` or `@"` and `"`, and reports whether
+// it holds an SQL statement.
+func sqlString(tok []byte) (open, close int, ok bool) {
+	q := bytes.IndexAny(tok, "\"'`")
+	if q < 0 || q > 2 {
+		// at most a short prefix, such as "@" or "r", before the quote
+		return 0, 0, false
+	}
+	open, close = q+1, 1
+	if triple := bytes.Repeat(tok[q:q+1], 3); len(tok)-q >= 6 && bytes.HasPrefix(tok[q:], triple) && bytes.HasSuffix(tok, triple) {
+		open, close = q+3, 3
+	}
+	if len(tok) < open+close || tok[len(tok)-1] != tok[q] {
+		return 0, 0, false
+	}
+	return open, close, sqlStatementRE.Match(tok[open : len(tok)-close])
+}
+
+// scanSQL scans a token of the string literal that holds an SQL statement,
+// after its opening delimiter: a token of the statement, lexed as SQL, or
+// the closing delimiter, which is String. As the whole literal was scanned
+// before, data holds the rest of it.
+func (s *Scanner) scanSQL(data []byte) (int, Kind) {
+	if s.sqlRest == 0 {
+		n := s.sqlClose
+		s.sql, s.sqlClose = nil, 0
+		return n, String
+	}
+	n, kind := s.sql.Lex(data[:s.sqlRest], true)
+	s.sqlRest -= n
+	return n, kind
+}
@@ -0,0 +1,62 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"testing/iotest"
+)
+
+func TestSQLString(t *testing.T) {
+	tests := []struct {
+		tok         string
+		open, close int
+		ok          bool
+	}{
+		{`"SELECT * FROM t"`, 1, 1, true},
+		{`'select id from users where x = 1'`, 1, 1, true},
+		{"`\n  INSERT INTO t VALUES (?)`", 1, 1, true},
+		{`@"UPDATE t SET a = 1"`, 2, 1, true},
+		{`"""with x as (select 1) select * from x"""`, 3, 3, true},
+		{`"Select a file from the list"`, 1, 1, true},
+		{`"select a file"`, 1, 1, false},
+		{`"update the docs"`, 1, 1, false},
+		{`"SELECT`, 0, 0, false},
+		{`'S'`, 1, 1, false},
+	}
+	for _, test := range tests {
+		open, close, ok := sqlString([]byte(test.tok))
+		if ok != test.ok || ok && (open != test.open || close != test.close) {
+			t.Errorf("%q: got %d, %d, %v, want %d, %d, %v", test.tok, open, close, ok, test.open, test.close, test.ok)
+		}
+	}
+}
+
+func TestScannerEmbeddedSQL(t *testing.T) {
+	src := "db.Query(\"SELECT id FROM t WHERE n = 'x'\", n) // done"
+	want := []token{
+		{Namespace, "db"}, {Punctuation, "."}, {Type, "Query"}, {Punctuation, "("},
+		{String, `"`}, {Keyword, "SELECT"}, {Whitespace, " "}, {Plaintext, "id"}, {Whitespace, " "}, {Keyword, "FROM"}, {Whitespace, " "},
+		{Plaintext, "t"}, {Whitespace, " "}, {Keyword, "WHERE"}, {Whitespace, " "}, {Plaintext, "n"}, {Whitespace, " "}, {Punctuation, "="},
+		{Whitespace, " "}, {String, "'x'"}, {String, `"`},
+		{Punctuation, ","}, {Whitespace, " "}, {Plaintext, "n"}, {Punctuation, ")"}, {Whitespace, " "}, {Comment, "// done"},
+	}
+	for _, oneByte := range []bool{false, true} {
+		s := NewScanner([]byte(src))
+		if oneByte {
+			s = NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(src))))
+		}
+		s.UseLanguage(LookupLanguage("go"))
+		s.EmbeddedSQL()
+		if got := scanAll(t, s); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q:\nwant %v\ngot  %v", src, want, got)
+		}
+	}
+
+	// without EmbeddedSQL, the statement is a string
+	s := NewScanner([]byte(src))
+	s.UseLanguage(LookupLanguage("go"))
+	if got := scanAll(t, s); got[4] != (token{String, "\"SELECT id FROM t WHERE n = 'x'\""}) {
+		t.Errorf("got %v, want the statement as a String", got[4])
+	}
+}
@@ -0,0 +1,226 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// systemdSpecifierRE matches the specifiers of systemd unit files, such as
+// "%i", and the references to environment variables in their commands.
+var systemdSpecifierRE = regexp.MustCompile(`%[a-zA-Z%]|\$\{\w+\}|\$\w+`)
+
+// sshTokenRE matches the tokens that ssh_config expands in some values,
+// such as "%h" and "${HOME}".
+var sshTokenRE = regexp.MustCompile(`%[a-zA-Z%]|\$\{\w+\}`)
+
+// configLiterals are the values of options in systemd unit files and
+// ssh_config that are booleans.
+var configLiterals = setOf("yes", "no", "true", "false", "on", "off")
+
+// cronSchedules are the special schedules of crontab entries.
+var cronSchedules = setOf("@reboot", "@yearly", "@annually", "@monthly", "@weekly", "@daily", "@midnight", "@hourly")
+
+// cronFieldKinds are the kinds of the five time fields of crontab entries,
+// which alternate so that adjacent fields are told apart.
+var cronFieldKinds = [5]Kind{Decimal, Literal, Decimal, Literal, Decimal}
+
+// newSystemdLexer returns a Lexer for systemd unit files, which highlights
+// section names, as in "[Service]", as Label, the keys of settings as
+// Variable and their values as String, or Literal for booleans and Decimal
+// for numbers, with specifiers such as "%i" and variables as Variable.
+// Comments, after "#" or ";" at the start of a line, are Comment.
+func newSystemdLexer() Lexer {
+	continued := false // the line continues the value of the one before
+	return &lineLexer{lexLine: func(line []byte) []lexToken {
+		var toks lineTokens
+		i := blanks(line)
+		toks.add(i, Whitespace)
+		rest := line[i:]
+		switch {
+		case continued:
+		case len(rest) > 0 && (rest[0] == '#' || rest[0] == ';'):
+			toks.add(len(rest), Comment)
+			return toks
+		case len(rest) > 0 && rest[0] == '[':
+			configSection(&toks, rest)
+			return toks
+		default:
+			key := bytes.IndexByte(rest, '=')
+			if key < 0 {
+				toks.add(len(rest), Plaintext)
+				return toks
+			}
+			name := bytes.TrimRight(rest[:key], " \t")
+			toks.add(len(name), Variable)
+			toks.add(key-len(name), Whitespace)
+			toks.add(1, Punctuation)
+			n := blanks(rest[key+1:])
+			toks.add(n, Whitespace)
+			rest = rest[key+1+n:]
+		}
+		continued = endsWithBackslash(rest)
+		configValue(&toks, rest, systemdSpecifierRE)
+		return toks
+	}}
+}
+
+// newSSHConfigLexer returns a Lexer for ssh_config and sshd_config, which
+// highlights the Host and Match keywords as Keyword and their patterns as
+// Label, other keywords as Variable, and their arguments as String, or
+// Literal for booleans and Decimal for numbers, with tokens such as "%h" as
+// Variable. Comments, after "#", are Comment.
+func newSSHConfigLexer() Lexer {
+	return &lineLexer{lexLine: func(line []byte) []lexToken {
+		var toks lineTokens
+		i := blanks(line)
+		toks.add(i, Whitespace)
+		rest := line[i:]
+		if len(rest) > 0 && rest[0] == '#' {
+			toks.add(len(rest), Comment)
+			return toks
+		}
+		n := 0
+		for n < len(rest) && !isBlank(rest[n]) && rest[n] != '=' {
+			n++
+		}
+		keyword := rest[:n]
+		section := bytes.EqualFold(keyword, []byte("Host")) || bytes.EqualFold(keyword, []byte("Match"))
+		if section {
+			toks.add(n, Keyword)
+		} else {
+			toks.add(n, Variable)
+		}
+		rest = rest[n:]
+		n = blanks(rest)
+		toks.add(n, Whitespace)
+		rest = rest[n:]
+		if len(rest) > 0 && rest[0] == '=' {
+			toks.add(1, Punctuation)
+			n := blanks(rest[1:])
+			toks.add(n, Whitespace)
+			rest = rest[1+n:]
+		}
+		if section {
+			configWords(&toks, rest, Label)
+			return toks
+		}
+		configValue(&toks, rest, sshTokenRE)
+		return toks
+	}}
+}
+
+// newCrontabLexer returns a Lexer for crontab files. The five time fields
+// of entries are alternately Decimal and Literal, so that each is told
+// apart from the next, and special schedules such as "@daily" are
+// Keyword; commands are highlighted as shell. Environment settings, such
+// as "MAILTO=root", are highlighted as in .env files, and comments, after
+// "#", are Comment.
+func newCrontabLexer() Lexer {
+	env := newPropertiesLexer(true).(*lineLexer)
+	return &lineLexer{lexLine: func(line []byte) []lexToken {
+		var toks lineTokens
+		i := blanks(line)
+		toks.add(i, Whitespace)
+		rest := line[i:]
+		switch {
+		case len(rest) > 0 && rest[0] == '#':
+			toks.add(len(rest), Comment)
+			return toks
+		case len(rest) == 0:
+			return toks
+		case rest[0] != '@' && rest[0] != '*' && !isDecimal(rune(rest[0])) && bytes.IndexByte(rest, '=') > 0:
+			for _, tok := range env.lexLine(rest) {
+				toks.add(tok.n, tok.kind)
+			}
+			return toks
+		}
+
+		fields := 5
+		if rest[0] == '@' {
+			fields = 1
+		}
+		for f := 0; f < fields && len(rest) > 0; f++ {
+			n := 0
+			for n < len(rest) && !isBlank(rest[n]) {
+				n++
+			}
+			switch {
+			case fields == 1 && cronSchedules[string(rest[:n])]:
+				toks.add(n, Keyword)
+			case fields == 1:
+				toks.add(n, Plaintext)
+			default:
+				toks.add(n, cronFieldKinds[f])
+			}
+			rest = rest[n:]
+			n = blanks(rest)
+			toks.add(n, Whitespace)
+			rest = rest[n:]
+		}
+
+		addLanguageTokens(&toks, rest, "shell")
+		return toks
+	}}
+}
+
+// configSection adds the tokens of a section header of an INI-style file,
+// such as "[Service]", at the start of line.
+func configSection(toks *lineTokens, line []byte) {
+	end := bytes.IndexByte(line, ']')
+	if end < 0 {
+		end = len(line)
+	}
+	toks.add(1, Punctuation)
+	toks.add(end-1, Label)
+	if end < len(line) {
+		toks.add(1, Punctuation)
+		rest := line[end+1:]
+		n := blanks(rest)
+		toks.add(n, Whitespace)
+		toks.add(len(rest)-n, Comment)
+	}
+}
+
+// configValue adds the tokens of the value of a setting: a boolean, which
+// is Literal, a number, which is Decimal, or else String, in which the
+// matches of vars, if not nil, are Variable.
+func configValue(toks *lineTokens, value []byte, vars *regexp.Regexp) {
+	switch word := string(bytes.TrimRight(value, " \t\r")); {
+	case configLiterals[word]:
+		toks.add(len(word), Literal)
+		toks.add(len(value)-len(word), Whitespace)
+		return
+	case word != "" && len(bytes.Trim([]byte(word), "0123456789")) == 0:
+		toks.add(len(word), Decimal)
+		toks.add(len(value)-len(word), Whitespace)
+		return
+	}
+	start := 0
+	if vars == nil {
+		toks.add(len(value), String)
+		return
+	}
+	for _, m := range vars.FindAllIndex(value, -1) {
+		toks.add(m[0]-start, String)
+		toks.add(m[1]-m[0], Variable)
+		start = m[1]
+	}
+	toks.add(len(value)-start, String)
+}
+
+// configWords adds the tokens of the words of line, separated by blanks,
+// which are of the given kind.
+func configWords(toks *lineTokens, line []byte, kind Kind) {
+	for len(line) > 0 {
+		n := blanks(line)
+		if n == 0 {
+			for n < len(line) && !isBlank(line[n]) {
+				n++
+			}
+			toks.add(n, kind)
+		} else {
+			toks.add(n, Whitespace)
+		}
+		line = line[n:]
+	}
+}
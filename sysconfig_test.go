@@ -0,0 +1,49 @@
+package syntaxhighlight
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSysconfigLexers(t *testing.T) {
+	tests := []struct {
+		lang, src string
+		want      []token
+	}{
+		{"systemd", "[Service]\n# run it\nExecStart=/usr/bin/app --id %i \\\n  --home $HOME\nRestart = no\nRestartSec=5\n", []token{
+			{Punctuation, "["}, {Label, "Service"}, {Punctuation, "]"}, {Whitespace, "\n"},
+			{Comment, "# run it"}, {Whitespace, "\n"},
+			{Variable, "ExecStart"}, {Punctuation, "="}, {String, "/usr/bin/app --id "}, {Variable, "%i"}, {String, " \\"}, {Whitespace, "\n"},
+			{Whitespace, "  "}, {String, "--home "}, {Variable, "$HOME"}, {Whitespace, "\n"},
+			{Variable, "Restart"}, {Whitespace, " "}, {Punctuation, "="}, {Whitespace, " "}, {Literal, "no"}, {Whitespace, "\n"},
+			{Variable, "RestartSec"}, {Punctuation, "="}, {Decimal, "5"}, {Whitespace, "\n"},
+		}},
+		{"crontab", "MAILTO=root\n# nightly\n*/5 2 * * 1-5 /bin/backup > /dev/null\n@reboot echo up\n", []token{
+			{Variable, "MAILTO"}, {Punctuation, "="}, {String, "root"}, {Whitespace, "\n"},
+			{Comment, "# nightly"}, {Whitespace, "\n"},
+			{Decimal, "*/5"}, {Whitespace, " "}, {Literal, "2"}, {Whitespace, " "}, {Decimal, "*"}, {Whitespace, " "}, {Literal, "*"}, {Whitespace, " "},
+			{Decimal, "1-5"}, {Whitespace, " "}, {Punctuation, "/"}, {Plaintext, "bin"}, {Punctuation, "/"}, {Plaintext, "backup"}, {Whitespace, " "},
+			{Punctuation, ">"}, {Whitespace, " "}, {Punctuation, "/"}, {Plaintext, "dev"}, {Punctuation, "/"}, {Keyword, "null"}, {Whitespace, "\n"},
+			{Keyword, "@reboot"}, {Whitespace, " "}, {Plaintext, "echo"}, {Whitespace, " "}, {Plaintext, "up"}, {Whitespace, "\n"},
+		}},
+		{"ssh-config", "Host web *.example.com\n  HostName %h.internal\n  Port 2222\n  ForwardAgent=yes\n", []token{
+			{Keyword, "Host"}, {Whitespace, " "}, {Label, "web"}, {Whitespace, " "}, {Label, "*.example.com"}, {Whitespace, "\n"},
+			{Whitespace, "  "}, {Variable, "HostName"}, {Whitespace, " "}, {Variable, "%h"}, {String, ".internal"}, {Whitespace, "\n"},
+			{Whitespace, "  "}, {Variable, "Port"}, {Whitespace, " "}, {Decimal, "2222"}, {Whitespace, "\n"},
+			{Whitespace, "  "}, {Variable, "ForwardAgent"}, {Punctuation, "="}, {Literal, "yes"}, {Whitespace, "\n"},
+		}},
+	}
+	for _, test := range tests {
+		s := NewScanner([]byte(test.src))
+		s.UseLanguage(LookupLanguage(test.lang))
+		if got := scanAll(t, s); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s %q:\nwant %v\ngot  %v", test.lang, test.src, test.want, got)
+		}
+	}
+
+	for name, want := range map[string]string{"nginx.service": "systemd", "/etc/crontab": "crontab", "/etc/ssh/sshd_config": "ssh-config"} {
+		if got := DetectLanguage(name, nil); got != want {
+			t.Errorf("%s: want %q, got %q", name, want, got)
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package syntaxhighlight
+
+import "bytes"
+
+// templateTag is a kind of tag of a template language, such as "{{ }}".
+type templateTag struct {
+	open, close string
+	comment     bool // the tag is a comment, highlighted as a whole
+}
+
+// templateSyntax describes a template language.
+type templateSyntax struct {
+	// tags are the tags of the language. Tags whose opening delimiter is
+	// a prefix of that of another, such as "{{" of "{{-", come after it.
+	tags []templateTag
+	// keywords are the keywords of the code in tags, and literals the
+	// identifiers that are literals, such as "true".
+	keywords, literals map[string]bool
+	// lang, if set, is the language of the code in tags, whose own rules
+	// are used instead of keywords and literals.
+	lang string
+}
+
+// goTemplateSyntax is the syntax of Go's text/template and html/template.
+var goTemplateSyntax = &templateSyntax{
+	tags: []templateTag{
+		{open: "{{/*", close: "*/}}", comment: true},
+		{open: "{{- /*", close: "*/ -}}", comment: true},
+		{open: "{{-", close: "}}"},
+		{open: "{{", close: "}}"},
+	},
+	keywords: setOf("if", "else", "end", "range", "with", "define", "template", "block", "break", "continue"),
+	literals: setOf("true", "false", "nil"),
+}
+
+// jinjaSyntax is the syntax of Jinja2 and Django templates.
+var jinjaSyntax = &templateSyntax{
+	tags: []templateTag{
+		{open: "{#", close: "#}", comment: true},
+		{open: "{%-", close: "%}"},
+		{open: "{%+", close: "%}"},
+		{open: "{%", close: "%}"},
+		{open: "{{-", close: "}}"},
+		{open: "{{", close: "}}"},
+	},
+	keywords: setOf(
+		"if", "elif", "else", "endif", "for", "endfor", "in", "not", "and", "or", "is",
+		"block", "endblock", "extends", "include", "import", "from", "as", "macro", "endmacro",
+		"call", "endcall", "set", "endset", "with", "endwith", "filter", "endfilter", "raw", "endraw",
+		"autoescape", "endautoescape", "load", "url", "csrf_token", "empty",
+	),
+	literals: setOf("true", "false", "none", "True", "False", "None"),
+}
+
+// erbSyntax is the syntax of ERB, Ruby's embedded templates.
+var erbSyntax = &templateSyntax{
+	tags: []templateTag{
+		{open: "<%#", close: "%>", comment: true},
+		{open: "<%=", close: "%>"},
+		{open: "<%-", close: "%>"},
+		{open: "<%", close: "%>"},
+	},
+	lang: "ruby",
+}
+
+// setOf returns a set of the given words.
+func setOf(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// templateLexer is a Lexer that overlays a template language on a host
+// language, such as Jinja on HTML. The host lexer sees the source with the
+// template tags masked out, so that a host token around a tag, such as an
+// attribute value in `href="{{ .URL }}"`, is split around it rather than
+// cut short by it.
+type templateLexer struct {
+	syntax *templateSyntax
+	host   Lexer
+
+	queue []lexToken // host tokens from the current position on
+	close string     // closing delimiter of the current tag, if in one
+	code  Lexer      // lexer of the code of the current tag
+	buf   []byte     // masked source given to the host lexer
+}
+
+// newTemplateLexer returns a function making Lexers for templates with the
+// given syntax, with the text around tags in the host language, or plain
+// text if host is "".
+func newTemplateLexer(syntax *templateSyntax, host string) func() Lexer {
+	return func() Lexer {
+		var lang *Language
+		if host != "" {
+			lang = LookupLanguage(host)
+		}
+		return &templateLexer{syntax: syntax, host: newLanguageLexer(lang)}
+	}
+}
+
+func (l *templateLexer) Lex(data []byte, atEOF bool) (int, Kind) {
+	if len(l.queue) == 0 && !l.lexHost(data, atEOF) {
+		return 0, 0
+	}
+	n, kind := l.lexTemplate(data, atEOF)
+	l.consume(n)
+	return n, kind
+}
+
+// lexTemplate returns the token at the start of data: a tag delimiter or
+// the code in a tag, or else the host token there, cut short at the next
+// tag.
+func (l *templateLexer) lexTemplate(data []byte, atEOF bool) (int, Kind) {
+	if l.close != "" {
+		for _, end := range []string{"-" + l.close, l.close} {
+			if hasPrefix(data, end) {
+				l.close, l.code = "", nil
+				return len(end), Punctuation
+			}
+		}
+		return l.code.Lex(data[:l.codeEnd(data)], true)
+	}
+	if tag, ok := l.tagAt(data); ok {
+		if tag.comment {
+			return markupUntil(data, len(tag.open), tag.close, atEOF), Comment
+		}
+		l.close, l.code = tag.close, l.newCodeLexer()
+		return len(tag.open), Punctuation
+	}
+	n, kind := l.queue[0].n, l.queue[0].kind
+	if i := l.nextTag(data[:l.searchEnd(data, n)]); i >= 0 && i < n {
+		n = i
+	}
+	return n, kind
+}
+
+// codeEnd returns the length of the code of the current tag at the start
+// of data, which ends before its closing delimiter and any "-" trim marker
+// before it. The tag is complete in data, as the host lexer has been past
+// it.
+func (l *templateLexer) codeEnd(data []byte) int {
+	i := bytes.Index(data, []byte(l.close))
+	if i < 0 {
+		return len(data)
+	}
+	if i > 0 && data[i-1] == '-' {
+		i--
+	}
+	return i
+}
+
+// newCodeLexer returns a Lexer for the code in a tag.
+func (l *templateLexer) newCodeLexer() Lexer {
+	if l.syntax.lang != "" {
+		return newLanguageLexer(LookupLanguage(l.syntax.lang))
+	}
+	return &templateCodeLexer{syntax: l.syntax, Lexer: &languageLexer{&Scanner{}}}
+}
+
+// tagAt returns the tag whose opening delimiter is at the start of data.
+func (l *templateLexer) tagAt(data []byte) (templateTag, bool) {
+	for _, tag := range l.syntax.tags {
+		if hasPrefix(data, tag.open) {
+			return tag, true
+		}
+	}
+	return templateTag{}, false
+}
+
+// nextTag returns the index of the first opening delimiter of a tag in
+// data, or -1 if there is none.
+func (l *templateLexer) nextTag(data []byte) int {
+	next := -1
+	for _, tag := range l.syntax.tags {
+		if i := bytes.Index(data, []byte(tag.open)); i >= 0 && (next < 0 || i < next) {
+			next = i
+		}
+	}
+	return next
+}
+
+// templateWindow is the initial length of the source that the host lexer
+// is given at a time. It is doubled for host tokens that need more, so
+// that masking tags costs in proportion to the tokens rather than to the
+// source.
+const templateWindow = 256
+
+// lexHost lexes host tokens from the start of data into the queue, with
+// the tags in data masked out. Tokens are lexed until the host lexer is
+// past the tags it has reached, so that its position is never inside a
+// tag when the queue runs out. It returns false if more data is needed.
+func (l *templateLexer) lexHost(data []byte, atEOF bool) bool {
+	h := 0
+	for limit := templateWindow; ; limit *= 2 {
+		masked, tags, ok := l.mask(data, limit, atEOF)
+		if !ok {
+			return len(l.queue) > 0
+		}
+		whole := len(masked) == len(data)
+		for h < len(masked) {
+			n, kind := l.host.Lex(masked[h:], atEOF && whole)
+			if n == 0 {
+				break
+			}
+			l.queue = append(l.queue, lexToken{n, kind})
+			h += n
+			inTag := false
+			for _, t := range tags {
+				if t[0] <= h && h < t[1] {
+					inTag = true
+				}
+			}
+			if !inTag {
+				return true
+			}
+		}
+		if whole {
+			return len(l.queue) > 0
+		}
+	}
+}
+
+// mask returns a copy of the first limit bytes of data, or more to include
+// whole tags, in which the bytes of tags, other than newlines, are replaced
+// by "x", and the start and end of the tags. It returns false if more data
+// is needed, to find the end of a tag or because data ends with what may be
+// the start of an opening delimiter.
+func (l *templateLexer) mask(data []byte, limit int, atEOF bool) ([]byte, [][2]int, bool) {
+	if limit > len(data) {
+		limit = len(data)
+	}
+	var tags [][2]int
+	i := 0
+	if l.close != "" {
+		i = -1 // in a tag, whose opening delimiter is already consumed
+	}
+	for i < limit {
+		var tag templateTag
+		if i < 0 {
+			tag, i = templateTag{close: l.close}, 0
+		} else {
+			j := l.nextTag(data[i:l.searchEnd(data, limit)])
+			if j < 0 {
+				break
+			}
+			i += j
+			tag, _ = l.tagAt(data[i:])
+		}
+		end := bytes.Index(data[i+len(tag.open):], []byte(tag.close))
+		if end < 0 {
+			if !atEOF {
+				return nil, nil, false
+			}
+			end = len(data)
+		} else {
+			end += i + len(tag.open) + len(tag.close)
+		}
+		tags = append(tags, [2]int{i, end})
+		if end > limit {
+			limit = end
+		}
+		i = end
+	}
+	if !atEOF && limit == len(data) {
+		for _, tag := range l.syntax.tags {
+			for k := 1; k < len(tag.open) && k <= len(data); k++ {
+				if bytes.HasSuffix(data, []byte(tag.open[:k])) {
+					return nil, nil, false
+				}
+			}
+		}
+	}
+	if len(tags) == 0 {
+		return data[:limit:limit], nil, true
+	}
+	l.buf = append(l.buf[:0], data[:limit]...)
+	for _, t := range tags {
+		for k := t[0]; k < t[1]; k++ {
+			if l.buf[k] != '\n' {
+				l.buf[k] = 'x'
+			}
+		}
+	}
+	return l.buf[:limit:limit], tags, true
+}
+
+// searchEnd returns the end of the part of data to search for the opening
+// delimiters that start before limit.
+func (l *templateLexer) searchEnd(data []byte, limit int) int {
+	end := limit
+	for _, tag := range l.syntax.tags {
+		if limit+len(tag.open)-1 > end {
+			end = limit + len(tag.open) - 1
+		}
+	}
+	if end > len(data) {
+		end = len(data)
+	}
+	return end
+}
+
+// consume removes n bytes from the start of the queue.
+func (l *templateLexer) consume(n int) {
+	for n > 0 && len(l.queue) > 0 {
+		if l.queue[0].n > n {
+			l.queue[0].n -= n
+			return
+		}
+		n -= l.queue[0].n
+		l.queue = l.queue[1:]
+	}
+}
+
+// templateCodeLexer is a Lexer for the code in the tags of a template
+// language without a language of its own, which highlights its keywords
+// and literals.
+type templateCodeLexer struct {
+	Lexer
+	syntax *templateSyntax
+}
+
+func (l *templateCodeLexer) Lex(data []byte, atEOF bool) (int, Kind) {
+	n, kind := l.Lexer.Lex(data, atEOF)
+	switch word := string(data[:n]); {
+	case kind == Whitespace || kind == Punctuation || kind == String || kind == Decimal || kind == Comment:
+	case l.syntax.keywords[word]:
+		kind = Keyword
+	case l.syntax.literals[word]:
+		kind = Literal
+	case kind == Keyword:
+		kind = Plaintext
+	}
+	return n, kind
+}
@@ -0,0 +1,39 @@
+package syntaxhighlight
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTemplateLexer(t *testing.T) {
+	tests := []struct {
+		lang, src string
+		want      []token
+	}{
+		{"html+gotemplate", `<a href="/u/{{ .Name }}">{{- if $ok -}}hi{{end}}</a>{{/* c */}}`, []token{
+			{Punctuation, "<"}, {HTMLTag, "a"}, {Whitespace, " "}, {HTMLAttrName, "href"}, {Punctuation, "="},
+			{HTMLAttrValue, `"/u/`}, {Punctuation, "{{"}, {Whitespace, " "}, {Punctuation, "."}, {Type, "Name"}, {Whitespace, " "}, {Punctuation, "}}"},
+			{HTMLAttrValue, `"`}, {Punctuation, ">"},
+			{Punctuation, "{{-"}, {Whitespace, " "}, {Keyword, "if"}, {Whitespace, " "}, {Variable, "$ok"}, {Whitespace, " "}, {Punctuation, "-}}"},
+			{Plaintext, "hi"}, {Punctuation, "{{"}, {Keyword, "end"}, {Punctuation, "}}"},
+			{Punctuation, "</"}, {HTMLTag, "a"}, {Punctuation, ">"}, {Comment, "{{/* c */}}"},
+		}},
+		{"jinja", "{% for x in xs %}\n{# note #}{{ x|upper }}{% endfor %}", []token{
+			{Punctuation, "{%"}, {Whitespace, " "}, {Keyword, "for"}, {Whitespace, " "}, {Plaintext, "x"}, {Whitespace, " "},
+			{Keyword, "in"}, {Whitespace, " "}, {Plaintext, "xs"}, {Whitespace, " "}, {Punctuation, "%}"}, {Whitespace, "\n"},
+			{Comment, "{# note #}"}, {Punctuation, "{{"}, {Whitespace, " "}, {Plaintext, "x"}, {Punctuation, "|"}, {Plaintext, "upper"}, {Whitespace, " "}, {Punctuation, "}}"},
+			{Punctuation, "{%"}, {Whitespace, " "}, {Keyword, "endfor"}, {Whitespace, " "}, {Punctuation, "%}"},
+		}},
+		{"erb", "Hi <%= @user.name %>!<%# c %>", []token{
+			{Plaintext, "Hi "}, {Punctuation, "<%="}, {Whitespace, " "}, {Punctuation, "@"}, {Plaintext, "user"}, {Punctuation, "."}, {Plaintext, "name"}, {Whitespace, " "}, {Punctuation, "%>"},
+			{Plaintext, "!"}, {Comment, "<%# c %>"},
+		}},
+	}
+	for _, test := range tests {
+		s := NewScanner([]byte(test.src))
+		s.UseLanguage(LookupLanguage(test.lang))
+		if got := scanAll(t, s); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s %q:\nwant %v\ngot  %v", test.lang, test.src, test.want, got)
+		}
+	}
+}
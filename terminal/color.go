@@ -0,0 +1,49 @@
+package terminal
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// ColorMode says when to write colored output.
+type ColorMode int
+
+const (
+	// ColorAuto writes colors if the output is a terminal and the NO_COLOR
+	// environment variable is not set (see https://no-color.org).
+	ColorAuto ColorMode = iota
+	// ColorAlways writes colors, even if NO_COLOR is set.
+	ColorAlways
+	// ColorNever never writes colors.
+	ColorNever
+)
+
+// ParseColorMode parses a color mode given as "auto", "always" or "never",
+// as in the --color flags of many command line tools.
+func ParseColorMode(s string) (ColorMode, error) {
+	switch s {
+	case "auto", "":
+		return ColorAuto, nil
+	case "always":
+		return ColorAlways, nil
+	case "never":
+		return ColorNever, nil
+	}
+	return 0, fmt.Errorf("terminal: invalid color mode %q", s)
+}
+
+// Enabled reports whether colors are to be written to f in mode m.
+func (m ColorMode) Enabled(f *os.File) bool {
+	switch m {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return term.IsTerminal(int(f.Fd()))
+}
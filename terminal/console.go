@@ -0,0 +1,138 @@
+package terminal
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Writer returns a writer for colored output to f, such as that of
+// syntaxhighlight.ANSIPrinter. On Windows, it turns on the processing of
+// ANSI escape sequences by the console of f, which older consoles such as
+// those of cmd.exe do not do by default. Consoles that do not support it
+// at all get a writer that sets their colors through the console API
+// instead, approximating the colors of the escape sequences with the 16 of
+// the console. Elsewhere, and if f is not a console, f is returned as is.
+func Writer(f *os.File) io.Writer {
+	return newWriter(f)
+}
+
+// Console text attributes, as used by the console API.
+const (
+	foregroundMask      = 0x0f
+	foregroundIntensity = 0x08
+)
+
+// consolePalette holds the default colors of the console, by their
+// foreground attributes.
+var consolePalette = [16][3]int{
+	{0, 0, 0}, {0, 0, 128}, {0, 128, 0}, {0, 128, 128},
+	{128, 0, 0}, {128, 0, 128}, {128, 128, 0}, {192, 192, 192},
+	{128, 128, 128}, {0, 0, 255}, {0, 255, 0}, {0, 255, 255},
+	{255, 0, 0}, {255, 0, 255}, {255, 255, 0}, {255, 255, 255},
+}
+
+// consoleColor returns the foreground attributes of the console color
+// closest to the given one.
+func consoleColor(r, g, b int) uint16 {
+	best, bestDist := 0, -1
+	for i, c := range consolePalette {
+		dr, dg, db := r-c[0], g-c[1], b-c[2]
+		if dist := dr*dr + dg*dg + db*db; bestDist < 0 || dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	return uint16(best)
+}
+
+// consoleWriter writes text to w and translates the "select graphic
+// rendition" escape sequences in it into calls of setAttr, for consoles
+// that do not interpret escape sequences. Other escape sequences are
+// dropped.
+type consoleWriter struct {
+	w       io.Writer
+	setAttr func(attr uint16) error
+	reset   uint16 // attributes restored by a reset
+	attr    uint16 // current attributes
+	pending []byte // incomplete escape sequence
+}
+
+func (c *consoleWriter) Write(p []byte) (int, error) {
+	data := p
+	if len(c.pending) > 0 {
+		data = append(c.pending, p...)
+		c.pending = nil
+	}
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\x1b')
+		if i < 0 {
+			i = len(data)
+		}
+		if i > 0 {
+			if _, err := c.w.Write(data[:i]); err != nil {
+				return 0, err
+			}
+		}
+		data = data[i:]
+		if len(data) == 0 {
+			break
+		}
+		n := csiLen(data)
+		if n == 0 {
+			c.pending = append([]byte(nil), data...)
+			break
+		}
+		if data[n-1] == 'm' && data[1] == '[' {
+			if err := c.sgr(string(data[2 : n-1])); err != nil {
+				return 0, err
+			}
+		}
+		data = data[n:]
+	}
+	return len(p), nil
+}
+
+// csiLen returns the length of the escape sequence at the start of data, or
+// 0 if it is incomplete.
+func csiLen(data []byte) int {
+	if len(data) < 2 {
+		return 0
+	}
+	if data[1] != '[' {
+		return 2
+	}
+	for i := 2; i < len(data); i++ {
+		if data[i] >= 0x40 && data[i] <= 0x7e {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// sgr applies the parameters of a "select graphic rendition" escape
+// sequence.
+func (c *consoleWriter) sgr(params string) error {
+	attr := c.attr
+	ps := strings.Split(params, ";")
+	for i := 0; i < len(ps); i++ {
+		switch ps[i] {
+		case "", "0":
+			attr = c.reset
+		case "1":
+			attr |= foregroundIntensity
+		case "38":
+			if i+4 < len(ps) && ps[i+1] == "2" {
+				var rgb [3]int
+				for j := range rgb {
+					rgb[j], _ = strconv.Atoi(ps[i+2+j])
+				}
+				attr = attr&^foregroundMask | consoleColor(rgb[0], rgb[1], rgb[2])
+				i += 4
+			}
+		}
+	}
+	c.attr = attr
+	return c.setAttr(attr)
+}
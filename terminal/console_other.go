@@ -0,0 +1,15 @@
+//go:build !windows
+// +build !windows
+
+package terminal
+
+import (
+	"io"
+	"os"
+)
+
+// newWriter returns f, as terminals other than the Windows console
+// interpret escape sequences.
+func newWriter(f *os.File) io.Writer {
+	return f
+}
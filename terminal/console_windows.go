@@ -0,0 +1,41 @@
+package terminal
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+var procSetConsoleTextAttribute = windows.NewLazySystemDLL("kernel32.dll").NewProc("SetConsoleTextAttribute")
+
+// newWriter turns on virtual terminal processing for the console of f, or
+// returns a consoleWriter for it if that fails.
+func newWriter(f *os.File) io.Writer {
+	h := windows.Handle(f.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(h, &mode); err != nil {
+		// not a console
+		return f
+	}
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 ||
+		windows.SetConsoleMode(h, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING) == nil {
+		return f
+	}
+
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(h, &info); err != nil {
+		return f
+	}
+	return &consoleWriter{
+		w:     f,
+		reset: info.Attributes,
+		attr:  info.Attributes,
+		setAttr: func(attr uint16) error {
+			if ok, _, err := procSetConsoleTextAttribute.Call(uintptr(h), uintptr(attr)); ok == 0 {
+				return err
+			}
+			return nil
+		},
+	}
+}
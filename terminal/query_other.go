@@ -0,0 +1,11 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package terminal
+
+import "time"
+
+// queryBackground is not supported on this system.
+func queryBackground(timeout time.Duration) (rgb [3]float64, ok bool) {
+	return rgb, false
+}
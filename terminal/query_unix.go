@@ -0,0 +1,52 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package terminal
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// queryBackground asks the controlling terminal for its background color
+// and waits up to timeout for the answer.
+func queryBackground(timeout time.Duration) (rgb [3]float64, ok bool) {
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		return rgb, false
+	}
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return rgb, false
+	}
+	defer tty.Close()
+	state, err := term.MakeRaw(int(tty.Fd()))
+	if err != nil {
+		return rgb, false
+	}
+	defer term.Restore(int(tty.Fd()), state)
+
+	if _, err := tty.WriteString("\x1b]11;?\x1b\\"); err != nil {
+		return rgb, false
+	}
+	if err := tty.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		// without a deadline, a terminal that does not answer would
+		// block forever
+		return rgb, false
+	}
+	var answer []byte
+	buf := make([]byte, 64)
+	for len(answer) < 256 {
+		n, err := tty.Read(buf)
+		answer = append(answer, buf[:n]...)
+		if s := string(answer); strings.HasSuffix(s, "\x07") || strings.HasSuffix(s, "\x1b\\") {
+			return parseOSC11(s)
+		}
+		if err != nil {
+			break
+		}
+	}
+	return rgb, false
+}
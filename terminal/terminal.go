@@ -0,0 +1,82 @@
+// Package terminal detects properties of the terminal that highlighted code
+// is written to, so that a theme that suits it can be picked.
+package terminal
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/syntaxhighlight"
+)
+
+// queryTimeout is how long to wait for the terminal to answer a query.
+// Terminals that answer do so within a few milliseconds.
+const queryTimeout = 100 * time.Millisecond
+
+// DarkBackground reports whether the background of the terminal is dark.
+// It asks the terminal for its background color with an OSC 11 query,
+// which most terminal emulators answer, and falls back to the COLORFGBG
+// environment variable set by some of those that do not. ok is false if
+// neither gives an answer, such as when the output is not a terminal.
+func DarkBackground() (dark, ok bool) {
+	if rgb, ok := queryBackground(queryTimeout); ok {
+		return isDark(rgb), true
+	}
+	return parseCOLORFGBG(os.Getenv("COLORFGBG"))
+}
+
+// Theme returns a theme that suits the background of the terminal:
+// syntaxhighlight.DarkTheme on a dark background and
+// syntaxhighlight.DefaultTheme otherwise.
+func Theme() syntaxhighlight.Theme {
+	if dark, ok := DarkBackground(); ok && dark {
+		return syntaxhighlight.DarkTheme
+	}
+	return syntaxhighlight.DefaultTheme
+}
+
+// isDark reports whether the color rgb, with components from 0 to 1, is
+// dark.
+func isDark(rgb [3]float64) bool {
+	return 0.299*rgb[0]+0.587*rgb[1]+0.114*rgb[2] < 0.5
+}
+
+// parseCOLORFGBG parses the value of the COLORFGBG environment variable,
+// such as "15;0", whose last field is the ANSI color number of the
+// background.
+func parseCOLORFGBG(v string) (dark, ok bool) {
+	fields := strings.Split(v, ";")
+	bg, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil || bg < 0 || bg > 15 {
+		return false, false
+	}
+	// colors 0-6 and 8 are black and dark colors; 7 and 9-15 are light
+	return bg < 7 || bg == 8, true
+}
+
+// parseOSC11 parses the answer of a terminal to an OSC 11 query, such as
+// "\x1b]11;rgb:1e1e/1e1e/1e1e\x1b\\", into the background color.
+func parseOSC11(answer string) (rgb [3]float64, ok bool) {
+	i := strings.Index(answer, "rgb:")
+	if i < 0 {
+		return rgb, false
+	}
+	answer = strings.TrimRight(answer[i+len("rgb:"):], "\x07\x1b\\")
+	parts := strings.Split(answer, "/")
+	if len(parts) != 3 {
+		return rgb, false
+	}
+	for i, part := range parts {
+		if len(part) < 1 || len(part) > 4 {
+			return rgb, false
+		}
+		v, err := strconv.ParseUint(part, 16, 16)
+		if err != nil {
+			return rgb, false
+		}
+		rgb[i] = float64(v) / float64(uint64(1)<<(4*uint(len(part)))-1)
+	}
+	return rgb, true
+}
@@ -0,0 +1,110 @@
+package terminal
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseCOLORFGBG(t *testing.T) {
+	tests := []struct {
+		v        string
+		dark, ok bool
+	}{
+		{"15;0", true, true},
+		{"0;15", false, true},
+		{"0;7", false, true},
+		{"12;8", true, true},
+		{"15;default;0", true, true},
+		{"default;default", false, false},
+		{"", false, false},
+	}
+	for _, test := range tests {
+		if dark, ok := parseCOLORFGBG(test.v); dark != test.dark || ok != test.ok {
+			t.Errorf("%q: want %v, %v, got %v, %v", test.v, test.dark, test.ok, dark, ok)
+		}
+	}
+}
+
+func TestParseOSC11(t *testing.T) {
+	tests := []struct {
+		answer   string
+		dark, ok bool
+	}{
+		{"\x1b]11;rgb:1e1e/1e1e/1e1e\x1b\\", true, true},
+		{"\x1b]11;rgb:ffff/ffff/dddd\x07", false, true},
+		{"\x1b]11;rgb:f/f/f\x07", false, true},
+		{"\x1b]11;rgb:00/00/80\x07", true, true},
+		{"\x1b]11;rgb:zz/00/00\x07", false, false},
+		{"\x1b[?1;2c", false, false},
+	}
+	for _, test := range tests {
+		rgb, ok := parseOSC11(test.answer)
+		if ok != test.ok || ok && isDark(rgb) != test.dark {
+			t.Errorf("%q: want %v, %v, got %v (%v)", test.answer, test.dark, test.ok, ok, rgb)
+		}
+	}
+}
+
+func TestColorMode(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tests := []struct {
+		mode    string
+		noColor string
+		want    bool
+	}{
+		{"always", "", true},
+		{"always", "1", true},
+		{"never", "", false},
+		{"auto", "", false}, // not a terminal
+		{"auto", "1", false},
+	}
+	for _, test := range tests {
+		t.Setenv("NO_COLOR", test.noColor)
+		mode, err := ParseColorMode(test.mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := mode.Enabled(f); got != test.want {
+			t.Errorf("%s, NO_COLOR=%q: want %v, got %v", test.mode, test.noColor, test.want, got)
+		}
+	}
+
+	if _, err := ParseColorMode("sometimes"); err == nil {
+		t.Error("want error for invalid mode")
+	}
+}
+
+func TestConsoleWriter(t *testing.T) {
+	var (
+		buf   bytes.Buffer
+		calls []string
+	)
+	c := &consoleWriter{
+		w:     &buf,
+		reset: 0x07,
+		attr:  0x07,
+		setAttr: func(attr uint16) error {
+			calls = append(calls, fmt.Sprintf("%s%#x", buf.String(), attr))
+			return nil
+		},
+	}
+	for _, s := range []string{"a\x1b[1;38;2;250;10;10mb\x1b", "[0m\x1b[38;2;0;0;", "120mc\x1b[Kd"} {
+		if n, err := c.Write([]byte(s)); n != len(s) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", s, n, err)
+		}
+	}
+	if want := "abcd"; buf.String() != want {
+		t.Errorf("want text %q, got %q", want, buf.String())
+	}
+	if want := []string{"a0xc", "ab0x7", "ab0x1"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("want attributes set %q, got %q", want, calls)
+	}
+}
@@ -0,0 +1,2 @@
+!function(e,t){"object"==typeof exports&&"undefined"!=typeof module?module.exports=t():"function"==typeof define&&define.amd?define(t):(e=e||self).debounce=t()}(this,function(){"use strict";function e(e,t,n){var o,i,u,r,c;function f(){var a=Date.now()-r;a<t&&a>=0?o=setTimeout(f,t-a):(o=null,n||(c=e.apply(u,i),u=i=null))}null==t&&(t=100);var a=function(){u=this,i=arguments,r=Date.now();var a=n&&!o;return o||(o=setTimeout(f,t)),a&&(c=e.apply(u,i),u=i=null),c};return a.clear=function(){o&&(clearTimeout(o),o=null)},a.flush=function(){o&&(c=e.apply(u,i),u=i=null,clearTimeout(o),o=null)},a}return e.debounce=e,e});
+var n=[1,2,3].map(function(x){return{v:x*2,k:"k"+x}}).filter(function(o){return o.v>2&&o.k!=="k3"||!o.v}),s=n.reduce(function(a,b){return a+b.v},0);if(s>=4){console.log({n:n,s:s})}else{throw new Error("bad: "+JSON.stringify(n))}
@@ -0,0 +1,147 @@
+package syntaxhighlight
+
+import "bytes"
+
+// texVerbatimEnvironments are the environments of LaTeX whose contents are
+// not TeX but text to be typeset as is.
+var texVerbatimEnvironments = setOf("verbatim", "verbatim*", "Verbatim", "lstlisting", "comment")
+
+// texLexer is a Lexer for TeX and LaTeX source.
+type texLexer struct {
+	env      bool   // the name of an environment is next, after \begin or \end
+	begin    bool   // the environment is begun rather than ended
+	verbatim string // name of the verbatim environment being begun
+	end      string // end of the contents of the current verbatim environment
+}
+
+// newTeXLexer returns a Lexer for TeX and LaTeX source. Control sequences
+// such as \section are Keyword, and control symbols such as \% Literal;
+// the names of environments, as in \begin{itemize}, are Tag. Math, between
+// "$", "$$", "\(" and "\)" or "\[" and "\]", is String as a whole, as are
+// the contents of verbatim environments and of \verb. Comments, after "%",
+// are Comment, and macro parameters such as #1 Variable.
+func newTeXLexer() Lexer {
+	return &texLexer{}
+}
+
+func (l *texLexer) Lex(data []byte, atEOF bool) (int, Kind) {
+	if l.end != "" {
+		i := bytes.Index(data, []byte(l.end))
+		if i < 0 {
+			if !atEOF {
+				return 0, 0
+			}
+			i = len(data)
+		}
+		if i > 0 {
+			return i, String
+		}
+		l.end = ""
+	}
+	if !atEOF && len(data) < 3 {
+		return 0, 0
+	}
+
+	switch c := data[0]; {
+	case isBlank(c) || c == '\n':
+		n := markupSpace(data)
+		if n == len(data) && !atEOF {
+			return 0, 0
+		}
+		return n, Whitespace
+	case c == '%':
+		return scanLine(data, atEOF), Comment
+	case hasPrefix(data, "$$"):
+		return texMath(data, 2, "$$", atEOF), String
+	case c == '$':
+		return texMath(data, 1, "$", atEOF), String
+	case hasPrefix(data, `\(`):
+		return texMath(data, 2, `\)`, atEOF), String
+	case hasPrefix(data, `\[`):
+		return texMath(data, 2, `\]`, atEOF), String
+	case hasPrefix(data, `\verb`) && len(data) > 5 && !texLetter(data[5]) && data[5] != '*':
+		// the argument of \verb is delimited by the character after it
+		return markupUntil(data, 6, string(data[5]), atEOF), String
+	case c == '\\':
+		n := 1
+		for n < len(data) && (texLetter(data[n]) || data[n] == '@') {
+			n++
+		}
+		if n < len(data) && n > 1 && data[n] == '*' {
+			n++
+		}
+		if n == len(data) && !atEOF {
+			return 0, 0
+		}
+		if n == 1 {
+			if len(data) == 1 {
+				return 1, Punctuation
+			}
+			return 2, Literal
+		}
+		name := string(data[1:n])
+		l.env, l.begin = name == "begin" || name == "end", name == "begin"
+		return n, Keyword
+	case c == '{':
+		return 1, Punctuation
+	case c == '}':
+		l.env = false
+		if l.verbatim != "" {
+			l.end = `\end{` + l.verbatim + "}"
+			l.verbatim = ""
+		}
+		return 1, Punctuation
+	case c == '#' && len(data) > 1 && '1' <= data[1] && data[1] <= '9':
+		return 2, Variable
+	case bytes.IndexByte([]byte("[]&~^_#"), c) >= 0:
+		l.env = false
+		return 1, Punctuation
+	}
+
+	n := 0
+	for n < len(data) && !isBlank(data[n]) && bytes.IndexByte([]byte("\n\\%${}[]&~^_#"), data[n]) < 0 {
+		n++
+	}
+	if l.env {
+		n = bytes.IndexByte(data, '}')
+		if n < 0 {
+			if !atEOF {
+				return 0, 0
+			}
+			n = len(data)
+		}
+		if name := string(data[:n]); l.begin && texVerbatimEnvironments[name] {
+			l.verbatim = name
+		}
+		l.env = false
+		return n, Tag
+	}
+	if n == len(data) && !atEOF {
+		return 0, 0
+	}
+	return n, Plaintext
+}
+
+// texMath returns the length of the math at the start of data, which ends
+// with end, searched for from offset i after any escaped characters. Math
+// that is not closed runs to the end of the source.
+func texMath(data []byte, i int, end string, atEOF bool) int {
+	for ; i < len(data); i++ {
+		if hasPrefix(data[i:], end) {
+			return i + len(end)
+		}
+		if data[i] == '\\' {
+			i++
+		}
+	}
+	if !atEOF {
+		return 0
+	}
+	return len(data)
+}
+
+// texLetter reports whether b is a letter, of which the names of control
+// sequences are made.
+func texLetter(b byte) bool {
+	return 'a' <= lower(rune(b)) && lower(rune(b)) <= 'z'
+}
@@ -0,0 +1,44 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"testing/iotest"
+)
+
+func TestTeXLexer(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []token
+	}{
+		{"\\section*{Intro} % todo\nCost: \\$5, $x^2 + \\$$ and \\[ a \\]", []token{
+			{Keyword, `\section*`}, {Punctuation, "{"}, {Plaintext, "Intro"}, {Punctuation, "}"}, {Whitespace, " "}, {Comment, "% todo"},
+			{Whitespace, "\n"}, {Plaintext, "Cost:"}, {Whitespace, " "}, {Literal, `\$`}, {Plaintext, "5,"}, {Whitespace, " "},
+			{String, `$x^2 + \$$`}, {Whitespace, " "}, {Plaintext, "and"}, {Whitespace, " "}, {String, `\[ a \]`},
+		}},
+		{"\\begin{itemize}\n\\item~a\n\\end{itemize}", []token{
+			{Keyword, `\begin`}, {Punctuation, "{"}, {Tag, "itemize"}, {Punctuation, "}"}, {Whitespace, "\n"},
+			{Keyword, `\item`}, {Punctuation, "~"}, {Plaintext, "a"}, {Whitespace, "\n"},
+			{Keyword, `\end`}, {Punctuation, "{"}, {Tag, "itemize"}, {Punctuation, "}"},
+		}},
+		{"\\begin{verbatim}\n$ % \\x\n\\end{verbatim} \\verb|\\a| \\newcommand{\\f}[1]{#1}", []token{
+			{Keyword, `\begin`}, {Punctuation, "{"}, {Tag, "verbatim"}, {Punctuation, "}"}, {String, "\n$ % \\x\n"},
+			{Keyword, `\end`}, {Punctuation, "{"}, {Tag, "verbatim"}, {Punctuation, "}"}, {Whitespace, " "}, {String, `\verb|\a|`},
+			{Whitespace, " "}, {Keyword, `\newcommand`}, {Punctuation, "{"}, {Keyword, `\f`}, {Punctuation, "}"}, {Punctuation, "["},
+			{Plaintext, "1"}, {Punctuation, "]"}, {Punctuation, "{"}, {Variable, "#1"}, {Punctuation, "}"},
+		}},
+	}
+	for _, test := range tests {
+		for _, oneByte := range []bool{false, true} {
+			s := NewScanner([]byte(test.src))
+			if oneByte {
+				s = NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(test.src))))
+			}
+			s.UseLanguage(LookupLanguage("latex"))
+			if got := scanAll(t, s); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%q:\nwant %v\ngot  %v", test.src, test.want, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Theme is a color scheme: it maps token kinds to text styles.
+type Theme struct {
+	Name string
+
+	// Background and Foreground are the default colors of the text, as
+	// "#rrggbb" or "#rgb".
+	Background string
+	Foreground string
+
+	// Styles holds the style of each kind. Kinds without a style are
+	// written in the foreground color.
+	Styles map[Kind]Style
+}
+
+// Style holds the text style properties of a token kind.
+type Style struct {
+	// Color is the text color, as "#rrggbb" or "#rgb". If empty, the
+	// theme's foreground color is used.
+	Color     string
+	Bold      bool
+	Italic    bool
+	Underline bool
+
+	// Class, if set, is the CSS class of the kind, replacing the one of the
+	// HTMLConfig (see ThemeClasses).
+	Class string
+}
+
+// Minimum contrast ratios required by the Web Content Accessibility
+// Guidelines (WCAG 2) for normal text.
+const (
+	WCAGAA  = 4.5
+	WCAGAAA = 7
+)
+
+// DefaultTheme is a light theme with the colors of google-code-prettify's
+// default stylesheet. All of its colors meet WCAG AA contrast.
+var DefaultTheme = Theme{
+	Name:       "default",
+	Background: "#ffffff",
+	Foreground: "#000000",
+	Styles: map[Kind]Style{
+		String:        {Color: "#008800"},
+		Keyword:       {Color: "#000088"},
+		Comment:       {Color: "#880000"},
+		Type:          {Color: "#660066"},
+		Literal:       {Color: "#006666"},
+		Punctuation:   {Color: "#666600"},
+		Tag:           {Color: "#000088"},
+		HTMLAttrName:  {Color: "#660066"},
+		HTMLAttrValue: {Color: "#008800"},
+		Decimal:       {Color: "#006666"},
+		DocComment:    {Color: "#880000", Italic: true},
+		Shebang:       {Color: "#880000"},
+		Label:         {Color: "#660066"},
+		Variable:      {Color: "#003399"},
+		Confusable:    {Color: "#cc0000", Underline: true},
+		LogError:      {Color: "#cc0000", Bold: true},
+		LogWarning:    {Color: "#885500", Bold: true},
+		LogInfo:       {Color: "#000088"},
+		XMLNamespace:  {Color: "#660066", Italic: true},
+		ProcInst:      {Color: "#666600", Italic: true},
+		Doctype:       {Color: "#666666"},
+	},
+}
+
+// HighContrastTheme is a dark theme with bright colors on black. All of its
+// colors meet WCAG AAA contrast, and kinds are told apart by text style as
+// well as by color, so that it stays readable without color vision.
+var HighContrastTheme = Theme{
+	Name:       "high-contrast",
+	Background: "#000000",
+	Foreground: "#ffffff",
+	Styles: map[Kind]Style{
+		String:        {Color: "#00ff00"},
+		Keyword:       {Color: "#ffff00", Bold: true},
+		Comment:       {Color: "#c0c0c0", Italic: true},
+		Type:          {Color: "#00ffff", Bold: true},
+		Literal:       {Color: "#ff80ff"},
+		Punctuation:   {Color: "#ffffff"},
+		Tag:           {Color: "#ffff00", Bold: true},
+		HTMLAttrName:  {Color: "#00ffff"},
+		HTMLAttrValue: {Color: "#00ff00"},
+		Decimal:       {Color: "#ff80ff"},
+		DocComment:    {Color: "#c0c0c0", Italic: true},
+		Shebang:       {Color: "#c0c0c0", Italic: true},
+		Label:         {Color: "#ffffff", Underline: true},
+		Variable:      {Color: "#ffc080"},
+		Confusable:    {Color: "#ff8080", Bold: true, Underline: true},
+		LogError:      {Color: "#ff8080", Bold: true},
+		LogWarning:    {Color: "#ffc000", Bold: true, Italic: true},
+		LogInfo:       {Color: "#80c0ff"},
+		XMLNamespace:  {Color: "#00ffff", Italic: true},
+		ProcInst:      {Color: "#c0c0c0", Bold: true},
+		Doctype:       {Color: "#c0c0c0", Bold: true, Underline: true},
+	},
+}
+
+// DarkTheme is a dark theme with the colors of VS Code's default dark
+// theme. All of its colors meet WCAG AA contrast.
+var DarkTheme = Theme{
+	Name:       "dark",
+	Background: "#1e1e1e",
+	Foreground: "#d4d4d4",
+	Styles: map[Kind]Style{
+		String:        {Color: "#ce9178"},
+		Keyword:       {Color: "#569cd6"},
+		Comment:       {Color: "#6a9955"},
+		Type:          {Color: "#4ec9b0"},
+		Literal:       {Color: "#569cd6"},
+		Punctuation:   {Color: "#d4d4d4"},
+		Tag:           {Color: "#569cd6"},
+		HTMLAttrName:  {Color: "#9cdcfe"},
+		HTMLAttrValue: {Color: "#ce9178"},
+		Decimal:       {Color: "#b5cea8"},
+		DocComment:    {Color: "#6a9955", Italic: true},
+		Shebang:       {Color: "#6a9955"},
+		Label:         {Color: "#c8c8c8"},
+		Variable:      {Color: "#9cdcfe"},
+		Confusable:    {Color: "#f48771", Underline: true},
+		LogError:      {Color: "#f48771", Bold: true},
+		LogWarning:    {Color: "#cca700", Bold: true},
+		LogInfo:       {Color: "#75beff"},
+		XMLNamespace:  {Color: "#c586c0"},
+		ProcInst:      {Color: "#a0a0a0", Italic: true},
+		Doctype:       {Color: "#a0a0a0"},
+	},
+}
+
+// color returns the text color of kind in theme t.
+func (t Theme) color(kind Kind) string {
+	if st, ok := t.Styles[kind]; ok && st.Color != "" {
+		return st.Color
+	}
+	return t.Foreground
+}
+
+// kinds returns the kinds that have a style in t, in order.
+func (t Theme) kinds() []Kind {
+	kinds := make([]Kind, 0, len(t.Styles))
+	for kind := range t.Styles {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
+	return kinds
+}
+
+// LowContrast returns the kinds whose color has a contrast ratio of less
+// than min against the background of t, such as WCAGAA, in order. The
+// foreground color is checked as Plaintext unless Plaintext has a style of
+// its own.
+func (t Theme) LowContrast(min float64) ([]Kind, error) {
+	kinds := t.kinds()
+	if _, ok := t.Styles[Plaintext]; !ok {
+		kinds = append([]Kind{Plaintext}, kinds...)
+	}
+	bg, err := luminance(t.Background)
+	if err != nil {
+		return nil, fmt.Errorf("syntaxhighlight: theme %q: invalid background color %q", t.Name, t.Background)
+	}
+	var low []Kind
+	for _, kind := range kinds {
+		fg, err := luminance(t.color(kind))
+		if err != nil {
+			return nil, fmt.Errorf("syntaxhighlight: theme %q: invalid color %q for %#v", t.Name, t.color(kind), kind)
+		}
+		if contrast(fg, bg) < min {
+			low = append(low, kind)
+		}
+	}
+	return low, nil
+}
+
+// ContrastRatio returns the WCAG 2 contrast ratio of two colors given as
+// "#rrggbb" or "#rgb". It ranges from 1 (no contrast) to 21 (black on
+// white).
+func ContrastRatio(a, b string) (float64, error) {
+	la, err := luminance(a)
+	if err != nil {
+		return 0, err
+	}
+	lb, err := luminance(b)
+	if err != nil {
+		return 0, err
+	}
+	return contrast(la, lb), nil
+}
+
+// contrast returns the contrast ratio of two relative luminances.
+func contrast(la, lb float64) float64 {
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// luminance returns the WCAG 2 relative luminance of color.
+func luminance(color string) (float64, error) {
+	rgb, err := parseColor(color)
+	if err != nil {
+		return 0, err
+	}
+	var l [3]float64
+	for i, c := range rgb {
+		v := float64(c) / 255
+		if v <= 0.03928 {
+			l[i] = v / 12.92
+		} else {
+			l[i] = math.Pow((v+0.055)/1.055, 2.4)
+		}
+	}
+	return 0.2126*l[0] + 0.7152*l[1] + 0.0722*l[2], nil
+}
+
+// parseColor parses a color given as "#rrggbb" or "#rgb".
+func parseColor(color string) ([3]uint8, error) {
+	var rgb [3]uint8
+	if !strings.HasPrefix(color, "#") {
+		return rgb, fmt.Errorf("syntaxhighlight: invalid color %q", color)
+	}
+	hex := color[1:]
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return rgb, fmt.Errorf("syntaxhighlight: invalid color %q", color)
+	}
+	for i := range rgb {
+		v, err := strconv.ParseUint(hex[2*i:2*i+2], 16, 8)
+		if err != nil {
+			return rgb, fmt.Errorf("syntaxhighlight: invalid color %q", color)
+		}
+		rgb[i] = uint8(v)
+	}
+	return rgb, nil
+}
+
+// validColor reports whether color is a valid "#rrggbb" or "#rgb" color.
+func validColor(color string) bool {
+	_, err := parseColor(color)
+	return err == nil
+}
+
+// CSS returns a stylesheet that applies t to HTML output that uses the
+// classes of c. The rules are scoped to the elements matched by selector,
+// which also get the background and foreground colors; if selector is
+// empty, the rules are not scoped and the default colors are left out.
+// Invalid colors and classes (see HTMLConfig.Validate) are skipped, so
+// that a hostile theme or configuration cannot inject CSS.
+//
+// Example:
+// DefaultTheme.CSS("pre.code", DefaultHTMLConfig)
+func (t Theme) CSS(selector string, c HTMLConfig) string {
+	var buf bytes.Buffer
+	if selector != "" {
+		var decls []string
+		if validColor(t.Foreground) {
+			decls = append(decls, "color: "+t.Foreground)
+		}
+		if validColor(t.Background) {
+			decls = append(decls, "background-color: "+t.Background)
+		}
+		if len(decls) > 0 {
+			fmt.Fprintf(&buf, "%s { %s; }\n", selector, strings.Join(decls, "; "))
+		}
+		selector += " "
+	}
+kinds:
+	for _, kind := range t.kinds() {
+		classes := strings.Fields(c.Class(kind))
+		if len(classes) == 0 {
+			continue
+		}
+		for _, class := range classes {
+			if !classNameRE.MatchString(class) {
+				continue kinds
+			}
+		}
+		st := t.Styles[kind]
+		var decls []string
+		if validColor(st.Color) {
+			decls = append(decls, "color: "+st.Color)
+		}
+		if st.Bold {
+			decls = append(decls, "font-weight: bold")
+		}
+		if st.Italic {
+			decls = append(decls, "font-style: italic")
+		}
+		if st.Underline {
+			decls = append(decls, "text-decoration: underline")
+		}
+		if len(decls) == 0 {
+			continue
+		}
+		fmt.Fprintf(&buf, "%s.%s { %s; }\n", selector, strings.Join(classes, "."), strings.Join(decls, "; "))
+	}
+	return buf.String()
+}
+
+// ThemeClasses sets the classes of the kinds whose style in t has a Class,
+// so that the classes of a theme loaded from a file (see package themefile)
+// are used both in the output and in the rules of t.CSS.
+//
+// Example:
+// AsHTML(input, ThemeClasses(theme))
+func ThemeClasses(t Theme) Option {
+	return func(o *HTMLConfig) {
+		for kind, st := range t.Styles {
+			if st.Class != "" {
+				o.setClass(kind, st.Class)
+			}
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package syntaxhighlight
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestContrastRatio(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"#000000", "#ffffff", 21},
+		{"#fff", "#000", 21},
+		{"#777777", "#777777", 1},
+		{"#767676", "#ffffff", 4.54},
+	}
+	for _, test := range tests {
+		got, err := ContrastRatio(test.a, test.b)
+		if err != nil {
+			t.Errorf("%s on %s: %s", test.a, test.b, err)
+			continue
+		}
+		if math.Abs(got-test.want) > 0.01 {
+			t.Errorf("%s on %s: want %.2f, got %.2f", test.a, test.b, test.want, got)
+		}
+	}
+
+	for _, color := range []string{"", "fff", "#ffff", "#gggggg"} {
+		if _, err := ContrastRatio(color, "#fff"); err == nil {
+			t.Errorf("%q: want error", color)
+		}
+	}
+}
+
+func TestBundledThemesContrast(t *testing.T) {
+	tests := []struct {
+		theme Theme
+		min   float64
+	}{
+		{DefaultTheme, WCAGAA},
+		{DarkTheme, WCAGAA},
+		{HighContrastTheme, WCAGAAA},
+	}
+	for _, test := range tests {
+		low, err := test.theme.LowContrast(test.min)
+		if err != nil {
+			t.Errorf("%s: %s", test.theme.Name, err)
+			continue
+		}
+		if len(low) > 0 {
+			t.Errorf("%s: kinds below contrast %v: %#v", test.theme.Name, test.min, low)
+		}
+	}
+}
+
+func TestLowContrast(t *testing.T) {
+	theme := Theme{
+		Name:       "test",
+		Background: "#ffffff",
+		Foreground: "#000000",
+		Styles: map[Kind]Style{
+			Keyword: {Color: "#cccccc"},
+			String:  {Color: "#008800"},
+			Comment: {Bold: true},
+		},
+	}
+	low, err := theme.LowContrast(WCAGAA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Kind{Keyword}; !reflect.DeepEqual(low, want) {
+		t.Errorf("want %#v, got %#v", want, low)
+	}
+
+	theme.Styles[String] = Style{Color: "green"}
+	if _, err := theme.LowContrast(WCAGAA); err == nil {
+		t.Error("want error for invalid color")
+	}
+}
+
+func TestThemeCSS(t *testing.T) {
+	theme := Theme{
+		Background: "#ffffff",
+		Foreground: "#000000",
+		Styles: map[Kind]Style{
+			Keyword:    {Color: "#000088", Bold: true},
+			DocComment: {Italic: true},
+			Whitespace: {Underline: true},
+		},
+	}
+	want := `pre { color: #000000; background-color: #ffffff; }
+pre .kwd { color: #000088; font-weight: bold; }
+pre .com.doc { font-style: italic; }
+`
+	if got := theme.CSS("pre", DefaultHTMLConfig); got != want {
+		t.Errorf("want ==========\n%s\ngot ===========\n%s", want, got)
+	}
+
+	want = ".kwd { color: #000088; font-weight: bold; }\n.com.doc { font-style: italic; }\n"
+	if got := theme.CSS("", DefaultHTMLConfig); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestThemeCSSHostile(t *testing.T) {
+	theme := Theme{
+		Background: "#fff; } body { display: none",
+		Foreground: "#000",
+		Styles: map[Kind]Style{
+			Keyword: {Color: "red; } * { color: red", Bold: true},
+			String:  {Color: "#080"},
+		},
+	}
+	c := DefaultHTMLConfig
+	c.String = "str} body {"
+	want := "pre { color: #000; }\npre .kwd { font-weight: bold; }\n"
+	if got := theme.CSS("pre", c); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestThemeClasses(t *testing.T) {
+	theme := Theme{Styles: map[Kind]Style{
+		Keyword:    {Color: "#859900", Bold: true},
+		DocComment: {Color: "#93a1a1", Italic: true, Class: "doc"},
+	}}
+	out, err := AsHTML([]byte("/// x"), ThemeClasses(theme))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<span class="doc">/// x</span>`; string(out) != want {
+		t.Errorf("want %q, got %q", want, out)
+	}
+}
@@ -0,0 +1,74 @@
+// Package themefile loads syntaxhighlight themes from YAML or JSON files.
+// It is a package of its own to keep YAML out of the dependencies of
+// syntaxhighlight.
+package themefile
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/sourcegraph/syntaxhighlight"
+	"gopkg.in/yaml.v3"
+)
+
+// Load reads a theme from r in YAML or JSON (which is also YAML), such as:
+//
+//	name: solarized-light
+//	background: "#fdf6e3"
+//	foreground: "#657b83"
+//	styles:
+//	  keyword: {color: "#859900", bold: true}
+//	  doc_comment: {color: "#93a1a1", italic: true, class: doc}
+//
+// Kinds are named as in Go, without regard to case, underscores or hyphens,
+// so "DocComment", "doccomment" and "doc-comment" are all the same. Colors
+// are "#rrggbb" or "#rgb". Unknown kinds and fields and invalid colors are
+// errors. Classes given in the file are applied with
+// syntaxhighlight.ThemeClasses.
+func Load(r io.Reader) (syntaxhighlight.Theme, error) {
+	var f struct {
+		Name       string
+		Background string
+		Foreground string
+		Styles     map[string]syntaxhighlight.Style
+	}
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil && err != io.EOF {
+		return syntaxhighlight.Theme{}, fmt.Errorf("themefile: loading theme: %v", err)
+	}
+
+	t := syntaxhighlight.Theme{Name: f.Name, Background: f.Background, Foreground: f.Foreground, Styles: map[syntaxhighlight.Kind]syntaxhighlight.Style{}}
+	for _, color := range []string{t.Background, t.Foreground} {
+		if color != "" && !validColor.MatchString(color) {
+			return syntaxhighlight.Theme{}, fmt.Errorf("themefile: loading theme %q: invalid color %q", t.Name, color)
+		}
+	}
+	for name, st := range f.Styles {
+		kind, ok := kindByName(name)
+		if !ok {
+			return syntaxhighlight.Theme{}, fmt.Errorf("themefile: loading theme %q: unknown kind %q", t.Name, name)
+		}
+		if st.Color != "" && !validColor.MatchString(st.Color) {
+			return syntaxhighlight.Theme{}, fmt.Errorf("themefile: loading theme %q: invalid color %q for %s", t.Name, st.Color, name)
+		}
+		t.Styles[kind] = st
+	}
+	return t, nil
+}
+
+// validColor matches the colors of themes, "#rrggbb" or "#rgb".
+var validColor = regexp.MustCompile(`^#([[:xdigit:]]{3}|[[:xdigit:]]{6})$`)
+
+// kindByName returns the kind with the given name, as described in Load.
+func kindByName(name string) (syntaxhighlight.Kind, bool) {
+	name = strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(name))
+	for _, kind := range syntaxhighlight.Kinds() {
+		if strings.ToLower(strings.TrimPrefix(kind.GoString(), "syntaxhighlight.")) == name {
+			return kind, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,56 @@
+package themefile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/syntaxhighlight"
+)
+
+func TestLoad(t *testing.T) {
+	want := syntaxhighlight.Theme{
+		Name:       "test",
+		Background: "#fdf6e3",
+		Foreground: "#657b83",
+		Styles: map[syntaxhighlight.Kind]syntaxhighlight.Style{
+			syntaxhighlight.Keyword:    {Color: "#859900", Bold: true},
+			syntaxhighlight.DocComment: {Color: "#93a1a1", Italic: true, Class: "doc"},
+		},
+	}
+	for _, src := range []string{
+		`name: test
+background: "#fdf6e3"
+foreground: "#657b83"
+styles:
+  keyword: {color: "#859900", bold: true}
+  doc_comment:
+    color: "#93a1a1"
+    italic: true
+    class: doc
+`,
+		`{"name": "test", "background": "#fdf6e3", "foreground": "#657b83", "styles": {
+			"Keyword": {"color": "#859900", "bold": true},
+			"doc-comment": {"color": "#93a1a1", "italic": true, "class": "doc"}}}`,
+	} {
+		got, err := Load(strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %+v, got %+v", want, got)
+		}
+	}
+
+	for _, src := range []string{
+		"styles: {nokind: {color: '#fff'}}",
+		"styles: {keyword: {color: red}}",
+		"background: '#ggg'",
+		"styles: {keyword: {colour: '#fff'}}",
+		"[1, 2]",
+	} {
+		if _, err := Load(strings.NewReader(src)); err == nil {
+			t.Errorf("%q: want error", src)
+		}
+	}
+}
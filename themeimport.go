@@ -0,0 +1,505 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+// pygmentsTokens maps kinds to the Pygments token types whose style they
+// take, in Chroma's notation, which joins the components of a Pygments
+// token type without dots and with "String" and "Number" under "Literal".
+var pygmentsTokens = map[Kind]string{
+	String:        "LiteralString",
+	Keyword:       "Keyword",
+	Comment:       "CommentSingle",
+	Type:          "KeywordType",
+	Literal:       "KeywordConstant",
+	Punctuation:   "Punctuation",
+	Plaintext:     "Name",
+	Tag:           "NameTag",
+	HTMLTag:       "NameTag",
+	HTMLAttrName:  "NameAttribute",
+	HTMLAttrValue: "LiteralString",
+	Decimal:       "LiteralNumber",
+	DocComment:    "CommentSpecial",
+	Shebang:       "CommentHashbang",
+	Label:         "NameLabel",
+	Namespace:     "NameNamespace",
+	Variable:      "NameVariable",
+}
+
+// ImportPygmentsStyle reads a Pygments style, given as the Python source of
+// its Style class, and converts it to a Theme. The styles of token types
+// are inherited by their subtypes as in Pygments; unsupported style
+// properties, such as borders and ANSI color names, are ignored.
+func ImportPygmentsStyle(r io.Reader) (Theme, error) {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return Theme{}, err
+	}
+	defs := map[string]string{}
+	for _, m := range pygmentsEntryRE.FindAllStringSubmatch(string(src), -1) {
+		defs[pygmentsTokenName(m[1])] = m[3]
+	}
+	if len(defs) == 0 {
+		return Theme{}, fmt.Errorf("syntaxhighlight: importing Pygments style: no styles found")
+	}
+	var name, bg string
+	if m := pygmentsAttrRE.FindAllStringSubmatch(string(src), -1); m != nil {
+		for _, m := range m {
+			switch m[1] {
+			case "name":
+				name = m[3]
+			case "background_color":
+				bg = m[3]
+			}
+		}
+	}
+	if name == "" {
+		if m := pygmentsClassRE.FindStringSubmatch(string(src)); m != nil {
+			name = strings.ToLower(m[1])
+		}
+	}
+	return importedTheme(name, bg, defs), nil
+}
+
+var (
+	pygmentsEntryRE = regexp.MustCompile(`(?m)^\s*((?:Token\.)?[A-Z]\w*(?:\.[A-Z]\w*)*)\s*:\s*(["'])(.*?)["']`)
+	pygmentsAttrRE  = regexp.MustCompile(`(?m)^\s*(name|background_color)\s*=\s*(["'])(.*?)["']`)
+	pygmentsClassRE = regexp.MustCompile(`class\s+(\w+?)(?:Style)?\s*\(`)
+)
+
+// pygmentsTokenName converts a Pygments token type, such as
+// "Token.String.Doc", to Chroma's notation, such as "LiteralStringDoc".
+func pygmentsTokenName(name string) string {
+	name = strings.TrimPrefix(name, "Token.")
+	if name == "Token" {
+		return ""
+	}
+	if strings.HasPrefix(name, "String") || strings.HasPrefix(name, "Number") {
+		name = "Literal." + name
+	}
+	return strings.Replace(name, ".", "", -1)
+}
+
+// ImportChromaStyle reads a Chroma style in XML, such as
+//
+//	<style name="monokai">
+//	  <entry type="Background" style="#f8f8f2 bg:#272822"/>
+//	  <entry type="Keyword" style="#66d9ef"/>
+//	</style>
+//
+// and converts it to a Theme, like ImportPygmentsStyle.
+func ImportChromaStyle(r io.Reader) (Theme, error) {
+	var style struct {
+		Name    string `xml:"name,attr"`
+		Entries []struct {
+			Type  string `xml:"type,attr"`
+			Style string `xml:"style,attr"`
+		} `xml:"entry"`
+	}
+	if err := xml.NewDecoder(r).Decode(&style); err != nil {
+		return Theme{}, fmt.Errorf("syntaxhighlight: importing Chroma style: %v", err)
+	}
+	defs := map[string]string{}
+	for _, e := range style.Entries {
+		if e.Type == "Background" {
+			e.Type = ""
+		}
+		defs[e.Type] = e.Style
+	}
+	return importedTheme(style.Name, "", defs), nil
+}
+
+// importedTheme returns the theme with the given name, background color
+// and style definitions by token type in Chroma's notation, where "" is the
+// root token type.
+func importedTheme(name, bg string, defs map[string]string) Theme {
+	root := resolvePygmentsStyle(defs, "")
+	if bg == "" {
+		bg = root.bg
+	}
+	if !validColor(bg) {
+		bg = "#ffffff"
+	}
+	fg := resolvePygmentsStyle(defs, "Text").Color
+	if !validColor(fg) {
+		fg = "#000000"
+		if l, _ := luminance(bg); l < 0.18 {
+			fg = "#ffffff"
+		}
+	}
+
+	t := Theme{Name: name, Background: bg, Foreground: fg, Styles: map[Kind]Style{}}
+	for kind, typ := range pygmentsTokens {
+		// styles that only repeat the foreground color are left out
+		st := resolvePygmentsStyle(defs, typ).Style
+		if st != (Style{}) && st != (Style{Color: fg}) {
+			t.Styles[kind] = st
+		}
+	}
+	return t
+}
+
+// pygmentsStyle is a style parsed from a Pygments or Chroma definition.
+type pygmentsStyle struct {
+	Style
+	bg string
+}
+
+// resolvePygmentsStyle returns the style of token type typ in defs, which
+// is its own definition applied on top of the style of its parent type.
+func resolvePygmentsStyle(defs map[string]string, typ string) pygmentsStyle {
+	var st pygmentsStyle
+	if typ != "" {
+		st = resolvePygmentsStyle(defs, pygmentsParent(typ))
+	}
+	def, ok := defs[typ]
+	if !ok {
+		return st
+	}
+	for _, word := range strings.Fields(def) {
+		switch {
+		case word == "noinherit":
+			st = pygmentsStyle{}
+		case word == "bold":
+			st.Bold = true
+		case word == "nobold":
+			st.Bold = false
+		case word == "italic":
+			st.Italic = true
+		case word == "noitalic":
+			st.Italic = false
+		case word == "underline":
+			st.Underline = true
+		case word == "nounderline":
+			st.Underline = false
+		case strings.HasPrefix(word, "bg:"):
+			st.bg = word[len("bg:"):]
+		case validColor(word):
+			st.Color = word
+		}
+	}
+	return st
+}
+
+// pygmentsParent returns the parent of token type typ in Chroma's notation,
+// such as "LiteralString" for "LiteralStringDoc", or "" for a top-level
+// type.
+func pygmentsParent(typ string) string {
+	for i := len(typ) - 1; i > 0; i-- {
+		if 'A' <= typ[i] && typ[i] <= 'Z' {
+			return typ[:i]
+		}
+	}
+	return ""
+}
+
+// textMateScopes maps kinds to the TextMate scopes whose style they take.
+var textMateScopes = map[Kind]string{
+	String:        "string.quoted",
+	Keyword:       "keyword.control",
+	Comment:       "comment.line",
+	Type:          "entity.name.type",
+	Literal:       "constant.language",
+	Punctuation:   "punctuation.separator",
+	Tag:           "entity.name.tag",
+	HTMLTag:       "entity.name.tag",
+	HTMLAttrName:  "entity.other.attribute-name",
+	HTMLAttrValue: "string.quoted.double.html",
+	Decimal:       "constant.numeric",
+	DocComment:    "comment.block.documentation",
+	Shebang:       "comment.line.shebang",
+	Label:         "entity.name.label",
+	Namespace:     "entity.name.namespace",
+	Variable:      "variable.other",
+}
+
+// textMateRule is a rule of a TextMate or VS Code theme. Unset properties
+// are nil.
+type textMateRule struct {
+	scopes     []string
+	foreground *string
+	fontStyle  *string
+}
+
+// ImportVSCodeTheme reads a VS Code color theme in JSON, which may contain
+// comments and trailing commas, and converts it to a Theme. The style of a
+// kind is taken from the token color rules with the most specific scopes
+// that match its TextMate scope, such as "keyword.control" for Keyword,
+// separately for the color and the font style, as in VS Code. Scope
+// selectors with descendants or exclusions are ignored.
+func ImportVSCodeTheme(r io.Reader) (Theme, error) {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return Theme{}, err
+	}
+	var theme struct {
+		Name        string
+		Type        string
+		Colors      map[string]string
+		TokenColors []struct {
+			Scope    interface{}
+			Settings struct {
+				Foreground *string
+				FontStyle  *string
+			}
+		}
+	}
+	if err := json.Unmarshal(stripJSONComments(src), &theme); err != nil {
+		return Theme{}, fmt.Errorf("syntaxhighlight: importing VS Code theme: %v", err)
+	}
+
+	var rules []textMateRule
+	for _, tc := range theme.TokenColors {
+		rule := textMateRule{foreground: tc.Settings.Foreground, fontStyle: tc.Settings.FontStyle}
+		switch scope := tc.Scope.(type) {
+		case string:
+			rule.scopes = strings.Split(scope, ",")
+		case []interface{}:
+			for _, s := range scope {
+				if s, ok := s.(string); ok {
+					rule.scopes = append(rule.scopes, s)
+				}
+			}
+		}
+		rules = append(rules, rule)
+	}
+	bg, fg := theme.Colors["editor.background"], theme.Colors["editor.foreground"]
+	return textMateTheme(theme.Name, bg, fg, strings.Contains(theme.Type, "dark"), rules), nil
+}
+
+// ImportTextMateTheme reads a TextMate theme (.tmTheme), a property list in
+// XML, and converts it to a Theme like ImportVSCodeTheme.
+func ImportTextMateTheme(r io.Reader) (Theme, error) {
+	v, err := decodePlist(xml.NewDecoder(r))
+	if err != nil {
+		return Theme{}, fmt.Errorf("syntaxhighlight: importing TextMate theme: %v", err)
+	}
+	theme, _ := v.(map[string]interface{})
+	name, _ := theme["name"].(string)
+	settings, _ := theme["settings"].([]interface{})
+	if settings == nil {
+		return Theme{}, fmt.Errorf("syntaxhighlight: importing TextMate theme: no settings found")
+	}
+
+	var bg, fg string
+	var rules []textMateRule
+	for _, s := range settings {
+		s, _ := s.(map[string]interface{})
+		props, _ := s["settings"].(map[string]interface{})
+		scope, ok := s["scope"].(string)
+		if !ok {
+			// the global settings
+			bg, _ = props["background"].(string)
+			fg, _ = props["foreground"].(string)
+			continue
+		}
+		rule := textMateRule{scopes: strings.Split(scope, ",")}
+		if fg, ok := props["foreground"].(string); ok {
+			rule.foreground = &fg
+		}
+		if fs, ok := props["fontStyle"].(string); ok {
+			rule.fontStyle = &fs
+		}
+		rules = append(rules, rule)
+	}
+	return textMateTheme(name, bg, fg, false, rules), nil
+}
+
+// textMateTheme returns the theme with the given name, default colors and
+// rules. dark selects the default colors if bg or fg are missing.
+func textMateTheme(name, bg, fg string, dark bool, rules []textMateRule) Theme {
+	bg, fg = opaqueColor(bg), opaqueColor(fg)
+	if !validColor(bg) {
+		bg = "#ffffff"
+		if dark {
+			bg = "#1e1e1e"
+		}
+	}
+	if !validColor(fg) {
+		fg = "#000000"
+		if l, _ := luminance(bg); l < 0.18 {
+			fg = "#ffffff"
+		}
+	}
+
+	t := Theme{Name: name, Background: bg, Foreground: fg, Styles: map[Kind]Style{}}
+	for kind, scope := range textMateScopes {
+		var st Style
+		if color := matchTextMateRule(rules, scope, func(r textMateRule) *string { return r.foreground }); color != nil {
+			if c := opaqueColor(*color); validColor(c) {
+				st.Color = c
+			}
+		}
+		if fontStyle := matchTextMateRule(rules, scope, func(r textMateRule) *string { return r.fontStyle }); fontStyle != nil {
+			for _, word := range strings.Fields(*fontStyle) {
+				switch word {
+				case "bold":
+					st.Bold = true
+				case "italic":
+					st.Italic = true
+				case "underline":
+					st.Underline = true
+				}
+			}
+		}
+		if st != (Style{}) {
+			t.Styles[kind] = st
+		}
+	}
+	return t
+}
+
+// matchTextMateRule returns the property prop of the rule with the most
+// specific selector that matches scope, among the rules that set it. Of
+// equally specific rules, the last one wins.
+func matchTextMateRule(rules []textMateRule, scope string, prop func(textMateRule) *string) *string {
+	var best *string
+	bestLen := -1
+	for _, rule := range rules {
+		v := prop(rule)
+		if v == nil {
+			continue
+		}
+		for _, sel := range rule.scopes {
+			sel = strings.TrimSpace(sel)
+			if sel == "" || strings.ContainsAny(sel, " \t") || sel[0] == '-' {
+				// descendant selectors and exclusions are not supported
+				continue
+			}
+			if (scope == sel || strings.HasPrefix(scope, sel+".")) && len(sel) >= bestLen {
+				best, bestLen = v, len(sel)
+			}
+		}
+	}
+	return best
+}
+
+// opaqueColor drops the alpha channel of a color given as "#rrggbbaa" or
+// "#rgba".
+func opaqueColor(color string) string {
+	if strings.HasPrefix(color, "#") && (len(color) == 9 || len(color) == 5) {
+		return color[:len(color)-len(color)/4]
+	}
+	return color
+}
+
+// stripJSONComments removes the comments and trailing commas that VS Code
+// allows in JSON files.
+func stripJSONComments(src []byte) []byte {
+	out := make([]byte, 0, len(src))
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == '"':
+			j := i + 1
+			for j < len(src) && src[j] != '"' {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(src) {
+				j = len(src) - 1
+			}
+			out = append(out, src[i:j+1]...)
+			i = j
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := bytes.Index(src[i+2:], []byte("*/"))
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+		case c == ']' || c == '}':
+			trimmed := bytes.TrimRight(out, " \t\r\n")
+			if len(trimmed) > 0 && trimmed[len(trimmed)-1] == ',' {
+				out = append(trimmed[:len(trimmed)-1], out[len(trimmed):]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// decodePlist decodes the property list read by d into maps, slices and
+// strings; other values are decoded as strings as well.
+func decodePlist(d *xml.Decoder) (interface{}, error) {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local != "plist" {
+			return decodePlistValue(d, start)
+		}
+	}
+}
+
+// decodePlistValue decodes the value that starts with start.
+func decodePlistValue(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	switch start.Name.Local {
+	case "dict":
+		m := map[string]interface{}{}
+		var key string
+		for {
+			tok, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch tok := tok.(type) {
+			case xml.StartElement:
+				if tok.Name.Local == "key" {
+					if err := d.DecodeElement(&key, &tok); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				v, err := decodePlistValue(d, tok)
+				if err != nil {
+					return nil, err
+				}
+				m[key] = v
+			case xml.EndElement:
+				return m, nil
+			}
+		}
+	case "array":
+		var a []interface{}
+		for {
+			tok, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch tok := tok.(type) {
+			case xml.StartElement:
+				v, err := decodePlistValue(d, tok)
+				if err != nil {
+					return nil, err
+				}
+				a = append(a, v)
+			case xml.EndElement:
+				if a == nil {
+					a = []interface{}{}
+				}
+				return a, nil
+			}
+		}
+	}
+	var s string
+	err := d.DecodeElement(&s, &start)
+	return s, err
+}
@@ -0,0 +1,187 @@
+package syntaxhighlight
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestImportPygmentsStyle(t *testing.T) {
+	src := `
+from pygments.style import Style
+from pygments.token import Keyword, Name, Comment, String, Number, Operator
+
+class FriendlyStyle(Style):
+    background_color = "#f0f0f0"
+
+    styles = {
+        Comment:                   "italic #60a0b0",
+        Comment.Hashbang:          "noinherit #888",
+        Keyword:                   "bold #007020",
+        Keyword.Type:              "nobold #902000",
+        String:                    "#4070a0",
+        Number:                    "#40a070",
+        Name.Namespace:            'bold #0e84b5',
+        Name.Tag:                  "bold border:#ff0000 #062873",
+    }
+`
+	got, err := ImportPygmentsStyle(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Theme{
+		Name:       "friendly",
+		Background: "#f0f0f0",
+		Foreground: "#000000",
+		Styles: map[Kind]Style{
+			Comment:       {Color: "#60a0b0", Italic: true},
+			DocComment:    {Color: "#60a0b0", Italic: true},
+			Shebang:       {Color: "#888"},
+			Keyword:       {Color: "#007020", Bold: true},
+			Type:          {Color: "#902000"},
+			Literal:       {Color: "#007020", Bold: true},
+			String:        {Color: "#4070a0"},
+			HTMLAttrValue: {Color: "#4070a0"},
+			Decimal:       {Color: "#40a070"},
+			Namespace:     {Color: "#0e84b5", Bold: true},
+			Tag:           {Color: "#062873", Bold: true},
+			HTMLTag:       {Color: "#062873", Bold: true},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+
+	if _, err := ImportPygmentsStyle(strings.NewReader("print('hello')")); err == nil {
+		t.Error("want error for source without styles")
+	}
+}
+
+func TestImportChromaStyle(t *testing.T) {
+	src := `<style name="monokai">
+  <entry type="Background" style="#f8f8f2 bg:#272822"/>
+  <entry type="Keyword" style="#66d9ef"/>
+  <entry type="LiteralStringDoc" style="italic"/>
+  <entry type="CommentSpecial" style="#75715e italic"/>
+</style>`
+	got, err := ImportChromaStyle(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Theme{
+		Name:       "monokai",
+		Background: "#272822",
+		Foreground: "#f8f8f2",
+		Styles: map[Kind]Style{
+			Keyword:    {Color: "#66d9ef"},
+			Type:       {Color: "#66d9ef"},
+			Literal:    {Color: "#66d9ef"},
+			DocComment: {Color: "#75715e", Italic: true},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+
+	if _, err := ImportChromaStyle(strings.NewReader("<style")); err == nil {
+		t.Error("want error for malformed XML")
+	}
+}
+
+func TestImportVSCodeTheme(t *testing.T) {
+	src := `{
+	// a comment, with "quotes"
+	"name": "Test Dark",
+	"type": "dark",
+	"colors": {
+		"editor.background": "#1e1e1e",
+		"editor.foreground": "#d4d4d4ff", /* with alpha */
+	},
+	"tokenColors": [
+		{"scope": "comment", "settings": {"foreground": "#6a9955", "fontStyle": "italic"}},
+		{"scope": ["keyword", "storage"], "settings": {"foreground": "#569cd6"}},
+		{"scope": "keyword.control", "settings": {"foreground": "#c586c0"}},
+		{"scope": "keyword.control", "settings": {"fontStyle": "bold"}},
+		{"scope": "string, constant.numeric", "settings": {"foreground": "#ce9178"}},
+		{"scope": "constant.numeric", "settings": {"foreground": "#b5cea8"}},
+		{"scope": "source.go keyword.control", "settings": {"foreground": "#ff0000"}},
+		{"scope": "comment.block.documentation", "settings": {"fontStyle": ""}},
+	],
+}`
+	got, err := ImportVSCodeTheme(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Theme{
+		Name:       "Test Dark",
+		Background: "#1e1e1e",
+		Foreground: "#d4d4d4",
+		Styles: map[Kind]Style{
+			Comment:       {Color: "#6a9955", Italic: true},
+			Shebang:       {Color: "#6a9955", Italic: true},
+			DocComment:    {Color: "#6a9955"},
+			Keyword:       {Color: "#c586c0", Bold: true},
+			String:        {Color: "#ce9178"},
+			HTMLAttrValue: {Color: "#ce9178"},
+			Decimal:       {Color: "#b5cea8"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+
+	if _, err := ImportVSCodeTheme(strings.NewReader(`{"name": `)); err == nil {
+		t.Error("want error for malformed JSON")
+	}
+}
+
+func TestImportTextMateTheme(t *testing.T) {
+	src := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>name</key>
+	<string>Test</string>
+	<key>settings</key>
+	<array>
+		<dict>
+			<key>settings</key>
+			<dict>
+				<key>background</key>
+				<string>#FDF6E3</string>
+				<key>foreground</key>
+				<string>#657B83</string>
+			</dict>
+		</dict>
+		<dict>
+			<key>name</key>
+			<string>Keyword</string>
+			<key>scope</key>
+			<string>keyword</string>
+			<key>settings</key>
+			<dict>
+				<key>foreground</key>
+				<string>#859900</string>
+				<key>fontStyle</key>
+				<string>bold underline</string>
+			</dict>
+		</dict>
+	</array>
+</dict>
+</plist>`
+	got, err := ImportTextMateTheme(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Theme{
+		Name:       "Test",
+		Background: "#FDF6E3",
+		Foreground: "#657B83",
+		Styles: map[Kind]Style{
+			Keyword: {Color: "#859900", Bold: true, Underline: true},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
@@ -0,0 +1,174 @@
+// Package tokenpb marshals tokens and annotations produced by
+// syntaxhighlight in the protocol buffer format described by token.proto,
+// so that they can be sent to programs written in other languages, such as
+// over gRPC.
+package tokenpb
+
+import (
+	"fmt"
+
+	"github.com/sourcegraph/annotate"
+	"github.com/sourcegraph/syntaxhighlight"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers of the messages in token.proto.
+const (
+	tokensTokens = 1
+
+	tokenKind = 1
+	tokenText = 2
+
+	annotationsAnnotations = 1
+
+	annotationStart     = 1
+	annotationEnd       = 2
+	annotationLeft      = 3
+	annotationRight     = 4
+	annotationWantInner = 5
+)
+
+// MarshalTokens returns toks encoded as a Tokens message.
+func MarshalTokens(toks []syntaxhighlight.Token) []byte {
+	var b, msg []byte
+	for _, tok := range toks {
+		msg = msg[:0]
+		if tok.Kind != 0 {
+			msg = protowire.AppendTag(msg, tokenKind, protowire.VarintType)
+			msg = protowire.AppendVarint(msg, uint64(tok.Kind))
+		}
+		if tok.Text != "" {
+			msg = protowire.AppendTag(msg, tokenText, protowire.BytesType)
+			msg = protowire.AppendString(msg, tok.Text)
+		}
+		b = protowire.AppendTag(b, tokensTokens, protowire.BytesType)
+		b = protowire.AppendBytes(b, msg)
+	}
+	return b
+}
+
+// UnmarshalTokens decodes a Tokens message.
+func UnmarshalTokens(b []byte) ([]syntaxhighlight.Token, error) {
+	var toks []syntaxhighlight.Token
+	err := fields(b, func(num protowire.Number, typ protowire.Type, v uint64, msg []byte) error {
+		if num != tokensTokens || typ != protowire.BytesType {
+			return nil
+		}
+		var tok syntaxhighlight.Token
+		err := fields(msg, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+			switch {
+			case num == tokenKind && typ == protowire.VarintType:
+				tok.Kind = syntaxhighlight.Kind(v)
+			case num == tokenText && typ == protowire.BytesType:
+				tok.Text = string(b)
+			}
+			return nil
+		})
+		toks = append(toks, tok)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("tokenpb: unmarshaling tokens: %v", err)
+	}
+	return toks, nil
+}
+
+// MarshalAnnotations returns anns encoded as an Annotations message.
+func MarshalAnnotations(anns annotate.Annotations) []byte {
+	var b, msg []byte
+	for _, a := range anns {
+		msg = msg[:0]
+		for _, f := range []struct {
+			num protowire.Number
+			v   int
+		}{
+			{annotationStart, a.Start},
+			{annotationEnd, a.End},
+			{annotationWantInner, a.WantInner},
+		} {
+			if f.v != 0 {
+				msg = protowire.AppendTag(msg, f.num, protowire.VarintType)
+				msg = protowire.AppendVarint(msg, uint64(int64(f.v)))
+			}
+		}
+		if len(a.Left) > 0 {
+			msg = protowire.AppendTag(msg, annotationLeft, protowire.BytesType)
+			msg = protowire.AppendBytes(msg, a.Left)
+		}
+		if len(a.Right) > 0 {
+			msg = protowire.AppendTag(msg, annotationRight, protowire.BytesType)
+			msg = protowire.AppendBytes(msg, a.Right)
+		}
+		b = protowire.AppendTag(b, annotationsAnnotations, protowire.BytesType)
+		b = protowire.AppendBytes(b, msg)
+	}
+	return b
+}
+
+// UnmarshalAnnotations decodes an Annotations message.
+func UnmarshalAnnotations(b []byte) (annotate.Annotations, error) {
+	var anns annotate.Annotations
+	err := fields(b, func(num protowire.Number, typ protowire.Type, v uint64, msg []byte) error {
+		if num != annotationsAnnotations || typ != protowire.BytesType {
+			return nil
+		}
+		a := &annotate.Annotation{}
+		err := fields(msg, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+			if typ == protowire.VarintType {
+				switch num {
+				case annotationStart:
+					a.Start = int(int64(v))
+				case annotationEnd:
+					a.End = int(int64(v))
+				case annotationWantInner:
+					a.WantInner = int(int64(v))
+				}
+			} else if typ == protowire.BytesType {
+				switch num {
+				case annotationLeft:
+					a.Left = append([]byte(nil), b...)
+				case annotationRight:
+					a.Right = append([]byte(nil), b...)
+				}
+			}
+			return nil
+		})
+		anns = append(anns, a)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("tokenpb: unmarshaling annotations: %v", err)
+	}
+	return anns, nil
+}
+
+// fields calls f with the number, type and value of each field of the
+// message b, in order. Varint fields are passed in v and length-delimited
+// fields in data; other fields are skipped.
+func fields(b []byte, f func(num protowire.Number, typ protowire.Type, v uint64, data []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		var v uint64
+		var data []byte
+		switch typ {
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(b)
+		case protowire.BytesType:
+			data, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if err := f(num, typ, v, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
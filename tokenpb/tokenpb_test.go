@@ -0,0 +1,52 @@
+package tokenpb
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/annotate"
+	"github.com/sourcegraph/syntaxhighlight"
+)
+
+func TestTokens(t *testing.T) {
+	src := []byte("package main // é\n\nvar x = \"y\"\n")
+	var want []syntaxhighlight.Token
+	s := syntaxhighlight.NewScanner(src)
+	for s.Scan() {
+		want = append(want, syntaxhighlight.Token{Kind: s.Kind(), Text: s.Text()})
+	}
+
+	got, err := UnmarshalTokens(MarshalTokens(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	// a Tokens message with one Token{kind: KEYWORD, text: "if"}
+	b := []byte{0x0a, 0x06, 0x08, 0x02, 0x12, 0x02, 'i', 'f'}
+	if got := MarshalTokens([]syntaxhighlight.Token{{Kind: syntaxhighlight.Keyword, Text: "if"}}); string(got) != string(b) {
+		t.Errorf("want % x, got % x", b, got)
+	}
+
+	if _, err := UnmarshalTokens(b[:len(b)-1]); err == nil {
+		t.Error("want error for truncated message")
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	anns, err := syntaxhighlight.Annotate([]byte("a := 1 // b"), syntaxhighlight.HTMLAnnotator(syntaxhighlight.DefaultHTMLConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	anns = append(anns, &annotate.Annotation{Start: 0, End: 11, Left: []byte("<x>"), WantInner: -1})
+
+	got, err := UnmarshalAnnotations(MarshalAnnotations(anns))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, anns) {
+		t.Errorf("want %v, got %v", anns, got)
+	}
+}
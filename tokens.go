@@ -0,0 +1,151 @@
+package syntaxhighlight
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Token is a token of source code and its Kind.
+type Token struct {
+	Kind Kind
+	Text string
+}
+
+// tokenFormat is the version of the binary format of EncodeTokens.
+const tokenFormat = 1
+
+// EncodeTokens writes all tokens from s to w in a compact binary format,
+// so that they can be stored and later rendered with DecodeTokens and
+// PrintTokens without scanning the source again. The format is a version
+// byte followed by each token's kind as a byte and its length as a uvarint,
+// then its text.
+func EncodeTokens(w io.Writer, s *Scanner) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteByte(tokenFormat)
+	var n [binary.MaxVarintLen64]byte
+	for s.Scan() {
+		tok := s.Bytes()
+		bw.WriteByte(byte(s.Kind()))
+		bw.Write(n[:binary.PutUvarint(n[:], uint64(len(tok)))])
+		bw.Write(tok)
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// DecodeTokens reads tokens written by EncodeTokens from r.
+func DecodeTokens(r io.Reader) ([]Token, error) {
+	br := bufio.NewReader(r)
+	version, err := br.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("syntaxhighlight: decoding tokens: %v", err)
+	}
+	if version != tokenFormat {
+		return nil, fmt.Errorf("syntaxhighlight: decoding tokens: unknown format version %d", version)
+	}
+
+	var toks []Token
+	for {
+		kind, err := br.ReadByte()
+		if err == io.EOF {
+			return toks, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("syntaxhighlight: decoding tokens: %v", err)
+		}
+		n, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, errTruncatedTokens
+		}
+		// the text is copied as it is read rather than allocated up front,
+		// as the length may be corrupt
+		if n > math.MaxInt32 {
+			return nil, fmt.Errorf("syntaxhighlight: decoding tokens: token length %d out of range", n)
+		}
+		var text strings.Builder
+		if _, err := io.CopyN(&text, br, int64(n)); err != nil {
+			return nil, errTruncatedTokens
+		}
+		toks = append(toks, Token{Kind(kind), text.String()})
+	}
+}
+
+var errTruncatedTokens = errors.New("syntaxhighlight: decoding tokens: unexpected end of data")
+
+// PrintTokens prints toks to w using p, like Print does with the tokens of
+// a Scanner.
+func PrintTokens(toks []Token, w io.Writer, p Printer) error {
+	return printStream(&tokenSlice{toks: toks}, w, p)
+}
+
+// TokensAsHTML renders toks as HTML like AsHTML renders the tokens of its
+// source, with the given options. Options that affect scanning, such as
+// Coalesce or Lang, have no effect.
+func TokensAsHTML(toks []Token, options ...Option) ([]byte, error) {
+	c := newHTMLConfig(options)
+	return c.render(&tokenSlice{toks: toks}, c.printer())
+}
+
+// tokenSlice is a tokenStream of a slice of tokens.
+type tokenSlice struct {
+	toks []Token
+	cur  Token
+}
+
+func (t *tokenSlice) Scan() bool {
+	if len(t.toks) == 0 {
+		return false
+	}
+	t.cur, t.toks = t.toks[0], t.toks[1:]
+	return true
+}
+
+func (t *tokenSlice) Kind() Kind   { return t.cur.Kind }
+func (t *tokenSlice) Text() string { return t.cur.Text }
+func (t *tokenSlice) Err() error   { return nil }
+
+// TokensChan scans the source read from r in a new goroutine and sends its
+// tokens on the returned channel, which is closed at the end of the source,
+// when reading fails or when ctx is done, so that lexing can run
+// concurrently with rendering. The returned function waits for scanning to
+// end and returns the error that ended it early, if any: the error of r,
+// that of ctx, or an *ErrUnsupportedLanguage, in which case the channel is
+// closed right away. The scanning options among options, such as Lang or
+// Coalesce, are applied.
+func TokensChan(ctx context.Context, r io.Reader, options ...Option) (<-chan Token, func() error) {
+	c := newHTMLConfig(options)
+	ch := make(chan Token, 64)
+	done := make(chan struct{})
+	var err error
+	go func() {
+		defer close(done)
+		defer close(ch)
+		var lang *Language
+		if lang, err = c.language(); err != nil {
+			return
+		}
+		s := NewScannerReader(r)
+		c.configure(s, lang)
+		for s.Scan() {
+			select {
+			case ch <- Token{Kind: s.Kind(), Text: s.Text()}:
+			case <-ctx.Done():
+				err = ctx.Err()
+				return
+			}
+		}
+		err = s.Err()
+	}()
+	return ch, func() error {
+		<-done
+		return err
+	}
+}
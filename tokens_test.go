@@ -0,0 +1,129 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestEncodeTokens(t *testing.T) {
+	src := []byte("// é\nx := \"" + string(bytes.Repeat([]byte("y"), 200)) + "\"\n")
+	var buf bytes.Buffer
+	if err := EncodeTokens(&buf, NewScanner(src)); err != nil {
+		t.Fatal(err)
+	}
+	toks, err := DecodeTokens(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := scanAll(t, NewScanner(src))
+	got := make([]token, len(toks))
+	for i, tok := range toks {
+		got[i] = token{tok.Kind, tok.Text}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	// rendering decoded tokens must give the same output as rendering the source
+	for _, options := range [][]Option{nil, {OrderedList()}, {LineAnchors()}} {
+		c := newHTMLConfig(options)
+		var out bytes.Buffer
+		if err := PrintTokens(toks, &out, c.printer()); err != nil {
+			t.Fatal(err)
+		}
+		var wantOut bytes.Buffer
+		if err := Print(NewScanner(src), &wantOut, c.printer()); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != wantOut.String() {
+			t.Errorf("want %q, got %q", wantOut.String(), out.String())
+		}
+	}
+
+	// lengths beyond the data, up to the largest uvarint, must not be
+	// allocated for
+	huge := []byte{1, 3, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01, 'x'}
+	large := []byte{1, 3, 0xff, 0xff, 0xff, 0x7f, 'x'}
+	for _, bad := range [][]byte{nil, {2}, buf.Bytes()[:len(buf.Bytes())-1], {1, 3, 0x80}, huge, large} {
+		if _, err := DecodeTokens(bytes.NewReader(bad)); err == nil {
+			t.Errorf("%q: want error", bad)
+		}
+	}
+}
+
+func TestTokensAsHTML(t *testing.T) {
+	src := []byte("a := 1\nb()\n")
+	toks := make([]Token, 0)
+	for _, tok := range scanAll(t, NewScanner(src)) {
+		toks = append(toks, Token{tok.kind, tok.text})
+	}
+	for _, options := range [][]Option{nil, {OrderedList()}, {Table()}} {
+		want, err := AsHTML(src, options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := TokensAsHTML(toks, options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	}
+}
+
+func TestTokensChan(t *testing.T) {
+	src := "a := 1\nb()\n"
+	var got []token
+	toks, errf := TokensChan(context.Background(), strings.NewReader(src), Coalesce())
+	for tok := range toks {
+		got = append(got, token{tok.Kind, tok.Text})
+	}
+	if err := errf(); err != nil {
+		t.Fatal(err)
+	}
+	s := NewScanner([]byte(src))
+	s.Coalesce()
+	if want := scanAll(t, s); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	// cancelling stops the scanning goroutine, which closes the channel
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, errf := TokensChan(ctx, strings.NewReader(strings.Repeat("x ", 10000)))
+	<-ch
+	cancel()
+	n := 0
+	for range ch {
+		n++
+	}
+	if n >= 20000 {
+		t.Errorf("got all %d tokens after cancelling", n)
+	}
+	if err := errf(); err != context.Canceled {
+		t.Errorf("want %v after cancelling, got %v", context.Canceled, err)
+	}
+
+	// errors are told apart from the end of the source
+	errRead := errors.New("read error")
+	ch, errf = TokensChan(context.Background(), io.MultiReader(strings.NewReader("a b"), iotest.ErrReader(errRead)))
+	for range ch {
+	}
+	if err := errf(); err != errRead {
+		t.Errorf("want %v, got %v", errRead, err)
+	}
+	ch, errf = TokensChan(context.Background(), strings.NewReader(src), Lang("frobnicate"))
+	if _, ok := <-ch; ok {
+		t.Error("got a token for an unsupported language")
+	}
+	if _, ok := errf().(*ErrUnsupportedLanguage); !ok {
+		t.Errorf("want an *ErrUnsupportedLanguage, got %v", errf())
+	}
+}
@@ -0,0 +1,165 @@
+// Package treesitter highlights source code parsed by tree-sitter, for
+// languages where the lexical rules of syntaxhighlight are not precise
+// enough. The syntax tree is turned into syntaxhighlight tokens, so it is
+// rendered by the same printers and options.
+package treesitter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/sourcegraph/syntaxhighlight"
+)
+
+// Kinds maps the types of syntax tree nodes, such as "comment" or
+// "type_identifier", to token kinds. A node whose type is in the map is a
+// single token, whatever its children are.
+type Kinds map[string]syntaxhighlight.Kind
+
+// DefaultKinds holds the kinds of node types that are common to many
+// tree-sitter grammars.
+var DefaultKinds = Kinds{
+	"comment":       syntaxhighlight.Comment,
+	"line_comment":  syntaxhighlight.Comment,
+	"block_comment": syntaxhighlight.Comment,
+
+	"string":                     syntaxhighlight.String,
+	"string_literal":             syntaxhighlight.String,
+	"interpreted_string_literal": syntaxhighlight.String,
+	"raw_string_literal":         syntaxhighlight.String,
+	"template_string":            syntaxhighlight.String,
+	"char_literal":               syntaxhighlight.String,
+	"character_literal":          syntaxhighlight.String,
+	"rune_literal":               syntaxhighlight.String,
+
+	"number":            syntaxhighlight.Decimal,
+	"integer":           syntaxhighlight.Decimal,
+	"float":             syntaxhighlight.Decimal,
+	"int_literal":       syntaxhighlight.Decimal,
+	"integer_literal":   syntaxhighlight.Decimal,
+	"float_literal":     syntaxhighlight.Decimal,
+	"imaginary_literal": syntaxhighlight.Decimal,
+	"number_literal":    syntaxhighlight.Decimal,
+
+	"true":  syntaxhighlight.Literal,
+	"false": syntaxhighlight.Literal,
+	"nil":   syntaxhighlight.Literal,
+	"null":  syntaxhighlight.Literal,
+	"none":  syntaxhighlight.Literal,
+	"iota":  syntaxhighlight.Literal,
+
+	"type_identifier": syntaxhighlight.Type,
+	"primitive_type":  syntaxhighlight.Type,
+	"predefined_type": syntaxhighlight.Type,
+
+	"package_identifier":   syntaxhighlight.Namespace,
+	"namespace_identifier": syntaxhighlight.Namespace,
+	"label_name":           syntaxhighlight.Label,
+}
+
+// Tokens parses src as lang and returns its tokens. Nodes are mapped to
+// kinds by kinds; other leaf nodes are plain text if they are named, and if
+// they are anonymous, keywords if they are made of letters, as keywords are
+// in tree-sitter grammars, whitespace if they are made of spaces and
+// punctuation otherwise. The text between nodes is whitespace.
+func Tokens(src []byte, lang *sitter.Language, kinds Kinds) ([]syntaxhighlight.Token, error) {
+	p := sitter.NewParser()
+	defer p.Close()
+	p.SetLanguage(lang)
+	tree, err := p.ParseCtx(context.Background(), nil, src)
+	if err != nil {
+		return nil, fmt.Errorf("treesitter: parsing: %v", err)
+	}
+	defer tree.Close()
+
+	t := tokenizer{src: src, kinds: kinds}
+	t.node(tree.RootNode())
+	t.emit(uint32(len(src)), syntaxhighlight.Whitespace)
+	return t.toks, nil
+}
+
+// AsHTML parses src as lang and renders it as HTML like syntaxhighlight.AsHTML,
+// with the given options. Node types are mapped to kinds by kinds, as with
+// Tokens.
+func AsHTML(src []byte, lang *sitter.Language, kinds Kinds, options ...syntaxhighlight.Option) ([]byte, error) {
+	toks, err := Tokens(src, lang, kinds)
+	if err != nil {
+		return nil, err
+	}
+	return syntaxhighlight.TokensAsHTML(toks, options...)
+}
+
+// tokenizer turns a syntax tree into tokens.
+type tokenizer struct {
+	src   []byte
+	kinds Kinds
+	toks  []syntaxhighlight.Token
+	off   uint32 // end of the last token
+}
+
+func (t *tokenizer) node(n *sitter.Node) {
+	if kind, ok := t.kinds[n.Type()]; ok {
+		t.token(n, kind)
+		return
+	}
+	count := int(n.ChildCount())
+	if count == 0 {
+		switch {
+		case n.IsNamed():
+			t.token(n, syntaxhighlight.Plaintext)
+		case strings.TrimSpace(n.Type()) == "":
+			// such as the newlines that end statements in Go
+			t.token(n, syntaxhighlight.Whitespace)
+		case isWord(n.Type()):
+			t.token(n, syntaxhighlight.Keyword)
+		default:
+			t.token(n, syntaxhighlight.Punctuation)
+		}
+		return
+	}
+	for i := 0; i < count; i++ {
+		t.node(n.Child(i))
+	}
+}
+
+// token adds n as a token of the given kind, preceded by the whitespace
+// since the last token.
+func (t *tokenizer) token(n *sitter.Node, kind syntaxhighlight.Kind) {
+	start := n.StartByte()
+	if start < t.off {
+		// overlaps the last token; can only happen in broken trees
+		start = t.off
+	}
+	t.emit(start, syntaxhighlight.Whitespace)
+	t.emit(n.EndByte(), kind)
+}
+
+// emit adds the source from the end of the last token up to end as a token
+// of the given kind, unless it is empty. Whitespace that is not only
+// spaces, which is text skipped by the parser, is plain text.
+func (t *tokenizer) emit(end uint32, kind syntaxhighlight.Kind) {
+	if end > uint32(len(t.src)) {
+		end = uint32(len(t.src))
+	}
+	if end <= t.off {
+		return
+	}
+	text := string(t.src[t.off:end])
+	if kind == syntaxhighlight.Whitespace && strings.TrimSpace(text) != "" {
+		kind = syntaxhighlight.Plaintext
+	}
+	t.toks = append(t.toks, syntaxhighlight.Token{Kind: kind, Text: text})
+	t.off = end
+}
+
+// isWord reports whether s is made of letters and underscores.
+func isWord(s string) bool {
+	for _, r := range s {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || r == '_') {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,57 @@
+package treesitter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/smacker/go-tree-sitter/golang"
+	"github.com/sourcegraph/syntaxhighlight"
+)
+
+func TestTokens(t *testing.T) {
+	src := []byte("package main\n\nvar x int = 1 // c\n")
+	toks, err := Tokens(src, golang.GetLanguage(), DefaultKinds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type token struct {
+		kind syntaxhighlight.Kind
+		text string
+	}
+	want := []token{
+		{syntaxhighlight.Keyword, "package"},
+		{syntaxhighlight.Whitespace, " "},
+		{syntaxhighlight.Namespace, "main"},
+		{syntaxhighlight.Whitespace, "\n\n"},
+		{syntaxhighlight.Keyword, "var"},
+		{syntaxhighlight.Whitespace, " "},
+		{syntaxhighlight.Plaintext, "x"},
+		{syntaxhighlight.Whitespace, " "},
+		{syntaxhighlight.Type, "int"},
+		{syntaxhighlight.Whitespace, " "},
+		{syntaxhighlight.Punctuation, "="},
+		{syntaxhighlight.Whitespace, " "},
+		{syntaxhighlight.Decimal, "1"},
+		{syntaxhighlight.Whitespace, " "},
+		{syntaxhighlight.Comment, "// c"},
+		{syntaxhighlight.Whitespace, "\n"},
+	}
+	var got []token
+	for _, tok := range toks {
+		got = append(got, token{tok.Kind, tok.Text})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestAsHTML(t *testing.T) {
+	got, err := AsHTML([]byte(`x := "a\n"`), golang.GetLanguage(), DefaultKinds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<span class="pln">x</span> <span class="pun">:=</span> <span class="str">&#34;a\n&#34;</span>`
+	if string(got) != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
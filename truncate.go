@@ -0,0 +1,132 @@
+package syntaxhighlight
+
+import (
+	"errors"
+	"io"
+	"unicode/utf8"
+)
+
+// errTruncated stops printing once a truncatingPrinter has reached its
+// line limit.
+var errTruncated = errors.New("syntaxhighlight: output truncated")
+
+// AsHTMLTruncated is like AsHTML, but only renders the first maxLines lines
+// of src and the first maxCols characters of each line. Output is only ever
+// cut between tokens or between the characters of a token, so no markup or
+// multi-byte character is broken up. Where anything was left out, the
+// configured ellipsis marker (see Ellipsis) is written instead: at the end of
+// a cut line, and as a line of its own after the last line. A limit of zero
+// or less means no limit.
+func AsHTMLTruncated(src []byte, maxLines, maxCols int, options ...Option) ([]byte, error) {
+	opt := newHTMLConfig(options)
+	s, err := opt.scanner(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &truncatingPrinter{
+		p:        asLinePrinter(opt.printer()),
+		maxLines: maxLines,
+		maxCols:  maxCols,
+		ellipsis: opt.Ellipsis,
+	}
+	out, err := opt.render(s, p)
+	if err != nil && err != errTruncated {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Ellipsis sets the marker written where AsHTMLTruncated leaves out
+// part of the input.
+//
+// Example:
+// AsHTMLTruncated(input, 10, 80, Ellipsis(`<span class="more">...</span>`))
+func Ellipsis(marker string) Option {
+	return func(o *HTMLConfig) {
+		o.Ellipsis = marker
+	}
+}
+
+// nopLinePrinter makes a LinePrinter out of a Printer that does no line
+// wrapping of its own.
+type nopLinePrinter struct {
+	Printer
+}
+
+func (nopLinePrinter) BeginLine(w io.Writer, line int) error { return nil }
+func (nopLinePrinter) EndLine(w io.Writer, line int) error   { return nil }
+
+// asLinePrinter returns p as a LinePrinter, wrapping it if necessary.
+func asLinePrinter(p Printer) LinePrinter {
+	if lp, ok := p.(LinePrinter); ok {
+		return lp
+	}
+	return nopLinePrinter{p}
+}
+
+// truncatingPrinter passes on at most maxCols characters of at most maxLines
+// lines to p.
+type truncatingPrinter struct {
+	p                 LinePrinter
+	maxLines, maxCols int
+	ellipsis          string
+
+	col  int  // characters printed on the current line
+	cut  bool // whether the current line has been cut
+	line int  // the current line
+}
+
+func (t *truncatingPrinter) Print(w io.Writer, kind Kind, tokText string) error {
+	if t.maxLines > 0 && t.line > t.maxLines {
+		// a line past the limit is only cut once it turns out to have
+		// content, unlike the empty one after a final newline
+		if tokText == "" {
+			return nil
+		}
+		if _, err := io.WriteString(w, t.ellipsis); err != nil {
+			return err
+		}
+		if err := t.p.EndLine(w, t.line); err != nil {
+			return err
+		}
+		return errTruncated
+	}
+	if tokText == "\n" {
+		return t.p.Print(w, kind, tokText)
+	}
+	if t.cut {
+		return nil
+	}
+	if t.maxCols <= 0 {
+		return t.p.Print(w, kind, tokText)
+	}
+
+	n := utf8.RuneCountInString(tokText)
+	if t.col+n <= t.maxCols {
+		t.col += n
+		return t.p.Print(w, kind, tokText)
+	}
+
+	keep := 0
+	for i := t.maxCols - t.col; i > 0; i-- {
+		_, size := utf8.DecodeRuneInString(tokText[keep:])
+		keep += size
+	}
+	if keep > 0 {
+		if err := t.p.Print(w, kind, tokText[:keep]); err != nil {
+			return err
+		}
+	}
+	t.cut = true
+	_, err := io.WriteString(w, t.ellipsis)
+	return err
+}
+
+func (t *truncatingPrinter) BeginLine(w io.Writer, line int) error {
+	t.col, t.cut, t.line = 0, false, line
+	return t.p.BeginLine(w, line)
+}
+
+func (t *truncatingPrinter) EndLine(w io.Writer, line int) error {
+	return t.p.EndLine(w, line)
+}
@@ -0,0 +1,57 @@
+package syntaxhighlight
+
+import "testing"
+
+func TestAsHTMLTruncated(t *testing.T) {
+	tests := []struct {
+		src               string
+		maxLines, maxCols int
+		options           []Option
+		want              string
+	}{
+		{
+			src:  "a\nb",
+			want: "<span class=\"pln\">a</span>\n<span class=\"pln\">b</span>",
+		},
+		{
+			src:      "a\nb\nc",
+			maxLines: 2,
+			want:     "<span class=\"pln\">a</span>\n<span class=\"pln\">b</span>\n&#8230;",
+		},
+		{
+			src:     `x = "héllo"`,
+			maxCols: 7,
+			want:    `<span class="pln">x</span> <span class="pun">=</span> <span class="str">&#34;hé</span>&#8230;`,
+		},
+		{
+			src:      "abc\nd\ne",
+			maxLines: 1,
+			maxCols:  2,
+			options:  []Option{OrderedList(), Ellipsis("...")},
+			want:     "<ol>\n<li><span class=\"pln\">ab</span>...</li>\n<li>...</li>\n</ol>",
+		},
+	}
+	// exactly maxLines lines with a final newline are not cut
+	for _, options := range [][]Option{nil, {OrderedList()}} {
+		src := "a\nb\n"
+		want, err := AsHTML([]byte(src), options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tests = append(tests, struct {
+			src               string
+			maxLines, maxCols int
+			options           []Option
+			want              string
+		}{src, 2, 0, options, string(want)})
+	}
+	for _, test := range tests {
+		got, err := AsHTMLTruncated([]byte(test.src), test.maxLines, test.maxCols, test.options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != test.want {
+			t.Errorf("%q (%d lines, %d cols): want %q, got %q", test.src, test.maxLines, test.maxCols, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package syntaxhighlight
+
+// outputVersion is the version of the output, see OutputVersion.
+const outputVersion = 4
+
+// OutputVersion returns the version of the output of this package. Identical
+// input and configuration yield byte-identical output from the same version,
+// whatever the run or platform, so the version can be part of the key of a
+// cache of rendered output.
+//
+// The version is bumped whenever a change to lexing or printing alters the
+// output for some input and configuration, which includes the lexing of new
+// languages and changes to the default classes and themes. New options,
+// kinds and languages that are not used leave the output, and so the
+// version, unchanged.
+func OutputVersion() int {
+	return outputVersion
+}
@@ -0,0 +1,70 @@
+package syntaxhighlight
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// outputHashes holds, by output version, the hash of the output of
+// outputHash. A change in the output must come with a bump of
+// OutputVersion and a new entry here.
+var outputHashes = map[int]string{
+	1: "01e896092806920e34686e759430f9c05377232284ffeebd3f1ffb1abcac7197",
+	2: "33626177de5ee635d26bb094c1033b621668722f78bb7c2e390c8a8f51af6d4a",
+	3: "6807e38ada15a05d4c0ed70a28830840817dd7bca34b4babaeed7e80ecece483",
+	4: "b0d9fe34f8b3ab4ae67ff1ff39a3764c11a252a7a8a93246a40e175e58bcef4d",
+}
+
+// outputHash returns a hash of the output for the sources in testdata with a
+// variety of configurations.
+func outputHash(t *testing.T) string {
+	files, err := filepath.Glob("testdata/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	configs := [][]Option{
+		nil,
+		{OrderedList()},
+		{Table(), LineAnchors()},
+		{Coalesce(), Interpolation(), FlagConfusables(), FlagInvisible("inv")},
+		{WrapWidth(20), BarePlaintext()},
+	}
+	h := sha256.New()
+	for _, name := range files {
+		if strings.HasSuffix(name, ".html") {
+			continue
+		}
+		src, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, options := range configs {
+			out, err := AsHTML(src, append([]Option{Lang(DetectLanguage(name, src))}, options...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fmt.Fprintf(h, "%s %d\n", filepath.Base(name), len(out))
+			h.Write(out)
+		}
+	}
+	h.Write([]byte(DefaultTheme.CSS(".highlight", DefaultHTMLConfig)))
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+func TestOutputVersion(t *testing.T) {
+	got := outputHash(t)
+	if again := outputHash(t); again != got {
+		t.Fatalf("output differs between runs: %s != %s", got, again)
+	}
+	want, ok := outputHashes[OutputVersion()]
+	if !ok {
+		t.Fatalf("no output hash for version %d; add %q", OutputVersion(), got)
+	}
+	if got != want {
+		t.Errorf("output of version %d changed: want hash %s, got %s; bump OutputVersion and add the new hash", OutputVersion(), want, got)
+	}
+}
@@ -0,0 +1,76 @@
+package syntaxhighlight
+
+import "io"
+
+// WrapLines returns a Printer that prints like p, but soft-wraps lines
+// longer than width characters by starting a new line of output after
+// every width characters. Tokens are split where they are wrapped and each
+// piece is printed by p on its own, so that spans or colors are closed
+// before the line break and opened again after it. If p is a LinePrinter,
+// so is the returned Printer, and the continuation lines are part of the
+// same line of p. A width of zero or less means no wrapping.
+func WrapLines(p Printer, width int) Printer {
+	wp := &wrappingPrinter{p: p, width: width}
+	if lp, ok := p.(LinePrinter); ok {
+		return &wrappingLinePrinter{wrappingPrinter: wp, lp: lp}
+	}
+	return wp
+}
+
+// WrapWidth soft-wraps lines longer than width characters (see WrapLines).
+//
+// Example:
+// AsHTML(input, WrapWidth(80))
+func WrapWidth(width int) Option {
+	return func(o *HTMLConfig) {
+		o.WrapWidth = width
+	}
+}
+
+// wrappingPrinter passes on tokens to p, split where lines are wrapped.
+type wrappingPrinter struct {
+	p     Printer
+	width int
+	col   int // characters printed on the current line of output
+}
+
+func (p *wrappingPrinter) Print(w io.Writer, kind Kind, tokText string) error {
+	if p.width <= 0 {
+		return p.p.Print(w, kind, tokText)
+	}
+	start := 0
+	for i, r := range tokText {
+		if r == '\n' {
+			p.col = 0
+			continue
+		}
+		if p.col == p.width {
+			if i > start {
+				if err := p.p.Print(w, kind, tokText[start:i]); err != nil {
+					return err
+				}
+			}
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+			start, p.col = i, 0
+		}
+		p.col++
+	}
+	return p.p.Print(w, kind, tokText[start:])
+}
+
+// wrappingLinePrinter is a wrappingPrinter of a LinePrinter.
+type wrappingLinePrinter struct {
+	*wrappingPrinter
+	lp LinePrinter
+}
+
+func (p *wrappingLinePrinter) BeginLine(w io.Writer, line int) error {
+	p.col = 0
+	return p.lp.BeginLine(w, line)
+}
+
+func (p *wrappingLinePrinter) EndLine(w io.Writer, line int) error {
+	return p.lp.EndLine(w, line)
+}
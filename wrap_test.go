@@ -0,0 +1,45 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWrapWidth(t *testing.T) {
+	tests := []struct {
+		src     string
+		options []Option
+		want    string
+	}{
+		{
+			src:  "abcdef + 1\nxy",
+			want: `<span class="pln">abcd</span>` + "\n" + `<span class="pln">ef</span> <span class="pun">+</span>` + "\n" + ` <span class="dec">1</span>` + "\n" + `<span class="pln">xy</span>`,
+		},
+		{
+			src:     "/* é\n123456 */",
+			options: []Option{LineAnchors()},
+			want:    `<span class="line" id="L1" data-line="1"><span class="com">/* é</span></span>` + "\n" + `<span class="line" id="L2" data-line="2"><span class="com">1234</span>` + "\n" + `<span class="com">56 *</span>` + "\n" + `<span class="com">/</span></span>`,
+		},
+	}
+	for _, test := range tests {
+		got, err := AsHTML([]byte(test.src), append(test.options, WrapWidth(4))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != test.want {
+			t.Errorf("%q: want %q, got %q", test.src, test.want, got)
+		}
+	}
+}
+
+func TestWrapLinesANSI(t *testing.T) {
+	theme := Theme{Styles: map[Kind]Style{String: {Color: "#f00"}}}
+	var buf bytes.Buffer
+	if err := Print(NewScanner([]byte(`"abcde"`)), &buf, WrapLines(ANSIPrinter(theme), 4)); err != nil {
+		t.Fatal(err)
+	}
+	want := "\x1b[38;2;255;0;0m\"abc\x1b[0m\n\x1b[38;2;255;0;0mde\"\x1b[0m"
+	if got := buf.String(); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
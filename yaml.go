@@ -0,0 +1,371 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// yamlNumberRE matches the plain scalars of YAML that are numbers:
+// integers, including hexadecimal and octal ones, floats, infinities and
+// not-a-number.
+var yamlNumberRE = regexp.MustCompile(`^([-+]?(\d[\d_]*(\.\d*)?|\.\d+)([eE][-+]?\d+)?|0x[0-9a-fA-F_]+|0o[0-7_]+|[-+]?\.(inf|Inf|INF)|\.(nan|NaN|NAN))$`)
+
+// yamlLiterals are the plain scalars of YAML that are null or booleans,
+// including the booleans of YAML 1.1, such as "yes", which many tools
+// still read as such.
+var yamlLiterals = setOf(
+	"~", "null", "Null", "NULL", "true", "True", "TRUE", "false", "False", "FALSE",
+	"yes", "Yes", "YES", "no", "No", "NO", "on", "On", "ON", "off", "Off", "OFF",
+)
+
+// yamlMode specializes the YAML lexer for documents of a particular kind,
+// such as Kubernetes manifests. The path passed to its functions is that of
+// the keys down to the current one, with "-" for the items of sequences, as
+// in {"spec", "containers", "-", "image"}.
+type yamlMode struct {
+	// key returns the kind of the key at the end of path, and false if it
+	// is highlighted as other keys are. It may be nil.
+	key func(path []string) (Kind, bool)
+	// value adds the tokens of value, the scalar, which may be quoted, of
+	// the key or item at the end of path, and reports whether it did; if
+	// not, the value is highlighted as in other documents. It may be nil.
+	// Scalars at the top level of a document, whose path is empty, are not
+	// passed to it.
+	value func(toks *lineTokens, path []string, value []byte) bool
+}
+
+// yamlEntry is a key, or "-" for an item of a sequence, on the path to the
+// current node of a YAML document.
+type yamlEntry struct {
+	indent int // column of the key or "-"
+	key    string
+}
+
+// yamlLexer holds the state of a YAML lexer between lines.
+type yamlLexer struct {
+	mode  *yamlMode
+	path  []yamlEntry
+	block int // column of the owner of the current block scalar, or -1
+	flow  int // depth of the flow collections the lexer is in
+}
+
+// newYAMLLexer returns a Lexer for YAML. Keys are Variable and scalar
+// values String, or Literal for null and booleans and Decimal for numbers,
+// as are the contents of block scalars after "|" and ">". Anchors and
+// aliases, such as &base and *base, are Label, tags such as !!str Type,
+// the merge key "<<" Keyword and indicators such as "-" and ":"
+// Punctuation. Comments, after "#", are Comment.
+func newYAMLLexer() Lexer {
+	return newYAMLModeLexer(nil)()
+}
+
+// newYAMLModeLexer returns a function making Lexers for YAML specialized
+// by mode, which may be nil.
+func newYAMLModeLexer(mode *yamlMode) func() Lexer {
+	return func() Lexer {
+		l := &yamlLexer{mode: mode, block: -1}
+		return &lineLexer{lexLine: l.lexLine}
+	}
+}
+
+// lexLine returns the tokens of a line of a YAML document.
+func (l *yamlLexer) lexLine(line []byte) []lexToken {
+	var toks lineTokens
+	indent := 0
+	for indent < len(line) && line[indent] == ' ' {
+		indent++
+	}
+	rest := line[indent:]
+	content := bytes.TrimRight(rest, " \t\r")
+	if l.block >= 0 {
+		if len(content) == 0 || indent > l.block {
+			toks.add(indent, Whitespace)
+			toks.add(len(content), String)
+			toks.add(len(rest)-len(content), Whitespace)
+			return toks
+		}
+		l.block = -1
+	}
+	toks.add(indent, Whitespace)
+	switch {
+	case len(content) == 0:
+		toks.add(len(rest), Whitespace)
+		return toks
+	case l.flow > 0:
+		l.lexFlow(&toks, rest)
+		return toks
+	case content[0] == '#':
+		toks.add(len(content), Comment)
+		toks.add(len(rest)-len(content), Whitespace)
+		return toks
+	case indent == 0 && content[0] == '%':
+		toks.add(len(content), Keyword)
+		toks.add(len(rest)-len(content), Whitespace)
+		return toks
+	case indent == 0 && (hasPrefix(rest, "---") || hasPrefix(rest, "...")) && (len(rest) == 3 || isBlank(rest[3])):
+		l.path = l.path[:0]
+		toks.add(3, Punctuation)
+		n := blanks(rest[3:])
+		toks.add(n, Whitespace)
+		l.lexValue(&toks, rest[3+n:], 0)
+		return toks
+	}
+	l.lexNode(&toks, rest, indent)
+	return toks
+}
+
+// lexNode adds the tokens of s, the rest of a line from column col: the
+// "-" of any items of sequences, a key and its value.
+func (l *yamlLexer) lexNode(toks *lineTokens, s []byte, col int) {
+	item := yamlItem(s)
+	for len(l.path) > 0 {
+		e := l.path[len(l.path)-1]
+		// a sequence may be as indented as the key it is the value of
+		if e.indent < col || e.indent == col && item && e.key != "-" {
+			break
+		}
+		l.path = l.path[:len(l.path)-1]
+	}
+	owner := col
+	for yamlItem(s) {
+		l.path = append(l.path, yamlEntry{col, "-"})
+		owner = col
+		n := 1 + blanks(s[1:])
+		toks.add(1, Punctuation)
+		toks.add(n-1, Whitespace)
+		s, col = s[n:], col+n
+	}
+	if key, n, colon := yamlKey(s); n > 0 {
+		l.path = append(l.path, yamlEntry{col, key})
+		owner = col
+		kind := Variable
+		if key == "<<" {
+			kind = Keyword
+		}
+		if l.mode != nil && l.mode.key != nil {
+			if k, ok := l.mode.key(l.keys()); ok {
+				kind = k
+			}
+		}
+		toks.add(n, kind)
+		toks.add(colon-n, Whitespace)
+		toks.add(1, Punctuation)
+		m := blanks(s[colon+1:])
+		toks.add(m, Whitespace)
+		s = s[colon+1+m:]
+	}
+	l.lexValue(toks, s, owner)
+}
+
+// lexValue adds the tokens of s, the value of a key or item, which is owned
+// by the key or "-" at column owner, and the comment after it.
+func (l *yamlLexer) lexValue(toks *lineTokens, s []byte, owner int) {
+	for len(s) > 0 {
+		n := 0
+		switch c := s[0]; c {
+		case '#':
+			yamlComment(toks, s)
+			return
+		case '&', '*', '!':
+			for n < len(s) && !isBlank(s[n]) {
+				n++
+			}
+			if c == '!' {
+				toks.add(n, Type)
+			} else {
+				toks.add(n, Label)
+			}
+			m := blanks(s[n:])
+			toks.add(m, Whitespace)
+			s = s[n+m:]
+			continue
+		case '|', '>':
+			n = 1
+			for n < len(s) && bytes.IndexByte([]byte("+-0123456789"), s[n]) >= 0 {
+				n++
+			}
+			if m := blanks(s[n:]); n+m == len(s) || s[n+m] == '#' {
+				toks.add(n, Punctuation)
+				l.block = owner
+				yamlComment(toks, s[n:])
+				return
+			}
+		case '[', '{':
+			l.lexFlow(toks, s)
+			return
+		}
+
+		end := yamlScalarEnd(s)
+		value := s[:end]
+		if l.mode == nil || l.mode.value == nil || len(l.path) == 0 || !l.mode.value(toks, l.keys(), value) {
+			yamlScalar(toks, value)
+		}
+		yamlComment(toks, s[end:])
+		return
+	}
+}
+
+// lexFlow adds the tokens of s, which is in a flow collection, such as
+// [a, b] or {a: 1}, or starts one.
+func (l *yamlLexer) lexFlow(toks *lineTokens, s []byte) {
+	for i := 0; i < len(s); {
+		n := 1
+		switch c := s[i]; {
+		case isBlank(c):
+			n = blanks(s[i:])
+			toks.add(n, Whitespace)
+		case c == '#' && (i == 0 || isBlank(s[i-1])):
+			toks.add(len(s)-i, Comment)
+			return
+		case c == '[' || c == '{':
+			l.flow++
+			toks.add(1, Punctuation)
+		case c == ']' || c == '}':
+			if l.flow > 0 {
+				l.flow--
+			}
+			toks.add(1, Punctuation)
+		case c == ',' || c == ':':
+			toks.add(1, Punctuation)
+		case c == '&' || c == '*' || c == '!':
+			for i+n < len(s) && !isBlank(s[i+n]) && bytes.IndexByte([]byte(",[]{}"), s[i+n]) < 0 {
+				n++
+			}
+			if c == '!' {
+				toks.add(n, Type)
+			} else {
+				toks.add(n, Label)
+			}
+		default:
+			if c == '"' || c == '\'' {
+				n = yamlQuoted(s[i:])
+			} else {
+				for n < len(s)-i && bytes.IndexByte([]byte(",[]{}"), s[i+n]) < 0 && !yamlIndicatorAt(s[i:], n) {
+					n++
+				}
+				n = len(bytes.TrimRight(s[i:i+n], " \t\r"))
+			}
+			if j := i + n + blanks(s[i+n:]); j < len(s) && s[j] == ':' {
+				toks.add(n, Variable)
+			} else {
+				yamlScalar(toks, s[i:i+n])
+			}
+		}
+		i += n
+	}
+}
+
+// keys returns the path of keys to the current node.
+func (l *yamlLexer) keys() []string {
+	keys := make([]string, len(l.path))
+	for i, e := range l.path {
+		keys[i] = e.key
+	}
+	return keys
+}
+
+// yamlItem reports whether s starts with the "-" of an item of a sequence.
+func yamlItem(s []byte) bool {
+	return len(s) > 0 && s[0] == '-' && (len(s) == 1 || isBlank(s[1]))
+}
+
+// yamlKey returns the key of the block mapping at the start of s, without
+// its quotes, the length of the key as written and the index of the colon
+// after it, or a length of 0 if s does not start with a key.
+func yamlKey(s []byte) (key string, n, colon int) {
+	if len(s) == 0 || bytes.IndexByte([]byte("-?,[]{}#&*!|>%@`"), s[0]) >= 0 && !(s[0] == '-' && len(s) > 1 && !isBlank(s[1])) {
+		return "", 0, 0
+	}
+	if s[0] == '"' || s[0] == '\'' {
+		n = yamlQuoted(s)
+		colon = n + blanks(s[n:])
+		if colon < len(s) && s[colon] == ':' && (colon+1 == len(s) || isBlank(s[colon+1])) {
+			return string(s[1 : n-1]), n, colon
+		}
+		return "", 0, 0
+	}
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '#' && i > 0 && isBlank(s[i-1]):
+			return "", 0, 0
+		case s[i] == ':' && (i+1 == len(s) || isBlank(s[i+1])):
+			key := bytes.TrimRight(s[:i], " \t")
+			return string(key), len(key), i
+		}
+	}
+	return "", 0, 0
+}
+
+// yamlQuoted returns the length of the quoted scalar at the start of s, in
+// which double quotes may be escaped with a backslash and single quotes
+// are doubled. One that is not closed on the line runs to its end.
+func yamlQuoted(s []byte) int {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[0] == '"' && s[i] == '\\':
+			i++
+		case s[i] == s[0] && s[0] == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == s[0]:
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// yamlScalarEnd returns the length of the scalar at the start of s, the
+// value of a key or item, which ends before any comment and trailing
+// blanks.
+func yamlScalarEnd(s []byte) int {
+	if s[0] == '"' || s[0] == '\'' {
+		return yamlQuoted(s)
+	}
+	end := len(s)
+	for i := 1; i < len(s); i++ {
+		if s[i] == '#' && isBlank(s[i-1]) {
+			end = i
+			break
+		}
+	}
+	return len(bytes.TrimRight(s[:end], " \t\r"))
+}
+
+// yamlIndicatorAt reports whether s[i] is a colon that ends a key in a flow
+// collection or the "#" of a comment.
+func yamlIndicatorAt(s []byte, i int) bool {
+	switch s[i] {
+	case ':':
+		return i+1 == len(s) || isBlank(s[i+1]) || bytes.IndexByte([]byte(",[]{}"), s[i+1]) >= 0
+	case '#':
+		return i > 0 && isBlank(s[i-1])
+	}
+	return false
+}
+
+// yamlScalar adds the token of the scalar s, which is String, or Literal
+// for null and booleans and Decimal for numbers if it is plain.
+func yamlScalar(toks *lineTokens, s []byte) {
+	switch {
+	case len(s) == 0:
+	case s[0] == '"' || s[0] == '\'':
+		toks.add(len(s), String)
+	case yamlLiterals[string(s)]:
+		toks.add(len(s), Literal)
+	case yamlNumberRE.Match(s):
+		toks.add(len(s), Decimal)
+	default:
+		toks.add(len(s), String)
+	}
+}
+
+// yamlComment adds the tokens of s, the rest of a line after a value:
+// blanks, then any comment.
+func yamlComment(toks *lineTokens, s []byte) {
+	n := blanks(s)
+	toks.add(n, Whitespace)
+	if n < len(s) && s[n] == '#' {
+		toks.add(len(s)-n, Comment)
+	} else {
+		toks.add(len(s)-n, String)
+	}
+}
@@ -0,0 +1,101 @@
+package syntaxhighlight
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestYAMLLexer(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []token
+	}{
+		{"# config\nname: app\nversion: 1.2\nenabled: yes # on\nnone: ~\nlist:\n  - a\n  - \"b: c\"\n", []token{
+			{Comment, "# config"}, {Whitespace, "\n"},
+			{Variable, "name"}, {Punctuation, ":"}, {Whitespace, " "}, {String, "app"}, {Whitespace, "\n"},
+			{Variable, "version"}, {Punctuation, ":"}, {Whitespace, " "}, {Decimal, "1.2"}, {Whitespace, "\n"},
+			{Variable, "enabled"}, {Punctuation, ":"}, {Whitespace, " "}, {Literal, "yes"}, {Whitespace, " "}, {Comment, "# on"}, {Whitespace, "\n"},
+			{Variable, "none"}, {Punctuation, ":"}, {Whitespace, " "}, {Literal, "~"}, {Whitespace, "\n"},
+			{Variable, "list"}, {Punctuation, ":"}, {Whitespace, "\n"},
+			{Whitespace, "  "}, {Punctuation, "-"}, {Whitespace, " "}, {String, "a"}, {Whitespace, "\n"},
+			{Whitespace, "  "}, {Punctuation, "-"}, {Whitespace, " "}, {String, `"b: c"`}, {Whitespace, "\n"},
+		}},
+		{"%YAML 1.2\n---\nbase: &base\n  url: http://x.io/a#b\n\"quoted key\": !!str 1\nother:\n  <<: *base\n...\n", []token{
+			{Keyword, "%YAML 1.2"}, {Whitespace, "\n"},
+			{Punctuation, "---"}, {Whitespace, "\n"},
+			{Variable, "base"}, {Punctuation, ":"}, {Whitespace, " "}, {Label, "&base"}, {Whitespace, "\n"},
+			{Whitespace, "  "}, {Variable, "url"}, {Punctuation, ":"}, {Whitespace, " "}, {String, "http://x.io/a#b"}, {Whitespace, "\n"},
+			{Variable, `"quoted key"`}, {Punctuation, ":"}, {Whitespace, " "}, {Type, "!!str"}, {Whitespace, " "}, {Decimal, "1"}, {Whitespace, "\n"},
+			{Variable, "other"}, {Punctuation, ":"}, {Whitespace, "\n"},
+			{Whitespace, "  "}, {Keyword, "<<"}, {Punctuation, ":"}, {Whitespace, " "}, {Label, "*base"}, {Whitespace, "\n"},
+			{Punctuation, "..."}, {Whitespace, "\n"},
+		}},
+		{"script: |-\n  echo: hi\n\n  # not a comment\nnext: [1, 'two', {k: null}]\nmulti: {\n  a: b }\n", []token{
+			{Variable, "script"}, {Punctuation, ":"}, {Whitespace, " "}, {Punctuation, "|-"}, {Whitespace, "\n"},
+			{Whitespace, "  "}, {String, "echo: hi"}, {Whitespace, "\n"},
+			{Whitespace, "\n"},
+			{Whitespace, "  "}, {String, "# not a comment"}, {Whitespace, "\n"},
+			{Variable, "next"}, {Punctuation, ":"}, {Whitespace, " "}, {Punctuation, "["}, {Decimal, "1"}, {Punctuation, ","}, {Whitespace, " "},
+			{String, "'two'"}, {Punctuation, ","}, {Whitespace, " "}, {Punctuation, "{"}, {Variable, "k"}, {Punctuation, ":"}, {Whitespace, " "},
+			{Literal, "null"}, {Punctuation, "}]"}, {Whitespace, "\n"},
+			{Variable, "multi"}, {Punctuation, ":"}, {Whitespace, " "}, {Punctuation, "{"}, {Whitespace, "\n"},
+			{Whitespace, "  "}, {Variable, "a"}, {Punctuation, ":"}, {Whitespace, " "}, {String, "b"}, {Whitespace, " "}, {Punctuation, "}"}, {Whitespace, "\n"},
+		}},
+	}
+	for _, test := range tests {
+		for _, oneByte := range []bool{false, true} {
+			s := NewScanner([]byte(test.src))
+			if oneByte {
+				s = NewScannerReader(iotest.OneByteReader(bytes.NewReader([]byte(test.src))))
+			}
+			s.UseLanguage(LookupLanguage("yaml"))
+			if got := scanAll(t, s); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%q:\nwant %v\ngot  %v", test.src, test.want, got)
+			}
+		}
+	}
+}
+
+func TestYAMLPath(t *testing.T) {
+	src := "a:\n  b:\n  - c: 1\n    d:\n      - e\nf: 2\n"
+	want := [][]string{{"a"}, {"a", "b"}, {"a", "b", "-", "c"}, {"a", "b", "-", "d"}, {"a", "b", "-", "d", "-"}, {"f"}}
+	l := &yamlLexer{block: -1}
+	for i, line := range bytes.Split(bytes.TrimSuffix([]byte(src), []byte("\n")), []byte("\n")) {
+		l.lexLine(line)
+		if got := l.keys(); !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("%q: got path %q, want %q", line, got, want[i])
+		}
+	}
+}
+
+func TestYAMLModesTopLevel(t *testing.T) {
+	// top-level scalars, whose path is empty, and streams of documents
+	srcs := []string{"x", "=", "'q'", "--- a\n--- |\n  b\n...\n", "- 1\n---\nkind: Pod\n--- !tag x\n--- [1]\n"}
+	for _, lang := range []string{"yaml", "kubernetes", "openapi", "openapi-json", "ansible"} {
+		for _, src := range srcs {
+			s := NewScanner([]byte(src))
+			s.UseLanguage(LookupLanguage(lang))
+			var text strings.Builder
+			for _, tok := range scanAll(t, s) {
+				text.WriteString(tok.text)
+			}
+			if text.String() != src {
+				t.Errorf("%s %q: tokens add up to %q", lang, src, text.String())
+			}
+		}
+	}
+
+	s := NewScanner([]byte("--- a\n---\nkind: Pod\n"))
+	s.UseLanguage(LookupLanguage("kubernetes"))
+	want := []token{
+		{Punctuation, "---"}, {Whitespace, " "}, {String, "a"}, {Whitespace, "\n"},
+		{Punctuation, "---"}, {Whitespace, "\n"},
+		{Keyword, "kind"}, {Punctuation, ":"}, {Whitespace, " "}, {Type, "Pod"}, {Whitespace, "\n"},
+	}
+	if got := scanAll(t, s); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}